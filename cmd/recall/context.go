@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+var (
+	contextJournalIDFlag string
+	contextTagsFlag      []string
+	contextTokensFlag    int
+	contextStatsFlag     bool
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context [query...]",
+	Short: "Print the most relevant entries that fit in a token budget as Markdown",
+	Long: `Rank entries by how well they match the query and --tags, like 'recall search --hybrid',
+and print the best of them that fit in --tokens as one Markdown block ready to paste into a prompt.
+Each entry is a numbered section, and a list of sources at the end cites its title and ID.
+Long entries are cut down to their leading section. Token counts are estimates.
+
+  recall context "how do we deploy" --tags ops --tokens 1500`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := strings.Join(args, " ")
+		var tags []string
+		for _, tag := range contextTagsFlag {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		if strings.TrimSpace(query) == "" && len(tags) == 0 {
+			return errors.New("a query or --tags is required")
+		}
+		if contextTokensFlag <= 0 {
+			return errors.New("--tokens must be positive")
+		}
+		journalID := uuid.Nil
+		if contextJournalIDFlag != "" {
+			var err error
+			if journalID, err = uuid.Parse(contextJournalIDFlag); err != nil {
+				return fmt.Errorf("invalid journal ID: %w", err)
+			}
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		ranked, err := memories.SearchEntriesHybrid(cmd.Context(), store, journalID, tags, query, memories.DefaultRankWeights(), 0)
+		if err != nil {
+			return fmt.Errorf("failed to rank entries: %w", err)
+		}
+		pack := memories.PackContext(ranked, contextTokensFlag)
+		fmt.Print(pack.Markdown)
+		if contextStatsFlag {
+			cmd.PrintErrf("%d entries, about %d tokens, %d left out\n", len(pack.Entries), pack.Tokens, pack.Omitted)
+		}
+		return nil
+	},
+}
+
+func initContextCmd() {
+	contextCmd.Flags().StringVar(&contextJournalIDFlag, "journal", "", "Journal ID to take entries from (default: all journals)")
+	contextCmd.Flags().StringSliceVar(&contextTagsFlag, "tags", nil, "Comma-separated tags that make entries more relevant")
+	contextCmd.Flags().IntVar(&contextTokensFlag, "tokens", memories.DefaultContextTokens, "Token budget of the output")
+	contextCmd.Flags().BoolVar(&contextStatsFlag, "stats", false, "Print the number of entries and tokens used to stderr")
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	pkgdb "github.com/unowned-ai/recall/pkg/db"
+)
+
+var (
+	dbTargetVersionFlag int64
+	dbDryRunFlag        bool
+	dbBackupDirFlag     string
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the recall database",
+	Long: `Provides commands for managing the Recall database, including schema upgrades. GIGO.
+
+PostgreSQL databases (--db postgres://...) have their own memoriesdb-postgres component.
+Backups and restores are only available for SQLite; use pg_dump for PostgreSQL.`,
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the schema version of the memoriesdb component and pending migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbConn, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer dbConn.Close()
+
+		component, targetVersion := memoriesComponent()
+		currentVersion, err := pkgdb.GetComponentSchemaVersion(dbConn, component)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Database:        %s\n", pkgdb.RedactDSN(dbPath))
+		fmt.Printf("Component:       %s\n", component)
+		fmt.Printf("Current version: %d\n", currentVersion)
+		fmt.Printf("Target version:  %d\n", targetVersion)
+		fmt.Println("\nMigrations:")
+		for _, m := range pkgdb.Migrations(component) {
+			state := "pending"
+			if m.Version <= currentVersion {
+				state = "applied"
+			}
+			fmt.Printf("  %3d  %-8s %s\n", m.Version, state, m.Description)
+		}
+		if currentVersion > targetVersion {
+			fmt.Printf("\nDatabase schema version %d is newer than this application supports (%d). Please upgrade recall.\n", currentVersion, targetVersion)
+		}
+		return nil
+	},
+}
+
+var dbUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade the Recall database schema to the latest version for the memoriesdb component",
+	Long: `Connects to the SQLite database at the specified path (or system default if --db is not provided)
+and applies any necessary schema migrations to bring the memoriesdb component up to the current application schema version.
+If the database does not exist or is uninitialized for this component, it will be created
+and initialized with the latest schema for the memoriesdb component.
+
+Use --to to stop at an earlier version and --dry-run to list the migrations without applying them.
+Before an existing SQLite database is migrated, a timestamped backup is written next to it (or to --backup-dir).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		component, latestVersion := memoriesComponent()
+		targetVersion := latestVersion
+		if cmd.Flags().Changed("to") {
+			targetVersion = dbTargetVersionFlag
+		}
+		if targetVersion > latestVersion {
+			return fmt.Errorf("target version %d is newer than the latest supported schema version %d", targetVersion, latestVersion)
+		}
+
+		if pkgdb.IsPostgresDSN(dbPath) {
+			fmt.Printf("Attempting to upgrade %s component in database at: %s\n", component, pkgdb.RedactDSN(dbPath))
+		} else {
+			fmt.Printf("Attempting to upgrade memoriesdb component in database at: %s (WAL: %t, Sync: %s)\n", dbPath, walMode, syncMode)
+		}
+
+		dbConn, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer dbConn.Close()
+
+		if dbDryRunFlag {
+			currentVersion, err := pkgdb.GetComponentSchemaVersion(dbConn, component)
+			if err != nil {
+				return err
+			}
+			if currentVersion > targetVersion {
+				return fmt.Errorf("database is at schema version %d, which is newer than the target version %d; use 'recall db downgrade' instead", currentVersion, targetVersion)
+			}
+			return printMigrationPlan(dbConn, targetVersion, "upgrade")
+		}
+
+		if pkgdb.IsPostgresDSN(dbPath) {
+			if err := pkgdb.UpgradePostgresDB(dbConn, pkgdb.RedactDSN(dbPath), targetVersion); err != nil {
+				return err
+			}
+			fmt.Println("Database schema upgrade completed successfully for", component, "component to version", targetVersion)
+			return nil
+		}
+
+		backupPath, err := pkgdb.UpgradeDBWithBackup(dbConn, dbPath, targetVersion, dbBackupDirFlag)
+		if err != nil {
+			return err
+		}
+		if backupPath != "" {
+			fmt.Println("Pre-migration backup written to", backupPath)
+		}
+		fmt.Println("Database schema upgrade completed successfully for memoriesdb component to version", targetVersion)
+		return nil
+	},
+}
+
+var dbDowngradeCmd = &cobra.Command{
+	Use:   "downgrade",
+	Short: "Revert the memoriesdb component to an earlier schema version",
+	Long: `Runs the down migrations of the memoriesdb component, newest first, until the database is at the version given by --to.
+Downgrading drops tables and columns added by the reverted migrations, along with their data.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if dbTargetVersionFlag < 0 {
+			return fmt.Errorf("invalid target version: %d", dbTargetVersionFlag)
+		}
+
+		dbConn, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer dbConn.Close()
+
+		if dbDryRunFlag {
+			return printMigrationPlan(dbConn, dbTargetVersionFlag, "downgrade")
+		}
+
+		if pkgdb.IsPostgresDSN(dbPath) {
+			return downgradePostgres(dbConn, dbTargetVersionFlag)
+		}
+
+		backupPath, err := pkgdb.DowngradeDB(dbConn, dbPath, dbTargetVersionFlag, dbBackupDirFlag)
+		if err != nil {
+			return err
+		}
+		if backupPath != "" {
+			fmt.Println("Pre-migration backup written to", backupPath)
+		}
+		fmt.Println("Database schema downgrade completed successfully for memoriesdb component to version", dbTargetVersionFlag)
+		return nil
+	},
+}
+
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Write a timestamped copy of the database",
+	Long: `Copies the database with SQLite's online backup API, so the copy is consistent even while other
+processes are writing in WAL mode. The copy is written next to the database file, or to --backup-dir.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireSQLite("recall db backup"); err != nil {
+			return err
+		}
+		dbConn, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer dbConn.Close()
+
+		backupPath, err := pkgdb.BackupDB(cmd.Context(), dbConn, dbBackupDirFlag)
+		if err != nil {
+			return fmt.Errorf("failed to back up database: %w", err)
+		}
+		fmt.Println("Database backed up to", backupPath)
+		return nil
+	},
+}
+
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore [backup-file]",
+	Short: "Replace the database with a backup",
+	Long: `Replaces the database with the given backup file. The backup must contain a memoriesdb component
+at a schema version this version of recall supports. The database being replaced is itself backed up
+next to it (or to --backup-dir) before the files are swapped.
+
+Stop any running 'recall mcp' servers using the database before restoring.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireSQLite("recall db restore"); err != nil {
+			return err
+		}
+		safetyBackupPath, err := pkgdb.RestoreDB(cmd.Context(), args[0], dbPath, dbBackupDirFlag)
+		if err != nil {
+			return fmt.Errorf("failed to restore database: %w", err)
+		}
+		if safetyBackupPath != "" {
+			fmt.Println("Previous database backed up to", safetyBackupPath)
+		}
+		fmt.Printf("Database %s restored from %s\n", dbPath, args[0])
+		return nil
+	},
+}
+
+// memoriesComponent returns the memories component of the database at --db and the
+// latest schema version this build supports for it.
+func memoriesComponent() (string, int64) {
+	if pkgdb.IsPostgresDSN(dbPath) {
+		return pkgdb.PostgresMemoriesDBComponent, pkgdb.TargetPostgresSchemaVersion
+	}
+	return pkgdb.MemoriesDBComponent, pkgdb.TargetSchemaVersion
+}
+
+// downgradePostgres reverts the memoriesdb-postgres component to targetVersion. No backup
+// is written, as PostgreSQL databases are backed up with pg_dump.
+func downgradePostgres(dbConn *sql.DB, targetVersion int64) error {
+	currentVersion, err := pkgdb.GetComponentSchemaVersion(dbConn, pkgdb.PostgresMemoriesDBComponent)
+	if err != nil {
+		return err
+	}
+	if currentVersion < targetVersion {
+		return fmt.Errorf("database has schema version %d, which is older than the requested downgrade version %d", currentVersion, targetVersion)
+	}
+	if err := pkgdb.MigrateComponent(dbConn, pkgdb.PostgresMemoriesDBComponent, targetVersion); err != nil {
+		return err
+	}
+	fmt.Println("Database schema downgrade completed successfully for", pkgdb.PostgresMemoriesDBComponent, "component to version", targetVersion)
+	return nil
+}
+
+// printMigrationPlan lists the migrations that would run to reach targetVersion.
+func printMigrationPlan(dbConn *sql.DB, targetVersion int64, direction string) error {
+	component, _ := memoriesComponent()
+	plan, err := pkgdb.PlanMigrations(dbConn, component, targetVersion)
+	if err != nil {
+		return err
+	}
+	if len(plan) == 0 {
+		fmt.Println("Nothing to do: the database is already at schema version", targetVersion)
+		return nil
+	}
+	fmt.Printf("Dry run: the following migrations would %s the %s component to version %d:\n", direction, component, targetVersion)
+	for _, m := range plan {
+		fmt.Printf("  %3d  %s\n", m.Version, m.Description)
+	}
+	return nil
+}
+
+func initDbCmd() {
+	dbCmd.PersistentFlags().StringVar(&dbBackupDirFlag, "backup-dir", "", "Directory for database backups (default: next to the database file)")
+
+	dbUpgradeCmd.Flags().Int64Var(&dbTargetVersionFlag, "to", 0, "Schema version to upgrade to (default: latest)")
+	dbUpgradeCmd.Flags().BoolVar(&dbDryRunFlag, "dry-run", false, "List the migrations that would run without applying them")
+
+	dbDowngradeCmd.Flags().Int64Var(&dbTargetVersionFlag, "to", 0, "Schema version to downgrade to (required)")
+	dbDowngradeCmd.Flags().BoolVar(&dbDryRunFlag, "dry-run", false, "List the migrations that would be reverted without applying them")
+	dbDowngradeCmd.MarkFlagRequired("to")
+
+	dbCmd.AddCommand(
+		dbStatusCmd,
+		dbUpgradeCmd,
+		dbDowngradeCmd,
+		dbBackupCmd,
+		dbRestoreCmd,
+	)
+}
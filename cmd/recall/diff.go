@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+)
+
+// lineDiff returns a line-by-line diff from oldText to newText. Unchanged lines are
+// prefixed with two spaces, removed lines with "- " and added lines with "+ ".
+//
+// Lines the texts start and end with are trimmed first, and the rest is diffed with
+// Hirschberg's algorithm, so memory stays linear in the number of lines however large
+// the revisions are.
+func lineDiff(oldText, newText string) []string {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	var diff []string
+	for _, line := range oldLines[:prefix] {
+		diff = append(diff, "  "+line)
+	}
+	diff = appendLineDiff(diff, oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix])
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		diff = append(diff, "  "+line)
+	}
+	return diff
+}
+
+// splitLines splits text at newlines. Empty text has no lines rather than one empty line,
+// so that content added to or removed from an empty entry doesn't come with a blank line.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// appendLineDiff appends the diff from oldLines to newLines to diff. It splits oldLines in
+// half, finds where a longest common subsequence crosses that split in newLines, and diffs
+// both halves on their own.
+func appendLineDiff(diff, oldLines, newLines []string) []string {
+	switch {
+	case len(oldLines) == 0:
+		for _, line := range newLines {
+			diff = append(diff, "+ "+line)
+		}
+		return diff
+	case len(newLines) == 0:
+		for _, line := range oldLines {
+			diff = append(diff, "- "+line)
+		}
+		return diff
+	case len(oldLines) == 1:
+		for j, line := range newLines {
+			if line == oldLines[0] {
+				diff = appendLineDiff(diff, nil, newLines[:j])
+				diff = append(diff, "  "+line)
+				return appendLineDiff(diff, nil, newLines[j+1:])
+			}
+		}
+		diff = append(diff, "- "+oldLines[0])
+		return appendLineDiff(diff, nil, newLines)
+	}
+
+	mid := len(oldLines) / 2
+	head := lcsPrefixLengths(oldLines[:mid], newLines)
+	tail := lcsSuffixLengths(oldLines[mid:], newLines)
+	split := 0
+	for j := range head {
+		if head[j]+tail[j] > head[split]+tail[split] {
+			split = j
+		}
+	}
+	diff = appendLineDiff(diff, oldLines[:mid], newLines[:split])
+	return appendLineDiff(diff, oldLines[mid:], newLines[split:])
+}
+
+// lcsPrefixLengths returns, for every j, the length of the longest common subsequence of
+// oldLines and newLines[:j], keeping only two rows of the table.
+func lcsPrefixLengths(oldLines, newLines []string) []int {
+	row := make([]int, len(newLines)+1)
+	prev := make([]int, len(newLines)+1)
+	for _, oldLine := range oldLines {
+		row, prev = prev, row
+		for j, newLine := range newLines {
+			if oldLine == newLine {
+				row[j+1] = prev[j] + 1
+			} else {
+				row[j+1] = max(prev[j+1], row[j])
+			}
+		}
+	}
+	return row
+}
+
+// lcsSuffixLengths returns, for every j, the length of the longest common subsequence of
+// oldLines and newLines[j:].
+func lcsSuffixLengths(oldLines, newLines []string) []int {
+	row := make([]int, len(newLines)+1)
+	prev := make([]int, len(newLines)+1)
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		row, prev = prev, row
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				row[j] = prev[j+1] + 1
+			} else {
+				row[j] = max(prev[j], row[j+1])
+			}
+		}
+	}
+	return row
+}
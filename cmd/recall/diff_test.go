@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{"both empty", "", "", nil},
+		{"empty old", "", "a\nb", []string{"+ a", "+ b"}},
+		{"empty new", "a\nb", "", []string{"- a", "- b"}},
+		{"identical", "a\nb\nc", "a\nb\nc", []string{"  a", "  b", "  c"}},
+		{"full replacement", "a\nb", "c\nd\ne", []string{"- a", "- b", "+ c", "+ d", "+ e"}},
+		{"trailing newline added", "a\nb", "a\nb\n", []string{"  a", "  b", "+ "}},
+		{"trailing newline removed", "a\nb\n", "a\nb", []string{"  a", "  b", "- "}},
+		{"changed middle", "a\nb\nc", "a\nx\nc", []string{"  a", "- b", "+ x", "  c"}},
+		{"repeated lines", "a\na\nb\na", "a\nb\na\na", []string{"  a", "- a", "  b", "+ a", "  a"}},
+		{"repeated lines removed", "x\nx\nx\nx", "x\nx", []string{"  x", "  x", "- x", "- x"}},
+		{"moved line", "a\nb\nc\nd", "b\nc\nd\na", []string{"- a", "  b", "  c", "  d", "+ a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lineDiff(tt.old, tt.new)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lineDiff(%q, %q):\nexpected %q\ngot      %q", tt.old, tt.new, tt.want, got)
+			}
+			// Whatever the edit script, it has to turn the old text into the new one.
+			var oldLines, newLines []string
+			for _, line := range got {
+				switch line[:2] {
+				case "  ":
+					oldLines = append(oldLines, line[2:])
+					newLines = append(newLines, line[2:])
+				case "- ":
+					oldLines = append(oldLines, line[2:])
+				case "+ ":
+					newLines = append(newLines, line[2:])
+				}
+			}
+			if strings.Join(oldLines, "\n") != tt.old || strings.Join(newLines, "\n") != tt.new {
+				t.Errorf("lineDiff(%q, %q) = %q doesn't turn the old text into the new one", tt.old, tt.new, got)
+			}
+		})
+	}
+}
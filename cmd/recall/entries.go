@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
@@ -16,6 +19,10 @@ var (
 	contentTypeFlag    string
 	includeDeletedFlag bool
 	showTagsFlag       bool
+	sortEntriesFlag    string
+	olderThanFlag      string
+	linkRelationFlag   string
+	unlinkRelationFlag string
 )
 
 var entriesCmd = &cobra.Command{
@@ -37,6 +44,7 @@ var createEntryCmd = &cobra.Command{
 		title, _ := cmd.Flags().GetString("title")
 		content, _ := cmd.Flags().GetString("content")
 		tagsStr, _ := cmd.Flags().GetString("tags")
+		ttl, _ := cmd.Flags().GetString("ttl")
 
 		if title == "" {
 			return errors.New("entry title is required")
@@ -46,6 +54,13 @@ var createEntryCmd = &cobra.Command{
 			return errors.New("entry content is required")
 		}
 
+		var expiresAt float64
+		if ttl != "" {
+			if expiresAt, err = memories.ParseTTL(ttl, time.Now()); err != nil {
+				return fmt.Errorf("invalid --ttl: %w", err)
+			}
+		}
+
 		var tagNames []string
 		if tagsStr != "" {
 			tagNames = strings.Split(tagsStr, ",")
@@ -61,30 +76,35 @@ var createEntryCmd = &cobra.Command{
 			tagNames = actualTags
 		}
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		entry, err := memories.CreateEntry(cmd.Context(), dbConn, journalID, title, content, contentTypeFlag)
+		entry, err := store.CreateEntry(cmd.Context(), journalID, title, content, contentTypeFlag)
 		if errors.Is(err, memories.ErrJournalNotFound) {
 			return fmt.Errorf("journal not found: %s", journalIDFlag)
 		}
 		if err != nil {
 			return fmt.Errorf("failed to create entry: %w", err)
 		}
+		if expiresAt != 0 {
+			if entry, err = store.SetEntryExpiry(cmd.Context(), entry.ID, expiresAt); err != nil {
+				return fmt.Errorf("entry created, but its expiry could not be set: %w", err)
+			}
+		}
 
 		var lastTaggingError error
 		for _, tagName := range tagNames {
-			err = memories.TagEntry(cmd.Context(), dbConn, entry.ID, tagName)
+			err = store.TagEntry(cmd.Context(), entry.ID, tagName)
 			if err != nil {
 				lastTaggingError = fmt.Errorf("failed to apply tag '%s': %w", tagName, err)
 				cmd.PrintErrln(lastTaggingError)
 			}
 		}
 
-		createdEntryTags, listTagsErr := memories.ListTagsForEntry(cmd.Context(), dbConn, entry.ID)
+		createdEntryTags, listTagsErr := store.ListTagsForEntry(cmd.Context(), entry.ID)
 		if listTagsErr != nil {
 			cmd.PrintErrf("Failed to retrieve tags for new entry: %v\n", listTagsErr)
 		}
@@ -109,13 +129,13 @@ var getEntryCmd = &cobra.Command{
 			return fmt.Errorf("invalid entry ID: %w", err)
 		}
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		entry, err := memories.GetEntry(context.Background(), dbConn, entryID)
+		entry, err := store.GetEntry(context.Background(), entryID)
 		if errors.Is(err, memories.ErrEntryNotFound) {
 			return fmt.Errorf("entry not found: %s", entryIDStr)
 		}
@@ -125,7 +145,7 @@ var getEntryCmd = &cobra.Command{
 
 		var tags []memories.Tag
 		if showTagsFlag {
-			tags, err = memories.ListTagsForEntry(context.Background(), dbConn, entry.ID)
+			tags, err = store.ListTagsForEntry(context.Background(), entry.ID)
 			if err != nil {
 				return fmt.Errorf("failed to get tags for entry: %w", err)
 			}
@@ -139,23 +159,42 @@ var getEntryCmd = &cobra.Command{
 var listEntriesCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List entries in a journal",
-	Long:  `List all entries in a specified journal.`,
+	Long: `List all entries in a specified journal, most recently updated first.
+
+With --sort accessed, entries are listed most recently read by agents first, with the
+number of reads of each; --limit then keeps the top entries.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		journalID, err := uuid.Parse(journalIDFlag)
 		if err != nil {
 			return fmt.Errorf("invalid journal ID: %w", err)
 		}
+		switch sortEntriesFlag {
+		case "updated":
+		case "accessed":
+			if cursorFlag != "" {
+				return errors.New("--cursor cannot be used with --sort accessed")
+			}
+		default:
+			return fmt.Errorf("invalid --sort %q: must be updated or accessed", sortEntriesFlag)
+		}
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
+
+		if sortEntriesFlag == "accessed" {
+			return listEntriesByAccess(cmd.Context(), store, journalID)
+		}
 
-		entries, err := memories.ListEntries(context.Background(), dbConn, journalID, includeDeletedFlag)
+		entries, next, err := store.ListEntriesPage(context.Background(), journalID, includeDeletedFlag, pageFromFlags())
 		if errors.Is(err, memories.ErrJournalNotFound) {
 			return fmt.Errorf("journal not found: %s", journalIDFlag)
 		}
+		if pageErr := pageError(err); pageErr != nil {
+			return pageErr
+		}
 		if err != nil {
 			return fmt.Errorf("failed to list entries: %w", err)
 		}
@@ -175,7 +214,7 @@ var listEntriesCmd = &cobra.Command{
 				updatedAt := formatTimestamp(e.UpdatedAt)
 
 				// Get tags for this entry
-				tags, err := memories.ListTagsForEntry(context.Background(), dbConn, e.ID)
+				tags, err := store.ListTagsForEntry(context.Background(), e.ID)
 				if err != nil {
 					return fmt.Errorf("failed to get tags for entry %s: %w", e.ID, err)
 				}
@@ -193,15 +232,65 @@ var listEntriesCmd = &cobra.Command{
 					e.ID, e.Title, e.ContentType, e.Deleted, createdAt, updatedAt)
 			}
 		}
+		printNextCursor(next)
 		return nil
 	},
 }
 
+// listEntriesByAccess prints the entries of a journal most recently read first, with
+// entries that were never read last.
+func listEntriesByAccess(ctx context.Context, store memories.Store, journalID uuid.UUID) error {
+	entries, err := store.ListEntries(ctx, journalID, includeDeletedFlag)
+	if errors.Is(err, memories.ErrJournalNotFound) {
+		return fmt.Errorf("journal not found: %s", journalIDFlag)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list entries: %w", err)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No entries found in this journal.")
+		return nil
+	}
+	sortByAccess(entries)
+	if limitFlag > 0 && len(entries) > limitFlag {
+		entries = entries[:limitFlag]
+	}
+
+	fmt.Println("Entries:")
+	fmt.Println("ID | Title | Reads | Last Accessed | Updated At")
+	fmt.Println("------------------------------------------------------------")
+	for _, e := range entries {
+		fmt.Printf("%s | %s | %d | %s | %s\n",
+			e.ID, e.Title, e.AccessCount, formatAccessTime(e.LastAccessedAt), formatTimestamp(e.UpdatedAt))
+	}
+	return nil
+}
+
+// sortByAccess orders entries by their last read, then their number of reads, newest
+// and most first.
+func sortByAccess(entries []memories.Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].LastAccessedAt != entries[j].LastAccessedAt {
+			return entries[i].LastAccessedAt > entries[j].LastAccessedAt
+		}
+		return entries[i].AccessCount > entries[j].AccessCount
+	})
+}
+
+// formatAccessTime formats the last read of an entry, which is 0 when it was never read.
+func formatAccessTime(at float64) string {
+	if at == 0 {
+		return "never"
+	}
+	return formatTimestamp(at)
+}
+
 var updateEntryCmd = &cobra.Command{
 	Use:   "update [entry-id]",
 	Short: "Update an entry",
-	Long:  `Update an entry's title, content, or content type.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Update an entry's title, content, or content type, or when it expires with --ttl.
+--ttl never removes the expiry.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		entryIDStr := args[0]
 		entryID, err := uuid.Parse(entryIDStr)
@@ -211,14 +300,29 @@ var updateEntryCmd = &cobra.Command{
 
 		title, _ := cmd.Flags().GetString("title")
 		content, _ := cmd.Flags().GetString("content")
+		ttl, _ := cmd.Flags().GetString("ttl")
+		setExpiry := cmd.Flags().Changed("ttl")
+
+		var expiresAt float64
+		if setExpiry {
+			if expiresAt, err = memories.ParseTTL(ttl, time.Now()); err != nil {
+				return fmt.Errorf("invalid --ttl: %w", err)
+			}
+		}
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		entry, err := memories.UpdateEntry(cmd.Context(), dbConn, entryID, title, content, contentTypeFlag)
+		var entry memories.Entry
+		if title != "" || content != "" || contentTypeFlag != "" || !setExpiry {
+			entry, err = store.UpdateEntry(memories.WithActor(cmd.Context(), "cli"), entryID, title, content, contentTypeFlag)
+		}
+		if err == nil && setExpiry {
+			entry, err = store.SetEntryExpiry(cmd.Context(), entryID, expiresAt)
+		}
 		if errors.Is(err, memories.ErrEntryNotFound) {
 			return fmt.Errorf("entry not found: %s", entryIDStr)
 		}
@@ -228,7 +332,7 @@ var updateEntryCmd = &cobra.Command{
 
 		fmt.Println("Entry updated successfully!")
 		var updatedEntryTags []memories.Tag
-		updatedEntryTags, err = memories.ListTagsForEntry(cmd.Context(), dbConn, entry.ID)
+		updatedEntryTags, err = store.ListTagsForEntry(cmd.Context(), entry.ID)
 		if err != nil {
 			cmd.PrintErrf("Failed to retrieve tags for updated entry: %v\n", err)
 		}
@@ -249,13 +353,13 @@ var deleteEntryCmd = &cobra.Command{
 			return fmt.Errorf("invalid entry ID: %w", err)
 		}
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		err = memories.DeleteEntry(cmd.Context(), dbConn, entryID)
+		err = store.DeleteEntry(cmd.Context(), entryID)
 		if errors.Is(err, memories.ErrEntryNotFound) {
 			return fmt.Errorf("entry not found: %s", entryIDStr)
 		}
@@ -268,23 +372,73 @@ var deleteEntryCmd = &cobra.Command{
 	},
 }
 
+var restoreEntryCmd = &cobra.Command{
+	Use:   "restore [entry-id]",
+	Short: "Restore a deleted entry",
+	Long:  `Bring back an entry that was soft-deleted or has expired. An expiry that has passed is removed so the entry is not reaped again; one still ahead is kept.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entryIDStr := args[0]
+		entryID, err := uuid.Parse(entryIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid entry ID: %w", err)
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		entry, err := store.RestoreEntry(cmd.Context(), entryID)
+		if errors.Is(err, memories.ErrEntryNotFound) {
+			return fmt.Errorf("entry not found: %s", entryIDStr)
+		}
+		if errors.Is(err, memories.ErrEntryNotDeleted) {
+			return fmt.Errorf("entry %s is not deleted", entryIDStr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restore entry: %w", err)
+		}
+
+		fmt.Printf("Entry %s restored.\n", entryIDStr)
+		printEntry(entry, nil)
+		return nil
+	},
+}
+
 var cleanEntriesCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "Permanently delete soft-deleted entries",
-	Long:  `Permanently delete all entries that have been previously soft-deleted in a journal.`,
+	Long: `Permanently delete all entries that have been previously soft-deleted in a journal.
+With --older-than, only entries deleted longer ago than the given duration, such as 30d,
+are removed and the rest stay restorable.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		journalID, err := uuid.Parse(journalIDFlag)
 		if err != nil {
 			return fmt.Errorf("invalid journal ID: %w", err)
 		}
 
-		dbConn, err := openDB()
+		var olderThan time.Duration
+		if olderThanFlag != "" {
+			if olderThan, err = memories.ParseDuration(olderThanFlag); err != nil {
+				return fmt.Errorf("invalid --older-than: %w", err)
+			}
+		}
+
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		count, err := memories.CleanDeletedEntries(cmd.Context(), dbConn, journalID)
+		var count int64
+		if olderThan > 0 {
+			before := float64(time.Now().Add(-olderThan).Unix())
+			count, err = store.CleanDeletedEntriesBefore(cmd.Context(), journalID, before)
+		} else {
+			count, err = store.CleanDeletedEntries(cmd.Context(), journalID)
+		}
 		if errors.Is(err, memories.ErrJournalNotFound) {
 			return fmt.Errorf("journal not found: %s", journalIDFlag)
 		}
@@ -297,6 +451,146 @@ var cleanEntriesCmd = &cobra.Command{
 	},
 }
 
+var historyEntryCmd = &cobra.Command{
+	Use:   "history [entry-id]",
+	Short: "Show the revision history of an entry",
+	Long:  `List the prior versions of an entry, newest first. Every update that changes an entry's title, content, or content type records the replaced version as a revision.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entryIDStr := args[0]
+		entryID, err := uuid.Parse(entryIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid entry ID: %w", err)
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		revisions, err := store.ListEntryRevisions(cmd.Context(), entryID)
+		if errors.Is(err, memories.ErrEntryNotFound) {
+			return fmt.Errorf("entry not found: %s", entryIDStr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list entry revisions: %w", err)
+		}
+
+		if len(revisions) == 0 {
+			fmt.Println("No revisions found for this entry.")
+			return nil
+		}
+
+		fmt.Println("Revisions:")
+		fmt.Println("Revision | Title | Content Type | Actor | Replaced At")
+		fmt.Println("------------------------------------------------------------")
+		for _, r := range revisions {
+			actor := r.Actor
+			if actor == "" {
+				actor = "unknown"
+			}
+			fmt.Printf("%d | %s | %s | %s | %s\n",
+				r.Revision, r.Title, r.ContentType, actor, formatTimestamp(r.CreatedAt))
+		}
+		return nil
+	},
+}
+
+var diffEntryCmd = &cobra.Command{
+	Use:   "diff [entry-id] [revision]",
+	Short: "Compare a revision with the current entry",
+	Long:  `Show a line-by-line diff from a prior revision of an entry to its current version.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entryIDStr := args[0]
+		entryID, err := uuid.Parse(entryIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid entry ID: %w", err)
+		}
+		revisionNumber, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid revision number: %w", err)
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		revision, err := store.GetEntryRevision(cmd.Context(), entryID, revisionNumber)
+		if errors.Is(err, memories.ErrEntryNotFound) {
+			return fmt.Errorf("entry not found: %s", entryIDStr)
+		}
+		if errors.Is(err, memories.ErrRevisionNotFound) {
+			return fmt.Errorf("revision %d not found for entry %s", revisionNumber, entryIDStr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get entry revision: %w", err)
+		}
+
+		entry, err := store.GetEntry(cmd.Context(), entryID)
+		if err != nil {
+			return fmt.Errorf("failed to get entry: %w", err)
+		}
+
+		fmt.Printf("--- revision %d\n", revision.Revision)
+		fmt.Println("+++ current")
+		if revision.Title != entry.Title {
+			fmt.Printf("Title:        %s -> %s\n", revision.Title, entry.Title)
+		}
+		if revision.ContentType != entry.ContentType {
+			fmt.Printf("Content Type: %s -> %s\n", revision.ContentType, entry.ContentType)
+		}
+		fmt.Println("------------------------------------------------------------")
+		for _, line := range lineDiff(revision.Content, entry.Content) {
+			fmt.Println(line)
+		}
+		fmt.Println("------------------------------------------------------------")
+		return nil
+	},
+}
+
+var revertEntryCmd = &cobra.Command{
+	Use:   "revert [entry-id] [revision]",
+	Short: "Restore an entry to a prior revision",
+	Long:  `Restore an entry's title, content, and content type from a prior revision. The version being replaced is kept as a new revision.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entryIDStr := args[0]
+		entryID, err := uuid.Parse(entryIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid entry ID: %w", err)
+		}
+		revisionNumber, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid revision number: %w", err)
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		entry, err := store.RestoreEntryRevision(memories.WithActor(cmd.Context(), "cli"), entryID, revisionNumber)
+		if errors.Is(err, memories.ErrEntryNotFound) {
+			return fmt.Errorf("entry not found: %s", entryIDStr)
+		}
+		if errors.Is(err, memories.ErrRevisionNotFound) {
+			return fmt.Errorf("revision %d not found for entry %s", revisionNumber, entryIDStr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restore entry revision: %w", err)
+		}
+
+		fmt.Printf("Entry %s restored to revision %d.\n", entryIDStr, revisionNumber)
+		printEntry(entry, nil)
+		return nil
+	},
+}
+
 var tagEntryCmd = &cobra.Command{
 	Use:   "tag [entry-id] [tag]...",
 	Short: "Tag an entry",
@@ -311,14 +605,14 @@ var tagEntryCmd = &cobra.Command{
 
 		tags := args[1:]
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
 		for _, tag := range tags {
-			err = memories.TagEntry(context.Background(), dbConn, entryID, tag)
+			err = store.TagEntry(context.Background(), entryID, tag)
 			if errors.Is(err, memories.ErrEntryNotFound) {
 				return fmt.Errorf("entry not found: %s", entryIDStr)
 			}
@@ -346,15 +640,15 @@ var untagEntryCmd = &cobra.Command{
 
 		tags := args[1:]
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
 		var failedTags []string
 		for _, tag := range tags {
-			err = memories.DetachTag(context.Background(), dbConn, entryID, tag)
+			err = store.DetachTag(context.Background(), entryID, tag)
 			if errors.Is(err, memories.ErrTagNotFound) {
 				failedTags = append(failedTags, tag)
 				continue
@@ -392,6 +686,90 @@ var untagEntryCmd = &cobra.Command{
 	},
 }
 
+var linkEntryCmd = &cobra.Command{
+	Use:   "link [source-entry-id] [target-entry-id]",
+	Short: "Link an entry to another",
+	Long: `Link the source entry to the target entry with a relation, read as "source relation
+target": relates_to (see also), supersedes (the source replaces the target) or
+depends_on (the source builds on the target). Entries may belong to different journals.
+Follow links with "recall graph" or the get_related_entries MCP tool.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sourceID, targetID, err := parseLinkArgs(args)
+		if err != nil {
+			return err
+		}
+		relation, err := memories.ParseLinkRelation(linkRelationFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --relation: %w", err)
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		link, err := store.LinkEntries(cmd.Context(), sourceID, targetID, relation)
+		if errors.Is(err, memories.ErrEntryNotFound) {
+			return fmt.Errorf("entry not found: %s or %s", args[0], args[1])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to link entries: %w", err)
+		}
+
+		fmt.Printf("Entry %s %s entry %s.\n", link.SourceID, link.Relation, link.TargetID)
+		return nil
+	},
+}
+
+var unlinkEntryCmd = &cobra.Command{
+	Use:   "unlink [source-entry-id] [target-entry-id]",
+	Short: "Remove links between entries",
+	Long:  `Remove the link from the source entry to the target entry with --relation, or their links of every relation if it is not given.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sourceID, targetID, err := parseLinkArgs(args)
+		if err != nil {
+			return err
+		}
+		var relation memories.LinkRelation
+		if unlinkRelationFlag != "" {
+			if relation, err = memories.ParseLinkRelation(unlinkRelationFlag); err != nil {
+				return fmt.Errorf("invalid --relation: %w", err)
+			}
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		err = store.UnlinkEntries(cmd.Context(), sourceID, targetID, relation)
+		if errors.Is(err, memories.ErrLinkNotFound) {
+			return fmt.Errorf("entry %s is not linked to entry %s", sourceID, targetID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to unlink entries: %w", err)
+		}
+
+		fmt.Printf("Entry %s unlinked from entry %s.\n", sourceID, targetID)
+		return nil
+	},
+}
+
+// parseLinkArgs parses the source and target entry IDs of link and unlink.
+func parseLinkArgs(args []string) (sourceID, targetID uuid.UUID, err error) {
+	if sourceID, err = uuid.Parse(args[0]); err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid source entry ID: %w", err)
+	}
+	if targetID, err = uuid.Parse(args[1]); err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid target entry ID: %w", err)
+	}
+	return sourceID, targetID, nil
+}
+
 func initEntriesCmd() {
 	// entriesCmd.PersistentFlags().StringVar(&dbPath, "db", "", "Path to the database file (required)") // Inherited from rootCmd
 	// entriesCmd.PersistentFlags().BoolVar(&walMode, "wal", true, "Enable SQLite WAL (Write-Ahead Logging) mode") // Inherited from rootCmd
@@ -405,6 +783,7 @@ func initEntriesCmd() {
 	createEntryCmd.Flags().String("title", "", "Title of the entry (required)")
 	createEntryCmd.Flags().String("content", "", "Content of the entry (required)")
 	createEntryCmd.Flags().String("tags", "", "Comma-separated list of tags for the entry")
+	createEntryCmd.Flags().String("ttl", "", "Time until the entry expires, such as 14d, 2w or 12h (default: never)")
 	createEntryCmd.MarkFlagRequired("title")
 	createEntryCmd.MarkFlagRequired("content")
 	createEntryCmd.MarkFlagRequired("journal")
@@ -413,22 +792,35 @@ func initEntriesCmd() {
 
 	listEntriesCmd.Flags().BoolVar(&includeDeletedFlag, "include-deleted", false, "Include soft-deleted entries in the listing")
 	listEntriesCmd.Flags().BoolVar(&showTagsFlag, "tags", false, "Show tags for each entry")
+	listEntriesCmd.Flags().StringVar(&sortEntriesFlag, "sort", "updated", "Order of the listing: updated or accessed")
+	addPageFlags(listEntriesCmd)
 	listEntriesCmd.MarkFlagRequired("journal")
 
 	updateEntryCmd.Flags().String("title", "", "New title for the entry")
 	updateEntryCmd.Flags().String("content", "", "New content for the entry")
+	updateEntryCmd.Flags().String("ttl", "", "New time until the entry expires, such as 14d, or 'never'")
 
+	cleanEntriesCmd.Flags().StringVar(&olderThanFlag, "older-than", "", "Only delete entries deleted longer ago than this, such as 30d (default: all)")
 	cleanEntriesCmd.MarkFlagRequired("journal")
 
+	linkEntryCmd.Flags().StringVar(&linkRelationFlag, "relation", string(memories.LinkRelatesTo), "Relation of the link: relates_to, supersedes or depends_on")
+	unlinkEntryCmd.Flags().StringVar(&unlinkRelationFlag, "relation", "", "Relation of the link to remove (default: all)")
+
 	entriesCmd.AddCommand(
 		createEntryCmd,
 		getEntryCmd,
 		listEntriesCmd,
 		updateEntryCmd,
 		deleteEntryCmd,
+		restoreEntryCmd,
 		cleanEntriesCmd,
+		historyEntryCmd,
+		diffEntryCmd,
+		revertEntryCmd,
 		tagEntryCmd,
 		untagEntryCmd,
+		linkEntryCmd,
+		unlinkEntryCmd,
 	)
 }
 
@@ -449,6 +841,13 @@ func printEntry(entry memories.Entry, tags []memories.Tag) {
 
 	fmt.Printf("Created At:   %s\n", createdAt)
 	fmt.Printf("Updated At:   %s\n", updatedAt)
+	if entry.ExpiresAt != 0 {
+		expiresAt := formatTimestamp(entry.ExpiresAt)
+		if entry.Expired(time.Now()) {
+			expiresAt += " (expired)"
+		}
+		fmt.Printf("Expires At:   %s\n", expiresAt)
+	}
 	fmt.Println("\nContent:")
 	fmt.Println("------------------------------------------------------------")
 	fmt.Println(entry.Content)
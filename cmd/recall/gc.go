@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Soft-delete or purge expired entries",
+	Long: `Remove the entries whose time to live has passed, as "recall mcp" does in the
+background. Entries of journals with the soft_delete expiry policy are marked as
+deleted; entries of journals with the purge policy are removed with their tags and
+history. Set a journal's policy with "recall journals update --expiry-policy".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		result, err := store.ReapExpiredEntries(cmd.Context(), float64(time.Now().Unix()))
+		if err != nil {
+			return fmt.Errorf("failed to reap expired entries: %w", err)
+		}
+		fmt.Printf("Expired entries: %d soft-deleted, %d purged\n", result.Deleted, result.Purged)
+		return nil
+	},
+}
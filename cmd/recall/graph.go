@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+var (
+	graphJournalIDFlag string
+	graphEntryIDFlag   string
+	graphDepthFlag     int
+	graphRelationsFlag []string
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Print the links between entries as a Graphviz DOT graph",
+	Long: `Print entries and the links between them in the Graphviz DOT language: every entry of
+a journal with --journal, or the entries reached from one entry by following links in
+either direction with --entry, up to --depth links away. Deleted and expired entries
+are left out. Link entries with "recall entries link".
+
+  recall graph --journal <journal-id> | dot -Tsvg -o graph.svg
+  recall graph --entry <entry-id> --depth 2 --relations supersedes`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if (graphJournalIDFlag == "") == (graphEntryIDFlag == "") {
+			return errors.New("exactly one of --journal and --entry is required")
+		}
+		if graphDepthFlag <= 0 {
+			return errors.New("--depth must be positive")
+		}
+		var relations []memories.LinkRelation
+		for _, name := range graphRelationsFlag {
+			relation, err := memories.ParseLinkRelation(name)
+			if err != nil {
+				return fmt.Errorf("invalid --relations: %w", err)
+			}
+			relations = append(relations, relation)
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		var graph memories.EntryGraph
+		if graphJournalIDFlag != "" {
+			journalID, err := uuid.Parse(graphJournalIDFlag)
+			if err != nil {
+				return fmt.Errorf("invalid journal ID: %w", err)
+			}
+			graph, err = memories.JournalGraph(cmd.Context(), store, journalID, relations...)
+			if errors.Is(err, memories.ErrJournalNotFound) {
+				return fmt.Errorf("journal not found: %s", graphJournalIDFlag)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to build graph: %w", err)
+			}
+		} else {
+			entryID, err := uuid.Parse(graphEntryIDFlag)
+			if err != nil {
+				return fmt.Errorf("invalid entry ID: %w", err)
+			}
+			graph, err = memories.RelatedEntries(cmd.Context(), store, entryID, memories.GraphQuery{Depth: graphDepthFlag, Relations: relations})
+			if errors.Is(err, memories.ErrEntryNotFound) {
+				return fmt.Errorf("entry not found: %s", graphEntryIDFlag)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to build graph: %w", err)
+			}
+		}
+		return memories.WriteDOT(os.Stdout, graph)
+	},
+}
+
+func initGraphCmd() {
+	graphCmd.Flags().StringVar(&graphJournalIDFlag, "journal", "", "Journal ID to graph every entry of")
+	graphCmd.Flags().StringVar(&graphEntryIDFlag, "entry", "", "Entry ID to follow links from")
+	graphCmd.Flags().IntVar(&graphDepthFlag, "depth", memories.DefaultLinkDepth, "Number of links to follow from --entry")
+	graphCmd.Flags().StringSliceVar(&graphRelationsFlag, "relations", nil, "Comma-separated relations to follow (default: all)")
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
@@ -29,21 +30,31 @@ var createJournalCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name, _ := cmd.Flags().GetString("name")
 		description, _ := cmd.Flags().GetString("description")
+		policyFlag, _ := cmd.Flags().GetString("expiry-policy")
 
 		if name == "" {
 			return errors.New("journal name is required")
 		}
+		policy, err := memories.ParseExpiryPolicy(policyFlag)
+		if err != nil {
+			return err
+		}
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		journal, err := memories.CreateJournal(context.Background(), dbConn, name, description)
+		journal, err := store.CreateJournal(context.Background(), name, description)
 		if err != nil {
 			return fmt.Errorf("failed to create journal: %w", err)
 		}
+		if policy != journal.ExpiryPolicy {
+			if journal, err = store.SetJournalExpiryPolicy(context.Background(), journal.ID, policy); err != nil {
+				return fmt.Errorf("journal created, but its expiry policy could not be set: %w", err)
+			}
+		}
 
 		printJournal(journal)
 		return nil
@@ -62,13 +73,13 @@ var getJournalCmd = &cobra.Command{
 			return fmt.Errorf("invalid journal ID: %w", err)
 		}
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		journal, err := memories.GetJournal(context.Background(), dbConn, journalID)
+		journal, err := store.GetJournal(context.Background(), journalID)
 		if errors.Is(err, memories.ErrJournalNotFound) {
 			return fmt.Errorf("journal not found: %s", journalIDStr)
 		}
@@ -86,14 +97,17 @@ var listJournalsCmd = &cobra.Command{
 	Short: "List journals",
 	Long:  `List all journals, or only active ones.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		journals, err := memories.ListJournals(context.Background(), dbConn, activeOnly)
+		journals, next, err := store.ListJournalsPage(context.Background(), activeOnly, pageFromFlags())
 		if err != nil {
+			if pageErr := pageError(err); pageErr != nil {
+				return pageErr
+			}
 			return fmt.Errorf("failed to list journals: %w", err)
 		}
 
@@ -111,6 +125,7 @@ var listJournalsCmd = &cobra.Command{
 			fmt.Printf("%s | %s | %s | %t | %s | %s\n",
 				j.ID, j.Name, j.Description, j.Active, createdAt, updatedAt)
 		}
+		printNextCursor(next)
 		return nil
 	},
 }
@@ -118,7 +133,7 @@ var listJournalsCmd = &cobra.Command{
 var updateJournalCmd = &cobra.Command{
 	Use:   "update [journal-id]",
 	Short: "Update a journal",
-	Long:  `Update a journal's name, description, or active status.`,
+	Long:  `Update a journal's name, description, active status, or expiry policy.`,
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		journalIDStr := args[0]
@@ -130,14 +145,22 @@ var updateJournalCmd = &cobra.Command{
 		name, _ := cmd.Flags().GetString("name")
 		description, _ := cmd.Flags().GetString("description")
 		active, _ := cmd.Flags().GetBool("active")
+		policyFlag, _ := cmd.Flags().GetString("expiry-policy")
+
+		var policy memories.ExpiryPolicy
+		if cmd.Flags().Changed("expiry-policy") {
+			if policy, err = memories.ParseExpiryPolicy(policyFlag); err != nil {
+				return err
+			}
+		}
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		currentJournal, err := memories.GetJournal(context.Background(), dbConn, journalID)
+		currentJournal, err := store.GetJournal(context.Background(), journalID)
 		if errors.Is(err, memories.ErrJournalNotFound) {
 			return fmt.Errorf("journal not found: %s", journalIDStr)
 		}
@@ -155,10 +178,15 @@ var updateJournalCmd = &cobra.Command{
 			active = currentJournal.Active
 		}
 
-		journal, err := memories.UpdateJournal(context.Background(), dbConn, journalID, name, description, active)
+		journal, err := store.UpdateJournal(context.Background(), journalID, name, description, active)
 		if err != nil {
 			return fmt.Errorf("failed to update journal: %w", err)
 		}
+		if policy != "" && policy != journal.ExpiryPolicy {
+			if journal, err = store.SetJournalExpiryPolicy(context.Background(), journalID, policy); err != nil {
+				return fmt.Errorf("failed to update journal: %w", err)
+			}
+		}
 
 		fmt.Println("Journal updated successfully!")
 		printJournal(journal)
@@ -178,13 +206,13 @@ var deleteJournalCmd = &cobra.Command{
 			return fmt.Errorf("invalid journal ID: %w", err)
 		}
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		err = memories.DeleteJournal(context.Background(), dbConn, journalID)
+		err = store.DeleteJournal(context.Background(), journalID)
 		if errors.Is(err, memories.ErrJournalNotFound) {
 			return fmt.Errorf("journal not found: %s", journalIDStr)
 		}
@@ -202,13 +230,13 @@ var cleanJournalsCmd = &cobra.Command{
 	Short: "Delete inactive journals",
 	Long:  `Delete all inactive journals from the database.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		count, err := memories.DeleteInactiveJournals(context.Background(), dbConn)
+		count, err := store.DeleteInactiveJournals(context.Background())
 		if err != nil {
 			return fmt.Errorf("failed to clean inactive journals: %w", err)
 		}
@@ -226,13 +254,16 @@ func initJournalsCmd() {
 
 	createJournalCmd.Flags().String("name", "", "Name of the journal (required)")
 	createJournalCmd.Flags().String("description", "", "Description of the journal")
+	createJournalCmd.Flags().String("expiry-policy", string(memories.ExpirySoftDelete), "What the reaper does with expired entries: soft_delete or purge")
 	createJournalCmd.MarkFlagRequired("name")
 
 	listJournalsCmd.Flags().BoolVar(&activeOnly, "active-only", false, "List only active journals")
+	addPageFlags(listJournalsCmd)
 
 	updateJournalCmd.Flags().String("name", "", "New name for the journal")
 	updateJournalCmd.Flags().String("description", "", "New description for the journal")
 	updateJournalCmd.Flags().Bool("active", true, "Set journal active status")
+	updateJournalCmd.Flags().String("expiry-policy", "", "What the reaper does with expired entries: soft_delete or purge")
 
 	journalsCmd.AddCommand(
 		createJournalCmd,
@@ -244,13 +275,66 @@ func initJournalsCmd() {
 	)
 }
 
+// openDB opens the database at --db, a SQLite file or a postgres:// URL.
 func openDB() (*sql.DB, error) {
 	if dbPath == "" {
 		return nil, errors.New("database path is required")
 	}
+	if db.IsPostgresDSN(dbPath) {
+		return db.OpenPostgresConnection(dbPath)
+	}
 	return db.OpenDBConnection(dbPath, walMode, syncMode)
 }
 
+// openStore opens the memory store in the database at --db.
+func openStore() (memories.Store, error) {
+	store, err := openEmbeddingStore()
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// openEmbeddingStore opens the memory store in the database at --db, embedding entries
+// with the embedder selected by --embedder.
+func openEmbeddingStore() (*memories.EmbeddingStore, error) {
+	embedder, err := newEmbedder()
+	if err != nil {
+		return nil, err
+	}
+	dbConn, err := openDB()
+	if err != nil {
+		return nil, err
+	}
+	if db.IsPostgresDSN(dbPath) {
+		return memories.NewEmbeddingStore(memories.NewPostgresStore(dbConn), embedder), nil
+	}
+	return memories.NewEmbeddingStore(memories.NewSQLiteStore(dbConn), embedder), nil
+}
+
+// newEmbedder returns the embedder selected by --embedder.
+func newEmbedder() (memories.Embedder, error) {
+	name, model, _ := strings.Cut(embedderFlag, ":")
+	switch name {
+	case "hashing":
+		return memories.NewHashingEmbedder(0), nil
+	case "ollama":
+		if model == "" {
+			model = defaultOllamaModel
+		}
+		return memories.NewOllamaEmbedder(ollamaURLFlag, model), nil
+	}
+	return nil, fmt.Errorf("invalid --embedder %q: must be 'hashing' or 'ollama:<model>'", embedderFlag)
+}
+
+// requireSQLite fails for commands that only work on SQLite databases when --db is a PostgreSQL URL.
+func requireSQLite(what string) error {
+	if db.IsPostgresDSN(dbPath) {
+		return fmt.Errorf("%s is only supported for SQLite databases", what)
+	}
+	return nil
+}
+
 func printJournal(journal memories.Journal) {
 	createdAt := formatTimestamp(journal.CreatedAt)
 	updatedAt := formatTimestamp(journal.UpdatedAt)
@@ -260,6 +344,7 @@ func printJournal(journal memories.Journal) {
 	fmt.Printf("Name:        %s\n", journal.Name)
 	fmt.Printf("Description: %s\n", journal.Description)
 	fmt.Printf("Active:      %t\n", journal.Active)
+	fmt.Printf("Expiry:      %s\n", journal.ExpiryPolicy)
 	fmt.Printf("Created At:  %s\n", createdAt)
 	fmt.Printf("Updated At:  %s\n", updatedAt)
 }
@@ -7,6 +7,7 @@ import (
 
 	recall "github.com/unowned-ai/recall/pkg"
 	pkgdb "github.com/unowned-ai/recall/pkg/db"
+	"github.com/unowned-ai/recall/pkg/memories"
 	recallutils "github.com/unowned-ai/recall/pkg/utils"
 
 	"github.com/spf13/cobra"
@@ -15,6 +16,11 @@ import (
 var dbPath string
 var walMode bool
 var syncMode string
+var embedderFlag string
+var ollamaURLFlag string
+
+// defaultOllamaModel is the embedding model used by --embedder ollama.
+const defaultOllamaModel = "nomic-embed-text"
 
 var rootCmd = &cobra.Command{
 	Use:     "recall",
@@ -26,6 +32,9 @@ var rootCmd = &cobra.Command{
 		case "completion", "version", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
 			return nil
 		}
+		if pkgdb.IsPostgresDSN(dbPath) {
+			return nil
+		}
 		resolvedPath, err := recallutils.ResolveAndEnsureDBPath(dbPath)
 		if err != nil {
 			return fmt.Errorf("error preparing database path: %w", err)
@@ -93,51 +102,27 @@ var versionCmd = &cobra.Command{
 	},
 }
 
-var dbCmd = &cobra.Command{
-	Use:   "db",
-	Short: "Manage the recall database",
-	Long:  `Provides commands for managing the Recall SQLite database, including schema upgrades. GIGO.`,
-}
-
-var dbUpgradeCmd = &cobra.Command{
-	Use:   "upgrade",
-	Short: "Upgrade the Recall database schema to the latest version for the memoriesdb component",
-	Long: `Connects to the SQLite database at the specified path (or system default if --db is not provided)
-and applies any necessary schema migrations to bring the memoriesdb component up to the current application schema version.
-If the database does not exist or is uninitialized for this component, it will be created
-and initialized with the latest schema for the memoriesdb component.`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-
-		fmt.Printf("Attempting to upgrade memoriesdb component in database at: %s (WAL: %t, Sync: %s)\n", dbPath, walMode, syncMode)
-
-		dbConn, err := pkgdb.OpenDBConnection(dbPath, walMode, syncMode)
-		if err != nil {
-			return err
-		}
-		defer dbConn.Close()
-
-		if err := pkgdb.UpgradeDB(dbConn, dbPath, pkgdb.TargetSchemaVersion); err != nil {
-			return err
-		}
-		fmt.Println("Database schema upgrade completed successfully for memoriesdb component to version", pkgdb.TargetSchemaVersion)
-		return nil
-	},
-}
-
 func initCmd() {
 	// package-level flags
-	rootCmd.PersistentFlags().StringVar(&dbPath, "db", "", "Path to the database file. Uses a system-specific default if not provided.")
+	rootCmd.PersistentFlags().StringVar(&dbPath, "db", "", "Path to the SQLite database file, or a postgres:// URL. Uses a system-specific default file if not provided.")
 	rootCmd.PersistentFlags().BoolVar(&walMode, "wal", false, "Enable SQLite WAL (Write-Ahead Logging) mode (default: false)")
 	rootCmd.PersistentFlags().StringVar(&syncMode, "sync", "FULL", "SQLite synchronous pragma (OFF, NORMAL, FULL, EXTRA) (default: FULL)")
+	rootCmd.PersistentFlags().StringVar(&embedderFlag, "embedder", "hashing", "Embedder for semantic search: 'hashing' (offline) or 'ollama:<model>' (default model "+defaultOllamaModel+")")
+	rootCmd.PersistentFlags().StringVar(&ollamaURLFlag, "ollama-url", memories.DefaultOllamaURL, "URL of the Ollama server used by --embedder ollama")
 
-	dbCmd.AddCommand(dbUpgradeCmd)
-
+	initDbCmd()
 	initJournalsCmd()
 	initEntriesCmd()
 	initTagsCmd()
 	initSearchCmd()
-
-	rootCmd.AddCommand(completionCmd, versionCmd, dbCmd, journalsCmd, entriesCmd, tagsCmd, searchCmd, mcpCmd)
+	initPortabilityCmd()
+	initMCPCmd()
+	initTokensCmd()
+	initContextCmd()
+	initStatsCmd()
+	initGraphCmd()
+
+	rootCmd.AddCommand(completionCmd, versionCmd, dbCmd, journalsCmd, entriesCmd, tagsCmd, searchCmd, contextCmd, statsCmd, graphCmd, gcCmd, exportCmd, importCmd, mcpCmd, tokensCmd)
 }
 
 func main() {
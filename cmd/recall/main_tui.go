@@ -3,6 +3,7 @@
 package main
 
 import (
+	pkgdb "github.com/unowned-ai/recall/pkg/db"
 	"github.com/unowned-ai/recall/pkg/tui"
 
 	"github.com/spf13/cobra"
@@ -13,13 +14,13 @@ var tuiCmd = &cobra.Command{
 	Short: "Show terminal UI",
 	Long:  `Display an interactive terminal UI for browsing data.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		return tui.ShowTUI(dbConn)
+		return tui.ShowTUI(store, pkgdb.RedactDSN(dbPath))
 	},
 }
 
@@ -3,67 +3,162 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/unowned-ai/recall/pkg/mcp"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+const (
+	transportStdio = "stdio"
+	transportHTTP  = "http"
+	transportSSE   = "sse"
+
+	defaultMCPListenAddr = "127.0.0.1:8765"
+)
+
+var (
+	mcpTransportFlag    string
+	mcpListenFlag       string
+	mcpReapIntervalFlag time.Duration
 )
 
 var mcpCmd = &cobra.Command{
 	Use:   "mcp",
-	Short: "Run the Recall MCP server (stdio)",
+	Short: "Run the Recall MCP server (stdio, HTTP or SSE)",
 	Long: `Start a Model Context Protocol (MCP) server that exposes all recall
 journals, entries, tags and search functionality as MCP tools via STDIO.
+Journals and entries are also published as recall:// resources.
+
+With --transport http the server listens on --listen instead and serves the
+streamable HTTP transport at /mcp, so several clients can share one long-lived
+process and database connection. --transport sse serves the older HTTP+SSE
+transport at /sse for clients that don't support streamable HTTP yet.
+Both HTTP transports require an API token from "recall tokens create" in an
+"Authorization: Bearer <token>" header; the token's scope limits which
+journals the client can see and whether it may change them.
+
+Every --reap-interval the server soft-deletes or purges expired entries, by the
+expiry policy of their journal, like "recall gc" does.
 
 The --db flag is now optional. If not provided, a system-specific default location will be used:
 - Windows: %USERPROFILE%\AppData\Roaming\recall\recall.db
 - macOS: ~/Library/Application Support/recall/recall.db
 - Linux: ~/.local/share/recall/recall.db
+A postgres:// URL uses a PostgreSQL database instead, which is migrated on startup.
 
 Example:
 
   recall mcp --db recall.db | tee server.log
   
   # Or simply use the default location:
-  recall mcp`,
+  recall mcp
+
+  # Serve MCP clients over HTTP at http://127.0.0.1:8765/mcp:
+  recall tokens create --name laptop
+  recall mcp --transport http --listen 127.0.0.1:8765`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		switch mcpTransportFlag {
+		case transportStdio, transportHTTP, transportSSE:
+		default:
+			return fmt.Errorf("invalid transport '%s': must be '%s', '%s' or '%s'", mcpTransportFlag, transportStdio, transportHTTP, transportSSE)
+		}
 
 		// Create server wrapper.
 		srv, err := mcp.NewRecallMCPServer(dbPath, walMode, syncMode)
 		if err != nil {
 			return err
 		}
+		// Close checkpoints the WAL once the transport has shut down.
+		defer srv.Close()
 
 		// Register all tools.
-		db := srv.DB()
+		embedder, err := newEmbedder()
+		if err != nil {
+			return err
+		}
+		// Reads by agents are counted in memory and written in batches, so that reads never
+		// write to the database themselves. Close writes the last batch before srv.Close.
+		recorder := memories.NewAccessRecorder(srv.Store(), 0)
+		defer recorder.Close()
+		store := memories.NewEmbeddingStore(memories.NewAccessTrackingStore(srv.Store(), recorder), embedder)
+		if mcpReapIntervalFlag > 0 {
+			reaper := memories.NewReaper(srv.Store(), mcpReapIntervalFlag, logReap)
+			defer reaper.Close()
+		}
 		s := srv.MCPRawServer()
 
 		mcp.RegisterPingTool(s)
-		mcp.RegisterCreateJournalTool(s, db)
-		mcp.RegisterListJournalsTool(s, db)
-		mcp.RegisterGetJournalTool(s, db)
-		mcp.RegisterUpdateJournalTool(s, db)
-		mcp.RegisterDeleteJournalTool(s, db)
-
-		mcp.RegisterCreateEntryTool(s, db)
-		mcp.RegisterListEntriesTool(s, db)
-		mcp.RegisterGetEntryTool(s, db)
-		mcp.RegisterUpdateEntryTool(s, db)
-		mcp.RegisterDeleteEntryTool(s, db)
-		mcp.RegisterManageEntryTagsTool(s, db)
-		mcp.RegisterListTagsTool(s, db)
-		mcp.RegisterSearchEntriesTool(s, db)
-
-		effectiveDbPath := dbPath
-		if effectiveDbPath == "" {
-			effectiveDbPath = srv.DbPath
+		mcp.RegisterCreateJournalTool(s, store)
+		mcp.RegisterListJournalsTool(s, store)
+		mcp.RegisterGetJournalTool(s, store)
+		mcp.RegisterUpdateJournalTool(s, store)
+		mcp.RegisterDeleteJournalTool(s, store)
+
+		mcp.RegisterCreateEntryTool(s, store)
+		mcp.RegisterListEntriesTool(s, store)
+		mcp.RegisterGetEntryTool(s, store)
+		mcp.RegisterUpdateEntryTool(s, store)
+		mcp.RegisterGetEntryHistoryTool(s, store)
+		mcp.RegisterDeleteEntryTool(s, store)
+		mcp.RegisterRestoreEntryTool(s, store)
+		mcp.RegisterManageEntryTagsTool(s, store)
+		mcp.RegisterLinkEntriesTool(s, store)
+		mcp.RegisterGetRelatedEntriesTool(s, store)
+		mcp.RegisterListTagsTool(s, store)
+		mcp.RegisterSearchEntriesTool(s, store)
+		mcp.RegisterSemanticSearchTool(s, store)
+		mcp.RegisterRecallContextTool(s, store)
+
+		// Expose journals and entries as resources so clients can attach them directly.
+		resources, err := mcp.RegisterResources(cmd.Context(), s, store)
+		if err != nil {
+			return fmt.Errorf("failed to register resources: %w", err)
 		}
+		srv.WatchResources(resources)
 
 		// Log to stderr so we don't contaminate the JSON-RPC stream on stdout.
-		fmt.Fprintf(os.Stderr, "Recall MCP server started. DB: %s\n", effectiveDbPath)
-		fmt.Fprintln(os.Stderr, "Available tools: ping, create_journal, list_journals, get_journal, update_journal, delete_journal, create_entry, list_entries, get_entry, update_entry, delete_entry, manage_entry_tags, list_tags, search_entries")
+		// srv.DbPath is the resolved database path, with any PostgreSQL password redacted.
+		fmt.Fprintf(os.Stderr, "Recall MCP server started. DB: %s\n", srv.DbPath)
+		fmt.Fprintln(os.Stderr, "Available tools: ping, create_journal, list_journals, get_journal, update_journal, delete_journal, create_entry, list_entries, get_entry, update_entry, get_entry_history, delete_entry, restore_entry, manage_entry_tags, link_entries, get_related_entries, list_tags, search_entries, semantic_search, recall_context")
+		fmt.Fprintln(os.Stderr, "Resources: recall://journals, recall://journals/{name}, recall://journals/{name}/entries/{title}, recall://entries/{id}")
+		switch mcpTransportFlag {
+		case transportHTTP:
+			fmt.Fprintf(os.Stderr, "Listening for MCP streamable HTTP on %s ... (Ctrl+C to quit)\n", endpointURL(mcpListenFlag, mcp.StreamableHTTPPath))
+			return srv.StartHTTP(mcpListenFlag)
+		case transportSSE:
+			fmt.Fprintf(os.Stderr, "Listening for MCP SSE on %s ... (Ctrl+C to quit)\n", endpointURL(mcpListenFlag, mcp.SSEPath))
+			return srv.StartSSE(mcpListenFlag)
+		}
+
 		fmt.Fprintln(os.Stderr, "Listening for MCP JSON-RPC on STDIN/STDOUT ... (Ctrl+C to quit)")
 
 		// Run the server (blocks until stdio closes).
 		return srv.Start()
 	},
 }
+
+// logReap reports a pass of the reaper to stderr when it removed entries or failed.
+func logReap(result memories.ReapResult, err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to reap expired entries: %v\n", err)
+	} else if result.Deleted > 0 || result.Purged > 0 {
+		fmt.Fprintf(os.Stderr, "Reaped expired entries: %d soft-deleted, %d purged\n", result.Deleted, result.Purged)
+	}
+}
+
+// endpointURL renders the URL clients connect to for a listen address such as ":8765".
+func endpointURL(listen, path string) string {
+	if strings.HasPrefix(listen, ":") {
+		listen = "localhost" + listen
+	}
+	return "http://" + listen + path
+}
+
+func initMCPCmd() {
+	mcpCmd.Flags().StringVar(&mcpTransportFlag, "transport", transportStdio, "Transport to serve MCP over: stdio, http or sse")
+	mcpCmd.Flags().StringVar(&mcpListenFlag, "listen", defaultMCPListenAddr, "Address to listen on for the http and sse transports")
+	mcpCmd.Flags().DurationVar(&mcpReapIntervalFlag, "reap-interval", memories.DefaultReapInterval, "How often to soft-delete or purge expired entries (0 disables it)")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+var (
+	limitFlag  int
+	cursorFlag string
+)
+
+// addPageFlags adds --limit and --cursor to a listing command.
+func addPageFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&limitFlag, "limit", 0, "Maximum number of items to list (0 means all)")
+	cmd.Flags().StringVar(&cursorFlag, "cursor", "", "Continue a listing from the cursor printed after the previous page")
+}
+
+// pageFromFlags returns the page selected by --limit and --cursor.
+func pageFromFlags() memories.Page {
+	return memories.Page{Limit: limitFlag, Cursor: cursorFlag}
+}
+
+// pageError explains a listing error caused by a bad --cursor.
+func pageError(err error) error {
+	if errors.Is(err, memories.ErrInvalidCursor) {
+		return fmt.Errorf("invalid --cursor: %q was not printed by a previous listing", cursorFlag)
+	}
+	return nil
+}
+
+// printNextCursor tells how to fetch the next page of a listing, if there is one.
+func printNextCursor(next string) {
+	if next != "" {
+		fmt.Printf("\nMore results: repeat with --cursor %s\n", next)
+	}
+}
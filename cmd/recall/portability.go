@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/unowned-ai/recall/pkg/memories"
+	"github.com/unowned-ai/recall/pkg/portability"
+)
+
+const (
+	formatJSONL    = "jsonl"
+	formatMarkdown = "markdown"
+)
+
+var (
+	exportFormatFlag         string
+	importFormatFlag         string
+	exportJournalFlag        string
+	exportIncludeDeletedFlag bool
+	importMergeFlag          string
+	importRemapIDsFlag       bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [file|dir]",
+	Short: "Export journals, entries and tags as JSONL or a Markdown vault",
+	Long: `Write the whole database, or a single journal with --journal, as versioned JSONL.
+IDs and timestamps are preserved, so the file can be imported into another database with 'recall import'.
+Without a file argument the export is written to stdout.
+
+With --format markdown the export is written to a directory instead: one folder per journal and
+one .md file per entry, with the entry's id, tags, content type and timestamps in YAML front matter.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireSQLite("recall export"); err != nil {
+			return err
+		}
+		if err := validateFormat(exportFormatFlag, args); err != nil {
+			return err
+		}
+		opts := portability.ExportOptions{IncludeDeleted: exportIncludeDeletedFlag}
+		if exportJournalFlag != "" {
+			journalID, err := uuid.Parse(exportJournalFlag)
+			if err != nil {
+				return fmt.Errorf("invalid journal ID: %w", err)
+			}
+			opts.JournalID = journalID
+		}
+
+		dbConn, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer dbConn.Close()
+
+		if exportFormatFlag == formatMarkdown {
+			stats, err := portability.ExportMarkdown(cmd.Context(), dbConn, args[0], opts)
+			if errors.Is(err, memories.ErrJournalNotFound) {
+				return fmt.Errorf("journal not found: %s", exportJournalFlag)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to export: %w", err)
+			}
+			fmt.Printf("Exported %d journals and %d entries to %s.\n", stats.Journals, stats.Entries, args[0])
+			return nil
+		}
+
+		var w io.Writer = cmd.OutOrStdout()
+		if len(args) == 1 && args[0] != "-" {
+			file, err := os.Create(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to create export file: %w", err)
+			}
+			defer file.Close()
+			w = file
+		}
+
+		stats, err := portability.Export(cmd.Context(), dbConn, w, opts)
+		if errors.Is(err, memories.ErrJournalNotFound) {
+			return fmt.Errorf("journal not found: %s", exportJournalFlag)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to export: %w", err)
+		}
+
+		// Report on stderr so that an export to stdout stays valid JSONL.
+		cmd.PrintErrf("Exported %d journals, %d entries, %d tags, %d tag aliases, %d entry tags and %d entry links.\n",
+			stats.Journals, stats.Entries, stats.Tags, stats.TagAliases, stats.EntryTags, stats.EntryLinks)
+		return nil
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import [file|dir]",
+	Short: "Import journals, entries and tags from a JSONL export or a Markdown vault",
+	Long: `Read a file written by 'recall export' (or stdin when no file is given) and add its records to the database.
+The import runs in a single transaction, so it is applied completely or not at all.
+
+By default the import fails if a journal or entry with the same ID already exists. Use:
+  --merge skip     to keep existing journals and entries as they are
+  --merge update   to overwrite them with the imported version (the replaced content is kept in the entry history)
+  --remap-ids      to give every imported journal and entry a new ID, e.g. to duplicate data
+
+With --format markdown the argument is a vault directory as written by 'recall export --format markdown'.
+Each folder is a journal; files whose front matter id matches an existing entry update it,
+and all other files become new entries. Merge flags do not apply to Markdown imports.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireSQLite("recall import"); err != nil {
+			return err
+		}
+		if err := validateFormat(importFormatFlag, args); err != nil {
+			return err
+		}
+		if importFormatFlag == formatMarkdown {
+			if importMergeFlag != "" || importRemapIDsFlag {
+				return errors.New("--merge and --remap-ids only apply to JSONL imports")
+			}
+			dbConn, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer dbConn.Close()
+
+			stats, err := portability.ImportMarkdown(cmd.Context(), dbConn, args[0])
+			if err != nil {
+				return fmt.Errorf("import aborted, nothing was changed: %w", err)
+			}
+			fmt.Printf("Imported %d entries (%d updated, %d unchanged) and created %d journals.\n",
+				stats.Entries, stats.Updated, stats.Skipped, stats.Journals)
+			return nil
+		}
+
+		mergeMode, err := portability.ParseMergeMode(importMergeFlag)
+		if err != nil {
+			return err
+		}
+		if importRemapIDsFlag && mergeMode != portability.MergeNone {
+			return errors.New("--merge and --remap-ids cannot be used together")
+		}
+
+		var r io.Reader = cmd.InOrStdin()
+		if len(args) == 1 && args[0] != "-" {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open import file: %w", err)
+			}
+			defer file.Close()
+			r = file
+		}
+
+		dbConn, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer dbConn.Close()
+
+		stats, err := portability.Import(cmd.Context(), dbConn, r, portability.ImportOptions{
+			Merge:    mergeMode,
+			RemapIDs: importRemapIDsFlag,
+		})
+		if errors.Is(err, portability.ErrIDConflict) {
+			return fmt.Errorf("import aborted, nothing was changed: %w (use --merge skip, --merge update or --remap-ids)", err)
+		}
+		if err != nil {
+			return fmt.Errorf("import aborted, nothing was changed: %w", err)
+		}
+
+		fmt.Printf("Imported %d journals, %d entries, %d tags, %d tag aliases, %d entry tags and %d entry links (%d updated, %d skipped).\n",
+			stats.Journals, stats.Entries, stats.Tags, stats.TagAliases, stats.EntryTags, stats.EntryLinks, stats.Updated, stats.Skipped)
+		return nil
+	},
+}
+
+// validateFormat checks a --format value and that Markdown vaults are given a directory.
+func validateFormat(format string, args []string) error {
+	switch format {
+	case formatJSONL:
+		return nil
+	case formatMarkdown:
+		if len(args) != 1 || args[0] == "-" {
+			return errors.New("--format markdown requires a directory argument")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid format '%s': must be '%s' or '%s'", format, formatJSONL, formatMarkdown)
+	}
+}
+
+func initPortabilityCmd() {
+	exportCmd.Flags().StringVar(&exportFormatFlag, "format", formatJSONL, "Export format: jsonl or markdown")
+	exportCmd.Flags().StringVar(&exportJournalFlag, "journal", "", "Export only the journal with this ID")
+	exportCmd.Flags().BoolVar(&exportIncludeDeletedFlag, "include-deleted", false, "Include soft-deleted entries in the export")
+
+	importCmd.Flags().StringVar(&importFormatFlag, "format", formatJSONL, "Import format: jsonl or markdown")
+	importCmd.Flags().StringVar(&importMergeFlag, "merge", "", "How to handle journals and entries that already exist: skip or update")
+	importCmd.Flags().BoolVar(&importRemapIDsFlag, "remap-ids", false, "Assign new IDs to every imported journal and entry")
+}
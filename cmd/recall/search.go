@@ -3,7 +3,9 @@ package main
 import (
 	"errors"
 	"fmt"
-	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
@@ -13,12 +15,56 @@ import (
 
 var searchCmdJournalIDFlag string
 var searchCmdTopNFlag int // Variable for the --top flag
+var searchCmdTextFlag string
+var searchCmdQueryFlag string
+var searchCmdSemanticFlag string
+var searchCmdHybridFlag bool
+var searchCmdWeightsFlag string
+var searchCmdFusionFlag string
+var searchCmdHalfLifeDaysFlag float64
 
 var searchCmd = &cobra.Command{
 	Use:   "search [tag1 tag2...]",
-	Short: "Search entries by matching tags within a journal",
-	Long:  `Search for entries in a specified journal based on a list of query tags. Entries are ranked by the number of matching tags.`,
+	Short: "Search entries by matching tags, a tag query, full text or meaning",
+	Long: `Search for entries in a specified journal based on a list of query tags. Entries are ranked by the number of matching tags.
+
+With --text, entry titles and content are searched for the given words instead, and entries are ranked by relevance (BM25).
+
+With --query, entries are selected by a boolean tag query and listed most recently updated first. Without --journal, every journal is searched. For example:
+
+  recall search --query 'proj-* AND NOT archived'
+  recall search --query '(bug OR incident) journal:work updated:>2026-01-01'
+  recall search --query 'type:text/markdown -draft'
+
+Terms next to each other must all match; OR, NOT (or a leading -) and parentheses combine them, and a trailing * matches a tag prefix. The fields journal:, type: and updated: (with >, >=, <, <= and dates as YYYY-MM-DD) match the entry instead of a tag. Combined with --text or --semantic, the query filters their results.
+
+With --semantic, entries are ranked by how close their title and content are in meaning to the given text, using the embedder selected by --embedder. Without --journal, every journal is searched. Entries without an embedding yet are embedded first, so the first search after an upgrade or an embedder change can take a while.
+
+With --hybrid, entries carrying any of the tag arguments or matching --text are ranked by a score that combines the number of matching tags, full-text relevance, how recently they were updated and how often they were read. Each result shows what every signal contributed. For example:
+
+  recall search --hybrid db ops --text "migrations" --weights recency=1,usage=0
+  recall search --hybrid --text "deploy" --fusion rrf --half-life-days 7
+
+--weights sets any of tags, text, recency and usage (default tags=1,text=1,recency=0.25,usage=0.25). --fusion weighted sums the signals scaled to [0, 1] times their weights, and --fusion rrf sums weight / (60 + rank) over the rankings by each signal. Without --journal, every journal is searched.`,
 	Args: func(cmd *cobra.Command, args []string) error {
+		if searchCmdTextFlag != "" && searchCmdSemanticFlag != "" {
+			return errors.New("--text and --semantic cannot be combined")
+		}
+		if searchCmdHybridFlag {
+			if searchCmdSemanticFlag != "" {
+				return errors.New("--hybrid and --semantic cannot be combined")
+			}
+			if len(args) == 0 && searchCmdTextFlag == "" {
+				return errors.New("--hybrid requires tag arguments or --text")
+			}
+			return nil
+		}
+		if searchCmdTextFlag != "" || searchCmdQueryFlag != "" || searchCmdSemanticFlag != "" {
+			if len(args) > 0 {
+				return errors.New("tag arguments cannot be combined with --text, --query or --semantic")
+			}
+			return nil
+		}
 		if len(args) < 1 {
 			return errors.New("requires at least one tag argument")
 		}
@@ -27,22 +73,49 @@ var searchCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		queryTags := args
 
-		if searchCmdJournalIDFlag == "" {
-			// This should be caught by MarkFlagRequired, but good to double check.
-			return errors.New("journal ID is required")
+		var query *memories.TagQuery
+		if searchCmdQueryFlag != "" {
+			var err error
+			if query, err = memories.ParseTagQuery(searchCmdQueryFlag); err != nil {
+				return fmt.Errorf("invalid --query: %w", err)
+			}
 		}
-		journalID, err := uuid.Parse(searchCmdJournalIDFlag)
-		if err != nil {
-			return fmt.Errorf("invalid journal ID: %w", err)
+
+		if searchCmdJournalIDFlag == "" && searchCmdSemanticFlag == "" && !searchCmdHybridFlag && (query == nil || searchCmdTextFlag != "") {
+			return errors.New("--journal is required unless searching with --query alone, --semantic or --hybrid")
+		}
+		journalID := uuid.Nil
+		if searchCmdJournalIDFlag != "" {
+			var err error
+			if journalID, err = uuid.Parse(searchCmdJournalIDFlag); err != nil {
+				return fmt.Errorf("invalid journal ID: %w", err)
+			}
 		}
 
-		dbConn, err := openDB() // Assumes openDB() is accessible from this package (e.g. defined in journals.go)
+		store, err := openEmbeddingStore() // Assumes openDB() is accessible from this package (e.g. defined in journals.go)
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
+
+		if searchCmdSemanticFlag != "" {
+			return runSemanticSearch(cmd, store, journalID, query)
+		}
+		if searchCmdHybridFlag {
+			weights, err := parseRankWeights(searchCmdWeightsFlag, searchCmdFusionFlag, searchCmdHalfLifeDaysFlag)
+			if err != nil {
+				return err
+			}
+			return runHybridSearch(cmd, store, journalID, queryTags, weights, query)
+		}
+		if searchCmdTextFlag != "" {
+			return runFullTextSearch(cmd, store, journalID, query)
+		}
+		if query != nil {
+			return runTagQuerySearch(cmd, store, journalID, query)
+		}
 
-		results, err := memories.SearchEntriesByTagMatchSQL(cmd.Context(), dbConn, journalID, queryTags)
+		results, err := store.SearchEntriesByTagMatch(cmd.Context(), journalID, queryTags)
 		if err != nil {
 			return fmt.Errorf("search failed: %w", err)
 		}
@@ -87,15 +160,236 @@ var searchCmd = &cobra.Command{
 	},
 }
 
-func initSearchCmd() {
-	searchCmd.Flags().StringVar(&searchCmdJournalIDFlag, "journal", "", "Journal ID to search within (required)")
-	if err := searchCmd.MarkFlagRequired("journal"); err != nil {
-		// This error typically happens at init time if the flag doesn't exist,
-		// but cobra handles it. For robustness, one might log or panic here if critical.
-		fmt.Fprintf(os.Stderr, "Error marking --journal flag required for search: %v\n", err)
-		// os.Exit(1) // Or handle more gracefully depending on desired startup behavior
+// runTagQuerySearch handles `recall search --query` without --text. journalID is
+// uuid.Nil to search every journal.
+func runTagQuerySearch(cmd *cobra.Command, store memories.Store, journalID uuid.UUID, query *memories.TagQuery) error {
+	filter := memories.EntryFilter{Query: query}
+	if journalID != uuid.Nil {
+		filter.JournalIDs = []uuid.UUID{journalID}
+	}
+	results, _, err := store.FindEntries(cmd.Context(), filter, memories.Page{Limit: searchCmdTopNFlag})
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No matching entries found.")
+		return nil
+	}
+
+	fmt.Printf("Found %d matching entries:\n", len(results))
+	for i, entry := range results {
+		tags := "none"
+		if len(entry.Tags) > 0 {
+			tags = strings.Join(entry.Tags, ", ")
+		}
+		fmt.Printf("\n--- Entry %d ---\n", i+1)
+		fmt.Printf("ID:           %s\n", entry.ID.String())
+		fmt.Printf("Journal ID:   %s\n", entry.JournalID.String())
+		fmt.Printf("Title:        %s\n", entry.Title)
+		fmt.Printf("Content Type: %s\n", entry.ContentType)
+		fmt.Printf("Tags:         %s\n", tags)
+		fmt.Printf("Updated At:   %s\n", formatTimestamp(entry.UpdatedAt))
+	}
+
+	return nil
+}
+
+// runFullTextSearch handles `recall search --text`, keeping only the matches selected by
+// query when it is not nil.
+func runFullTextSearch(cmd *cobra.Command, store memories.Store, journalID uuid.UUID, query *memories.TagQuery) error {
+	results, err := store.SearchEntriesFullText(cmd.Context(), journalID, searchCmdTextFlag, searchLimit(query))
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+	results, err = filterTop(cmd, store, results, func(match memories.FullTextMatch) uuid.UUID { return match.Entry.ID }, query, searchCmdTopNFlag)
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No matching entries found.")
+		return nil
+	}
+
+	fmt.Printf("Found %d matching entries:\n", len(results))
+	for i, match := range results {
+		fmt.Printf("\n--- Entry %d ---\n", i+1)
+		fmt.Printf("Score:        %.4f\n", match.Score)
+		fmt.Printf("ID:           %s\n", match.Entry.ID.String())
+		fmt.Printf("Journal ID:   %s\n", match.Entry.JournalID.String())
+		fmt.Printf("Title:        %s\n", match.Entry.Title)
+		fmt.Printf("Content Type: %s\n", match.Entry.ContentType)
+		fmt.Printf("Updated At:   %s\n", formatTimestamp(match.Entry.UpdatedAt))
+		fmt.Printf("Snippet:      %s\n", match.Snippet)
+	}
+
+	return nil
+}
+
+// runSemanticSearch handles `recall search --semantic`, keeping only the matches selected
+// by query when it is not nil. journalID is uuid.Nil to search every journal.
+func runSemanticSearch(cmd *cobra.Command, store *memories.EmbeddingStore, journalID uuid.UUID, query *memories.TagQuery) error {
+	results, err := store.SearchEntriesSemantic(cmd.Context(), journalID, searchCmdSemanticFlag, searchLimit(query))
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+	results, err = filterTop(cmd, store, results, func(match memories.SemanticMatch) uuid.UUID { return match.ID }, query, searchCmdTopNFlag)
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No matching entries found.")
+		return nil
+	}
+
+	fmt.Printf("Found %d matching entries:\n", len(results))
+	for i, match := range results {
+		tags := "none"
+		if len(match.Tags) > 0 {
+			tags = strings.Join(match.Tags, ", ")
+		}
+		fmt.Printf("\n--- Entry %d ---\n", i+1)
+		fmt.Printf("Similarity:   %.4f\n", match.Similarity)
+		fmt.Printf("ID:           %s\n", match.ID.String())
+		fmt.Printf("Journal ID:   %s\n", match.JournalID.String())
+		fmt.Printf("Title:        %s\n", match.Title)
+		fmt.Printf("Content Type: %s\n", match.ContentType)
+		fmt.Printf("Tags:         %s\n", tags)
+		fmt.Printf("Updated At:   %s\n", formatTimestamp(match.UpdatedAt))
+	}
+
+	return nil
+}
+
+// runHybridSearch handles `recall search --hybrid`, keeping only the matches selected by
+// query when it is not nil. journalID is uuid.Nil to search every journal.
+func runHybridSearch(cmd *cobra.Command, store memories.Store, journalID uuid.UUID, queryTags []string, weights memories.RankWeights, query *memories.TagQuery) error {
+	results, err := memories.SearchEntriesHybrid(cmd.Context(), store, journalID, queryTags, searchCmdTextFlag, weights, searchLimit(query))
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+	results, err = filterTop(cmd, store, results, func(result memories.RankedEntry) uuid.UUID { return result.ID }, query, searchCmdTopNFlag)
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No matching entries found.")
+		return nil
+	}
+
+	fmt.Printf("Found %d matching entries:\n", len(results))
+	for i, result := range results {
+		tags := "none"
+		if len(result.Tags) > 0 {
+			tags = strings.Join(result.Tags, ", ")
+		}
+		b := result.Breakdown
+		fmt.Printf("\n--- Entry %d ---\n", i+1)
+		fmt.Printf("Score:        %.4f (tags %.4f, text %.4f, recency %.4f, usage %.4f)\n", result.Score, b.TagMatch, b.Text, b.Recency, b.Usage)
+		fmt.Printf("Signals:      %d matching tags, text score %.4g, %d reads\n", result.MatchCount, result.TextScore, result.AccessCount)
+		fmt.Printf("ID:           %s\n", result.ID.String())
+		fmt.Printf("Journal ID:   %s\n", result.JournalID.String())
+		fmt.Printf("Title:        %s\n", result.Title)
+		fmt.Printf("Tags:         %s\n", tags)
+		fmt.Printf("Updated At:   %s\n", formatTimestamp(result.UpdatedAt))
+	}
+
+	return nil
+}
+
+// parseRankWeights returns the default ranking weights with the comma-separated name=value
+// pairs of spec, the fusion method and the recency half-life in days applied.
+func parseRankWeights(spec, fusion string, halfLifeDays float64) (memories.RankWeights, error) {
+	weights := memories.DefaultRankWeights()
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || err != nil {
+			return weights, fmt.Errorf("invalid --weights %q: expected name=number pairs", pair)
+		}
+		switch strings.TrimSpace(name) {
+		case "tags":
+			weights.TagMatch = weight
+		case "text":
+			weights.Text = weight
+		case "recency":
+			weights.Recency = weight
+		case "usage":
+			weights.Usage = weight
+		default:
+			return weights, fmt.Errorf("invalid --weights %q: the names are tags, text, recency and usage", pair)
+		}
+	}
+	weights.Fusion = memories.RankFusion(fusion)
+	weights.RecencyHalfLife = time.Duration(halfLifeDays * float64(24*time.Hour))
+	return weights, weights.Validate()
+}
+
+// searchLimit returns how many matches a search should ask for: --top, or every match
+// when query is not nil, since filterTop then cuts the filtered matches down to --top.
+func searchLimit(query *memories.TagQuery) int {
+	if query != nil {
+		return 0
+	}
+	return searchCmdTopNFlag
+}
+
+// filterTop keeps the results whose entries are selected by query, then cuts them down
+// to the first top, or keeps them all for a top of 0. Without a query, results are
+// returned as they are. entryID returns the ID of the entry of a result.
+func filterTop[T any](cmd *cobra.Command, store memories.Store, results []T, entryID func(T) uuid.UUID, query *memories.TagQuery, top int) ([]T, error) {
+	if query == nil || len(results) == 0 {
+		return results, nil
+	}
+	ids := make([]uuid.UUID, len(results))
+	for i, result := range results {
+		ids[i] = entryID(result)
 	}
+	keep, err := selectEntries(cmd, store, ids, query)
+	if err != nil {
+		return nil, err
+	}
+	var filtered []T
+	for _, result := range results {
+		if keep[entryID(result)] {
+			filtered = append(filtered, result)
+		}
+	}
+	if top > 0 && top < len(filtered) {
+		filtered = filtered[:top]
+	}
+	return filtered, nil
+}
+
+// selectEntries returns the IDs among ids of the entries selected by query.
+func selectEntries(cmd *cobra.Command, store memories.Store, ids []uuid.UUID, query *memories.TagQuery) (map[uuid.UUID]bool, error) {
+	selected, _, err := store.FindEntries(cmd.Context(), memories.EntryFilter{EntryIDs: ids, Query: query, IncludeDeleted: true}, memories.Page{})
+	if err != nil {
+		return nil, err
+	}
+	keep := make(map[uuid.UUID]bool, len(selected))
+	for _, entry := range selected {
+		keep[entry.ID] = true
+	}
+	return keep, nil
+}
+
+func initSearchCmd() {
+	searchCmd.Flags().StringVar(&searchCmdJournalIDFlag, "journal", "", "Journal ID to search within (required unless searching with --query alone or --semantic)")
 	searchCmd.Flags().IntVar(&searchCmdTopNFlag, "top", 0, "Return only the top N results (0 means all)")
+	searchCmd.Flags().StringVar(&searchCmdTextFlag, "text", "", "Search entry titles and content for these words instead of matching tags")
+	searchCmd.Flags().StringVar(&searchCmdSemanticFlag, "semantic", "", "Rank entries by how close they are in meaning to this text")
+	searchCmd.Flags().BoolVar(&searchCmdHybridFlag, "hybrid", false, "Rank entries by tag matches, full-text relevance, recency and usage combined")
+	searchCmd.Flags().StringVar(&searchCmdWeightsFlag, "weights", "", "Weights of the --hybrid signals, e.g. 'tags=2,recency=1' (names: tags, text, recency, usage)")
+	searchCmd.Flags().StringVar(&searchCmdFusionFlag, "fusion", string(memories.FusionWeighted), "How --hybrid combines the signals: 'weighted' or 'rrf'")
+	searchCmd.Flags().Float64Var(&searchCmdHalfLifeDaysFlag, "half-life-days", 30, "Age in days at which the --hybrid recency signal has halved")
+	searchCmd.Flags().StringVar(&searchCmdQueryFlag, "query", "", "Select entries with a boolean tag query, e.g. 'proj-* AND NOT archived journal:work'")
 	// No dbPath, walMode, syncMode flags here as they are persistent flags on a parent command (e.g. root or journalsCmd)
 	// and use the package-level variables from journals.go or main.go
 }
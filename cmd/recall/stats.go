@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+var (
+	statsJournalIDFlag string
+	statsTopFlag       int
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show counts of journals, entries and tags, and how often entries are read",
+	Long: `Show how many journals, entries and tags the database holds, how often agents read
+entries through the MCP server, the most read entries, and the entries read least
+recently, which are candidates for cleanup.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if statsTopFlag <= 0 {
+			return errors.New("--top must be positive")
+		}
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		ctx := cmd.Context()
+
+		var journals []memories.Journal
+		if statsJournalIDFlag != "" {
+			journalID, err := uuid.Parse(statsJournalIDFlag)
+			if err != nil {
+				return fmt.Errorf("invalid journal ID: %w", err)
+			}
+			journal, err := store.GetJournal(ctx, journalID)
+			if errors.Is(err, memories.ErrJournalNotFound) {
+				return fmt.Errorf("journal not found: %s", statsJournalIDFlag)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get journal: %w", err)
+			}
+			journals = []memories.Journal{journal}
+		} else if journals, err = store.ListJournals(ctx, false); err != nil {
+			return fmt.Errorf("failed to list journals: %w", err)
+		}
+
+		var entries []memories.Entry
+		deleted := 0
+		for _, journal := range journals {
+			journalEntries, err := store.ListEntries(ctx, journal.ID, true)
+			if err != nil {
+				return fmt.Errorf("failed to list entries of journal %s: %w", journal.ID, err)
+			}
+			for _, e := range journalEntries {
+				if e.Deleted {
+					deleted++
+					continue
+				}
+				entries = append(entries, e)
+			}
+		}
+		tags, err := store.ListAllTags(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list tags: %w", err)
+		}
+
+		var reads int64
+		neverRead := 0
+		for _, e := range entries {
+			reads += e.AccessCount
+			if e.AccessCount == 0 {
+				neverRead++
+			}
+		}
+		fmt.Printf("Journals:      %d\n", len(journals))
+		fmt.Printf("Entries:       %d (%d deleted)\n", len(entries), deleted)
+		fmt.Printf("Tags:          %d\n", len(tags))
+		fmt.Printf("Reads:         %d\n", reads)
+		fmt.Printf("Never read:    %d\n", neverRead)
+		if len(entries) == 0 {
+			return nil
+		}
+
+		mostRead := append([]memories.Entry(nil), entries...)
+		sort.SliceStable(mostRead, func(i, j int) bool {
+			return mostRead[i].AccessCount > mostRead[j].AccessCount
+		})
+		fmt.Println("\nMost read:")
+		printAccessStats(mostRead)
+
+		stale := append([]memories.Entry(nil), entries...)
+		sort.SliceStable(stale, func(i, j int) bool {
+			return stale[i].LastAccessedAt < stale[j].LastAccessedAt
+		})
+		fmt.Println("\nLeast recently read:")
+		printAccessStats(stale)
+		return nil
+	},
+}
+
+// printAccessStats prints the read statistics of the first --top entries.
+func printAccessStats(entries []memories.Entry) {
+	if len(entries) > statsTopFlag {
+		entries = entries[:statsTopFlag]
+	}
+	fmt.Println("ID | Title | Reads | Last Accessed")
+	fmt.Println("------------------------------------------------------------")
+	for _, e := range entries {
+		fmt.Printf("%s | %s | %d | %s\n", e.ID, e.Title, e.AccessCount, formatAccessTime(e.LastAccessedAt))
+	}
+}
+
+func initStatsCmd() {
+	statsCmd.Flags().StringVar(&statsJournalIDFlag, "journal", "", "Journal ID to show statistics of (default: all journals)")
+	statsCmd.Flags().IntVar(&statsTopFlag, "top", 5, "Number of entries in the most and least read lists")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
@@ -13,7 +14,7 @@ import (
 var tagsCmd = &cobra.Command{
 	Use:   "tags",
 	Short: "Manage tags",
-	Long:  `List and delete tags used in journals.`,
+	Long:  `List, delete, alias and merge tags used in journals.`,
 }
 
 var listTagsCmd = &cobra.Command{
@@ -26,13 +27,13 @@ var listTagsCmd = &cobra.Command{
 			return fmt.Errorf("invalid journal ID: %w", err)
 		}
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		tags, err := memories.ListTags(context.Background(), dbConn, journalID)
+		tags, err := store.ListTags(context.Background(), journalID)
 		if errors.Is(err, memories.ErrJournalNotFound) {
 			return fmt.Errorf("journal not found: %s", journalIDFlag)
 		}
@@ -65,13 +66,13 @@ var deleteTagCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tagName := args[0]
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		err = memories.DeleteTag(context.Background(), dbConn, tagName)
+		err = store.DeleteTag(context.Background(), tagName)
 		if errors.Is(err, memories.ErrTagNotFound) {
 			return fmt.Errorf("tag not found: %s", tagName)
 		}
@@ -92,13 +93,13 @@ var createTagCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tagName := args[0]
 
-		dbConn, err := openDB()
+		store, err := openStore()
 		if err != nil {
 			return err
 		}
-		defer dbConn.Close()
+		defer store.Close()
 
-		err = memories.CreateTag(context.Background(), dbConn, tagName)
+		err = store.CreateTag(context.Background(), tagName)
 		if err != nil {
 			return fmt.Errorf("failed to create tag: %w", err)
 		}
@@ -108,6 +109,163 @@ var createTagCmd = &cobra.Command{
 	},
 }
 
+var treeTagsCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Show tags as a tree of paths with entry counts",
+	Long: `Show the tags of a journal, or of every journal without --journal, as a tree of
+their "/"-separated paths. Each tag shows the number of entries carrying it or a tag
+below it; searching for a tag matches the same entries. Deleted and expired entries
+are not counted.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var journalIDs []uuid.UUID
+		if journalIDFlag != "" {
+			journalID, err := uuid.Parse(journalIDFlag)
+			if err != nil {
+				return fmt.Errorf("invalid journal ID: %w", err)
+			}
+			journalIDs = append(journalIDs, journalID)
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		ctx := context.Background()
+		if len(journalIDs) > 0 {
+			if _, err := store.GetJournal(ctx, journalIDs[0]); errors.Is(err, memories.ErrJournalNotFound) {
+				return fmt.Errorf("journal not found: %s", journalIDFlag)
+			} else if err != nil {
+				return fmt.Errorf("failed to get journal: %w", err)
+			}
+		}
+		tree, err := memories.TagTree(ctx, store, journalIDs)
+		if err != nil {
+			return fmt.Errorf("failed to list tags: %w", err)
+		}
+
+		if len(tree) == 0 {
+			fmt.Println("No tags found.")
+			return nil
+		}
+		printTagTree(tree, 0)
+		return nil
+	},
+}
+
+// printTagTree prints nodes and their children, indented by depth.
+func printTagTree(nodes []memories.TagNode, depth int) {
+	for _, node := range nodes {
+		fmt.Printf("%s%s (%d)\n", strings.Repeat("  ", depth), node.Name, node.Count)
+		printTagTree(node.Children, depth+1)
+	}
+}
+
+var aliasTagCmd = &cobra.Command{
+	Use:   "alias [alias] [tag-name]",
+	Short: "Make a tag name stand for another tag",
+	Long: `Make alias stand for a tag, so tagging, untagging and searching with alias use the
+tag instead. Entries already tagged with alias are retagged with the tag.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		alias, err := store.AliasTag(context.Background(), args[0], args[1])
+		if err != nil {
+			return fmt.Errorf("failed to alias tag: %w", err)
+		}
+
+		fmt.Printf("Tag '%s' is now an alias of '%s'.\n", alias.Alias, alias.Tag)
+		return nil
+	},
+}
+
+var unaliasTagCmd = &cobra.Command{
+	Use:   "unalias [alias]",
+	Short: "Remove a tag alias",
+	Long:  `Remove a tag alias. Entries retagged when it was made keep their tag.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		err = store.DeleteTagAlias(context.Background(), args[0])
+		if errors.Is(err, memories.ErrTagAliasNotFound) {
+			return fmt.Errorf("tag alias not found: %s", args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to remove tag alias: %w", err)
+		}
+
+		fmt.Printf("Tag alias '%s' removed successfully!\n", args[0])
+		return nil
+	},
+}
+
+var listTagAliasesCmd = &cobra.Command{
+	Use:   "aliases",
+	Short: "List tag aliases",
+	Long:  `List every tag alias and the tag it stands for.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		aliases, err := store.ListTagAliases(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to list tag aliases: %w", err)
+		}
+
+		if len(aliases) == 0 {
+			fmt.Println("No tag aliases found.")
+			return nil
+		}
+
+		fmt.Println("Alias | Tag | Created At")
+		fmt.Println("----------------------------------------")
+		for _, a := range aliases {
+			fmt.Printf("%s | %s | %s\n", a.Alias, a.Tag, formatTimestamp(a.CreatedAt))
+		}
+		return nil
+	},
+}
+
+var mergeTagsCmd = &cobra.Command{
+	Use:   "merge [from] [to]",
+	Short: "Merge a tag into another",
+	Long: `Retag every entry tagged with from with to and delete from. Aliases of from become
+aliases of to. Use "tags alias" instead to keep from working as a name for to.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		retagged, err := store.MergeTags(context.Background(), args[0], args[1])
+		if errors.Is(err, memories.ErrTagNotFound) {
+			return fmt.Errorf("tag not found: %s", args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to merge tags: %w", err)
+		}
+
+		fmt.Printf("Merged tag '%s' into '%s', retagging %d entries.\n", args[0], args[1], retagged)
+		return nil
+	},
+}
+
 // Tag and untag commands are defined in entries.go
 
 func initTagsCmd() {
@@ -119,10 +277,17 @@ func initTagsCmd() {
 	listTagsCmd.Flags().StringVar(&journalIDFlag, "journal", "", "Journal ID (required)")
 	listTagsCmd.MarkFlagRequired("journal")
 
+	treeTagsCmd.Flags().StringVar(&journalIDFlag, "journal", "", "Journal ID (default: all journals)")
+
 	tagsCmd.AddCommand(
 		listTagsCmd,
+		treeTagsCmd,
 		deleteTagCmd,
 		createTagCmd,
+		aliasTagCmd,
+		unaliasTagCmd,
+		listTagAliasesCmd,
+		mergeTagsCmd,
 	)
 }
 
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/unowned-ai/recall/pkg/auth"
+)
+
+var (
+	tokenNameFlag           string
+	tokenReadOnlyFlag       bool
+	tokenJournalsFlag       []string
+	tokenIncludeRevokedFlag bool
+)
+
+var tokensCmd = &cobra.Command{
+	Use:   "tokens",
+	Short: "Manage API tokens",
+	Long: `Create, list and revoke the API tokens that clients of the HTTP and SSE MCP
+transports must present as "Authorization: Bearer <token>".`,
+}
+
+var createTokenCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an API token",
+	Long: `Create an API token. The token is printed once and cannot be shown again.
+Use --read-only and --journals to limit what clients using it can do.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbConn, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer dbConn.Close()
+
+		scope := auth.Scope{ReadOnly: tokenReadOnlyFlag, Journals: tokenJournalsFlag}
+		token, secret, err := auth.CreateToken(context.Background(), dbConn, tokenNameFlag, scope)
+		if err != nil {
+			return fmt.Errorf("failed to create token: %w", err)
+		}
+
+		fmt.Printf("Token '%s' created successfully!\n", token.Name)
+		fmt.Printf("ID: %s\n", token.ID)
+		fmt.Printf("Scope: %s\n", token.Scope)
+		fmt.Println("Token (shown only once, store it safely):")
+		fmt.Println(secret)
+		return nil
+	},
+}
+
+var listTokensCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API tokens",
+	Long:  `List API tokens with their scopes. Revoked tokens are only shown with --all.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbConn, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer dbConn.Close()
+
+		tokens, err := auth.ListTokens(context.Background(), dbConn, tokenIncludeRevokedFlag)
+		if err != nil {
+			return fmt.Errorf("failed to list tokens: %w", err)
+		}
+
+		if len(tokens) == 0 {
+			fmt.Println("No tokens found.")
+			return nil
+		}
+
+		fmt.Println("Tokens:")
+		fmt.Println("ID | Name | Scope | Created At | Last Used At | Revoked At")
+		fmt.Println("----------------------------------------")
+		for _, t := range tokens {
+			lastUsedAt := "never"
+			if t.LastUsedAt != 0 {
+				lastUsedAt = formatTimestamp(t.LastUsedAt)
+			}
+			revokedAt := "-"
+			if t.Revoked() {
+				revokedAt = formatTimestamp(t.RevokedAt)
+			}
+			fmt.Printf("%s | %s | %s | %s | %s | %s\n", t.ID, t.Name, t.Scope, formatTimestamp(t.CreatedAt), lastUsedAt, revokedAt)
+		}
+		return nil
+	},
+}
+
+var revokeTokenCmd = &cobra.Command{
+	Use:   "revoke [token-id]",
+	Short: "Revoke an API token",
+	Long:  `Revoke an API token. Clients using it are rejected from their next request on.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokenID, err := uuid.Parse(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid token ID: %w", err)
+		}
+
+		dbConn, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer dbConn.Close()
+
+		err = auth.RevokeToken(context.Background(), dbConn, tokenID)
+		if errors.Is(err, auth.ErrTokenNotFound) {
+			return fmt.Errorf("token not found or already revoked: %s", args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to revoke token: %w", err)
+		}
+
+		fmt.Printf("Token %s revoked successfully!\n", tokenID)
+		return nil
+	},
+}
+
+func initTokensCmd() {
+	createTokenCmd.Flags().StringVar(&tokenNameFlag, "name", "", "Name describing the token's client (required)")
+	createTokenCmd.Flags().BoolVar(&tokenReadOnlyFlag, "read-only", false, "Only allow reading journals and entries")
+	createTokenCmd.Flags().StringSliceVar(&tokenJournalsFlag, "journals", nil, "Comma-separated names of the journals the token may access (default all)")
+	createTokenCmd.MarkFlagRequired("name")
+
+	listTokensCmd.Flags().BoolVar(&tokenIncludeRevokedFlag, "all", false, "Include revoked tokens")
+
+	tokensCmd.AddCommand(
+		createTokenCmd,
+		listTokensCmd,
+		revokeTokenCmd,
+	)
+}
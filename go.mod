@@ -6,7 +6,8 @@ replace github.com/unowned-ai/recall/pkg/tui => ./pkg/tui
 
 require (
 	github.com/google/uuid v1.6.0
-	github.com/mark3labs/mcp-go v0.26.0
+	github.com/lib/pq v1.10.9
+	github.com/mark3labs/mcp-go v0.32.0
 	github.com/mattn/go-sqlite3 v1.14.28
 	github.com/spf13/cobra v1.9.1
 	github.com/unowned-ai/recall/pkg/tui v0.0.0-00010101000000-000000000000
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrForbidden is returned when a token's scope does not permit an operation.
+var ErrForbidden = errors.New("forbidden")
+
+// Scope limits what a token may do.
+type Scope struct {
+	// ReadOnly tokens may read but not create, change or delete anything.
+	ReadOnly bool `json:"read_only"`
+	// Journals lists the names of the journals the token may use. Empty means every journal.
+	Journals []string `json:"journals,omitempty"`
+}
+
+// AllowsJournal reports whether the scope includes the named journal.
+func (s Scope) AllowsJournal(name string) bool {
+	return len(s.Journals) == 0 || slices.Contains(s.Journals, name)
+}
+
+// Check returns an error wrapping ErrForbidden unless the scope permits reading, or with
+// write set changing, the named journal.
+func (s Scope) Check(journalName string, write bool) error {
+	if write && s.ReadOnly {
+		return fmt.Errorf("%w: token is read-only", ErrForbidden)
+	}
+	if !s.AllowsJournal(journalName) {
+		return fmt.Errorf("%w: token may not access journal '%s'", ErrForbidden, journalName)
+	}
+	return nil
+}
+
+// String describes the scope for listings, e.g. "read-only: work, home".
+func (s Scope) String() string {
+	access := "read-write"
+	if s.ReadOnly {
+		access = "read-only"
+	}
+	if len(s.Journals) == 0 {
+		return access + ": all journals"
+	}
+	journals := ""
+	for i, name := range s.Journals {
+		if i > 0 {
+			journals += ", "
+		}
+		journals += name
+	}
+	return access + ": " + journals
+}
+
+// journalList returns the journals to store for the scope, never nil.
+func (s Scope) journalList() []string {
+	if s.Journals == nil {
+		return []string{}
+	}
+	return s.Journals
+}
+
+type scopeContextKey struct{}
+
+// WithScope returns a context whose operations are limited to scope.
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeContextKey{}, scope)
+}
+
+// ScopeFromContext returns the scope set by WithScope. Contexts without a scope, such as
+// those of the CLI, the TUI and the stdio MCP transport, are not limited.
+func ScopeFromContext(ctx context.Context) (Scope, bool) {
+	scope, ok := ctx.Value(scopeContextKey{}).(Scope)
+	return scope, ok
+}
+
+// Authorize checks the scope of ctx, if any, for reading or changing the named journal.
+func Authorize(ctx context.Context, journalName string, write bool) error {
+	scope, ok := ScopeFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return scope.Check(journalName, write)
+}
+
+// CanAccessJournal reports whether the scope of ctx, if any, includes the named journal.
+func CanAccessJournal(ctx context.Context, journalName string) bool {
+	scope, ok := ScopeFromContext(ctx)
+	return !ok || scope.AllowsJournal(journalName)
+}
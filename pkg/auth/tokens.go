@@ -0,0 +1,215 @@
+// Package auth manages the API tokens that authenticate clients of the networked MCP
+// transports, and the scopes that limit what each token may do.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTokenNotFound = errors.New("token not found")
+	ErrInvalidToken  = errors.New("invalid or revoked token")
+)
+
+// tokenPrefix marks recall tokens, so that they are easy to recognise in configuration
+// files and secret scanners.
+const tokenPrefix = "recall_"
+
+// lastUsedResolution is how stale last_used_at may get, in seconds. Recording every use
+// would turn each authenticated request into a write.
+const lastUsedResolution = 60
+
+// The statements use numbered placeholders, which both SQLite and PostgreSQL accept.
+const (
+	createTokenStatement = `
+	INSERT INTO api_tokens (id, name, token_hash, read_only, journals)
+	VALUES ($1, $2, $3, $4, $5)
+	`
+
+	getTokenStatement = `
+	SELECT id, name, read_only, journals, created_at, COALESCE(last_used_at, 0), COALESCE(revoked_at, 0)
+	FROM api_tokens
+	WHERE id = $1
+	`
+
+	listTokensStatement = `
+	SELECT id, name, read_only, journals, created_at, COALESCE(last_used_at, 0), COALESCE(revoked_at, 0)
+	FROM api_tokens
+	WHERE revoked_at IS NULL OR $1 = true
+	ORDER BY created_at, name
+	`
+
+	authenticateTokenStatement = `
+	SELECT id, name, read_only, journals, created_at, COALESCE(last_used_at, 0), COALESCE(revoked_at, 0)
+	FROM api_tokens
+	WHERE token_hash = $1 AND revoked_at IS NULL
+	`
+
+	touchTokenStatement = `
+	UPDATE api_tokens
+	SET last_used_at = unixepoch()
+	WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < unixepoch() - $2)
+	`
+
+	revokeTokenStatement = `
+	UPDATE api_tokens
+	SET revoked_at = unixepoch()
+	WHERE id = $1 AND revoked_at IS NULL
+	`
+)
+
+// Token describes an API token. The secret itself is only returned by CreateToken.
+type Token struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Scope      Scope     `json:"scope"`
+	CreatedAt  float64   `json:"created_at"`
+	LastUsedAt float64   `json:"last_used_at,omitempty"`
+	RevokedAt  float64   `json:"revoked_at,omitempty"`
+}
+
+// Revoked reports whether the token has been revoked.
+func (t Token) Revoked() bool {
+	return t.RevokedAt != 0
+}
+
+// hashToken returns the hex-encoded SHA-256 of a token secret. Tokens are random, so a
+// fast unsalted hash is enough to keep a copy of the database from revealing them.
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateToken stores a new token with the given name and scope and returns it together
+// with its secret. The secret cannot be recovered later.
+func CreateToken(ctx context.Context, db *sql.DB, name string, scope Scope) (Token, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return Token{}, "", errors.New("token name is required")
+	}
+	journals, err := json.Marshal(scope.journalList())
+	if err != nil {
+		return Token{}, "", err
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return Token{}, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	secret := tokenPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	tokenID := uuid.New()
+	_, err = db.ExecContext(ctx, createTokenStatement, tokenID, name, hashToken(secret), scope.ReadOnly, string(journals))
+	if err != nil {
+		return Token{}, "", err
+	}
+
+	token, err := GetToken(ctx, db, tokenID)
+	if err != nil {
+		return Token{}, "", err
+	}
+	return token, secret, nil
+}
+
+// GetToken returns the token with the given ID, revoked or not.
+func GetToken(ctx context.Context, db *sql.DB, id uuid.UUID) (Token, error) {
+	token, err := scanToken(db.QueryRowContext(ctx, getTokenStatement, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Token{}, ErrTokenNotFound
+	}
+	return token, err
+}
+
+// ListTokens returns the tokens that have not been revoked, or all tokens if includeRevoked is set.
+func ListTokens(ctx context.Context, db *sql.DB, includeRevoked bool) ([]Token, error) {
+	rows, err := db.QueryContext(ctx, listTokensStatement, includeRevoked)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []Token
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RevokeToken revokes a token so that it is no longer accepted. Revoked tokens are kept
+// so that they still show up in ListTokens with includeRevoked.
+func RevokeToken(ctx context.Context, db *sql.DB, id uuid.UUID) error {
+	res, err := db.ExecContext(ctx, revokeTokenStatement, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// Authenticate returns the token whose secret is given, and records that it was used.
+// It returns ErrInvalidToken for unknown and revoked tokens.
+func Authenticate(ctx context.Context, db *sql.DB, secret string) (Token, error) {
+	token, err := scanToken(db.QueryRowContext(ctx, authenticateTokenStatement, hashToken(secret)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Token{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	if _, err := db.ExecContext(ctx, touchTokenStatement, token.ID, lastUsedResolution); err != nil {
+		return Token{}, err
+	}
+	return token, nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanToken(row rowScanner) (Token, error) {
+	var token Token
+	var journals string
+	err := row.Scan(
+		&token.ID,
+		&token.Name,
+		&token.Scope.ReadOnly,
+		&journals,
+		&token.CreatedAt,
+		&token.LastUsedAt,
+		&token.RevokedAt,
+	)
+	if err != nil {
+		return Token{}, err
+	}
+	if err := json.Unmarshal([]byte(journals), &token.Scope.Journals); err != nil {
+		return Token{}, fmt.Errorf("invalid journals of token %s: %w", token.ID, err)
+	}
+	if len(token.Scope.Journals) == 0 {
+		token.Scope.Journals = nil
+	}
+	return token, nil
+}
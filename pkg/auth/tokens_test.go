@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/unowned-ai/recall/pkg/db"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	testDB, err := db.OpenDBConnection(":memory:", true, "NORMAL")
+	if err != nil {
+		t.Fatalf("Failed to open in-memory database: %v", err)
+	}
+
+	if err := db.InitializeSchema(testDB, db.TargetSchemaVersion); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	return testDB
+}
+
+func TestCreateTokenAndAuthenticate(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer testDB.Close()
+
+	ctx := context.Background()
+	scope := Scope{ReadOnly: true, Journals: []string{"work", "notes"}}
+	token, secret, err := CreateToken(ctx, testDB, "assistant", scope)
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		t.Errorf("Expected token to start with %q, got %q", tokenPrefix, secret)
+	}
+	if token.Name != "assistant" || !token.Scope.ReadOnly || len(token.Scope.Journals) != 2 {
+		t.Errorf("Unexpected token: %+v", token)
+	}
+
+	var storedHash string
+	if err := testDB.QueryRow("SELECT token_hash FROM api_tokens WHERE id = ?", token.ID).Scan(&storedHash); err != nil {
+		t.Fatalf("Failed to read token hash: %v", err)
+	}
+	if storedHash == secret || storedHash != hashToken(secret) {
+		t.Errorf("Expected the token to be stored as its hash, got %q", storedHash)
+	}
+
+	authenticated, err := Authenticate(ctx, testDB, secret)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if authenticated.ID != token.ID {
+		t.Errorf("Expected token %s, got %s", token.ID, authenticated.ID)
+	}
+	if authenticated.Scope.String() != "read-only: work, notes" {
+		t.Errorf("Unexpected scope: %s", authenticated.Scope)
+	}
+	if authenticated.LastUsedAt != 0 {
+		t.Errorf("Expected the token returned by Authenticate to show the previous use, got %v", authenticated.LastUsedAt)
+	}
+	used, err := GetToken(ctx, testDB, token.ID)
+	if err != nil {
+		t.Fatalf("GetToken failed: %v", err)
+	}
+	if used.LastUsedAt == 0 {
+		t.Error("Expected Authenticate to record last_used_at")
+	}
+
+	if _, err := Authenticate(ctx, testDB, secret+"x"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for an unknown token, got %v", err)
+	}
+}
+
+func TestCreateTokenRequiresName(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer testDB.Close()
+
+	if _, _, err := CreateToken(context.Background(), testDB, " ", Scope{}); err == nil {
+		t.Error("Expected an error for an empty token name")
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer testDB.Close()
+
+	ctx := context.Background()
+	token, secret, err := CreateToken(ctx, testDB, "laptop", Scope{})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if _, _, err := CreateToken(ctx, testDB, "desktop", Scope{}); err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+
+	if err := RevokeToken(ctx, testDB, token.ID); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if _, err := Authenticate(ctx, testDB, secret); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for a revoked token, got %v", err)
+	}
+	if err := RevokeToken(ctx, testDB, token.ID); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Expected ErrTokenNotFound when revoking twice, got %v", err)
+	}
+	if err := RevokeToken(ctx, testDB, uuid.New()); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Expected ErrTokenNotFound for an unknown token, got %v", err)
+	}
+
+	active, err := ListTokens(ctx, testDB, false)
+	if err != nil {
+		t.Fatalf("ListTokens failed: %v", err)
+	}
+	if len(active) != 1 || active[0].Name != "desktop" {
+		t.Errorf("Expected only the desktop token, got %+v", active)
+	}
+
+	all, err := ListTokens(ctx, testDB, true)
+	if err != nil {
+		t.Fatalf("ListTokens failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("Expected 2 tokens, got %d", len(all))
+	}
+	for _, tok := range all {
+		if (tok.ID == token.ID) != tok.Revoked() {
+			t.Errorf("Unexpected revocation state of token %s: %v", tok.Name, tok.RevokedAt)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	ctx := context.Background()
+	if err := Authorize(ctx, "work", true); err != nil {
+		t.Errorf("Expected contexts without a scope to be unrestricted, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		scope   Scope
+		journal string
+		write   bool
+		allowed bool
+	}{
+		{"full access", Scope{}, "work", true, true},
+		{"read-only read", Scope{ReadOnly: true}, "work", false, true},
+		{"read-only write", Scope{ReadOnly: true}, "work", true, false},
+		{"journal in scope", Scope{Journals: []string{"work"}}, "work", true, true},
+		{"journal out of scope", Scope{Journals: []string{"work"}}, "home", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Authorize(WithScope(ctx, tt.scope), tt.journal, tt.write)
+			if tt.allowed && err != nil {
+				t.Errorf("Expected access, got %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrForbidden) {
+				t.Errorf("Expected ErrForbidden, got %v", err)
+			}
+		})
+	}
+}
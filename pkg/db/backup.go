@@ -0,0 +1,210 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// backupTimestampFormat is used in backup file names, e.g. recall.db.20260102-150405.bak.
+const backupTimestampFormat = "20060102-150405"
+
+// DatabaseFilePath returns the path of the main database file behind db,
+// or an empty string for in-memory and temporary databases.
+func DatabaseFilePath(db *sql.DB) (string, error) {
+	var seq int
+	var name, file string
+	if err := db.QueryRow(`PRAGMA database_list;`).Scan(&seq, &name, &file); err != nil {
+		return "", fmt.Errorf("failed to read database file path: %w", err)
+	}
+	return file, nil
+}
+
+// BackupPath returns a new timestamped backup path for dbFilePath inside backupDir.
+// An empty backupDir places the backup next to the database file.
+func BackupPath(dbFilePath, backupDir string) string {
+	if backupDir == "" {
+		backupDir = filepath.Dir(dbFilePath)
+	}
+	base := filepath.Base(dbFilePath) + "." + time.Now().UTC().Format(backupTimestampFormat)
+
+	candidate := filepath.Join(backupDir, base+".bak")
+	for i := 1; ; i++ {
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = filepath.Join(backupDir, fmt.Sprintf("%s-%d.bak", base, i))
+	}
+}
+
+// BackupDB writes a consistent copy of db to a new timestamped file in backupDir
+// (or next to the database file if backupDir is empty) and returns its path.
+func BackupDB(ctx context.Context, db *sql.DB, backupDir string) (string, error) {
+	dbFilePath, err := DatabaseFilePath(db)
+	if err != nil {
+		return "", err
+	}
+	if dbFilePath == "" {
+		return "", errors.New("cannot back up an in-memory database")
+	}
+
+	if backupDir != "" {
+		if err := os.MkdirAll(backupDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create backup directory '%s': %w", backupDir, err)
+		}
+	}
+
+	backupPath := BackupPath(dbFilePath, backupDir)
+	if err := copyDatabase(ctx, db, backupPath); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
+
+// RestoreDB replaces the database file at dbFilePath with the backup at backupPath.
+// The backup is first copied next to the database with the online backup API and its
+// memoriesdb version is checked; the current database is then backed up into backupDir
+// (or next to it) before the files are swapped. It returns the path of that safety backup,
+// which is empty if there was no database to replace.
+func RestoreDB(ctx context.Context, backupPath, dbFilePath, backupDir string) (string, error) {
+	if _, err := os.Stat(backupPath); err != nil {
+		return "", fmt.Errorf("backup file '%s' is not accessible: %w", backupPath, err)
+	}
+
+	backupConn, err := OpenDBConnection("file:"+backupPath+"?mode=ro", false, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to open backup '%s': %w", backupPath, err)
+	}
+	defer backupConn.Close()
+
+	stagingPath := dbFilePath + ".restoring"
+	os.Remove(stagingPath)
+	if err := copyDatabase(ctx, backupConn, stagingPath); err != nil {
+		os.Remove(stagingPath)
+		return "", err
+	}
+	if err := verifyRestorable(stagingPath); err != nil {
+		os.Remove(stagingPath)
+		return "", fmt.Errorf("refusing to restore '%s': %w", backupPath, err)
+	}
+
+	var safetyBackupPath string
+	if _, err := os.Stat(dbFilePath); err == nil {
+		currentConn, err := OpenDBConnection(dbFilePath, false, "")
+		if err != nil {
+			os.Remove(stagingPath)
+			return "", fmt.Errorf("failed to open current database '%s': %w", dbFilePath, err)
+		}
+		safetyBackupPath, err = BackupDB(ctx, currentConn, backupDir)
+		if err == nil {
+			// Fold any WAL content into the main file so the stale -wal/-shm can be removed.
+			_, err = currentConn.Exec("PRAGMA wal_checkpoint(TRUNCATE);")
+		}
+		currentConn.Close()
+		if err != nil {
+			os.Remove(stagingPath)
+			return "", fmt.Errorf("failed to back up current database before restore: %w", err)
+		}
+	}
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbFilePath + suffix); err != nil && !os.IsNotExist(err) {
+			os.Remove(stagingPath)
+			return "", fmt.Errorf("failed to remove '%s': %w", dbFilePath+suffix, err)
+		}
+	}
+	if err := os.Rename(stagingPath, dbFilePath); err != nil {
+		os.Remove(stagingPath)
+		return "", fmt.Errorf("failed to replace '%s' with restored copy: %w", dbFilePath, err)
+	}
+	return safetyBackupPath, nil
+}
+
+// verifyRestorable checks that the database at path holds a memoriesdb component
+// at a schema version this application supports.
+func verifyRestorable(path string) error {
+	conn, err := OpenDBConnection(path, false, "")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	version, err := GetComponentSchemaVersion(conn, MemoriesDBComponent)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		return fmt.Errorf("no %s component version found in recall_versions; not a recall database", MemoriesDBComponent)
+	}
+	if version > TargetSchemaVersion {
+		return fmt.Errorf("component %s has schema version %d, which is newer than application's target schema version %d", MemoriesDBComponent, version, TargetSchemaVersion)
+	}
+	return nil
+}
+
+// copyDatabase copies the main database of src into a new file at destPath using
+// SQLite's online backup API, which produces a consistent snapshot even under WAL.
+func copyDatabase(ctx context.Context, src *sql.DB, destPath string) error {
+	destDB, err := sql.Open("sqlite3", destPath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup destination '%s': %w", destPath, err)
+	}
+	defer destDB.Close()
+
+	destConn, err := destDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to backup destination '%s': %w", destPath, err)
+	}
+	defer destConn.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire source connection for backup: %w", err)
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destDriverConn any) error {
+		return srcConn.Raw(func(srcDriverConn any) error {
+			destSQLite, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errors.New("backup destination is not a SQLite connection")
+			}
+			srcSQLite, ok := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errors.New("backup source is not a SQLite connection")
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return fmt.Errorf("failed to start backup to '%s': %w", destPath, err)
+			}
+			for {
+				done, err := backup.Step(-1)
+				if err != nil {
+					backup.Finish()
+					return fmt.Errorf("backup to '%s' failed: %w", destPath, err)
+				}
+				if done {
+					break
+				}
+				// The source was busy or locked; give the writer a moment and retry.
+				select {
+				case <-ctx.Done():
+					backup.Finish()
+					return ctx.Err()
+				case <-time.After(50 * time.Millisecond):
+				}
+			}
+			if err := backup.Finish(); err != nil {
+				return fmt.Errorf("failed to finish backup to '%s': %w", destPath, err)
+			}
+			return nil
+		})
+	})
+}
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// openFileFixture creates a WAL-mode database file in a temp directory loaded with a testdata fixture.
+func openFileFixture(t *testing.T, fixture string) (*sql.DB, string) {
+	t.Helper()
+	dbFilePath := filepath.Join(t.TempDir(), "recall.db")
+	db, err := OpenDBConnection(dbFilePath, true, "NORMAL")
+	if err != nil {
+		t.Fatalf("OpenDBConnection failed for '%s': %v", dbFilePath, err)
+	}
+	loadFixture(t, db, fixture)
+	return db, dbFilePath
+}
+
+func countRows(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+		t.Fatalf("Failed to count rows in '%s': %v", table, err)
+	}
+	return count
+}
+
+func TestBackupDB(t *testing.T) {
+	db, dbFilePath := openFileFixture(t, "memoriesdb_v1.sql")
+	defer db.Close()
+
+	backupDir := filepath.Join(t.TempDir(), "backups")
+	backupPath, err := BackupDB(context.Background(), db, backupDir)
+	if err != nil {
+		t.Fatalf("BackupDB failed: %v", err)
+	}
+	if filepath.Dir(backupPath) != backupDir {
+		t.Errorf("Expected backup in '%s', got '%s'", backupDir, backupPath)
+	}
+	if !strings.HasPrefix(filepath.Base(backupPath), filepath.Base(dbFilePath)+".") || !strings.HasSuffix(backupPath, ".bak") {
+		t.Errorf("Unexpected backup file name '%s'", backupPath)
+	}
+
+	backup, err := OpenDBConnection(backupPath, false, "")
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer backup.Close()
+	if got := countRows(t, backup, "entries"); got != 3 {
+		t.Errorf("Expected 3 entries in backup, got %d", got)
+	}
+
+	// A second backup within the same second must not overwrite the first.
+	secondPath, err := BackupDB(context.Background(), db, backupDir)
+	if err != nil {
+		t.Fatalf("Second BackupDB failed: %v", err)
+	}
+	if secondPath == backupPath {
+		t.Errorf("Expected distinct backup paths, got '%s' twice", backupPath)
+	}
+
+	memDB, err := OpenDBConnection(":memory:", false, "")
+	if err != nil {
+		t.Fatalf("OpenDBConnection failed for in-memory DB: %v", err)
+	}
+	defer memDB.Close()
+	if _, err := BackupDB(context.Background(), memDB, backupDir); err == nil {
+		t.Errorf("Expected BackupDB of an in-memory database to fail")
+	}
+}
+
+func TestUpgradeDB_WritesBackupBeforeMigrating(t *testing.T) {
+	db, dbFilePath := openFileFixture(t, "memoriesdb_v1.sql")
+	defer db.Close()
+
+	backupPath, err := UpgradeDBWithBackup(db, dbFilePath, TargetSchemaVersion, "")
+	if err != nil {
+		t.Fatalf("UpgradeDBWithBackup failed: %v", err)
+	}
+	if backupPath == "" {
+		t.Fatalf("Expected a backup path for an existing database")
+	}
+	if filepath.Dir(backupPath) != filepath.Dir(dbFilePath) {
+		t.Errorf("Expected backup next to the database, got '%s'", backupPath)
+	}
+
+	backup, err := OpenDBConnection(backupPath, false, "")
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer backup.Close()
+	version, err := GetComponentSchemaVersion(backup, MemoriesDBComponent)
+	if err != nil {
+		t.Fatalf("GetComponentSchemaVersion failed on backup: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("Expected backup to hold the pre-migration version 1, got %d", version)
+	}
+
+	// Nothing to migrate, so no new backup.
+	backupPath, err = UpgradeDBWithBackup(db, dbFilePath, TargetSchemaVersion, "")
+	if err != nil {
+		t.Fatalf("UpgradeDBWithBackup on an up-to-date database failed: %v", err)
+	}
+	if backupPath != "" {
+		t.Errorf("Expected no backup for an up-to-date database, got '%s'", backupPath)
+	}
+}
+
+func TestRestoreDB(t *testing.T) {
+	db, dbFilePath := openFileFixture(t, "memoriesdb_v1.sql")
+
+	backupDir := t.TempDir()
+	backupPath, err := BackupDB(context.Background(), db, backupDir)
+	if err != nil {
+		t.Fatalf("BackupDB failed: %v", err)
+	}
+
+	// Change the live database after the backup was taken.
+	if _, err := db.Exec(`DELETE FROM entries;`); err != nil {
+		t.Fatalf("Failed to delete entries: %v", err)
+	}
+	db.Close()
+
+	safetyBackupPath, err := RestoreDB(context.Background(), backupPath, dbFilePath, backupDir)
+	if err != nil {
+		t.Fatalf("RestoreDB failed: %v", err)
+	}
+	if safetyBackupPath == "" {
+		t.Errorf("Expected the replaced database to be backed up")
+	}
+
+	restored, err := OpenDBConnection(dbFilePath, true, "NORMAL")
+	if err != nil {
+		t.Fatalf("Failed to open restored database: %v", err)
+	}
+	defer restored.Close()
+	if got := countRows(t, restored, "entries"); got != 3 {
+		t.Errorf("Expected 3 entries after restore, got %d", got)
+	}
+
+	safety, err := OpenDBConnection(safetyBackupPath, false, "")
+	if err != nil {
+		t.Fatalf("Failed to open safety backup: %v", err)
+	}
+	defer safety.Close()
+	if got := countRows(t, safety, "entries"); got != 0 {
+		t.Errorf("Expected safety backup to hold the replaced database with 0 entries, got %d", got)
+	}
+}
+
+func TestRestoreDB_RejectsUnversionedDatabase(t *testing.T) {
+	dir := t.TempDir()
+	otherPath := filepath.Join(dir, "other.db")
+	other, err := OpenDBConnection(otherPath, false, "")
+	if err != nil {
+		t.Fatalf("OpenDBConnection failed: %v", err)
+	}
+	if _, err := other.Exec(`CREATE TABLE notes (body TEXT);`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	other.Close()
+
+	dbFilePath := filepath.Join(dir, "recall.db")
+	_, err = RestoreDB(context.Background(), otherPath, dbFilePath, "")
+	if err == nil {
+		t.Fatalf("Expected RestoreDB to reject a database without a memoriesdb version")
+	}
+	if !strings.Contains(err.Error(), "not a recall database") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, statErr := os.Stat(dbFilePath); !os.IsNotExist(statErr) {
+		t.Errorf("Expected no database file to be created by a rejected restore")
+	}
+	if _, statErr := os.Stat(dbFilePath + ".restoring"); !os.IsNotExist(statErr) {
+		t.Errorf("Expected the staging file to be removed after a rejected restore")
+	}
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -14,7 +15,7 @@ import (
 const (
 	// TargetSchemaVersion is the highest schema version this version of the code supports for the memoriesdb component.
 	// This constant is used by the CLI to pass to UpgradeDB.
-	TargetSchemaVersion int64 = 1
+	TargetSchemaVersion int64 = 10
 	// MemoriesDBComponent is the name for the main memories database component.
 	MemoriesDBComponent = "memoriesdb"
 )
@@ -22,7 +23,7 @@ const (
 // GetComponentSchemaVersion retrieves the schema version for a given component.
 // Returns 0 if the component is not found, the versions table is uninitialized, or the table doesn't exist.
 func GetComponentSchemaVersion(db *sql.DB, componentName string) (int64, error) {
-	query := `SELECT version FROM recall_versions WHERE component = ?;`
+	query := `SELECT version FROM recall_versions WHERE component = $1;`
 	row := db.QueryRow(query, componentName)
 
 	var version int64
@@ -32,8 +33,9 @@ func GetComponentSchemaVersion(db *sql.DB, componentName string) (int64, error)
 			// Component not found in the table, or table is empty.
 			return 0, nil
 		}
-		// Check if the error is due to the table not existing
-		if strings.Contains(err.Error(), "no such table") && strings.Contains(err.Error(), "recall_versions") {
+		// Check if the error is due to the table not existing ("no such table" in SQLite,
+		// "relation ... does not exist" in PostgreSQL).
+		if (strings.Contains(err.Error(), "no such table") || strings.Contains(err.Error(), "does not exist")) && strings.Contains(err.Error(), "recall_versions") {
 			// recall_versions table itself doesn't exist, so definitely version 0.
 			return 0, nil
 		}
@@ -45,49 +47,196 @@ func GetComponentSchemaVersion(db *sql.DB, componentName string) (int64, error)
 
 // InitializeSchema creates the database schema (all tables for memoriesdb)
 // and sets the specified schema version for the memoriesdb component.
+// Registered migrations are applied in order up to schemaVersionToSet (capped at the latest registered version).
 func InitializeSchema(db *sql.DB, schemaVersionToSet int64) error {
-	// Execute the schema creation SQL (SchemaV1 is our only schema definition for now)
-	_, err := db.Exec(SchemaV1)
-	if err != nil {
-		return fmt.Errorf("failed to execute schema v1 SQL: %w", err)
+	upTo := schemaVersionToSet
+	if latest := LatestVersion(MemoriesDBComponent); upTo > latest {
+		upTo = latest
+	}
+	if err := applyMigrations(db, MemoriesDBComponent, 0, upTo); err != nil {
+		return err
+	}
+
+	if err := setComponentSchemaVersion(db, MemoriesDBComponent, schemaVersionToSet); err != nil {
+		return err
 	}
 
-	// Insert or update the version for the memoriesdb component
+	fmt.Fprintf(os.Stderr, "Component %s initialized/updated to schema version %d\n", MemoriesDBComponent, schemaVersionToSet)
+	return nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// setComponentSchemaVersion inserts or updates the version row for a component.
+func setComponentSchemaVersion(db execer, componentName string, version int64) error {
 	insertVersionSQL := `
-INSERT INTO recall_versions (component, version) VALUES (?, ?)
+INSERT INTO recall_versions (component, version) VALUES ($1, $2)
 ON CONFLICT(component) DO UPDATE SET version = excluded.version, created_at = unixepoch();`
 
-	_, err = db.Exec(insertVersionSQL, MemoriesDBComponent, schemaVersionToSet)
+	_, err := db.Exec(insertVersionSQL, componentName, version)
 	if err != nil {
-		return fmt.Errorf("failed to insert/update version for component %s to %d: %w", MemoriesDBComponent, schemaVersionToSet, err)
+		return fmt.Errorf("failed to insert/update version for component %s to %d: %w", componentName, version, err)
 	}
+	return nil
+}
 
-	fmt.Fprintf(os.Stderr, "Component %s initialized/updated to schema version %d\n", MemoriesDBComponent, schemaVersionToSet)
+// PlanMigrations returns the migrations MigrateComponent would run to move componentName
+// from its current version in db to targetVersion, without changing anything.
+func PlanMigrations(db *sql.DB, componentName string, targetVersion int64) ([]Migration, error) {
+	currentVersion, err := GetComponentSchemaVersion(db, componentName)
+	if err != nil {
+		return nil, err
+	}
+	return planMigrations(componentName, currentVersion, targetVersion)
+}
+
+// MigrateComponent moves componentName from its current version in db to targetVersion,
+// upgrading or downgrading as needed. Each step runs in its own transaction together with
+// the recall_versions update, so a failure leaves the database at the last completed step.
+func MigrateComponent(db *sql.DB, componentName string, targetVersion int64) error {
+	currentVersion, err := GetComponentSchemaVersion(db, componentName)
+	if err != nil {
+		return err
+	}
+	return applyMigrations(db, componentName, currentVersion, targetVersion)
+}
+
+// applyMigrations runs the planned steps between fromVersion and toVersion.
+func applyMigrations(db *sql.DB, componentName string, fromVersion, toVersion int64) error {
+	plan, err := planMigrations(componentName, fromVersion, toVersion)
+	if err != nil {
+		return err
+	}
+	upgrading := toVersion >= fromVersion
+
+	for _, m := range plan {
+		step, newVersion, direction := m.Up, m.Version, "upgrade"
+		if !upgrading {
+			step, newVersion, direction = m.Down, m.Version-1, "downgrade"
+		}
+
+		// recall_versions only exists once version 1 has been applied, so the bootstrap
+		// step creates it before the version row is written in the same transaction.
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := step(tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to %s component %s at version %d (%s): %w", direction, componentName, m.Version, m.Description, err)
+		}
+		if err := setComponentSchemaVersion(tx, componentName, newVersion); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit %s of component %s to version %d: %w", direction, componentName, newVersion, err)
+		}
+		if upgrading {
+			fmt.Fprintf(os.Stderr, "Component %s migrated to schema version %d (%s)\n", componentName, newVersion, m.Description)
+		} else {
+			fmt.Fprintf(os.Stderr, "Component %s reverted to schema version %d (removed: %s)\n", componentName, newVersion, m.Description)
+		}
+	}
 	return nil
 }
 
 // UpgradeDB applies necessary migrations to bring the database, represented by the *sql.DB connection,
 // for the MemoriesDBComponent to the appTargetSchemaVersion.
+// Before an existing file-backed database is altered, a timestamped backup is written next to it.
 // dbIdentifierForLog is used for logging purposes only.
 func UpgradeDB(db *sql.DB, dbIdentifierForLog string, appTargetSchemaVersion int64) error {
+	_, err := UpgradeDBWithBackup(db, dbIdentifierForLog, appTargetSchemaVersion, "")
+	return err
+}
+
+// UpgradeDBWithBackup is UpgradeDB with the pre-migration backup written to backupDir
+// (next to the database file if empty). It returns the backup path, or an empty string
+// if no backup was needed because nothing was migrated, the database was new, or it is in memory.
+func UpgradeDBWithBackup(db *sql.DB, dbIdentifierForLog string, appTargetSchemaVersion int64, backupDir string) (string, error) {
 	currentDBVersion, err := GetComponentSchemaVersion(db, MemoriesDBComponent)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	if currentDBVersion == appTargetSchemaVersion {
+		fmt.Fprintf(os.Stderr, "Component %s in database '%s' is already up to date (schema version %d).\n", MemoriesDBComponent, dbIdentifierForLog, currentDBVersion)
+		return "", nil
+	} else if currentDBVersion > appTargetSchemaVersion {
+		return "", fmt.Errorf("component %s in database '%s' has schema version %d, which is newer than application's target schema version %d. Please upgrade the application", MemoriesDBComponent, dbIdentifierForLog, currentDBVersion, appTargetSchemaVersion)
+	}
+
+	if _, err := planMigrations(MemoriesDBComponent, currentDBVersion, appTargetSchemaVersion); err != nil {
+		return "", fmt.Errorf("failed to migrate component %s in database '%s' from schema version %d to %d: %w", MemoriesDBComponent, dbIdentifierForLog, currentDBVersion, appTargetSchemaVersion, err)
+	}
+
+	backupPath, err := backupBeforeMigration(db, currentDBVersion, backupDir)
+	if err != nil {
+		return "", err
 	}
 
 	if currentDBVersion == 0 { // 0 indicates component not versioned or new DB
 		fmt.Fprintf(os.Stderr, "Component %s in database '%s' appears to be uninitialized or at version 0. Initializing/Upgrading to schema version %d...\n", MemoriesDBComponent, dbIdentifierForLog, appTargetSchemaVersion)
-		err = InitializeSchema(db, appTargetSchemaVersion) // Use the appTargetSchemaVersion
-		if err != nil {
-			return fmt.Errorf("failed to initialize component %s in database '%s': %w", MemoriesDBComponent, dbIdentifierForLog, err)
-		}
-		return nil
-	} else if currentDBVersion == appTargetSchemaVersion {
-		fmt.Fprintf(os.Stderr, "Component %s in database '%s' is already up to date (schema version %d).\n", MemoriesDBComponent, dbIdentifierForLog, currentDBVersion)
-		return nil
-	} else if currentDBVersion < appTargetSchemaVersion {
-		return fmt.Errorf("component %s in database '%s' has schema version %d, which is older than application's target schema version %d. Automatic migration from this older version is not yet supported", MemoriesDBComponent, dbIdentifierForLog, currentDBVersion, appTargetSchemaVersion)
-	} else { // currentDBVersion > appTargetSchemaVersion
-		return fmt.Errorf("component %s in database '%s' has schema version %d, which is newer than application's target schema version %d. Please upgrade the application", MemoriesDBComponent, dbIdentifierForLog, currentDBVersion, appTargetSchemaVersion)
+	} else {
+		fmt.Fprintf(os.Stderr, "Component %s in database '%s' is at schema version %d. Migrating to schema version %d...\n", MemoriesDBComponent, dbIdentifierForLog, currentDBVersion, appTargetSchemaVersion)
+	}
+	if err := applyMigrations(db, MemoriesDBComponent, currentDBVersion, appTargetSchemaVersion); err != nil {
+		return backupPath, fmt.Errorf("failed to migrate component %s in database '%s' from schema version %d to %d: %w", MemoriesDBComponent, dbIdentifierForLog, currentDBVersion, appTargetSchemaVersion, err)
+	}
+	return backupPath, nil
+}
+
+// DowngradeDB reverts the MemoriesDBComponent in db to targetSchemaVersion using the registered down migrations.
+// A backup is written to backupDir (next to the database file if empty) first; its path is returned.
+// dbIdentifierForLog is used for logging purposes only.
+func DowngradeDB(db *sql.DB, dbIdentifierForLog string, targetSchemaVersion int64, backupDir string) (string, error) {
+	currentDBVersion, err := GetComponentSchemaVersion(db, MemoriesDBComponent)
+	if err != nil {
+		return "", err
+	}
+
+	if currentDBVersion == targetSchemaVersion {
+		fmt.Fprintf(os.Stderr, "Component %s in database '%s' is already at schema version %d.\n", MemoriesDBComponent, dbIdentifierForLog, currentDBVersion)
+		return "", nil
+	} else if currentDBVersion < targetSchemaVersion {
+		return "", fmt.Errorf("component %s in database '%s' has schema version %d, which is older than the requested downgrade version %d", MemoriesDBComponent, dbIdentifierForLog, currentDBVersion, targetSchemaVersion)
+	}
+
+	if _, err := planMigrations(MemoriesDBComponent, currentDBVersion, targetSchemaVersion); err != nil {
+		return "", fmt.Errorf("failed to downgrade component %s in database '%s' from schema version %d to %d: %w", MemoriesDBComponent, dbIdentifierForLog, currentDBVersion, targetSchemaVersion, err)
+	}
+
+	backupPath, err := backupBeforeMigration(db, currentDBVersion, backupDir)
+	if err != nil {
+		return "", err
+	}
+
+	if err := applyMigrations(db, MemoriesDBComponent, currentDBVersion, targetSchemaVersion); err != nil {
+		return backupPath, fmt.Errorf("failed to downgrade component %s in database '%s' from schema version %d to %d: %w", MemoriesDBComponent, dbIdentifierForLog, currentDBVersion, targetSchemaVersion, err)
+	}
+	return backupPath, nil
+}
+
+// backupBeforeMigration backs up a file-backed database that already holds data before it is migrated.
+func backupBeforeMigration(db *sql.DB, currentDBVersion int64, backupDir string) (string, error) {
+	if currentDBVersion == 0 {
+		return "", nil
+	}
+	dbFilePath, err := DatabaseFilePath(db)
+	if err != nil {
+		return "", err
+	}
+	if dbFilePath == "" {
+		return "", nil
+	}
+
+	backupPath, err := BackupDB(context.Background(), db, backupDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up database before migration: %w", err)
 	}
+	fmt.Fprintf(os.Stderr, "Backed up database to %s\n", backupPath)
+	return backupPath, nil
 }
@@ -3,6 +3,8 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -42,7 +44,7 @@ func TestUpgradeDB_NewDatabase(t *testing.T) {
 	}
 
 	// Verify all tables are created
-	expectedTables := []string{"recall_versions", "journals", "entries", "tags", "entry_tags"}
+	expectedTables := []string{"recall_versions", "journals", "entries", "tags", "entry_tags", "entries_fts", "entry_revisions", "api_tokens"}
 	for _, tableName := range expectedTables {
 		checkTableExists(t, db, tableName)
 	}
@@ -86,28 +88,90 @@ func TestUpgradeDB_AlreadyUpToDate(t *testing.T) {
 	}
 }
 
-func TestUpgradeDB_OlderVersionNeedsMigration(t *testing.T) {
+// loadFixture executes a SQL fixture from testdata against db.
+func loadFixture(t *testing.T, db *sql.DB, name string) {
+	t.Helper()
+	fixtureSQL, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture '%s': %v", name, err)
+	}
+	if _, err := db.Exec(string(fixtureSQL)); err != nil {
+		t.Fatalf("Failed to load fixture '%s': %v", name, err)
+	}
+}
+
+func TestUpgradeDB_WalksV1FixtureForward(t *testing.T) {
 	db, err := OpenDBConnection(":memory:", true, "NORMAL")
 	if err != nil {
 		t.Fatalf("OpenDBConnection failed for in-memory DB: %v", err)
 	}
 	defer db.Close()
 
-	const dbInitialSchemaVersion int64 = 1
-	const appTargetsSchemaVersion int64 = 2 // Simulate app wanting version 2
+	loadFixture(t, db, "memoriesdb_v1.sql")
 
-	// Initialize the database to an older version (e.g., 1)
-	if err := InitializeSchema(db, dbInitialSchemaVersion); err != nil {
-		t.Fatalf("InitializeSchema to version %d failed: %v", dbInitialSchemaVersion, err)
+	version, err := GetComponentSchemaVersion(db, MemoriesDBComponent)
+	if err != nil {
+		t.Fatalf("GetComponentSchemaVersion failed on fixture: %v", err)
+	}
+	if version != 1 {
+		t.Fatalf("Expected fixture to be at version 1, got %d", version)
+	}
+
+	if err := UpgradeDB(db, ":memory:", TargetSchemaVersion); err != nil {
+		t.Fatalf("UpgradeDB from the v1 fixture failed: %v", err)
+	}
+
+	version, err = GetComponentSchemaVersion(db, MemoriesDBComponent)
+	if err != nil {
+		t.Fatalf("GetComponentSchemaVersion failed: %v", err)
+	}
+	if version != TargetSchemaVersion {
+		t.Errorf("Expected component '%s' to be at version %d, but got %d", MemoriesDBComponent, TargetSchemaVersion, version)
+	}
+
+	// Existing rows must survive the upgrade untouched.
+	var entryCount, entryTagCount int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM entries;`).Scan(&entryCount); err != nil {
+		t.Fatalf("Failed to count entries: %v", err)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM entry_tags;`).Scan(&entryTagCount); err != nil {
+		t.Fatalf("Failed to count entry tags: %v", err)
+	}
+	if entryCount != 3 || entryTagCount != 2 {
+		t.Errorf("Expected 3 entries and 2 entry tags after upgrade, got %d and %d", entryCount, entryTagCount)
+	}
+
+	// Version 2: pre-existing entries are backfilled into the full-text index.
+	checkTableExists(t, db, "entries_fts")
+	var entryID string
+	err = db.QueryRow(`SELECT entry_id FROM entries_fts WHERE entries_fts MATCH 'migrations';`).Scan(&entryID)
+	if err != nil {
+		t.Fatalf("Full-text lookup of backfilled entry failed: %v", err)
+	}
+	if entryID != "a1f0c3d2-5b6e-4f78-9a0b-1c2d3e4f5a02" {
+		t.Errorf("Expected backfilled deployment checklist entry, got '%s'", entryID)
+	}
+}
+
+func TestUpgradeDB_UnknownTargetVersion(t *testing.T) {
+	db, err := OpenDBConnection(":memory:", true, "NORMAL")
+	if err != nil {
+		t.Fatalf("OpenDBConnection failed for in-memory DB: %v", err)
+	}
+	defer db.Close()
+
+	const appTargetsSchemaVersion = TargetSchemaVersion + 1 // Simulate app wanting a version with no schema definition
+
+	if err := InitializeSchema(db, TargetSchemaVersion); err != nil {
+		t.Fatalf("InitializeSchema to version %d failed: %v", TargetSchemaVersion, err)
 	}
 
-	// Call UpgradeDB, expecting the app to target a newer version (2)
 	err = UpgradeDB(db, ":memory:", appTargetsSchemaVersion)
 	if err == nil {
-		t.Fatalf("UpgradeDB should have failed for an older DB version requiring migration, but it did not")
+		t.Fatalf("UpgradeDB should have failed for a target version without a registered migration, but it did not")
 	}
 
-	expectedErrorMsg := fmt.Sprintf("component %s in database ':memory:' has schema version %d, which is older than application's target schema version %d", MemoriesDBComponent, dbInitialSchemaVersion, appTargetsSchemaVersion)
+	expectedErrorMsg := fmt.Sprintf("no migration registered for version %d", appTargetsSchemaVersion)
 	if !strings.Contains(err.Error(), expectedErrorMsg) {
 		t.Errorf("UpgradeDB error message mismatch.\nExpected to contain: %s\nGot: %s", expectedErrorMsg, err.Error())
 	}
@@ -117,8 +181,8 @@ func TestUpgradeDB_OlderVersionNeedsMigration(t *testing.T) {
 	if getErr != nil {
 		t.Fatalf("GetComponentSchemaVersion failed after attempted upgrade: %v", getErr)
 	}
-	if currentVersion != dbInitialSchemaVersion {
-		t.Errorf("Database schema version changed from %d to %d after a failed upgrade attempt that should have been a no-op.", dbInitialSchemaVersion, currentVersion)
+	if currentVersion != TargetSchemaVersion {
+		t.Errorf("Database schema version changed from %d to %d after a failed upgrade attempt that should have been a no-op.", TargetSchemaVersion, currentVersion)
 	}
 }
 
@@ -129,10 +193,10 @@ func TestUpgradeDB_NewerVersionUnsupported(t *testing.T) {
 	}
 	defer db.Close()
 
-	const dbInitialSchemaVersion int64 = 2  // DB is at version 2
-	const appTargetsSchemaVersion int64 = 1 // Simulate app wanting version 1
+	const dbInitialSchemaVersion int64 = TargetSchemaVersion + 1 // DB is newer than the app
+	const appTargetsSchemaVersion int64 = TargetSchemaVersion
 
-	// Initialize the database to a newer version (e.g., 2)
+	// Initialize the database to a newer version
 	if err := InitializeSchema(db, dbInitialSchemaVersion); err != nil {
 		t.Fatalf("InitializeSchema to version %d failed: %v", dbInitialSchemaVersion, err)
 	}
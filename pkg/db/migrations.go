@@ -0,0 +1,291 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Migration is a single step in a component's schema history.
+// Up moves the component from Version-1 to Version; Down, when set, reverses it.
+// Both run inside the transaction that also records the new version in recall_versions.
+type Migration struct {
+	Version     int64
+	Description string
+	Up          func(tx *sql.Tx) error
+	Down        func(tx *sql.Tx) error
+}
+
+// migrationRegistry holds the ordered migrations for each versioned component.
+// Versions for a component must start at 1 and increase by one with no gaps.
+var migrationRegistry = map[string][]Migration{
+	MemoriesDBComponent: {
+		{
+			Version:     1,
+			Description: "journals, entries, tags and entry_tags",
+			Up:          execSchema(SchemaV1),
+			Down:        execSchema(DropSchemaV1),
+		},
+		{
+			Version:     2,
+			Description: "entries_fts full-text index",
+			Up:          upEntriesFullTextIndex,
+			Down:        execSchema(DropSchemaV2),
+		},
+		{
+			Version:     3,
+			Description: "entry_revisions history table",
+			Up:          execSchema(SchemaV3),
+			Down:        execSchema(DropSchemaV3),
+		},
+		{
+			Version:     4,
+			Description: "api_tokens for networked MCP transports",
+			Up:          execSchema(SchemaV4),
+			Down:        execSchema(DropSchemaV4),
+		},
+		{
+			Version:     5,
+			Description: "indexes on entry_tags(tag) and entries(journal_id, deleted, updated_at)",
+			Up:          execSchema(SchemaV5),
+			Down:        execSchema(DropSchemaV5),
+		},
+		{
+			Version:     6,
+			Description: "entry_embeddings for semantic search",
+			Up:          execSchema(SchemaV6),
+			Down:        execSchema(DropSchemaV6),
+		},
+		{
+			Version:     7,
+			Description: "access_count and last_accessed_at on entries",
+			Up:          execSchema(SchemaV7),
+			Down:        execSchema(DropSchemaV7),
+		},
+		{
+			Version:     8,
+			Description: "expires_at on entries and expiry_policy on journals",
+			Up:          execSchema(SchemaV8),
+			Down:        execSchema(DropSchemaV8),
+		},
+		{
+			Version:     9,
+			Description: "entry_links between entries",
+			Up:          execSchema(SchemaV9),
+			Down:        execSchema(DropSchemaV9),
+		},
+		{
+			Version:     10,
+			Description: "tag_aliases and case-folded tag names",
+			Up:          upTagAliases(SchemaV10),
+			Down:        execSchema(DropSchemaV10),
+		},
+	},
+	PostgresMemoriesDBComponent: {
+		{
+			Version:     1,
+			Description: "journals, entries, tags and entry_tags",
+			Up:          execSchema(PostgresSchemaV1),
+			Down:        execSchema(DropPostgresSchemaV1),
+		},
+		{
+			Version:     2,
+			Description: "search_vector full-text index",
+			Up:          execSchema(PostgresSchemaV2),
+			Down:        execSchema(DropPostgresSchemaV2),
+		},
+		{
+			Version:     3,
+			Description: "entry_revisions history table",
+			Up:          execSchema(PostgresSchemaV3),
+			Down:        execSchema(DropSchemaV3),
+		},
+		{
+			Version:     4,
+			Description: "api_tokens for networked MCP transports",
+			Up:          execSchema(PostgresSchemaV4),
+			Down:        execSchema(DropSchemaV4),
+		},
+		{
+			Version:     5,
+			Description: "indexes on entry_tags(tag) and entries(journal_id, deleted, updated_at)",
+			Up:          execSchema(SchemaV5),
+			Down:        execSchema(DropSchemaV5),
+		},
+		{
+			Version:     6,
+			Description: "entry_embeddings for semantic search",
+			Up:          execSchema(PostgresSchemaV6),
+			Down:        execSchema(DropSchemaV6),
+		},
+		{
+			Version:     7,
+			Description: "access_count and last_accessed_at on entries",
+			Up:          execSchema(PostgresSchemaV7),
+			Down:        execSchema(DropSchemaV7),
+		},
+		{
+			Version:     8,
+			Description: "expires_at on entries and expiry_policy on journals",
+			Up:          execSchema(PostgresSchemaV8),
+			Down:        execSchema(DropSchemaV8),
+		},
+		{
+			Version:     9,
+			Description: "entry_links between entries",
+			Up:          execSchema(PostgresSchemaV9),
+			Down:        execSchema(DropSchemaV9),
+		},
+		{
+			Version:     10,
+			Description: "tag_aliases and case-folded tag names",
+			Up:          upTagAliases(PostgresSchemaV10),
+			Down:        execSchema(DropSchemaV10),
+		},
+	},
+}
+
+// Migrations returns the registered migrations for a component in version order.
+func Migrations(componentName string) []Migration {
+	return migrationRegistry[componentName]
+}
+
+// LatestVersion returns the highest registered migration version for a component, or 0 if it has none.
+func LatestVersion(componentName string) int64 {
+	migrations := migrationRegistry[componentName]
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// execSchema returns a migration step that executes a block of SQL statements.
+func execSchema(schemaSQL string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(schemaSQL)
+		return err
+	}
+}
+
+// upEntriesFullTextIndex creates entries_fts with FTS5 when SQLite was built with it, or FTS4 otherwise.
+func upEntriesFullTextIndex(tx *sql.Tx) error {
+	schemaSQL := SchemaV2FTS4
+	if fts5Available(tx) {
+		schemaSQL = SchemaV2FTS5
+	}
+	_, err := tx.Exec(schemaSQL)
+	return err
+}
+
+// upTagAliases returns a migration step that creates tag_aliases with schemaSQL and then
+// folds the existing tags to the form tags are now stored in.
+func upTagAliases(schemaSQL string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		if _, err := tx.Exec(schemaSQL); err != nil {
+			return err
+		}
+		return foldTags(tx)
+	}
+}
+
+// foldTags renames every tag that is not trimmed and in lower case, the form
+// memories.NormalizeTag gives tags before they are stored or searched for. Tags that
+// fold to the same name are merged, so an entry tagged "Go" and "go" keeps one tag.
+func foldTags(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT tag FROM tags;`)
+	if err != nil {
+		return err
+	}
+	var unfolded []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			rows.Close()
+			return err
+		}
+		if folded := foldTag(tag); folded != tag && folded != "" {
+			unfolded = append(unfolded, tag)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, tag := range unfolded {
+		folded := foldTag(tag)
+		if _, err := tx.Exec(`
+INSERT INTO tags (tag, created_at, updated_at)
+SELECT $1, created_at, updated_at FROM tags WHERE tag = $2
+ON CONFLICT (tag) DO NOTHING;`, folded, tag); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+INSERT INTO entry_tags (entry_id, tag, created_at)
+SELECT entry_id, $1, created_at FROM entry_tags WHERE tag = $2
+ON CONFLICT (entry_id, tag) DO NOTHING;`, folded, tag); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM entry_tags WHERE tag = $1;`, tag); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM tags WHERE tag = $1;`, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// foldTag trims and lower-cases a tag like memories.NormalizeTag, which this package
+// cannot import.
+func foldTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// queryRower is satisfied by *sql.DB, *sql.Conn and *sql.Tx.
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// fts5Available reports whether the linked SQLite library was compiled with FTS5.
+func fts5Available(db queryRower) bool {
+	var used int
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5');`).Scan(&used); err != nil {
+		return false
+	}
+	return used == 1
+}
+
+// planMigrations returns the steps needed to move a component from one version to another.
+// Upgrades are returned in ascending order and downgrades in descending order.
+func planMigrations(componentName string, fromVersion, toVersion int64) ([]Migration, error) {
+	migrations := migrationRegistry[componentName]
+	byVersion := make(map[int64]Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	var plan []Migration
+	if toVersion >= fromVersion {
+		for version := fromVersion + 1; version <= toVersion; version++ {
+			m, ok := byVersion[version]
+			if !ok {
+				return nil, fmt.Errorf("no migration registered for version %d of component %s", version, componentName)
+			}
+			plan = append(plan, m)
+		}
+		return plan, nil
+	}
+
+	for version := fromVersion; version > toVersion; version-- {
+		m, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration registered for version %d of component %s", version, componentName)
+		}
+		if m.Down == nil {
+			return nil, fmt.Errorf("migration to version %d of component %s cannot be reversed", version, componentName)
+		}
+		plan = append(plan, m)
+	}
+	return plan, nil
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrationRegistry_Contiguous(t *testing.T) {
+	for component, migrations := range migrationRegistry {
+		for i, m := range migrations {
+			if m.Version != int64(i+1) {
+				t.Errorf("Component '%s': migration at index %d has version %d, expected %d", component, i, m.Version, i+1)
+			}
+			if m.Up == nil {
+				t.Errorf("Component '%s': migration %d has no Up step", component, m.Version)
+			}
+		}
+	}
+
+	if latest := LatestVersion(MemoriesDBComponent); latest != TargetSchemaVersion {
+		t.Errorf("TargetSchemaVersion is %d but the latest registered migration for '%s' is %d", TargetSchemaVersion, MemoriesDBComponent, latest)
+	}
+	if latest := LatestVersion(PostgresMemoriesDBComponent); latest != TargetPostgresSchemaVersion {
+		t.Errorf("TargetPostgresSchemaVersion is %d but the latest registered migration for '%s' is %d", TargetPostgresSchemaVersion, PostgresMemoriesDBComponent, latest)
+	}
+}
+
+func TestPlanMigrations_DryRunLeavesDatabaseUntouched(t *testing.T) {
+	db, err := OpenDBConnection(":memory:", true, "NORMAL")
+	if err != nil {
+		t.Fatalf("OpenDBConnection failed for in-memory DB: %v", err)
+	}
+	defer db.Close()
+
+	loadFixture(t, db, "memoriesdb_v1.sql")
+
+	plan, err := PlanMigrations(db, MemoriesDBComponent, TargetSchemaVersion)
+	if err != nil {
+		t.Fatalf("PlanMigrations failed: %v", err)
+	}
+	if len(plan) != int(TargetSchemaVersion-1) {
+		t.Fatalf("Expected %d planned migrations, got %d", TargetSchemaVersion-1, len(plan))
+	}
+	if plan[0].Version != 2 {
+		t.Errorf("Expected first planned migration to be version 2, got %d", plan[0].Version)
+	}
+
+	version, err := GetComponentSchemaVersion(db, MemoriesDBComponent)
+	if err != nil {
+		t.Fatalf("GetComponentSchemaVersion failed: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("Planning migrations changed the schema version to %d", version)
+	}
+}
+
+func TestMigrateComponent_DowngradeAndUpgradeAgain(t *testing.T) {
+	db, err := OpenDBConnection(":memory:", true, "NORMAL")
+	if err != nil {
+		t.Fatalf("OpenDBConnection failed for in-memory DB: %v", err)
+	}
+	defer db.Close()
+
+	loadFixture(t, db, "memoriesdb_v1.sql")
+	if err := UpgradeDB(db, ":memory:", TargetSchemaVersion); err != nil {
+		t.Fatalf("UpgradeDB failed: %v", err)
+	}
+
+	if _, err := DowngradeDB(db, ":memory:", 1, ""); err != nil {
+		t.Fatalf("DowngradeDB to version 1 failed: %v", err)
+	}
+	version, err := GetComponentSchemaVersion(db, MemoriesDBComponent)
+	if err != nil {
+		t.Fatalf("GetComponentSchemaVersion failed: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("Expected version 1 after downgrade, got %d", version)
+	}
+	var ftsTables int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'entries_fts';`).Scan(&ftsTables); err != nil {
+		t.Fatalf("Failed to inspect schema: %v", err)
+	}
+	if ftsTables != 0 {
+		t.Errorf("Expected entries_fts to be dropped by the downgrade")
+	}
+
+	// Downgrading to a newer version is rejected.
+	if _, err := DowngradeDB(db, ":memory:", 2, ""); err == nil {
+		t.Errorf("Expected DowngradeDB to a newer version to fail")
+	}
+
+	if err := MigrateComponent(db, MemoriesDBComponent, TargetSchemaVersion); err != nil {
+		t.Fatalf("MigrateComponent back to version %d failed: %v", TargetSchemaVersion, err)
+	}
+	checkTableExists(t, db, "entries_fts")
+	checkTableExists(t, db, "entries")
+}
+
+func TestMigrateComponent_FoldsTags(t *testing.T) {
+	db, err := OpenDBConnection(":memory:", true, "NORMAL")
+	if err != nil {
+		t.Fatalf("OpenDBConnection failed for in-memory DB: %v", err)
+	}
+	defer db.Close()
+
+	loadFixture(t, db, "memoriesdb_v1.sql")
+	if err := MigrateComponent(db, MemoriesDBComponent, 9); err != nil {
+		t.Fatalf("MigrateComponent to version 9 failed: %v", err)
+	}
+	if _, err := db.Exec(`
+INSERT INTO tags (tag) VALUES ('Ops'), (' Kubernetes ');
+INSERT INTO entry_tags (entry_id, tag) VALUES
+    ('a1f0c3d2-5b6e-4f78-9a0b-1c2d3e4f5a02', 'Ops'),
+    ('a1f0c3d2-5b6e-4f78-9a0b-1c2d3e4f5a01', 'Ops'),
+    ('a1f0c3d2-5b6e-4f78-9a0b-1c2d3e4f5a01', ' Kubernetes ');`); err != nil {
+		t.Fatalf("Failed to insert unfolded tags: %v", err)
+	}
+
+	if err := MigrateComponent(db, MemoriesDBComponent, 10); err != nil {
+		t.Fatalf("MigrateComponent to version 10 failed: %v", err)
+	}
+	checkTableExists(t, db, "tag_aliases")
+
+	rows, err := db.Query(`SELECT entry_id, tag FROM entry_tags ORDER BY entry_id, tag;`)
+	if err != nil {
+		t.Fatalf("Failed to list entry tags: %v", err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var entryID, tag string
+		if err := rows.Scan(&entryID, &tag); err != nil {
+			t.Fatalf("Failed to scan entry tag: %v", err)
+		}
+		got = append(got, entryID[len(entryID)-2:]+":"+tag)
+	}
+	want := []string{"01:kubernetes", "01:ops", "01:preferences", "02:ops"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected entry tags %v after folding, got %v", want, got)
+	}
+	var tags int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tags;`).Scan(&tags); err != nil {
+		t.Fatalf("Failed to count tags: %v", err)
+	}
+	if tags != 3 {
+		t.Errorf("Expected the folded tags to be merged into 3 tags, got %d", tags)
+	}
+}
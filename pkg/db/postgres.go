@@ -0,0 +1,79 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+const (
+	// TargetPostgresSchemaVersion is the highest schema version this version of the code
+	// supports for the memoriesdb-postgres component.
+	TargetPostgresSchemaVersion int64 = 10
+	// PostgresMemoriesDBComponent is the name of the memories database component in
+	// PostgreSQL databases, which has its own migration history.
+	PostgresMemoriesDBComponent = "memoriesdb-postgres"
+)
+
+// IsPostgresDSN reports whether dsn is a PostgreSQL connection URL
+// (postgres://... or postgresql://...) rather than a SQLite database path.
+func IsPostgresDSN(dsn string) bool {
+	lower := strings.ToLower(dsn)
+	return strings.HasPrefix(lower, "postgres://") || strings.HasPrefix(lower, "postgresql://")
+}
+
+// RedactDSN returns dsn with any password replaced, for logging. Paths that are not
+// connection URLs are returned unchanged.
+func RedactDSN(dsn string) string {
+	if !IsPostgresDSN(dsn) {
+		return dsn
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "postgres://"
+	}
+	return u.Redacted()
+}
+
+// OpenPostgresConnection connects to the PostgreSQL database at dsn, a postgres:// URL
+// as understood by lib/pq.
+func OpenPostgresConnection(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database '%s': %w", RedactDSN(dsn), err)
+	}
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database '%s': %w", RedactDSN(dsn), err)
+	}
+
+	return db, nil
+}
+
+// UpgradePostgresDB applies the migrations needed to bring the PostgresMemoriesDBComponent
+// of db to appTargetSchemaVersion. Unlike UpgradeDB it writes no backup; use pg_dump.
+// dbIdentifierForLog is used for logging purposes only.
+func UpgradePostgresDB(db *sql.DB, dbIdentifierForLog string, appTargetSchemaVersion int64) error {
+	currentDBVersion, err := GetComponentSchemaVersion(db, PostgresMemoriesDBComponent)
+	if err != nil {
+		return err
+	}
+
+	if currentDBVersion == appTargetSchemaVersion {
+		fmt.Fprintf(os.Stderr, "Component %s in database '%s' is already up to date (schema version %d).\n", PostgresMemoriesDBComponent, dbIdentifierForLog, currentDBVersion)
+		return nil
+	} else if currentDBVersion > appTargetSchemaVersion {
+		return fmt.Errorf("component %s in database '%s' has schema version %d, which is newer than application's target schema version %d. Please upgrade the application", PostgresMemoriesDBComponent, dbIdentifierForLog, currentDBVersion, appTargetSchemaVersion)
+	}
+
+	fmt.Fprintf(os.Stderr, "Component %s in database '%s' is at schema version %d. Migrating to schema version %d...\n", PostgresMemoriesDBComponent, dbIdentifierForLog, currentDBVersion, appTargetSchemaVersion)
+	if err := applyMigrations(db, PostgresMemoriesDBComponent, currentDBVersion, appTargetSchemaVersion); err != nil {
+		return fmt.Errorf("failed to migrate component %s in database '%s' from schema version %d to %d: %w", PostgresMemoriesDBComponent, dbIdentifierForLog, currentDBVersion, appTargetSchemaVersion, err)
+	}
+	return nil
+}
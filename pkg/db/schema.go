@@ -42,5 +42,215 @@ CREATE TABLE IF NOT EXISTS entry_tags (
     created_at REAL DEFAULT (unixepoch()),
     PRIMARY KEY (entry_id, tag)
 );
+`
+	// SchemaV2FTS5 adds the entries_fts full-text index over entry titles and content,
+	// kept in sync with the entries table by triggers. It requires SQLite to be built with FTS5
+	// (the sqlite_fts5 build tag for go-sqlite3).
+	SchemaV2FTS5 = `
+CREATE VIRTUAL TABLE IF NOT EXISTS entries_fts USING fts5(
+    entry_id UNINDEXED,
+    title,
+    content
+);
+` + entriesFTSTriggers
+
+	// SchemaV2FTS4 is the fallback for SchemaV2FTS5 used when SQLite is built without FTS5.
+	// FTS4 has no built-in bm25(), so ranking is computed from matchinfo() instead.
+	SchemaV2FTS4 = `
+CREATE VIRTUAL TABLE IF NOT EXISTS entries_fts USING fts4(
+    entry_id,
+    title,
+    content,
+    notindexed=entry_id
+);
+` + entriesFTSTriggers
+
+	// entriesFTSTriggers keeps entries_fts in sync with entries and backfills existing rows.
+	entriesFTSTriggers = `
+CREATE TRIGGER IF NOT EXISTS entries_fts_after_insert AFTER INSERT ON entries BEGIN
+    INSERT INTO entries_fts (entry_id, title, content) VALUES (new.id, new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS entries_fts_after_update AFTER UPDATE OF title, content ON entries BEGIN
+    DELETE FROM entries_fts WHERE entry_id = old.id;
+    INSERT INTO entries_fts (entry_id, title, content) VALUES (new.id, new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS entries_fts_after_delete AFTER DELETE ON entries BEGIN
+    DELETE FROM entries_fts WHERE entry_id = old.id;
+END;
+
+INSERT INTO entries_fts (entry_id, title, content)
+SELECT id, title, content FROM entries
+WHERE id NOT IN (SELECT entry_id FROM entries_fts);
+`
+)
+
+const (
+	// DropSchemaV1 reverses SchemaV1. recall_versions is kept so the component can be recorded at version 0.
+	DropSchemaV1 = `
+DROP TABLE IF EXISTS entry_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS entries;
+DROP TABLE IF EXISTS journals;
+`
+
+	// DropSchemaV2 reverses SchemaV2FTS5 and SchemaV2FTS4.
+	DropSchemaV2 = `
+DROP TRIGGER IF EXISTS entries_fts_after_insert;
+DROP TRIGGER IF EXISTS entries_fts_after_update;
+DROP TRIGGER IF EXISTS entries_fts_after_delete;
+DROP TABLE IF EXISTS entries_fts;
+`
+)
+
+const (
+	// SchemaV3 adds entry_revisions, which keeps every prior title, content and content type of an entry.
+	SchemaV3 = `
+CREATE TABLE IF NOT EXISTS entry_revisions (
+    entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(256) NOT NULL,
+    content TEXT NOT NULL,
+    content_type VARCHAR(64) DEFAULT 'text/plain',
+    actor VARCHAR(256) NOT NULL DEFAULT '',
+    created_at REAL DEFAULT (unixepoch()),
+    PRIMARY KEY (entry_id, revision)
+);
+`
+
+	// DropSchemaV3 reverses SchemaV3.
+	DropSchemaV3 = `
+DROP TABLE IF EXISTS entry_revisions;
+`
+)
+
+const (
+	// SchemaV4 adds api_tokens, the bearer tokens accepted by the networked MCP transports.
+	// Only a SHA-256 hash of each token is stored. journals is a JSON array of the journal
+	// names a token may use; an empty array allows every journal.
+	SchemaV4 = `
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id UUID PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    read_only BOOLEAN NOT NULL DEFAULT FALSE,
+    journals TEXT NOT NULL DEFAULT '[]',
+    created_at REAL DEFAULT (unixepoch()),
+    last_used_at REAL,
+    revoked_at REAL
+);
+`
+
+	// DropSchemaV4 reverses SchemaV4.
+	DropSchemaV4 = `
+DROP TABLE IF EXISTS api_tokens;
+`
+)
+
+const (
+	// SchemaV5 adds indexes for listing and filtering entries across journals: finding the
+	// entries with a tag, and a journal's live entries in update order. PostgreSQL uses it too.
+	SchemaV5 = `
+CREATE INDEX IF NOT EXISTS entry_tags_tag_idx ON entry_tags(tag);
+CREATE INDEX IF NOT EXISTS entries_journal_deleted_updated_idx ON entries(journal_id, deleted, updated_at);
+`
+
+	// DropSchemaV5 reverses SchemaV5.
+	DropSchemaV5 = `
+DROP INDEX IF EXISTS entry_tags_tag_idx;
+DROP INDEX IF EXISTS entries_journal_deleted_updated_idx;
+`
+)
+
+const (
+	// SchemaV6 adds entry_embeddings, the vectors semantic search compares. An entry has one
+	// embedding per embedding model; vector holds little-endian float32 values.
+	SchemaV6 = `
+CREATE TABLE IF NOT EXISTS entry_embeddings (
+    entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    model VARCHAR(256) NOT NULL,
+    vector BLOB NOT NULL,
+    created_at REAL DEFAULT (unixepoch()),
+    PRIMARY KEY (entry_id, model)
+);
+`
+
+	// DropSchemaV6 reverses SchemaV6 and PostgresSchemaV6.
+	DropSchemaV6 = `
+DROP TABLE IF EXISTS entry_embeddings;
+`
+)
+
+const (
+	// SchemaV7 adds the read statistics of entries: how often agents read them and when
+	// they last did. last_accessed_at is 0 for entries that were never read.
+	SchemaV7 = `
+ALTER TABLE entries ADD COLUMN access_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE entries ADD COLUMN last_accessed_at REAL NOT NULL DEFAULT 0;
+`
+
+	// DropSchemaV7 reverses SchemaV7 and PostgresSchemaV7.
+	DropSchemaV7 = `
+ALTER TABLE entries DROP COLUMN last_accessed_at;
+ALTER TABLE entries DROP COLUMN access_count;
+`
+)
+
+const (
+	// SchemaV8 adds entry expiry: expires_at is when an entry stops being listed and becomes
+	// due for the reaper, or 0 for entries that never expire, and a journal's expiry_policy
+	// says whether the reaper soft-deletes or purges its expired entries.
+	SchemaV8 = `
+ALTER TABLE entries ADD COLUMN expires_at REAL NOT NULL DEFAULT 0;
+ALTER TABLE journals ADD COLUMN expiry_policy TEXT NOT NULL DEFAULT 'soft_delete' CHECK (expiry_policy IN ('soft_delete', 'purge'));
+CREATE INDEX IF NOT EXISTS entries_expires_at_idx ON entries(expires_at) WHERE expires_at > 0;
+`
+
+	// DropSchemaV8 reverses SchemaV8 and PostgresSchemaV8.
+	DropSchemaV8 = `
+DROP INDEX IF EXISTS entries_expires_at_idx;
+ALTER TABLE journals DROP COLUMN expiry_policy;
+ALTER TABLE entries DROP COLUMN expires_at;
+`
+)
+
+const (
+	// SchemaV9 adds entry_links, the typed, directed links from one entry to another, such
+	// as one entry superseding another. A pair of entries has at most one link of each
+	// relation.
+	SchemaV9 = `
+CREATE TABLE IF NOT EXISTS entry_links (
+    source_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    target_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    relation VARCHAR(64) NOT NULL CHECK (relation IN ('relates_to', 'supersedes', 'depends_on')),
+    created_at REAL DEFAULT (unixepoch()),
+    PRIMARY KEY (source_id, target_id, relation)
+);
+
+CREATE INDEX IF NOT EXISTS entry_links_target_idx ON entry_links(target_id);
+`
+
+	// DropSchemaV9 reverses SchemaV9 and PostgresSchemaV9.
+	DropSchemaV9 = `
+DROP TABLE IF EXISTS entry_links;
+`
+
+	// SchemaV10 adds tag_aliases, which map alternative spellings of a tag to the tag they
+	// stand for, such as k8s to kubernetes. Aliases go away with their tag.
+	SchemaV10 = `
+CREATE TABLE IF NOT EXISTS tag_aliases (
+    alias VARCHAR(256) PRIMARY KEY,
+    tag VARCHAR(256) NOT NULL REFERENCES tags(tag) ON DELETE CASCADE,
+    created_at REAL DEFAULT (unixepoch())
+);
+
+CREATE INDEX IF NOT EXISTS tag_aliases_tag_idx ON tag_aliases(tag);
+`
+
+	// DropSchemaV10 reverses SchemaV10 and PostgresSchemaV10. Tags folded by the upgrade
+	// keep their folded names.
+	DropSchemaV10 = `
+DROP TABLE IF EXISTS tag_aliases;
 `
 )
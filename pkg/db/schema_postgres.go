@@ -0,0 +1,159 @@
+package db
+
+// The PostgreSQL schema mirrors the SQLite one version for version, so that both
+// components can be compared at a glance. Timestamps are Unix seconds stored as
+// DOUBLE PRECISION, like SQLite's REAL columns, and a unixepoch() function stands in
+// for SQLite's built-in one so that statements shared with SQLite work unchanged.
+const (
+	// PostgresSchemaV1 defines version 1 of the memoriesdb-postgres component.
+	PostgresSchemaV1 = `
+CREATE OR REPLACE FUNCTION unixepoch() RETURNS DOUBLE PRECISION AS $$
+    SELECT extract(epoch FROM now())::double precision
+$$ LANGUAGE SQL STABLE;
+
+CREATE TABLE IF NOT EXISTS recall_versions (
+    component VARCHAR(64) PRIMARY KEY,
+    version INTEGER NOT NULL,
+    created_at DOUBLE PRECISION DEFAULT unixepoch()
+);
+
+CREATE TABLE IF NOT EXISTS journals (
+    id UUID PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    description TEXT,
+    active BOOLEAN DEFAULT TRUE,
+    created_at DOUBLE PRECISION DEFAULT unixepoch(),
+    updated_at DOUBLE PRECISION DEFAULT unixepoch()
+);
+
+CREATE TABLE IF NOT EXISTS entries (
+    id UUID PRIMARY KEY,
+    journal_id UUID NOT NULL REFERENCES journals(id) ON DELETE CASCADE,
+    title VARCHAR(256) NOT NULL,
+    content TEXT NOT NULL,
+    content_type VARCHAR(64) DEFAULT 'text/plain',
+    deleted BOOLEAN DEFAULT FALSE,
+    created_at DOUBLE PRECISION DEFAULT unixepoch(),
+    updated_at DOUBLE PRECISION DEFAULT unixepoch()
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+    tag VARCHAR(256) PRIMARY KEY,
+    created_at DOUBLE PRECISION DEFAULT unixepoch(),
+    updated_at DOUBLE PRECISION DEFAULT unixepoch()
+);
+
+CREATE TABLE IF NOT EXISTS entry_tags (
+    entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    tag VARCHAR(256) NOT NULL REFERENCES tags(tag) ON DELETE CASCADE,
+    created_at DOUBLE PRECISION DEFAULT unixepoch(),
+    PRIMARY KEY (entry_id, tag)
+);
+`
+
+	// PostgresSchemaV2 adds search_vector, a tsvector over entry titles (weight A) and
+	// content (weight B) that PostgreSQL keeps up to date, with a GIN index for full-text
+	// search. The 'simple' configuration does not stem, like SQLite's default tokenizer.
+	PostgresSchemaV2 = `
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(content, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS entries_search_vector_idx ON entries USING GIN (search_vector);
+`
+
+	// PostgresSchemaV3 adds entry_revisions, as SchemaV3 does for SQLite.
+	PostgresSchemaV3 = `
+CREATE TABLE IF NOT EXISTS entry_revisions (
+    entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(256) NOT NULL,
+    content TEXT NOT NULL,
+    content_type VARCHAR(64) DEFAULT 'text/plain',
+    actor VARCHAR(256) NOT NULL DEFAULT '',
+    created_at DOUBLE PRECISION DEFAULT unixepoch(),
+    PRIMARY KEY (entry_id, revision)
+);
+`
+
+	// PostgresSchemaV4 adds api_tokens, as SchemaV4 does for SQLite.
+	PostgresSchemaV4 = `
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id UUID PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    read_only BOOLEAN NOT NULL DEFAULT FALSE,
+    journals TEXT NOT NULL DEFAULT '[]',
+    created_at DOUBLE PRECISION DEFAULT unixepoch(),
+    last_used_at DOUBLE PRECISION,
+    revoked_at DOUBLE PRECISION
+);
+`
+
+	// PostgresSchemaV6 adds entry_embeddings, as SchemaV6 does for SQLite.
+	PostgresSchemaV6 = `
+CREATE TABLE IF NOT EXISTS entry_embeddings (
+    entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    model VARCHAR(256) NOT NULL,
+    vector BYTEA NOT NULL,
+    created_at DOUBLE PRECISION DEFAULT unixepoch(),
+    PRIMARY KEY (entry_id, model)
+);
+`
+
+	// PostgresSchemaV7 adds the read statistics of entries, as SchemaV7 does for SQLite.
+	PostgresSchemaV7 = `
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS access_count BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS last_accessed_at DOUBLE PRECISION NOT NULL DEFAULT 0;
+`
+
+	// PostgresSchemaV8 adds entry expiry and the expiry policy of journals, as SchemaV8 does
+	// for SQLite.
+	PostgresSchemaV8 = `
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS expires_at DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE journals ADD COLUMN IF NOT EXISTS expiry_policy TEXT NOT NULL DEFAULT 'soft_delete' CHECK (expiry_policy IN ('soft_delete', 'purge'));
+CREATE INDEX IF NOT EXISTS entries_expires_at_idx ON entries(expires_at) WHERE expires_at > 0;
+`
+
+	// PostgresSchemaV9 adds entry_links, as SchemaV9 does for SQLite.
+	PostgresSchemaV9 = `
+CREATE TABLE IF NOT EXISTS entry_links (
+    source_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    target_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    relation VARCHAR(64) NOT NULL CHECK (relation IN ('relates_to', 'supersedes', 'depends_on')),
+    created_at DOUBLE PRECISION DEFAULT unixepoch(),
+    PRIMARY KEY (source_id, target_id, relation)
+);
+
+CREATE INDEX IF NOT EXISTS entry_links_target_idx ON entry_links(target_id);
+`
+
+	// PostgresSchemaV10 adds tag_aliases, as SchemaV10 does for SQLite.
+	PostgresSchemaV10 = `
+CREATE TABLE IF NOT EXISTS tag_aliases (
+    alias VARCHAR(256) PRIMARY KEY,
+    tag VARCHAR(256) NOT NULL REFERENCES tags(tag) ON DELETE CASCADE,
+    created_at DOUBLE PRECISION DEFAULT unixepoch()
+);
+
+CREATE INDEX IF NOT EXISTS tag_aliases_tag_idx ON tag_aliases(tag);
+`
+)
+
+const (
+	// DropPostgresSchemaV1 reverses PostgresSchemaV1. recall_versions and unixepoch() are
+	// kept so the component can be recorded at version 0.
+	DropPostgresSchemaV1 = `
+DROP TABLE IF EXISTS entry_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS entries;
+DROP TABLE IF EXISTS journals;
+`
+
+	// DropPostgresSchemaV2 reverses PostgresSchemaV2.
+	DropPostgresSchemaV2 = `
+DROP INDEX IF EXISTS entries_search_vector_idx;
+ALTER TABLE entries DROP COLUMN IF EXISTS search_vector;
+`
+)
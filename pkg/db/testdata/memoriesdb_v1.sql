@@ -0,0 +1,58 @@
+-- A memoriesdb database as created by recall v0.0.3 (schema version 1).
+CREATE TABLE recall_versions (
+    component VARCHAR(64) PRIMARY KEY,
+    version INTEGER NOT NULL,
+    created_at REAL DEFAULT (unixepoch())
+);
+
+CREATE TABLE journals (
+    id UUID PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    description TEXT,
+    active BOOLEAN DEFAULT TRUE,
+    created_at REAL DEFAULT (unixepoch()),
+    updated_at REAL DEFAULT (unixepoch())
+);
+
+CREATE TABLE entries (
+    id UUID PRIMARY KEY,
+    journal_id UUID NOT NULL REFERENCES journals(id) ON DELETE CASCADE,
+    title VARCHAR(256) NOT NULL,
+    content TEXT NOT NULL,
+    content_type VARCHAR(64) DEFAULT 'text/plain',
+    deleted BOOLEAN DEFAULT FALSE,
+    created_at REAL DEFAULT (unixepoch()),
+    updated_at REAL DEFAULT (unixepoch())
+);
+
+CREATE TABLE tags (
+    tag VARCHAR(256) PRIMARY KEY,
+    created_at REAL DEFAULT (unixepoch()),
+    updated_at REAL DEFAULT (unixepoch())
+);
+
+CREATE TABLE entry_tags (
+    entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    tag VARCHAR(256) NOT NULL REFERENCES tags(tag) ON DELETE CASCADE,
+    created_at REAL DEFAULT (unixepoch()),
+    PRIMARY KEY (entry_id, tag)
+);
+
+INSERT INTO recall_versions (component, version, created_at) VALUES ('memoriesdb', 1, 1746000000);
+
+INSERT INTO journals (id, name, description, active, created_at, updated_at) VALUES
+    ('6f1c2f4e-8d1a-4c55-9a3e-0d7f4f0b9a11', 'memory', '', 1, 1746000000, 1746000000),
+    ('0b4d6a0e-2c7f-4f7e-8f55-3f1b8f7c2d22', 'work', 'Work notes', 1, 1746000100, 1746000100);
+
+INSERT INTO entries (id, journal_id, title, content, content_type, deleted, created_at, updated_at) VALUES
+    ('a1f0c3d2-5b6e-4f78-9a0b-1c2d3e4f5a01', '6f1c2f4e-8d1a-4c55-9a3e-0d7f4f0b9a11', 'Preferred editor', 'Uses neovim with a tabstop of four.', 'text/plain', 0, 1746000200, 1746000200),
+    ('a1f0c3d2-5b6e-4f78-9a0b-1c2d3e4f5a02', '0b4d6a0e-2c7f-4f7e-8f55-3f1b8f7c2d22', 'Deployment checklist', '1. Run migrations\n2. Restart the service', 'text/markdown', 0, 1746000300, 1746000400),
+    ('a1f0c3d2-5b6e-4f78-9a0b-1c2d3e4f5a03', '0b4d6a0e-2c7f-4f7e-8f55-3f1b8f7c2d22', 'Old sprint goals', 'Ship the TUI.', 'text/plain', 1, 1746000500, 1746000600);
+
+INSERT INTO tags (tag, created_at, updated_at) VALUES
+    ('preferences', 1746000200, 1746000200),
+    ('ops', 1746000300, 1746000300);
+
+INSERT INTO entry_tags (entry_id, tag, created_at) VALUES
+    ('a1f0c3d2-5b6e-4f78-9a0b-1c2d3e4f5a01', 'preferences', 1746000200),
+    ('a1f0c3d2-5b6e-4f78-9a0b-1c2d3e4f5a02', 'ops', 1746000300);
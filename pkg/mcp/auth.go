@@ -0,0 +1,50 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/unowned-ai/recall/pkg/auth"
+)
+
+// requireToken rejects HTTP requests without a valid API token in their Authorization
+// header, and limits the requests it accepts to the token's scope.
+func (s *RecallMCPServer) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || secret == "" {
+			unauthorized(w, "missing bearer token")
+			return
+		}
+		token, err := auth.Authenticate(r.Context(), s.db, strings.TrimSpace(secret))
+		if errors.Is(err, auth.ErrInvalidToken) {
+			unauthorized(w, err.Error())
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to authenticate request: %v\n", err)
+			http.Error(w, "failed to authenticate request", http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithScope(r.Context(), token.Scope)))
+	})
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="recall"`)
+	http.Error(w, message, http.StatusUnauthorized)
+}
+
+// authorize returns an error result if the token of the request may not read, or with
+// write set change, the named journal, and nil otherwise.
+func authorize(ctx context.Context, journalName string, write bool) *mcp.CallToolResult {
+	if err := auth.Authorize(ctx, journalName, write); err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	return nil
+}
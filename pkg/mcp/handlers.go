@@ -2,13 +2,19 @@ package mcp
 
 import (
 	"context"
-	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/unowned-ai/recall/pkg/auth"
 	"github.com/unowned-ai/recall/pkg/memories"
 )
 
@@ -26,7 +32,7 @@ func RegisterPingTool(s *server.MCPServer) {
 }
 
 // RegisterCreateJournalTool registers the create_journal tool.
-func RegisterCreateJournalTool(s *server.MCPServer, db *sql.DB) {
+func RegisterCreateJournalTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"create_journal",
 		mcp.WithDescription("Creates a new journal."),
@@ -34,13 +40,16 @@ func RegisterCreateJournalTool(s *server.MCPServer, db *sql.DB) {
 		mcp.WithString("description", mcp.Description("Optional description for the journal.")),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		name, _ := request.Params.Arguments["name"].(string)
+		name, _ := request.GetArguments()["name"].(string)
 		if strings.TrimSpace(name) == "" {
 			return mcp.NewToolResultError("'name' parameter is required and must be non-empty"), nil
 		}
-		desc, _ := request.Params.Arguments["description"].(string)
+		if denied := authorize(ctx, name, true); denied != nil {
+			return denied, nil
+		}
+		desc, _ := request.GetArguments()["description"].(string)
 
-		journal, err := memories.CreateJournal(ctx, db, name, desc)
+		journal, err := store.CreateJournal(ctx, name, desc)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to create journal: %v", err)), nil
 		}
@@ -50,37 +59,50 @@ func RegisterCreateJournalTool(s *server.MCPServer, db *sql.DB) {
 }
 
 // RegisterListJournalsTool lists all journals (active & inactive).
-func RegisterListJournalsTool(s *server.MCPServer, db *sql.DB) {
+func RegisterListJournalsTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"list_journals",
 		mcp.WithDescription("Lists all available journals."),
+		mcp.WithNumber("limit", mcp.Description(limitDescription)),
+		mcp.WithString("cursor", mcp.Description(cursorDescription)),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		journals, err := memories.ListJournals(ctx, db, false)
+		page, paged := pageArguments(request)
+		var journals []memories.Journal
+		var next string
+		var err error
+		if _, scoped := auth.ScopeFromContext(ctx); scoped {
+			// Journals outside the scope are dropped before paging, so that pages stay full.
+			journals, err = store.ListJournals(ctx, false)
+			if err == nil {
+				journals, next, err = memories.PaginateJournals(accessibleJournals(ctx, journals), page)
+			}
+		} else {
+			journals, next, err = store.ListJournalsPage(ctx, false, page)
+		}
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to list journals: %v", err)), nil
 		}
-		if len(journals) == 0 {
-			return mcp.NewToolResultText("[]"), nil
-		}
-		b, _ := json.Marshal(journals)
-		return mcp.NewToolResultText(string(b)), nil
+		return pagedResult("journals", journals, next, paged), nil
 	})
 }
 
 // RegisterGetJournalTool retrieves a journal by name.
-func RegisterGetJournalTool(s *server.MCPServer, db *sql.DB) {
+func RegisterGetJournalTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"get_journal",
 		mcp.WithDescription("Retrieves details for a specific journal by its name."),
 		mcp.WithString("name", mcp.Required(), mcp.Description("The name of the journal to retrieve.")),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		name, _ := request.Params.Arguments["name"].(string)
+		name, _ := request.GetArguments()["name"].(string)
 		if strings.TrimSpace(name) == "" {
 			return mcp.NewToolResultError("'name' parameter is required"), nil
 		}
-		j, err := getJournalByName(ctx, db, name)
+		if denied := authorize(ctx, name, false); denied != nil {
+			return denied, nil
+		}
+		j, err := getJournalByName(ctx, store, name)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal '%s': %v", name, err)), nil
 		}
@@ -93,88 +115,106 @@ func RegisterGetJournalTool(s *server.MCPServer, db *sql.DB) {
 }
 
 // RegisterUpdateJournalTool updates journal metadata.
-func RegisterUpdateJournalTool(s *server.MCPServer, db *sql.DB) {
+func RegisterUpdateJournalTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"update_journal",
-		mcp.WithDescription("Updates an existing journal's name, description, or active status."),
+		mcp.WithDescription("Updates an existing journal's name, description, active status, or expiry policy."),
 		mcp.WithString("name", mcp.Required(), mcp.Description("Current name of the journal.")),
 		mcp.WithString("new_name", mcp.Description("Optional new name for the journal.")),
 		mcp.WithString("description", mcp.Description("Optional new description.")),
 		mcp.WithBoolean("active", mcp.Description("Optional new active status (true/false).")),
+		mcp.WithString("expiry_policy", mcp.Enum(string(memories.ExpirySoftDelete), string(memories.ExpiryPurge)), mcp.Description("Optional new expiry policy: whether expired entries are soft-deleted or purged.")),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		name, _ := request.Params.Arguments["name"].(string)
+		name, _ := request.GetArguments()["name"].(string)
 		if strings.TrimSpace(name) == "" {
 			return mcp.NewToolResultError("'name' parameter is required"), nil
 		}
-		currentJournal, err := getJournalByName(ctx, db, name)
+		if denied := authorize(ctx, name, true); denied != nil {
+			return denied, nil
+		}
+		currentJournal, err := getJournalByName(ctx, store, name)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal: %v", err)), nil
 		}
 		if currentJournal == nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Journal '%s' not found", name)), nil
 		}
-		newNameVal, _ := request.Params.Arguments["new_name"].(string)
+		newNameVal, _ := request.GetArguments()["new_name"].(string)
 		if strings.TrimSpace(newNameVal) == "" {
 			newNameVal = currentJournal.Name
 		}
-		newDescVal, _ := request.Params.Arguments["description"].(string)
+		if denied := authorize(ctx, newNameVal, true); denied != nil {
+			return denied, nil
+		}
+		newDescVal, _ := request.GetArguments()["description"].(string)
 		if newDescVal == "" {
 			newDescVal = currentJournal.Description
 		}
 		activeVal := currentJournal.Active
-		if av, ok := request.Params.Arguments["active"].(bool); ok {
+		if av, ok := request.GetArguments()["active"].(bool); ok {
 			activeVal = av
 		}
-		updated, err := memories.UpdateJournal(ctx, db, currentJournal.ID, newNameVal, newDescVal, activeVal)
+		var policy memories.ExpiryPolicy
+		if policyVal, _ := request.GetArguments()["expiry_policy"].(string); policyVal != "" {
+			if policy, err = memories.ParseExpiryPolicy(policyVal); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		updated, err := store.UpdateJournal(ctx, currentJournal.ID, newNameVal, newDescVal, activeVal)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to update journal: %v", err)), nil
 		}
+		if policy != "" && policy != updated.ExpiryPolicy {
+			if updated, err = store.SetJournalExpiryPolicy(ctx, updated.ID, policy); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to update journal: %v", err)), nil
+			}
+		}
 		b, _ := json.Marshal(updated)
 		return mcp.NewToolResultText(string(b)), nil
 	})
 }
 
 // RegisterDeleteJournalTool deletes a journal by name (except the default).
-func RegisterDeleteJournalTool(s *server.MCPServer, db *sql.DB) {
+func RegisterDeleteJournalTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"delete_journal",
 		mcp.WithDescription("Deletes a journal and all its associated entries."),
 		mcp.WithString("name", mcp.Required(), mcp.Description("The name of the journal to delete.")),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		name, _ := request.Params.Arguments["name"].(string)
+		name, _ := request.GetArguments()["name"].(string)
 		if strings.TrimSpace(name) == "" {
 			return mcp.NewToolResultError("'name' parameter is required"), nil
 		}
 		if name == DefaultJournalName {
 			return mcp.NewToolResultError(fmt.Sprintf("Deleting the default journal '%s' is not allowed", DefaultJournalName)), nil
 		}
-		j, err := getJournalByName(ctx, db, name)
+		if denied := authorize(ctx, name, true); denied != nil {
+			return denied, nil
+		}
+		j, err := getJournalByName(ctx, store, name)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal: %v", err)), nil
 		}
 		if j == nil {
 			return mcp.NewToolResultText(fmt.Sprintf("Journal '%s' not found, nothing to delete.", name)), nil
 		}
-		if err := memories.DeleteJournal(ctx, db, j.ID); err != nil {
+		if err := store.DeleteJournal(ctx, j.ID); err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to delete journal: %v", err)), nil
 		}
 		return mcp.NewToolResultText(fmt.Sprintf("Journal '%s' deleted successfully.", name)), nil
 	})
 }
 
-// entryWithTags embeds memories.Entry and adds a Tags slice for MCP responses.
-type entryWithTags struct {
-	memories.Entry
-	Tags []string `json:"tags"`
-}
+// entryWithTags is an entry with its tag names, as returned in MCP responses.
+type entryWithTags = memories.TaggedEntry
 
 // helper to convert an Entry to entryWithTags.
-func enrichEntry(ctx context.Context, db *sql.DB, e memories.Entry) (entryWithTags, error) {
+func enrichEntry(ctx context.Context, store memories.Store, e memories.Entry) (entryWithTags, error) {
 	var out entryWithTags
 	out.Entry = e
-	tagObjs, err := memories.ListTagsForEntry(ctx, db, e.ID)
+	tagObjs, err := store.ListTagsForEntry(ctx, e.ID)
 	if err != nil {
 		return out, err
 	}
@@ -185,7 +225,7 @@ func enrichEntry(ctx context.Context, db *sql.DB, e memories.Entry) (entryWithTa
 }
 
 // RegisterCreateEntryTool registers the create_entry tool.
-func RegisterCreateEntryTool(s *server.MCPServer, db *sql.DB) {
+func RegisterCreateEntryTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"create_entry",
 		mcp.WithDescription("Creates a new entry within a journal."),
@@ -193,67 +233,86 @@ func RegisterCreateEntryTool(s *server.MCPServer, db *sql.DB) {
 		mcp.WithString("entry_title", mcp.Required(), mcp.Description("Title for the new entry.")),
 		mcp.WithString("content", mcp.Required(), mcp.Description("Content for the new entry.")),
 		mcp.WithString("content_type", mcp.DefaultString("text/plain"), mcp.Description("Optional content type.")),
-		mcp.WithString("tags", mcp.Description("Optional comma-separated tags.")),
+		mcp.WithString("tags", mcp.Description("Optional comma-separated tags, stored in lower case with aliases replaced by their tags.")),
+		mcp.WithString("ttl", mcp.Description(ttlDescription)),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		journalName, _ := request.Params.Arguments["journal_name"].(string)
+		journalName, _ := request.GetArguments()["journal_name"].(string)
 		if journalName == "" {
 			journalName = DefaultJournalName
 		}
-		title, _ := request.Params.Arguments["entry_title"].(string)
-		content, _ := request.Params.Arguments["content"].(string)
-		contentType, _ := request.Params.Arguments["content_type"].(string)
-		tagsStr, _ := request.Params.Arguments["tags"].(string)
+		if denied := authorize(ctx, journalName, true); denied != nil {
+			return denied, nil
+		}
+		title, _ := request.GetArguments()["entry_title"].(string)
+		content, _ := request.GetArguments()["content"].(string)
+		contentType, _ := request.GetArguments()["content_type"].(string)
+		tagsStr, _ := request.GetArguments()["tags"].(string)
 		if strings.TrimSpace(title) == "" {
 			return mcp.NewToolResultError("'entry_title' parameter is required"), nil
 		}
+		expiresAt, setExpiry, errResult := ttlArgument(request)
+		if errResult != nil {
+			return errResult, nil
+		}
 		// Ensure journal exists (create if missing)
-		journal, err := getJournalByName(ctx, db, journalName)
+		journal, err := getJournalByName(ctx, store, journalName)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error checking journal: %v", err)), nil
 		}
 		if journal == nil {
-			journalPtr, errCreate := memories.CreateJournal(ctx, db, journalName, "")
+			journalPtr, errCreate := store.CreateJournal(ctx, journalName, "")
 			if errCreate != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to create journal '%s': %v", journalName, errCreate)), nil
 			}
 			journal = &journalPtr
 		}
-		entry, err := memories.CreateEntry(ctx, db, journal.ID, title, content, contentType)
+		entry, err := store.CreateEntry(ctx, journal.ID, title, content, contentType)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to create entry: %v", err)), nil
 		}
+		if setExpiry && expiresAt != 0 {
+			if entry, err = store.SetEntryExpiry(ctx, entry.ID, expiresAt); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Entry created, but its expiry could not be set: %v", err)), nil
+			}
+		}
 		// Tagging if requested
 		if tagsStr != "" {
 			for _, t := range parseTags(tagsStr) {
-				_ = memories.TagEntry(ctx, db, entry.ID, t) // Ignore individual tag errors for now
+				_ = store.TagEntry(ctx, entry.ID, t) // Ignore individual tag errors for now
 			}
 		}
-		enriched, _ := enrichEntry(ctx, db, entry)
+		enriched, _ := enrichEntry(ctx, store, entry)
 		b, _ := json.Marshal(enriched)
 		return mcp.NewToolResultText(string(b)), nil
 	})
 }
 
 // RegisterListEntriesTool registers list_entries (filter by journal or tags).
-func RegisterListEntriesTool(s *server.MCPServer, db *sql.DB) {
+func RegisterListEntriesTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"list_entries",
 		mcp.WithDescription("Lists entries, optionally filtered by journal and/or tags."),
 		mcp.WithString("journal_name", mcp.DefaultString(DefaultJournalName), mcp.Description("Optional journal filter.")),
 		mcp.WithString("tags", mcp.Description("Optional comma-separated tags list.")),
+		mcp.WithNumber("limit", mcp.Description(limitDescription)),
+		mcp.WithString("cursor", mcp.Description(cursorDescription)),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		journalName, _ := request.Params.Arguments["journal_name"].(string)
+		page, paged := pageArguments(request)
+		journalName, _ := request.GetArguments()["journal_name"].(string)
 		if journalName == "" {
 			journalName = DefaultJournalName
 		}
-		tagsStr, _ := request.Params.Arguments["tags"].(string)
+		if denied := authorize(ctx, journalName, false); denied != nil {
+			return denied, nil
+		}
+		tagsStr, _ := request.GetArguments()["tags"].(string)
 		tagsFilter := parseTags(tagsStr)
 
 		var journals []memories.Journal
 		if journalName != "" {
-			j, err := getJournalByName(ctx, db, journalName)
+			j, err := getJournalByName(ctx, store, journalName)
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal: %v", err)), nil
 			}
@@ -262,62 +321,78 @@ func RegisterListEntriesTool(s *server.MCPServer, db *sql.DB) {
 			}
 			journals = append(journals, *j)
 		} else {
-			list, err := memories.ListJournals(ctx, db, false)
+			list, err := store.ListJournals(ctx, false)
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Error listing journals: %v", err)), nil
 			}
-			journals = list
+			journals = accessibleJournals(ctx, list)
 		}
 
-		var results []entryWithTags
-		for _, j := range journals {
-			es, err := memories.ListEntries(ctx, db, j.ID, false)
-			if err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("Error listing entries: %v", err)), nil
-			}
-			for _, e := range es {
-				if len(tagsFilter) == 0 {
-					en, _ := enrichEntry(ctx, db, e)
-					results = append(results, en)
-					continue
-				}
-				entryTags, err := memories.ListTagsForEntry(ctx, db, e.ID)
-				if err != nil {
-					return mcp.NewToolResultError(fmt.Sprintf("Error fetching tags: %v", err)), nil
-				}
-				if hasAllTags(entryTags, tagsFilter) {
-					en, _ := enrichEntry(ctx, db, e)
-					results = append(results, en)
-				}
-			}
+		journalIDs := make([]uuid.UUID, len(journals))
+		for i, j := range journals {
+			journalIDs[i] = j.ID
 		}
-		if len(results) == 0 {
-			return mcp.NewToolResultText("[]"), nil
+		if len(journalIDs) == 0 {
+			return pagedResult("entries", nil, "", paged), nil
 		}
-		b, _ := json.Marshal(results)
-		return mcp.NewToolResultText(string(b)), nil
+		results, next, err := store.FindEntries(ctx, memories.EntryFilter{JournalIDs: journalIDs, Tags: tagsFilter}, page)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error listing entries: %v", err)), nil
+		}
+		return pagedResult("entries", results, next, paged), nil
 	})
 }
 
-// Helper: check if entryTags include all desired tags.
-func hasAllTags(entryTags []memories.Tag, desired []string) bool {
-	if len(desired) == 0 {
-		return true
+const (
+	limitDescription  = "Optional maximum number of results. When 'limit' or 'cursor' is given, the result is an object holding a page of results and, if there are more, a 'next_cursor'."
+	cursorDescription = "Optional 'next_cursor' of the previous page, to fetch the page after it."
+	ttlDescription    = "Optional time until the entry expires, e.g. '14d', '2w' or '12h'. Expired entries are hidden and later deleted or purged, by the journal's expiry policy."
+
+	tagQueryDescription = "Optional boolean tag query, e.g. '(bug OR incident) AND NOT wontfix'. Adjacent terms must all match; OR, NOT (or a leading '-') and parentheses combine them, and 'proj-*' matches a tag prefix. A tag also matches the tags below it in a '/'-separated path, so 'project' matches 'project/recall'. The fields journal:<name>, type:<content type> and updated:>YYYY-MM-DD (also >=, <, <=) match the entry instead of a tag."
+)
+
+// pageArguments reads the limit and cursor arguments of a tool call. paged is false when
+// neither is given.
+func pageArguments(request mcp.CallToolRequest) (page memories.Page, paged bool) {
+	limit, hasLimit := request.GetArguments()["limit"].(float64)
+	cursor, _ := request.GetArguments()["cursor"].(string)
+	if limit > 0 {
+		page.Limit = int(limit)
 	}
-	tagSet := make(map[string]struct{}, len(entryTags))
-	for _, t := range entryTags {
-		tagSet[t.Tag] = struct{}{}
+	page.Cursor = cursor
+	return page, hasLimit || cursor != ""
+}
+
+// pagedResult returns items as a JSON array, or, for a paged call, as an object holding
+// them under key together with the cursor of the next page.
+func pagedResult(key string, items any, next string, paged bool) *mcp.CallToolResult {
+	b, _ := json.Marshal(items)
+	if string(b) == "null" {
+		b = []byte("[]")
 	}
-	for _, d := range desired {
-		if _, ok := tagSet[d]; !ok {
-			return false
-		}
+	if !paged {
+		return mcp.NewToolResultText(string(b))
+	}
+	b, _ = json.Marshal(map[string]any{key: json.RawMessage(b), "next_cursor": next})
+	return mcp.NewToolResultText(string(b))
+}
+
+// ttlArgument reads the ttl argument of a tool call as the time the entry expires, or 0
+// for 'never'. set is false when no ttl is given.
+func ttlArgument(request mcp.CallToolRequest) (expiresAt float64, set bool, errResult *mcp.CallToolResult) {
+	ttl, _ := request.GetArguments()["ttl"].(string)
+	if ttl == "" {
+		return 0, false, nil
+	}
+	expiresAt, err := memories.ParseTTL(ttl, time.Now())
+	if err != nil {
+		return 0, false, mcp.NewToolResultError(fmt.Sprintf("Invalid 'ttl': %v", err))
 	}
-	return true
+	return expiresAt, true, nil
 }
 
 // RegisterGetEntryTool fetches entry by title.
-func RegisterGetEntryTool(s *server.MCPServer, db *sql.DB) {
+func RegisterGetEntryTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"get_entry",
 		mcp.WithDescription("Retrieves entry details (including content and tags) by title."),
@@ -325,36 +400,40 @@ func RegisterGetEntryTool(s *server.MCPServer, db *sql.DB) {
 		mcp.WithString("entry_title", mcp.Required(), mcp.Description("Title of the entry.")),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		journalName, _ := request.Params.Arguments["journal_name"].(string)
+		journalName, _ := request.GetArguments()["journal_name"].(string)
 		if journalName == "" {
 			journalName = DefaultJournalName
 		}
-		title, _ := request.Params.Arguments["entry_title"].(string)
+		if denied := authorize(ctx, journalName, false); denied != nil {
+			return denied, nil
+		}
+		title, _ := request.GetArguments()["entry_title"].(string)
 		if strings.TrimSpace(title) == "" {
 			return mcp.NewToolResultError("'entry_title' parameter is required"), nil
 		}
-		journal, err := getJournalByName(ctx, db, journalName)
+		journal, err := getJournalByName(ctx, store, journalName)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal: %v", err)), nil
 		}
 		if journal == nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Journal '%s' not found", journalName)), nil
 		}
-		entry, err := getEntryByTitleAndJournalID(ctx, db, title, journal.ID)
+		entry, err := getEntryByTitleAndJournalID(ctx, store, title, journal.ID)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving entry: %v", err)), nil
 		}
 		if entry == nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Entry '%s' not found", title)), nil
 		}
-		enriched, _ := enrichEntry(ctx, db, *entry)
+		recordAccess(store, entry.ID)
+		enriched, _ := enrichEntry(ctx, store, *entry)
 		b, _ := json.Marshal(enriched)
 		return mcp.NewToolResultText(string(b)), nil
 	})
 }
 
 // RegisterUpdateEntryTool updates an entry.
-func RegisterUpdateEntryTool(s *server.MCPServer, db *sql.DB) {
+func RegisterUpdateEntryTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"update_entry",
 		mcp.WithDescription("Updates an existing entry."),
@@ -363,43 +442,109 @@ func RegisterUpdateEntryTool(s *server.MCPServer, db *sql.DB) {
 		mcp.WithString("new_title", mcp.Description("Optional new title.")),
 		mcp.WithString("new_content", mcp.Description("Optional new content.")),
 		mcp.WithString("new_content_type", mcp.Description("Optional new content type.")),
+		mcp.WithString("ttl", mcp.Description(ttlDescription+" 'never' removes the expiry.")),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		journalName, _ := request.Params.Arguments["journal_name"].(string)
+		journalName, _ := request.GetArguments()["journal_name"].(string)
 		if journalName == "" {
 			journalName = DefaultJournalName
 		}
-		title, _ := request.Params.Arguments["entry_title"].(string)
-		journal, err := getJournalByName(ctx, db, journalName)
+		if denied := authorize(ctx, journalName, true); denied != nil {
+			return denied, nil
+		}
+		title, _ := request.GetArguments()["entry_title"].(string)
+		journal, err := getJournalByName(ctx, store, journalName)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal: %v", err)), nil
 		}
 		if journal == nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Journal '%s' not found", journalName)), nil
 		}
-		entry, err := getEntryByTitleAndJournalID(ctx, db, title, journal.ID)
+		entry, err := getEntryByTitleAndJournalID(ctx, store, title, journal.ID)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving entry: %v", err)), nil
 		}
 		if entry == nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Entry '%s' not found", title)), nil
 		}
-		newTitle, _ := request.Params.Arguments["new_title"].(string)
-		newContent, _ := request.Params.Arguments["new_content"].(string)
-		newContentType, _ := request.Params.Arguments["new_content_type"].(string)
+		newTitle, _ := request.GetArguments()["new_title"].(string)
+		newContent, _ := request.GetArguments()["new_content"].(string)
+		newContentType, _ := request.GetArguments()["new_content_type"].(string)
+		expiresAt, setExpiry, errResult := ttlArgument(request)
+		if errResult != nil {
+			return errResult, nil
+		}
 
-		updated, err := memories.UpdateEntry(ctx, db, entry.ID, newTitle, newContent, newContentType)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to update entry: %v", err)), nil
+		updated := *entry
+		if newTitle != "" || newContent != "" || newContentType != "" || !setExpiry {
+			updated, err = store.UpdateEntry(memories.WithActor(ctx, "mcp"), entry.ID, newTitle, newContent, newContentType)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to update entry: %v", err)), nil
+			}
 		}
-		enriched, _ := enrichEntry(ctx, db, updated)
+		if setExpiry {
+			if updated, err = store.SetEntryExpiry(ctx, entry.ID, expiresAt); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to set the entry's expiry: %v", err)), nil
+			}
+		}
+		enriched, _ := enrichEntry(ctx, store, updated)
 		b, _ := json.Marshal(enriched)
 		return mcp.NewToolResultText(string(b)), nil
 	})
 }
 
+// RegisterGetEntryHistoryTool returns an entry together with its prior revisions.
+func RegisterGetEntryHistoryTool(s *server.MCPServer, store memories.Store) {
+	tool := mcp.NewTool(
+		"get_entry_history",
+		mcp.WithDescription("Retrieves the revision history of an entry: every prior title, content and content type it had, newest first."),
+		mcp.WithString("journal_name", mcp.DefaultString(DefaultJournalName), mcp.Description("Optional journal.")),
+		mcp.WithString("entry_title", mcp.Required(), mcp.Description("Current title of the entry.")),
+	)
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		journalName, _ := request.GetArguments()["journal_name"].(string)
+		if journalName == "" {
+			journalName = DefaultJournalName
+		}
+		if denied := authorize(ctx, journalName, false); denied != nil {
+			return denied, nil
+		}
+		title, _ := request.GetArguments()["entry_title"].(string)
+		if strings.TrimSpace(title) == "" {
+			return mcp.NewToolResultError("'entry_title' parameter is required"), nil
+		}
+		journal, err := getJournalByName(ctx, store, journalName)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal: %v", err)), nil
+		}
+		if journal == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Journal '%s' not found", journalName)), nil
+		}
+		entry, err := getEntryByTitleAndJournalID(ctx, store, title, journal.ID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving entry: %v", err)), nil
+		}
+		if entry == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Entry '%s' not found", title)), nil
+		}
+		revisions, err := store.ListEntryRevisions(ctx, entry.ID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error listing revisions: %v", err)), nil
+		}
+		if revisions == nil {
+			revisions = []memories.EntryRevision{}
+		}
+		enriched, _ := enrichEntry(ctx, store, *entry)
+		b, _ := json.Marshal(struct {
+			Entry     entryWithTags            `json:"entry"`
+			Revisions []memories.EntryRevision `json:"revisions"`
+		}{enriched, revisions})
+		return mcp.NewToolResultText(string(b)), nil
+	})
+}
+
 // RegisterDeleteEntryTool deletes an entry by title.
-func RegisterDeleteEntryTool(s *server.MCPServer, db *sql.DB) {
+func RegisterDeleteEntryTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"delete_entry",
 		mcp.WithDescription("Deletes an entry by title inside a journal."),
@@ -407,61 +552,262 @@ func RegisterDeleteEntryTool(s *server.MCPServer, db *sql.DB) {
 		mcp.WithString("entry_title", mcp.Required(), mcp.Description("Title of the entry to delete.")),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		journalName, _ := request.Params.Arguments["journal_name"].(string)
+		journalName, _ := request.GetArguments()["journal_name"].(string)
 		if journalName == "" {
 			journalName = DefaultJournalName
 		}
-		title, _ := request.Params.Arguments["entry_title"].(string)
-		journal, err := getJournalByName(ctx, db, journalName)
+		if denied := authorize(ctx, journalName, true); denied != nil {
+			return denied, nil
+		}
+		title, _ := request.GetArguments()["entry_title"].(string)
+		journal, err := getJournalByName(ctx, store, journalName)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal: %v", err)), nil
 		}
 		if journal == nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Journal '%s' not found", journalName)), nil
 		}
-		entry, err := getEntryByTitleAndJournalID(ctx, db, title, journal.ID)
+		entry, err := getEntryByTitleAndJournalID(ctx, store, title, journal.ID)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving entry: %v", err)), nil
 		}
 		if entry == nil {
 			return mcp.NewToolResultText(fmt.Sprintf("Entry '%s' not found, nothing to delete.", title)), nil
 		}
-		if err := memories.DeleteEntry(ctx, db, entry.ID); err != nil {
+		if err := store.DeleteEntry(ctx, entry.ID); err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to delete entry: %v", err)), nil
 		}
 		return mcp.NewToolResultText(fmt.Sprintf("Entry '%s' deleted successfully.", title)), nil
 	})
 }
 
+// RegisterRestoreEntryTool restores a deleted or expired entry by title.
+func RegisterRestoreEntryTool(s *server.MCPServer, store memories.Store) {
+	tool := mcp.NewTool(
+		"restore_entry",
+		mcp.WithDescription("Restores a deleted or expired entry by title inside a journal. If several deleted entries share the title, the most recently deleted one is restored."),
+		mcp.WithString("journal_name", mcp.DefaultString(DefaultJournalName), mcp.Description("Optional journal.")),
+		mcp.WithString("entry_title", mcp.Required(), mcp.Description("Title of the entry to restore.")),
+	)
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		journalName, _ := request.GetArguments()["journal_name"].(string)
+		if journalName == "" {
+			journalName = DefaultJournalName
+		}
+		if denied := authorize(ctx, journalName, true); denied != nil {
+			return denied, nil
+		}
+		title, _ := request.GetArguments()["entry_title"].(string)
+		if strings.TrimSpace(title) == "" {
+			return mcp.NewToolResultError("'entry_title' parameter is required"), nil
+		}
+		journal, err := getJournalByName(ctx, store, journalName)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal: %v", err)), nil
+		}
+		if journal == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Journal '%s' not found", journalName)), nil
+		}
+		entry, err := getTrashedEntryByTitleAndJournalID(ctx, store, title, journal.ID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving entry: %v", err)), nil
+		}
+		if entry == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("No deleted entry '%s' found", title)), nil
+		}
+		restored, err := store.RestoreEntry(ctx, entry.ID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to restore entry: %v", err)), nil
+		}
+		enriched, _ := enrichEntry(ctx, store, restored)
+		b, _ := json.Marshal(enriched)
+		return mcp.NewToolResultText(string(b)), nil
+	})
+}
+
+// linkRelationNames are the relations of entry links, for tool argument enums.
+func linkRelationNames() []string {
+	names := make([]string, len(memories.LinkRelations))
+	for i, relation := range memories.LinkRelations {
+		names[i] = string(relation)
+	}
+	return names
+}
+
+// RegisterLinkEntriesTool links an entry to another by title.
+func RegisterLinkEntriesTool(s *server.MCPServer, store memories.Store) {
+	tool := mcp.NewTool(
+		"link_entries",
+		mcp.WithDescription("Links an entry to another one, read as 'source relation target': 'relates_to' (see also), 'supersedes' (the source replaces the target) or 'depends_on' (the source builds on the target). Linking entries that are already linked with the relation does nothing. Follow links with get_related_entries."),
+		mcp.WithString("journal_name", mcp.DefaultString(DefaultJournalName), mcp.Description("Optional journal of the source entry.")),
+		mcp.WithString("source_title", mcp.Required(), mcp.Description("Title of the entry to link from.")),
+		mcp.WithString("target_title", mcp.Required(), mcp.Description("Title of the entry to link to.")),
+		mcp.WithString("target_journal_name", mcp.Description("Optional journal of the target entry; the journal of the source entry by default.")),
+		mcp.WithString("relation", mcp.DefaultString(string(memories.LinkRelatesTo)), mcp.Enum(linkRelationNames()...), mcp.Description("Relation of the link.")),
+	)
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		journalName, _ := request.GetArguments()["journal_name"].(string)
+		if journalName == "" {
+			journalName = DefaultJournalName
+		}
+		targetJournalName, _ := request.GetArguments()["target_journal_name"].(string)
+		if targetJournalName == "" {
+			targetJournalName = journalName
+		}
+		if denied := authorize(ctx, journalName, true); denied != nil {
+			return denied, nil
+		}
+		if denied := authorize(ctx, targetJournalName, false); denied != nil {
+			return denied, nil
+		}
+		relationName, _ := request.GetArguments()["relation"].(string)
+		if relationName == "" {
+			relationName = string(memories.LinkRelatesTo)
+		}
+		relation, err := memories.ParseLinkRelation(relationName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var ends [2]*memories.Entry
+		for i, side := range []struct{ journal, titleArgument string }{
+			{journalName, "source_title"},
+			{targetJournalName, "target_title"},
+		} {
+			title, _ := request.GetArguments()[side.titleArgument].(string)
+			if strings.TrimSpace(title) == "" {
+				return mcp.NewToolResultError(fmt.Sprintf("'%s' parameter is required", side.titleArgument)), nil
+			}
+			journal, err := getJournalByName(ctx, store, side.journal)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal: %v", err)), nil
+			}
+			if journal == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Journal '%s' not found", side.journal)), nil
+			}
+			entry, err := getEntryByTitleAndJournalID(ctx, store, title, journal.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error retrieving entry: %v", err)), nil
+			}
+			if entry == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Entry '%s' not found in journal '%s'", title, side.journal)), nil
+			}
+			ends[i] = entry
+		}
+
+		link, err := store.LinkEntries(ctx, ends[0].ID, ends[1].ID, relation)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to link entries: %v", err)), nil
+		}
+		b, _ := json.Marshal(link)
+		return mcp.NewToolResultText(string(b)), nil
+	})
+}
+
+// RegisterGetRelatedEntriesTool follows the links of an entry.
+func RegisterGetRelatedEntriesTool(s *server.MCPServer, store memories.Store) {
+	tool := mcp.NewTool(
+		"get_related_entries",
+		mcp.WithDescription("Follows the links of an entry in both directions and returns the entries reached, each with its 'depth' in links from the entry (0 for the entry itself), and the 'links' between them. Use it to gather a chain of context, such as what an entry depends on or which entry superseded it."),
+		mcp.WithString("journal_name", mcp.DefaultString(DefaultJournalName), mcp.Description("Optional journal.")),
+		mcp.WithString("entry_title", mcp.Required(), mcp.Description("Title of the entry to start from.")),
+		mcp.WithNumber("depth", mcp.DefaultNumber(memories.DefaultLinkDepth), mcp.Description("Number of links to follow.")),
+		mcp.WithString("relations", mcp.Description("Optional comma-separated relations to follow: relates_to, supersedes, depends_on. All are followed by default.")),
+	)
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		journalName, _ := request.GetArguments()["journal_name"].(string)
+		if journalName == "" {
+			journalName = DefaultJournalName
+		}
+		if denied := authorize(ctx, journalName, false); denied != nil {
+			return denied, nil
+		}
+		title, _ := request.GetArguments()["entry_title"].(string)
+		if strings.TrimSpace(title) == "" {
+			return mcp.NewToolResultError("'entry_title' parameter is required"), nil
+		}
+		query := memories.GraphQuery{Depth: memories.DefaultLinkDepth}
+		if depth, ok := request.GetArguments()["depth"].(float64); ok {
+			query.Depth = int(depth)
+		}
+		if query.Depth <= 0 {
+			return mcp.NewToolResultError("'depth' must be positive"), nil
+		}
+		relationsStr, _ := request.GetArguments()["relations"].(string)
+		for _, name := range parseTags(relationsStr) {
+			relation, err := memories.ParseLinkRelation(name)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			query.Relations = append(query.Relations, relation)
+		}
+
+		journals, err := store.ListJournals(ctx, false)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journals: %v", err)), nil
+		}
+		var journal *memories.Journal
+		for _, j := range accessibleJournals(ctx, journals) {
+			query.JournalIDs = append(query.JournalIDs, j.ID)
+			if j.Name == journalName {
+				journal = &j
+			}
+		}
+		if journal == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Journal '%s' not found", journalName)), nil
+		}
+		entry, err := getEntryByTitleAndJournalID(ctx, store, title, journal.ID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving entry: %v", err)), nil
+		}
+		if entry == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Entry '%s' not found", title)), nil
+		}
+
+		graph, err := memories.RelatedEntries(ctx, store, entry.ID, query)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to follow links: %v", err)), nil
+		}
+		for _, related := range graph.Entries {
+			recordAccess(store, related.ID)
+		}
+		b, _ := json.Marshal(graph)
+		return mcp.NewToolResultText(string(b)), nil
+	})
+}
+
 // RegisterManageEntryTagsTool adds/removes tags for an entry.
-func RegisterManageEntryTagsTool(s *server.MCPServer, db *sql.DB) {
+func RegisterManageEntryTagsTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"manage_entry_tags",
 		mcp.WithDescription("Adds or removes tags for a specific entry."),
 		mcp.WithString("journal_name", mcp.DefaultString(DefaultJournalName), mcp.Description("Optional journal.")),
 		mcp.WithString("entry_title", mcp.Required(), mcp.Description("Title of the entry.")),
-		mcp.WithString("add_tags", mcp.Description("Comma-separated tags to add.")),
+		mcp.WithString("add_tags", mcp.Description("Comma-separated tags to add, stored in lower case with aliases replaced by their tags.")),
 		mcp.WithString("remove_tags", mcp.Description("Comma-separated tags to remove.")),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		journalName, _ := request.Params.Arguments["journal_name"].(string)
+		journalName, _ := request.GetArguments()["journal_name"].(string)
 		if journalName == "" {
 			journalName = DefaultJournalName
 		}
-		title, _ := request.Params.Arguments["entry_title"].(string)
-		addStr, _ := request.Params.Arguments["add_tags"].(string)
-		removeStr, _ := request.Params.Arguments["remove_tags"].(string)
+		if denied := authorize(ctx, journalName, true); denied != nil {
+			return denied, nil
+		}
+		title, _ := request.GetArguments()["entry_title"].(string)
+		addStr, _ := request.GetArguments()["add_tags"].(string)
+		removeStr, _ := request.GetArguments()["remove_tags"].(string)
 		if addStr == "" && removeStr == "" {
 			return mcp.NewToolResultError("At least one of 'add_tags' or 'remove_tags' must be provided."), nil
 		}
-		journal, err := getJournalByName(ctx, db, journalName)
+		journal, err := getJournalByName(ctx, store, journalName)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal: %v", err)), nil
 		}
 		if journal == nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Journal '%s' not found", journalName)), nil
 		}
-		entry, err := getEntryByTitleAndJournalID(ctx, db, title, journal.ID)
+		entry, err := getEntryByTitleAndJournalID(ctx, store, title, journal.ID)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error retrieving entry: %v", err)), nil
 		}
@@ -469,38 +815,41 @@ func RegisterManageEntryTagsTool(s *server.MCPServer, db *sql.DB) {
 			return mcp.NewToolResultError(fmt.Sprintf("Entry '%s' not found", title)), nil
 		}
 		for _, t := range parseTags(addStr) {
-			_ = memories.TagEntry(ctx, db, entry.ID, t)
+			_ = store.TagEntry(ctx, entry.ID, t)
 		}
 		for _, t := range parseTags(removeStr) {
-			_ = memories.DetachTag(ctx, db, entry.ID, t)
+			_ = store.DetachTag(ctx, entry.ID, t)
 		}
-		updatedEntry, _ := memories.GetEntry(ctx, db, entry.ID)
-		enriched, _ := enrichEntry(ctx, db, updatedEntry)
+		updatedEntry, _ := store.GetEntry(ctx, entry.ID)
+		enriched, _ := enrichEntry(ctx, store, updatedEntry)
 		b, _ := json.Marshal(enriched)
 		return mcp.NewToolResultText(string(b)), nil
 	})
 }
 
 // RegisterListTagsTool lists all distinct tags across the database.
-func RegisterListTagsTool(s *server.MCPServer, db *sql.DB) {
+func RegisterListTagsTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"list_tags",
-		mcp.WithDescription("Lists all unique tags currently stored in the database."),
+		mcp.WithDescription("Lists all unique tags currently stored in the database. Tags can be '/'-separated paths such as 'project/recall/mcp', and searching for a tag also matches the tags below it. With format 'tree', tags are returned as a tree of path segments, each with its full 'path', the 'count' of entries carrying it or a tag below it, the 'direct' count of entries carrying exactly it, and its 'children'."),
+		mcp.WithString("format", mcp.DefaultString("list"), mcp.Enum("list", "tree"), mcp.Description("'list' for a flat list of tags, 'tree' for a tree of tag paths with entry counts.")),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		rows, err := db.QueryContext(ctx, "SELECT tag, created_at, updated_at FROM tags ORDER BY tag")
+		format, _ := request.GetArguments()["format"].(string)
+		switch format {
+		case "", "list":
+		case "tree":
+			return listTagTree(ctx, store)
+		default:
+			return mcp.NewToolResultError("'format' must be 'list' or 'tree'"), nil
+		}
+		if _, ok := auth.ScopeFromContext(ctx); ok {
+			return listScopedTags(ctx, store)
+		}
+		tags, err := store.ListAllTags(ctx)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to list tags: %v", err)), nil
 		}
-		defer rows.Close()
-		var tags []memories.Tag
-		for rows.Next() {
-			var t memories.Tag
-			if err := rows.Scan(&t.Tag, &t.CreatedAt, &t.UpdatedAt); err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to scan tag: %v", err)), nil
-			}
-			tags = append(tags, t)
-		}
 		if len(tags) == 0 {
 			return mcp.NewToolResultText("[]"), nil
 		}
@@ -509,48 +858,511 @@ func RegisterListTagsTool(s *server.MCPServer, db *sql.DB) {
 	})
 }
 
-// RegisterSearchEntriesTool searches entries by tags across all journals.
-func RegisterSearchEntriesTool(s *server.MCPServer, db *sql.DB) {
+// listScopedTags serves list_tags calls limited to some journals, listing the tags used
+// by their entries.
+func listScopedTags(ctx context.Context, store memories.Store) (*mcp.CallToolResult, error) {
+	journals, err := store.ListJournals(ctx, false)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list tags: %v", err)), nil
+	}
+	seen := make(map[string]bool)
+	var tags []memories.Tag
+	for _, j := range accessibleJournals(ctx, journals) {
+		journalTags, err := store.ListTags(ctx, j.ID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list tags: %v", err)), nil
+		}
+		for _, t := range journalTags {
+			if !seen[t.Tag] {
+				seen[t.Tag] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	if len(tags) == 0 {
+		return mcp.NewToolResultText("[]"), nil
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+	b, _ := json.Marshal(tags)
+	return mcp.NewToolResultText(string(b)), nil
+}
+
+// listTagTree serves list_tags calls for a tree, counting the entries of the journals the
+// caller can access.
+func listTagTree(ctx context.Context, store memories.Store) (*mcp.CallToolResult, error) {
+	var journalIDs []uuid.UUID
+	if _, ok := auth.ScopeFromContext(ctx); ok {
+		journals, err := store.ListJournals(ctx, false)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list tags: %v", err)), nil
+		}
+		for _, j := range accessibleJournals(ctx, journals) {
+			journalIDs = append(journalIDs, j.ID)
+		}
+		if len(journalIDs) == 0 {
+			return mcp.NewToolResultText("[]"), nil
+		}
+	}
+	tree, err := memories.TagTree(ctx, store, journalIDs)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list tags: %v", err)), nil
+	}
+	b, _ := json.Marshal(tree)
+	return mcp.NewToolResultText(string(b)), nil
+}
+
+// searchMatch is an entryWithTags returned by a full-text search_entries call.
+type searchMatch struct {
+	entryWithTags
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
+}
+
+// RegisterSearchEntriesTool searches entries by tags or free text across all journals.
+func RegisterSearchEntriesTool(s *server.MCPServer, store memories.Store) {
 	tool := mcp.NewTool(
 		"search_entries",
-		mcp.WithDescription("Searches for entries matching all specified tags across all journals."),
-		mcp.WithString("tags", mcp.Required(), mcp.Description("Comma-separated list of tags.")),
+		mcp.WithDescription("Searches for entries across all journals. With 'query', entry titles and content are searched for the given words and results are ranked by relevance; otherwise entries matching 'tags' and 'tag_query' are returned, most recently updated first. With rank 'hybrid', entries carrying any of 'tags' or matching 'query' are ranked by matching tags, text relevance, recency and usage combined, and each result carries its 'score' and a 'breakdown' of what every signal contributed."),
+		mcp.WithString("query", mcp.Description("Optional free-text query matched against entry titles and content.")),
+		mcp.WithString("tags", mcp.Description("Comma-separated list of tags, each also matching the tags below it in a '/'-separated path. Required unless 'query' or 'tag_query' is given; with 'query' it further filters the results.")),
+		mcp.WithString("match", mcp.DefaultString("all"), mcp.Enum("all", "any"), mcp.Description("Whether entries must carry all of 'tags' or any of them.")),
+		mcp.WithString("tag_query", mcp.Description(tagQueryDescription)),
+		mcp.WithString("rank", mcp.DefaultString("relevance"), mcp.Enum("relevance", "hybrid"), mcp.Description("'hybrid' ranks by tags, text, recency and usage combined; 'tags' then rank rather than filter, and 'match' is ignored.")),
+		mcp.WithObject("weights", mcp.Description("Optional weights for rank 'hybrid': numbers 'tag_match' (default 1), 'text' (1), 'recency' (0.25), 'usage' (0.25) and 'recency_half_life_days' (30), and 'fusion', either 'weighted' (default) or 'rrf' for reciprocal rank fusion.")),
+		mcp.WithNumber("limit", mcp.Description(limitDescription)),
+		mcp.WithString("cursor", mcp.Description(cursorDescription)),
 	)
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		tagsStr, _ := request.Params.Arguments["tags"].(string)
+		page, paged := pageArguments(request)
+		query, _ := request.GetArguments()["query"].(string)
+		tagsStr, _ := request.GetArguments()["tags"].(string)
 		tagsFilter := parseTags(tagsStr)
-		if len(tagsFilter) == 0 {
-			return mcp.NewToolResultError("'tags' parameter is required and must be non-empty"), nil
+		match, _ := request.GetArguments()["match"].(string)
+		if match != "" && match != "all" && match != "any" {
+			return mcp.NewToolResultError("'match' must be 'all' or 'any'"), nil
+		}
+		filter := memories.EntryFilter{Tags: tagsFilter, MatchAnyTag: match == "any"}
+		if tagQuery, _ := request.GetArguments()["tag_query"].(string); strings.TrimSpace(tagQuery) != "" {
+			parsed, err := memories.ParseTagQuery(tagQuery)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error parsing 'tag_query': %v", err)), nil
+			}
+			filter.Query = parsed
+		}
+		if rank, _ := request.GetArguments()["rank"].(string); rank == "hybrid" {
+			if len(tagsFilter) == 0 && strings.TrimSpace(query) == "" {
+				return mcp.NewToolResultError("rank 'hybrid' requires 'tags' or 'query'"), nil
+			}
+			weights, err := rankWeightsArgument(request)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error parsing 'weights': %v", err)), nil
+			}
+			return searchEntriesHybrid(ctx, store, tagsFilter, query, weights, filter.Query, page, paged)
+		} else if rank != "" && rank != "relevance" {
+			return mcp.NewToolResultError("'rank' must be 'relevance' or 'hybrid'"), nil
+		}
+		if strings.TrimSpace(query) != "" {
+			return searchEntriesFullText(ctx, store, query, filter, page, paged)
+		}
+		if len(tagsFilter) == 0 && filter.Query == nil {
+			return mcp.NewToolResultError("one of 'query', 'tags' or 'tag_query' must be provided and non-empty"), nil
+		}
+		if _, scoped := auth.ScopeFromContext(ctx); scoped {
+			journals, err := store.ListJournals(ctx, false)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error listing journals: %v", err)), nil
+			}
+			for _, j := range accessibleJournals(ctx, journals) {
+				filter.JournalIDs = append(filter.JournalIDs, j.ID)
+			}
+			if len(filter.JournalIDs) == 0 {
+				return pagedResult("entries", nil, "", paged), nil
+			}
+		}
+		matched, next, err := store.FindEntries(ctx, filter, page)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error searching entries: %v", err)), nil
 		}
-		journals, err := memories.ListJournals(ctx, db, false)
+		for _, m := range matched {
+			recordAccess(store, m.ID)
+		}
+		return pagedResult("entries", matched, next, paged), nil
+	})
+}
+
+// searchEntriesFullText serves search_entries calls that carry a free-text query, keeping
+// the results selected by the tag conditions of filter. Results are ranked rather than
+// ordered by time, so their cursor holds the offset of the next result.
+func searchEntriesFullText(ctx context.Context, store memories.Store, query string, filter memories.EntryFilter, page memories.Page, paged bool) (*mcp.CallToolResult, error) {
+	offset, err := parseOffsetCursor(page.Cursor)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error searching entries: %v", err)), nil
+	}
+	// Tag and scope filtering happen after ranking, so the limit is only applied in SQL
+	// when there is no filter. One more result than needed tells whether there is a next page.
+	_, scoped := auth.ScopeFromContext(ctx)
+	sqlLimit := 0
+	if page.Limit > 0 && len(filter.Tags) == 0 && filter.Query == nil && !scoped {
+		sqlLimit = offset + page.Limit + 1
+	}
+	var allowed map[uuid.UUID]bool
+	if scoped {
+		journals, err := store.ListJournals(ctx, false)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error listing journals: %v", err)), nil
 		}
-		var matched []entryWithTags
-		for _, j := range journals {
-			entries, err := memories.ListEntries(ctx, db, j.ID, false)
+		allowed = make(map[uuid.UUID]bool)
+		for _, j := range accessibleJournals(ctx, journals) {
+			allowed[j.ID] = true
+		}
+	}
+	results, err := store.SearchEntriesFullText(ctx, uuid.Nil, query, sqlLimit)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error searching entries: %v", err)), nil
+	}
+	var candidates []uuid.UUID
+	for _, r := range results {
+		if !scoped || allowed[r.Entry.JournalID] {
+			candidates = append(candidates, r.Entry.ID)
+		}
+	}
+	if len(candidates) == 0 {
+		return pagedResult("entries", nil, "", paged), nil
+	}
+	// Fetch the candidates' tags, and apply the tag filters, in one query.
+	filter.EntryIDs = candidates
+	filter.IncludeDeleted = true
+	tagged, _, err := store.FindEntries(ctx, filter, memories.Page{})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error fetching tags: %v", err)), nil
+	}
+	tagsByID := make(map[uuid.UUID][]string, len(tagged))
+	for _, te := range tagged {
+		tagsByID[te.ID] = te.Tags
+	}
+	var matched []searchMatch
+	for _, r := range results {
+		tags, ok := tagsByID[r.Entry.ID]
+		if !ok {
+			continue
+		}
+		en := entryWithTags{Entry: r.Entry, Tags: tags}
+		matched = append(matched, searchMatch{entryWithTags: en, Score: r.Score, Snippet: r.Snippet})
+	}
+	matched = matched[min(offset, len(matched)):]
+	next := ""
+	if page.Limit > 0 && len(matched) > page.Limit {
+		matched = matched[:page.Limit]
+		next = offsetCursor(offset + page.Limit)
+	}
+	for _, m := range matched {
+		recordAccess(store, m.ID)
+	}
+	return pagedResult("entries", matched, next, paged), nil
+}
+
+// searchEntriesHybrid serves search_entries calls with rank 'hybrid'. Like full-text
+// results, their cursor holds the offset of the next result.
+func searchEntriesHybrid(ctx context.Context, store memories.Store, tags []string, query string, weights memories.RankWeights, tagQuery *memories.TagQuery, page memories.Page, paged bool) (*mcp.CallToolResult, error) {
+	offset, err := parseOffsetCursor(page.Cursor)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error searching entries: %v", err)), nil
+	}
+	ranked, err := rankEntriesHybrid(ctx, store, uuid.Nil, tags, query, weights, tagQuery)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error searching entries: %v", err)), nil
+	}
+	ranked = ranked[min(offset, len(ranked)):]
+	next := ""
+	if page.Limit > 0 && len(ranked) > page.Limit {
+		ranked = ranked[:page.Limit]
+		next = offsetCursor(offset + page.Limit)
+	}
+	for _, r := range ranked {
+		recordAccess(store, r.ID)
+	}
+	return pagedResult("entries", ranked, next, paged), nil
+}
+
+// rankEntriesHybrid ranks entries with memories.SearchEntriesHybrid, keeping those selected
+// by tagQuery, when it is not nil, in journals the caller's scope can read.
+func rankEntriesHybrid(ctx context.Context, store memories.Store, journalID uuid.UUID, tags []string, query string, weights memories.RankWeights, tagQuery *memories.TagQuery) ([]memories.RankedEntry, error) {
+	filter := memories.EntryFilter{Query: tagQuery}
+	if _, scoped := auth.ScopeFromContext(ctx); scoped {
+		journals, err := store.ListJournals(ctx, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, j := range accessibleJournals(ctx, journals) {
+			filter.JournalIDs = append(filter.JournalIDs, j.ID)
+		}
+		if len(filter.JournalIDs) == 0 {
+			return nil, nil
+		}
+	}
+
+	ranked, err := memories.SearchEntriesHybrid(ctx, store, journalID, tags, query, weights, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(ranked) == 0 || (filter.Query == nil && len(filter.JournalIDs) == 0) {
+		return ranked, nil
+	}
+	for _, r := range ranked {
+		filter.EntryIDs = append(filter.EntryIDs, r.ID)
+	}
+	selected, _, err := store.FindEntries(ctx, filter, memories.Page{})
+	if err != nil {
+		return nil, err
+	}
+	keep := make(map[uuid.UUID]bool, len(selected))
+	for _, e := range selected {
+		keep[e.ID] = true
+	}
+	var kept []memories.RankedEntry
+	for _, r := range ranked {
+		if keep[r.ID] {
+			kept = append(kept, r)
+		}
+	}
+	return kept, nil
+}
+
+// RegisterRecallContextTool packs the entries most relevant to a query into a token budget,
+// as one Markdown block to place in a prompt.
+func RegisterRecallContextTool(s *server.MCPServer, store memories.Store) {
+	tool := mcp.NewTool(
+		"recall_context",
+		mcp.WithDescription("Returns the memories most relevant to 'query' and 'tags' that fit in 'token_budget' tokens, as one Markdown block ready to use as context. Each memory is a numbered section, long ones are cut to their leading section, and a list of sources at the end cites their titles and entry IDs."),
+		mcp.WithString("query", mcp.Description("What the context is for, in natural language. Required unless 'tags' is given.")),
+		mcp.WithString("tags", mcp.Description("Optional comma-separated tags that make entries more relevant.")),
+		mcp.WithNumber("token_budget", mcp.DefaultNumber(memories.DefaultContextTokens), mcp.Description("Maximum size of the result in tokens, estimated.")),
+		mcp.WithString("journal_name", mcp.Description("Optional journal to take memories from; all journals are used by default.")),
+		mcp.WithString("tag_query", mcp.Description(tagQueryDescription)),
+	)
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query, _ := request.GetArguments()["query"].(string)
+		tagsStr, _ := request.GetArguments()["tags"].(string)
+		tags := parseTags(tagsStr)
+		if strings.TrimSpace(query) == "" && len(tags) == 0 {
+			return mcp.NewToolResultError("one of 'query' or 'tags' must be provided and non-empty"), nil
+		}
+		budget := memories.DefaultContextTokens
+		if b, ok := request.GetArguments()["token_budget"].(float64); ok {
+			budget = int(b)
+		}
+		if budget <= 0 {
+			return mcp.NewToolResultError("'token_budget' must be positive"), nil
+		}
+		var tagQuery *memories.TagQuery
+		if raw, _ := request.GetArguments()["tag_query"].(string); strings.TrimSpace(raw) != "" {
+			parsed, err := memories.ParseTagQuery(raw)
 			if err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("Error listing entries: %v", err)), nil
+				return mcp.NewToolResultError(fmt.Sprintf("Error parsing 'tag_query': %v", err)), nil
 			}
-			for _, e := range entries {
-				entryTags, err := memories.ListTagsForEntry(ctx, db, e.ID)
-				if err != nil {
-					return mcp.NewToolResultError(fmt.Sprintf("Error fetching tags: %v", err)), nil
-				}
-				if hasAllTags(entryTags, tagsFilter) {
-					en, _ := enrichEntry(ctx, db, e)
-					matched = append(matched, en)
+			tagQuery = parsed
+		}
+		journalID := uuid.Nil
+		if journalName, _ := request.GetArguments()["journal_name"].(string); journalName != "" {
+			if denied := authorize(ctx, journalName, false); denied != nil {
+				return denied, nil
+			}
+			j, err := getJournalByName(ctx, store, journalName)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal: %v", err)), nil
+			}
+			if j == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Journal '%s' not found", journalName)), nil
+			}
+			journalID = j.ID
+		}
+
+		ranked, err := rankEntriesHybrid(ctx, store, journalID, tags, query, memories.DefaultRankWeights(), tagQuery)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error ranking entries: %v", err)), nil
+		}
+		pack := memories.PackContext(ranked, budget)
+		for _, e := range pack.Entries {
+			recordAccess(store, e.ID)
+		}
+		return mcp.NewToolResultText(pack.Markdown), nil
+	})
+}
+
+// rankWeightsArgument returns the default ranking weights with those of the 'weights'
+// argument applied.
+func rankWeightsArgument(request mcp.CallToolRequest) (memories.RankWeights, error) {
+	weights := memories.DefaultRankWeights()
+	raw, ok := request.GetArguments()["weights"]
+	if !ok || raw == nil {
+		return weights, nil
+	}
+	args, ok := raw.(map[string]any)
+	if !ok {
+		return weights, errors.New("must be an object")
+	}
+	for name, value := range args {
+		if name == "fusion" {
+			fusion, ok := value.(string)
+			if !ok {
+				return weights, errors.New("'fusion' must be a string")
+			}
+			weights.Fusion = memories.RankFusion(fusion)
+			continue
+		}
+		number, ok := value.(float64)
+		if !ok {
+			return weights, fmt.Errorf("'%s' must be a number", name)
+		}
+		switch name {
+		case "tag_match":
+			weights.TagMatch = number
+		case "text":
+			weights.Text = number
+		case "recency":
+			weights.Recency = number
+		case "usage":
+			weights.Usage = number
+		case "recency_half_life_days":
+			weights.RecencyHalfLife = time.Duration(number * float64(24*time.Hour))
+		default:
+			return weights, fmt.Errorf("unknown weight '%s'", name)
+		}
+	}
+	return weights, weights.Validate()
+}
+
+// RegisterSemanticSearchTool searches entries by meaning, comparing embeddings made by
+// the store's embedder.
+func RegisterSemanticSearchTool(s *server.MCPServer, store *memories.EmbeddingStore) {
+	tool := mcp.NewTool(
+		"semantic_search",
+		mcp.WithDescription("Finds entries whose title and content are close in meaning to 'query', even when they use different words, most similar first. Results carry a 'similarity' between 0 and 1."),
+		mcp.WithString("query", mcp.Required(), mcp.Description("What to look for, in natural language.")),
+		mcp.WithString("journal_name", mcp.Description("Optional journal to search; all journals are searched by default.")),
+		mcp.WithString("tag_query", mcp.Description(tagQueryDescription)),
+		mcp.WithNumber("limit", mcp.DefaultNumber(10), mcp.Description("Maximum number of results; 0 returns every entry with some similarity.")),
+	)
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query, _ := request.GetArguments()["query"].(string)
+		if strings.TrimSpace(query) == "" {
+			return mcp.NewToolResultError("'query' must be provided and non-empty"), nil
+		}
+		limit := 10
+		if l, ok := request.GetArguments()["limit"].(float64); ok {
+			limit = int(l)
+		}
+
+		// Scope and tag query filtering happen after ranking, like for full-text searches.
+		var filter memories.EntryFilter
+		filtered := false
+		if tagQuery, _ := request.GetArguments()["tag_query"].(string); strings.TrimSpace(tagQuery) != "" {
+			parsed, err := memories.ParseTagQuery(tagQuery)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error parsing 'tag_query': %v", err)), nil
+			}
+			filter.Query = parsed
+			filtered = true
+		}
+		journalID := uuid.Nil
+		if journalName, _ := request.GetArguments()["journal_name"].(string); journalName != "" {
+			if denied := authorize(ctx, journalName, false); denied != nil {
+				return denied, nil
+			}
+			j, err := getJournalByName(ctx, store, journalName)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error retrieving journal: %v", err)), nil
+			}
+			if j == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Journal '%s' not found", journalName)), nil
+			}
+			journalID = j.ID
+		} else if _, scoped := auth.ScopeFromContext(ctx); scoped {
+			journals, err := store.ListJournals(ctx, false)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error listing journals: %v", err)), nil
+			}
+			for _, j := range accessibleJournals(ctx, journals) {
+				filter.JournalIDs = append(filter.JournalIDs, j.ID)
+			}
+			if len(filter.JournalIDs) == 0 {
+				return mcp.NewToolResultText("[]"), nil
+			}
+			filtered = true
+		}
+
+		searchLimit := limit
+		if filtered {
+			searchLimit = 0
+		}
+		matches, err := store.SearchEntriesSemantic(ctx, journalID, query, searchLimit)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error searching entries: %v", err)), nil
+		}
+		if filtered && len(matches) > 0 {
+			for _, m := range matches {
+				filter.EntryIDs = append(filter.EntryIDs, m.ID)
+			}
+			selected, _, err := store.FindEntries(ctx, filter, memories.Page{})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error filtering entries: %v", err)), nil
+			}
+			keep := make(map[uuid.UUID]bool, len(selected))
+			for _, e := range selected {
+				keep[e.ID] = true
+			}
+			kept := matches[:0]
+			for _, m := range matches {
+				if keep[m.ID] {
+					kept = append(kept, m)
 				}
 			}
+			matches = kept
+			if limit > 0 && len(matches) > limit {
+				matches = matches[:limit]
+			}
 		}
-		if len(matched) == 0 {
-			return mcp.NewToolResultText("[]"), nil
+		for _, m := range matches {
+			recordAccess(store, m.ID)
 		}
-		b, _ := json.Marshal(matched)
+		b, _ := json.Marshal(matches)
 		return mcp.NewToolResultText(string(b)), nil
 	})
 }
 
+// offsetCursor returns the cursor of the page of ranked results starting at offset.
+func offsetCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+// parseOffsetCursor returns the offset held by a cursor from offsetCursor, or 0 for an
+// empty cursor.
+func parseOffsetCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, memories.ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), "offset:"))
+	if err != nil || offset < 0 || !strings.HasPrefix(string(raw), "offset:") {
+		return 0, memories.ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+// accessibleJournals returns the journals within the scope of ctx.
+func accessibleJournals(ctx context.Context, journals []memories.Journal) []memories.Journal {
+	var out []memories.Journal
+	for _, j := range journals {
+		if auth.CanAccessJournal(ctx, j.Name) {
+			out = append(out, j)
+		}
+	}
+	return out
+}
+
 // parseTags splits a comma-separated tag list.
 func parseTags(tagsStr string) []string {
 	var result []string
@@ -0,0 +1,179 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// StreamableHTTPPath is the endpoint of the streamable HTTP transport.
+	StreamableHTTPPath = "/mcp"
+	// SSEPath and SSEMessagePath are the endpoints of the legacy HTTP+SSE transport.
+	SSEPath        = "/sse"
+	SSEMessagePath = "/message"
+
+	// sessionIDHeader carries the session of a streamable HTTP client.
+	sessionIDHeader = "Mcp-Session-Id"
+
+	// shutdownTimeout bounds how long open requests may take to finish on shutdown.
+	shutdownTimeout = 10 * time.Second
+)
+
+// StartHTTP serves MCP over the streamable HTTP transport at http://addr/mcp until the
+// process is interrupted, then stops accepting requests and waits for open ones to finish.
+// Any number of clients can share the server and its database connection. Every request
+// must carry an API token created with `recall tokens create`.
+func (s *RecallMCPServer) StartHTTP(addr string) error {
+	httpServer := server.NewStreamableHTTPServer(s.mcpServer, server.WithEndpointPath(StreamableHTTPPath))
+
+	var handler http.Handler = httpServer
+	if s.resources != nil {
+		handler = s.streamableSubscriptionHandler(httpServer)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(StreamableHTTPPath, s.requireToken(handler))
+	return s.serveHTTP(addr, mux, httpServer.Shutdown)
+}
+
+// StartSSE serves MCP over the HTTP+SSE transport of earlier protocol versions, with the
+// event stream at http://addr/sse, for clients that don't support streamable HTTP yet.
+// Like StartHTTP, it requires an API token.
+func (s *RecallMCPServer) StartSSE(addr string) error {
+	sseServer := server.NewSSEServer(s.mcpServer, server.WithKeepAlive(true))
+
+	var handler http.Handler = sseServer
+	if s.resources != nil {
+		handler = s.sseSubscriptionHandler(sseServer)
+	}
+	return s.serveHTTP(addr, s.requireToken(handler), sseServer.Shutdown)
+}
+
+// serveHTTP listens on addr and serves handler until SIGINT or SIGTERM, watching for
+// resource changes meanwhile. shutdown is called to close the transport's sessions.
+func (s *RecallMCPServer) serveHTTP(addr string, handler http.Handler, shutdown func(context.Context) error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	// Event streams stay open until the client goes away, so end them when shutting
+	// down rather than waiting for them like for other requests.
+	streamsCtx, closeStreams := context.WithCancel(context.Background())
+	defer closeStreams()
+	httpServer := &http.Server{Handler: closeStreamsOnShutdown(streamsCtx, handler)}
+	httpServer.RegisterOnShutdown(closeStreams)
+	s.watchResources(ctx)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := shutdown(shutdownCtx); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to close MCP sessions: %v\n", err)
+	}
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down HTTP server: %w", err)
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// closeStreamsOnShutdown cancels the context of GET requests, which carry the
+// event streams of both HTTP transports, once streamsCtx is done.
+func closeStreamsOnShutdown(streamsCtx context.Context, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			stop := context.AfterFunc(streamsCtx, cancel)
+			defer stop()
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// streamableSubscriptionHandler answers resources/subscribe and resources/unsubscribe
+// requests posted to the streamable HTTP endpoint and forgets the subscriptions of
+// sessions the client deletes. Everything else is served by next.
+func (s *RecallMCPServer) streamableSubscriptionHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessionID := r.Header.Get(sessionIDHeader)
+		switch r.Method {
+		case http.MethodDelete:
+			s.resources.DropSession(sessionID)
+		case http.MethodPost:
+			body, ok := readBody(w, r)
+			if !ok {
+				return
+			}
+			if response, handled := s.resources.HandleSubscriptionRequest(r.Context(), sessionID, body); handled {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(response)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sseSubscriptionHandler answers resources/subscribe and resources/unsubscribe requests
+// posted to the SSE message endpoint, sending the response over the session's event
+// stream as the SSE transport requires. Everything else is served by sseServer.
+func (s *RecallMCPServer) sseSubscriptionHandler(sseServer *server.SSEServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == SSEMessagePath {
+			body, ok := readBody(w, r)
+			if !ok {
+				return
+			}
+			sessionID := r.URL.Query().Get("sessionId")
+			if response, handled := s.resources.HandleSubscriptionRequest(r.Context(), sessionID, body); handled {
+				if err := sseServer.SendEventToSession(sessionID, response); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+		}
+		sseServer.ServeHTTP(w, r)
+	})
+}
+
+// readBody reads the request body and puts it back so that the request can still be
+// passed on. It reports false after writing an error response if the body can't be read.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return nil, false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
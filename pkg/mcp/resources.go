@@ -0,0 +1,429 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/unowned-ai/recall/pkg/auth"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+const (
+	resourceScheme = "recall://"
+
+	JournalsResourceURI          = resourceScheme + "journals"
+	JournalResourceTemplate      = resourceScheme + "journals/{name}"
+	JournalEntryResourceTemplate = resourceScheme + "journals/{name}/entries/{title}"
+	EntryResourceTemplate        = resourceScheme + "entries/{id}"
+
+	entryResourcePrefix   = resourceScheme + "entries/"
+	journalResourcePrefix = resourceScheme + "journals/"
+
+	jsonMIMEType         = "application/json"
+	defaultEntryMIMEType = "text/plain"
+)
+
+// JournalResourceURI returns the recall://journals/{name} URI of a journal.
+func JournalResourceURI(name string) string {
+	return journalResourcePrefix + url.PathEscape(name)
+}
+
+// JournalEntryResourceURI returns the recall://journals/{name}/entries/{title} URI of an entry.
+func JournalEntryResourceURI(journalName, title string) string {
+	return JournalResourceURI(journalName) + "/entries/" + url.PathEscape(title)
+}
+
+// EntryResourceURI returns the recall://entries/{id} URI of an entry.
+func EntryResourceURI(id uuid.UUID) string {
+	return entryResourcePrefix + id.String()
+}
+
+// entryMIMEType maps an entry's content type to the MIME type of its resource.
+func entryMIMEType(e memories.Entry) string {
+	if e.ContentType == "" {
+		return defaultEntryMIMEType
+	}
+	return e.ContentType
+}
+
+// journalWithURI adds the resource URI to a journal in resource listings.
+type journalWithURI struct {
+	memories.Journal
+	URI string `json:"uri"`
+}
+
+// entrySummary describes an entry in a journal resource without its content.
+type entrySummary struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	ContentType string    `json:"content_type"`
+	CreatedAt   float64   `json:"created_at"`
+	UpdatedAt   float64   `json:"updated_at"`
+	URI         string    `json:"uri"`
+	TitleURI    string    `json:"title_uri"`
+}
+
+// registeredResource is a concrete resource registered by Sync, with the name of the
+// journal it belongs to.
+type registeredResource struct {
+	resource mcp.Resource
+	journal  string
+}
+
+// ResourceCatalog publishes journals and entries as MCP resources. Besides the fixed
+// recall://journals resource and the URI templates, it registers one concrete resource
+// per journal and per entry so that clients can browse and attach memories from
+// resources/list. Sync brings those concrete resources in line with the database.
+type ResourceCatalog struct {
+	s     *server.MCPServer
+	store memories.Store
+
+	mu         sync.Mutex
+	registered map[string]registeredResource
+
+	// subscriptions maps a session ID to the URIs it subscribed to, each with
+	// the fingerprint of the resource when it was last checked.
+	subscriptionsMu sync.Mutex
+	subscriptions   map[string]map[string]string
+
+	changed chan struct{}
+}
+
+// RegisterResources registers the recall:// resources and resource templates on s and
+// performs an initial Sync of the concrete journal and entry resources.
+func RegisterResources(ctx context.Context, s *server.MCPServer, store memories.Store) (*ResourceCatalog, error) {
+	read := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return serveResource(ctx, store, request.Params.URI)
+	}
+
+	s.AddResource(
+		mcp.NewResource(
+			JournalsResourceURI,
+			"journals",
+			mcp.WithResourceDescription("All journals, with the URI of each journal resource."),
+			mcp.WithMIMEType(jsonMIMEType),
+		),
+		read,
+	)
+
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate(
+			JournalResourceTemplate,
+			"journal",
+			mcp.WithTemplateDescription("A journal and the titles, content types and URIs of its entries."),
+			mcp.WithTemplateMIMEType(jsonMIMEType),
+		),
+		read,
+	)
+
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate(
+			JournalEntryResourceTemplate,
+			"journal entry",
+			mcp.WithTemplateDescription("The content of an entry, addressed by journal name and entry title. The MIME type is the entry's content type."),
+		),
+		read,
+	)
+
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate(
+			EntryResourceTemplate,
+			"entry",
+			mcp.WithTemplateDescription("The content of an entry, addressed by ID. The MIME type is the entry's content type."),
+		),
+		read,
+	)
+
+	catalog := &ResourceCatalog{
+		s:             s,
+		store:         store,
+		registered:    make(map[string]registeredResource),
+		subscriptions: make(map[string]map[string]string),
+		changed:       make(chan struct{}, 1),
+	}
+	if err := catalog.Sync(ctx); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// Sync registers a concrete resource for every journal and every entry that is not
+// deleted, updates resources whose name or MIME type changed and removes the rest.
+// The server notifies clients with notifications/resources/list_changed for each change.
+func (c *ResourceCatalog) Sync(ctx context.Context) error {
+	journals, err := c.store.ListJournals(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]registeredResource)
+	for _, journal := range journals {
+		uri := JournalResourceURI(journal.Name)
+		wanted[uri] = registeredResource{
+			resource: mcp.NewResource(
+				uri,
+				journal.Name,
+				mcp.WithResourceDescription(fmt.Sprintf("Journal '%s': %s", journal.Name, journal.Description)),
+				mcp.WithMIMEType(jsonMIMEType),
+			),
+			journal: journal.Name,
+		}
+
+		entries, err := c.store.ListEntries(ctx, journal.ID, false)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			uri := EntryResourceURI(entry.ID)
+			wanted[uri] = registeredResource{
+				resource: mcp.NewResource(
+					uri,
+					journal.Name+"/"+entry.Title,
+					mcp.WithResourceDescription(fmt.Sprintf("Entry '%s' in journal '%s'.", entry.Title, journal.Name)),
+					mcp.WithMIMEType(entryMIMEType(entry)),
+				),
+				journal: journal.Name,
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := false
+	for uri := range c.registered {
+		if _, ok := wanted[uri]; !ok {
+			c.s.RemoveResource(uri)
+			delete(c.registered, uri)
+			removed = true
+		}
+	}
+	if removed {
+		// RemoveResource announces the change under a method name clients don't recognise.
+		c.s.SendNotificationToAllClients(mcp.MethodNotificationResourcesListChanged, nil)
+	}
+	for uri, want := range wanted {
+		resource := want.resource
+		if current, ok := c.registered[uri]; ok && current.resource.Name == resource.Name &&
+			current.resource.Description == resource.Description && current.resource.MIMEType == resource.MIMEType {
+			c.registered[uri] = want
+			continue
+		}
+		c.s.AddResource(resource, c.readRegistered)
+		c.registered[uri] = want
+	}
+	return nil
+}
+
+// FilterListed removes the concrete resources of journals outside the scope of ctx
+// from a resources/list result.
+func (c *ResourceCatalog) FilterListed(ctx context.Context, result *mcp.ListResourcesResult) {
+	if _, ok := auth.ScopeFromContext(ctx); !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	allowed := result.Resources[:0]
+	for _, resource := range result.Resources {
+		if registered, ok := c.registered[resource.URI]; ok && !auth.CanAccessJournal(ctx, registered.journal) {
+			continue
+		}
+		allowed = append(allowed, resource)
+	}
+	result.Resources = allowed
+}
+
+// readRegistered serves a concrete journal or entry resource registered by Sync.
+func (c *ResourceCatalog) readRegistered(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return serveResource(ctx, c.store, request.Params.URI)
+}
+
+// serveResource answers a resources/read request for uri, counting a read of the entry
+// it addresses. Subscriptions read resources with readResource instead, so that checking
+// them for changes is not counted.
+func serveResource(ctx context.Context, store memories.Store, uri string) ([]mcp.ResourceContents, error) {
+	contents, err := readResource(ctx, store, uri)
+	if err != nil {
+		return nil, err
+	}
+	if path, _ := resourcePath(uri); isEntryPath(path) {
+		if entry, err := lookupEntry(ctx, store, path); err == nil {
+			recordAccess(store, entry.ID)
+		}
+	}
+	return contents, nil
+}
+
+// resourcePath splits a recall:// URI into its percent-decoded path segments.
+func resourcePath(uri string) ([]string, error) {
+	if !strings.HasPrefix(uri, resourceScheme) {
+		return nil, fmt.Errorf("unknown resource URI '%s': %w", uri, server.ErrResourceNotFound)
+	}
+	path := strings.Split(strings.TrimPrefix(uri, resourceScheme), "/")
+	for i, segment := range path {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resource URI '%s': %w", uri, server.ErrResourceNotFound)
+		}
+		path[i] = unescaped
+	}
+	return path, nil
+}
+
+// isEntryPath reports whether path addresses an entry, by ID or by journal name and title.
+func isEntryPath(path []string) bool {
+	return (len(path) == 2 && path[0] == "entries") ||
+		(len(path) == 4 && path[0] == "journals" && path[2] == "entries")
+}
+
+// readResource returns the contents of any recall:// resource, concrete or matching a template.
+// Resources of journals outside the scope of ctx are reported as not found. It has no side
+// effects, so subscriptions can call it to check resources for changes.
+func readResource(ctx context.Context, store memories.Store, uri string) ([]mcp.ResourceContents, error) {
+	path, err := resourcePath(uri)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(path) == 1 && path[0] == "journals":
+		return readJournalsResource(ctx, store, uri)
+	case len(path) == 2 && path[0] == "journals":
+		if !auth.CanAccessJournal(ctx, path[1]) {
+			return nil, fmt.Errorf("journal '%s' not found: %w", path[1], server.ErrResourceNotFound)
+		}
+		return readJournalResource(ctx, store, uri, path[1])
+	case isEntryPath(path):
+		entry, err := lookupEntry(ctx, store, path)
+		if err != nil {
+			return nil, err
+		}
+		return entryContents(uri, entry), nil
+	default:
+		return nil, fmt.Errorf("unknown resource URI '%s': %w", uri, server.ErrResourceNotFound)
+	}
+}
+
+// lookupEntry resolves an entry path to an entry that is neither deleted nor expired and
+// belongs to a journal within the scope of ctx.
+func lookupEntry(ctx context.Context, store memories.Store, path []string) (memories.Entry, error) {
+	if path[0] == "entries" {
+		id, err := uuid.Parse(path[1])
+		if err != nil {
+			return memories.Entry{}, fmt.Errorf("invalid entry ID '%s': %w", path[1], server.ErrResourceNotFound)
+		}
+		entry, err := store.GetEntry(ctx, id)
+		if errors.Is(err, memories.ErrEntryNotFound) || (err == nil && (entry.Deleted || entry.Expired(time.Now()))) {
+			return memories.Entry{}, fmt.Errorf("entry '%s' not found: %w", path[1], server.ErrResourceNotFound)
+		}
+		if err != nil {
+			return memories.Entry{}, err
+		}
+		if _, ok := auth.ScopeFromContext(ctx); ok {
+			journal, err := store.GetJournal(ctx, entry.JournalID)
+			if err != nil {
+				return memories.Entry{}, err
+			}
+			if !auth.CanAccessJournal(ctx, journal.Name) {
+				return memories.Entry{}, fmt.Errorf("entry '%s' not found: %w", path[1], server.ErrResourceNotFound)
+			}
+		}
+		return entry, nil
+	}
+
+	journalName, title := path[1], path[3]
+	if !auth.CanAccessJournal(ctx, journalName) {
+		return memories.Entry{}, fmt.Errorf("journal '%s' not found: %w", journalName, server.ErrResourceNotFound)
+	}
+	journal, err := getJournalByName(ctx, store, journalName)
+	if err != nil {
+		return memories.Entry{}, err
+	}
+	if journal == nil {
+		return memories.Entry{}, fmt.Errorf("journal '%s' not found: %w", journalName, server.ErrResourceNotFound)
+	}
+	entry, err := getEntryByTitleAndJournalID(ctx, store, title, journal.ID)
+	if err != nil {
+		return memories.Entry{}, err
+	}
+	if entry == nil {
+		return memories.Entry{}, fmt.Errorf("entry '%s' not found in journal '%s': %w", title, journalName, server.ErrResourceNotFound)
+	}
+	return *entry, nil
+}
+
+func readJournalsResource(ctx context.Context, store memories.Store, uri string) ([]mcp.ResourceContents, error) {
+	journals, err := store.ListJournals(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]journalWithURI, 0, len(journals))
+	for _, j := range journals {
+		if !auth.CanAccessJournal(ctx, j.Name) {
+			continue
+		}
+		out = append(out, journalWithURI{Journal: j, URI: JournalResourceURI(j.Name)})
+	}
+	return jsonContents(uri, out)
+}
+
+func readJournalResource(ctx context.Context, store memories.Store, uri, name string) ([]mcp.ResourceContents, error) {
+	journal, err := getJournalByName(ctx, store, name)
+	if err != nil {
+		return nil, err
+	}
+	if journal == nil {
+		return nil, fmt.Errorf("journal '%s' not found: %w", name, server.ErrResourceNotFound)
+	}
+	entries, err := store.ListEntries(ctx, journal.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]entrySummary, 0, len(entries))
+	for _, e := range entries {
+		summaries = append(summaries, entrySummary{
+			ID:          e.ID,
+			Title:       e.Title,
+			ContentType: e.ContentType,
+			CreatedAt:   e.CreatedAt,
+			UpdatedAt:   e.UpdatedAt,
+			URI:         EntryResourceURI(e.ID),
+			TitleURI:    JournalEntryResourceURI(journal.Name, e.Title),
+		})
+	}
+	return jsonContents(uri, struct {
+		Journal journalWithURI `json:"journal"`
+		Entries []entrySummary `json:"entries"`
+	}{journalWithURI{Journal: *journal, URI: JournalResourceURI(journal.Name)}, summaries})
+}
+
+func entryContents(uri string, entry memories.Entry) []mcp.ResourceContents {
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: entryMIMEType(entry),
+			Text:     entry.Content,
+		},
+	}
+}
+
+func jsonContents(uri string, v any) ([]mcp.ResourceContents, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: jsonMIMEType,
+			Text:     string(b),
+		},
+	}, nil
+}
@@ -1,38 +1,80 @@
 package mcp
 
 import (
+	"bufio"
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/mark3labs/mcp-go/mcp"
 
 	"github.com/mark3labs/mcp-go/server"
 	recallpkg "github.com/unowned-ai/recall/pkg"
 	pkgdb "github.com/unowned-ai/recall/pkg/db"
+	"github.com/unowned-ai/recall/pkg/memories"
 	recallutils "github.com/unowned-ai/recall/pkg/utils"
 )
 
+// stdioSessionID is the ID mcp-go gives the single session of a stdio server.
+const stdioSessionID = "stdio"
+
 type RecallMCPServer struct {
 	mcpServer *server.MCPServer
+	hooks     *server.Hooks
+	resources *ResourceCatalog
 	db        *sql.DB
-	DbPath    string
+	store     memories.Store
+	// DbPath is the SQLite database file, or the PostgreSQL URL without its password.
+	DbPath   string
+	postgres bool
 }
 
-// NewRecallMCPServer spins up an MCP server backed by the SQLite database at dbPath.
+// NewRecallMCPServer spins up an MCP server backed by the SQLite database at dbPath, or by
+// the PostgreSQL database at dbPath when it is a postgres:// URL. walEnabled and syncPragma
+// only apply to SQLite.
 func NewRecallMCPServer(dbPath string, walEnabled bool, syncPragma string) (*RecallMCPServer, error) {
-	finalDBPath, err := recallutils.ResolveAndEnsureDBPath(dbPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve database path '%s': %w", dbPath, err)
-	}
-
 	// Create base MCP server.
+	hooks := &server.Hooks{}
 	s := server.NewMCPServer(
 		"Recall MCP Server",
 		recallpkg.Version,
 		server.WithResourceCapabilities(true, true),
 		server.WithLogging(),
 		server.WithRecovery(),
+		server.WithHooks(hooks),
 	)
 
+	if pkgdb.IsPostgresDSN(dbPath) {
+		dbConn, err := pkgdb.OpenPostgresConnection(dbPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database connection: %w", err)
+		}
+		if err := pkgdb.UpgradePostgresDB(dbConn, pkgdb.RedactDSN(dbPath), pkgdb.TargetPostgresSchemaVersion); err != nil {
+			dbConn.Close()
+			return nil, fmt.Errorf("failed to initialize/upgrade database schema for '%s': %w", pkgdb.RedactDSN(dbPath), err)
+		}
+		return &RecallMCPServer{
+			mcpServer: s,
+			hooks:     hooks,
+			db:        dbConn,
+			store:     memories.NewPostgresStore(dbConn),
+			DbPath:    pkgdb.RedactDSN(dbPath),
+			postgres:  true,
+		}, nil
+	}
+
+	finalDBPath, err := recallutils.ResolveAndEnsureDBPath(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve database path '%s': %w", dbPath, err)
+	}
+
 	dbConn, err := pkgdb.OpenDBConnection(finalDBPath, walEnabled, syncPragma)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
@@ -47,14 +89,98 @@ func NewRecallMCPServer(dbPath string, walEnabled bool, syncPragma string) (*Rec
 
 	return &RecallMCPServer{
 		mcpServer: s,
+		hooks:     hooks,
 		db:        dbConn,
+		store:     memories.NewSQLiteStore(dbConn),
 		DbPath:    finalDBPath,
 	}, nil
 }
 
-// Start runs the stdio event loop. Make sure to register tools beforehand.
+// WatchResources makes Start keep the resources of catalog in sync with the database
+// and notify subscribed clients of changes, including writes by other processes.
+func (s *RecallMCPServer) WatchResources(catalog *ResourceCatalog) {
+	s.resources = catalog
+	s.hooks.AddAfterCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest, result *mcp.CallToolResult) {
+		catalog.toolCalled(message, result)
+	})
+	s.hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		catalog.DropSession(session.SessionID())
+	})
+	s.hooks.AddAfterListResources(func(ctx context.Context, id any, message *mcp.ListResourcesRequest, result *mcp.ListResourcesResult) {
+		catalog.FilterListed(ctx, result)
+	})
+}
+
+// Start runs the stdio event loop until stdin is closed or the process is interrupted.
+// Make sure to register tools and resources beforehand.
 func (s *RecallMCPServer) Start() error {
-	return server.ServeStdio(s.mcpServer)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	stdio := server.NewStdioServer(s.mcpServer)
+	stdio.SetErrorLogger(log.New(os.Stderr, "", log.LstdFlags))
+	if s.resources == nil {
+		return stdio.Listen(ctx, os.Stdin, os.Stdout)
+	}
+
+	s.watchResources(ctx)
+	out := &lockedWriter{w: os.Stdout}
+	return stdio.Listen(ctx, s.filterSubscriptionRequests(ctx, os.Stdin, out), out)
+}
+
+// watchResources keeps the resources in sync with the database until ctx is cancelled.
+func (s *RecallMCPServer) watchResources(ctx context.Context) {
+	if s.resources == nil {
+		return
+	}
+	go func() {
+		if err := s.resources.WatchChanges(ctx, DefaultChangePollInterval); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: resource change notifications disabled: %v\n", err)
+		}
+	}()
+}
+
+// filterSubscriptionRequests answers the resources/subscribe and resources/unsubscribe
+// requests read from in by writing the response to out, and passes every other line
+// on through the returned reader.
+func (s *RecallMCPServer) filterSubscriptionRequests(ctx context.Context, in io.Reader, out io.Writer) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				if response, ok := s.resources.HandleSubscriptionRequest(ctx, stdioSessionID, line); ok {
+					if b, err := json.Marshal(response); err == nil {
+						out.Write(append(b, '\n'))
+					}
+				} else if _, err := pw.Write(line); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
+
+// lockedWriter serialises writes from the stdio server and the subscription filter,
+// so that their JSON-RPC messages are never interleaved.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
 }
 
 // DB returns the underlying *sql.DB.
@@ -62,6 +188,11 @@ func (s *RecallMCPServer) DB() *sql.DB {
 	return s.db
 }
 
+// Store returns the memory store that tools and resources should use.
+func (s *RecallMCPServer) Store() memories.Store {
+	return s.store
+}
+
 // MCPRawServer exposes the raw mcp-go server (useful for additional configuration).
 func (s *RecallMCPServer) MCPRawServer() *server.MCPServer {
 	return s.mcpServer
@@ -69,6 +200,9 @@ func (s *RecallMCPServer) MCPRawServer() *server.MCPServer {
 
 // Close cleans up allocated resources.
 func (s *RecallMCPServer) Close() error {
+	if s.db != nil && s.postgres {
+		return s.db.Close()
+	}
 	if s.db != nil {
 		// Checkpointing: https://www.sqlite.org/c3ref/wal_checkpoint_v2.html
 		_, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE);")
@@ -0,0 +1,281 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+const (
+	methodResourcesSubscribe   = "resources/subscribe"
+	methodResourcesUnsubscribe = "resources/unsubscribe"
+
+	// DefaultChangePollInterval is how often WatchChanges checks the database for
+	// commits made outside this server, such as by the CLI or the TUI.
+	DefaultChangePollInterval = 2 * time.Second
+)
+
+// subscriptionRequest is the part of a resources/subscribe or resources/unsubscribe
+// request needed to answer it.
+type subscriptionRequest struct {
+	ID     mcp.RequestId `json:"id"`
+	Method string        `json:"method"`
+	Params struct {
+		URI string `json:"uri"`
+	} `json:"params"`
+}
+
+// HandleSubscriptionRequest answers resources/subscribe and resources/unsubscribe
+// requests for the given session, which mcp-go does not handle itself. It reports
+// false for any other message, which should then be passed on to the MCP server.
+func (c *ResourceCatalog) HandleSubscriptionRequest(ctx context.Context, sessionID string, message []byte) (mcp.JSONRPCMessage, bool) {
+	var request subscriptionRequest
+	if err := json.Unmarshal(message, &request); err != nil || request.ID.IsNil() {
+		return nil, false
+	}
+	if request.Method != methodResourcesSubscribe && request.Method != methodResourcesUnsubscribe {
+		return nil, false
+	}
+	if request.Params.URI == "" {
+		return mcp.NewJSONRPCError(request.ID, mcp.INVALID_PARAMS, "'uri' parameter is required", nil), true
+	}
+
+	if request.Method == methodResourcesUnsubscribe {
+		c.Unsubscribe(sessionID, request.Params.URI)
+		return mcp.NewJSONRPCResponse(request.ID, mcp.Result{}), true
+	}
+
+	err := c.Subscribe(ctx, sessionID, request.Params.URI)
+	if errors.Is(err, server.ErrResourceNotFound) {
+		return mcp.NewJSONRPCError(request.ID, mcp.RESOURCE_NOT_FOUND, err.Error(), nil), true
+	}
+	if err != nil {
+		return mcp.NewJSONRPCError(request.ID, mcp.INTERNAL_ERROR, err.Error(), nil), true
+	}
+	return mcp.NewJSONRPCResponse(request.ID, mcp.Result{}), true
+}
+
+// Subscribe sends the session notifications/resources/updated whenever the resource
+// at uri changes. Any recall:// URI can be subscribed to, including ones that match
+// a resource template.
+func (c *ResourceCatalog) Subscribe(ctx context.Context, sessionID, uri string) error {
+	if _, err := readResource(ctx, c.store, uri); err != nil {
+		return err
+	}
+	fingerprint, err := resourceFingerprint(ctx, c.store, uri)
+	if err != nil {
+		return err
+	}
+
+	c.subscriptionsMu.Lock()
+	defer c.subscriptionsMu.Unlock()
+	if c.subscriptions[sessionID] == nil {
+		c.subscriptions[sessionID] = make(map[string]string)
+	}
+	c.subscriptions[sessionID][uri] = fingerprint
+	return nil
+}
+
+// Unsubscribe stops notifications about uri for the session.
+func (c *ResourceCatalog) Unsubscribe(sessionID, uri string) {
+	c.subscriptionsMu.Lock()
+	defer c.subscriptionsMu.Unlock()
+	delete(c.subscriptions[sessionID], uri)
+	if len(c.subscriptions[sessionID]) == 0 {
+		delete(c.subscriptions, sessionID)
+	}
+}
+
+// DropSession forgets every subscription of a session that has ended.
+func (c *ResourceCatalog) DropSession(sessionID string) {
+	c.subscriptionsMu.Lock()
+	defer c.subscriptionsMu.Unlock()
+	delete(c.subscriptions, sessionID)
+}
+
+// Changed tells a running WatchChanges that the database was just written through
+// this server, so that clients are notified without waiting for the next poll.
+func (c *ResourceCatalog) Changed() {
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+// writeTools are the tools that can change what the resources show. Calls to any
+// other tool only read the database and don't make the resources go stale.
+var writeTools = map[string]bool{
+	"create_journal":    true,
+	"update_journal":    true,
+	"delete_journal":    true,
+	"create_entry":      true,
+	"update_entry":      true,
+	"delete_entry":      true,
+	"restore_entry":     true,
+	"link_entries":      true,
+	"manage_entry_tags": true,
+}
+
+// toolCalled calls Changed after a successful call to a tool that writes to the
+// database, and does nothing for tools that only read.
+func (c *ResourceCatalog) toolCalled(request *mcp.CallToolRequest, result *mcp.CallToolResult) {
+	if !writeTools[request.Params.Name] || result == nil || result.IsError {
+		return
+	}
+	c.Changed()
+}
+
+// Refresh syncs the concrete resources with the database and sends
+// notifications/resources/updated for every subscribed resource that changed.
+func (c *ResourceCatalog) Refresh(ctx context.Context) error {
+	if err := c.Sync(ctx); err != nil {
+		return err
+	}
+
+	c.subscriptionsMu.Lock()
+	defer c.subscriptionsMu.Unlock()
+	fingerprints := make(map[string]string)
+	for sessionID, uris := range c.subscriptions {
+		for uri, previous := range uris {
+			current, ok := fingerprints[uri]
+			if !ok {
+				var err error
+				current, err = resourceFingerprint(ctx, c.store, uri)
+				if err != nil {
+					return err
+				}
+				fingerprints[uri] = current
+			}
+			if current == previous {
+				continue
+			}
+			uris[uri] = current
+			err := c.s.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+			// HTTP clients without an open event stream can't be notified; they see
+			// the change the next time they read the resource.
+			if err != nil && !errors.Is(err, server.ErrSessionNotFound) {
+				fmt.Fprintf(os.Stderr, "Warning: failed to notify session %s about %s: %v\n", sessionID, uri, err)
+			}
+		}
+	}
+	return nil
+}
+
+// WatchChanges refreshes the resources until ctx is cancelled: right away when
+// Changed is called, and whenever PRAGMA data_version shows that another connection
+// committed to the database. Because data_version is read on a connection reserved
+// for it, this catches writes by this server as well as by other recall processes.
+// Stores other than SQLite are only refreshed when Changed is called.
+func (c *ResourceCatalog) WatchChanges(ctx context.Context, interval time.Duration) error {
+	sqliteStore, ok := unwrapStore(c.store).(*memories.SQLiteStore)
+	if !ok {
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-c.changed:
+				c.refresh(ctx)
+			}
+		}
+	}
+
+	conn, err := sqliteStore.DB().Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reserve a connection for change detection: %w", err)
+	}
+	defer conn.Close()
+
+	version, err := dataVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.changed:
+		case <-ticker.C:
+			current, err := dataVersion(ctx, conn)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to check for database changes: %v\n", err)
+				continue
+			}
+			if current == version {
+				continue
+			}
+			version = current
+		}
+		c.refresh(ctx)
+	}
+}
+
+// refresh calls Refresh, warning about failures unless ctx was cancelled.
+func (c *ResourceCatalog) refresh(ctx context.Context) {
+	if err := c.Refresh(ctx); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to refresh resources: %v\n", err)
+	}
+}
+
+// dataVersion reads PRAGMA data_version, which changes whenever a connection other
+// than conn commits to the database.
+func dataVersion(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var version int64
+	if err := conn.QueryRowContext(ctx, "PRAGMA data_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read data_version: %w", err)
+	}
+	return version, nil
+}
+
+// resourceFingerprint hashes what a client sees of the resource at uri, so that
+// changes can be detected by comparing fingerprints. Entry fingerprints include
+// the entry's tags. A resource that no longer exists has an empty fingerprint.
+func resourceFingerprint(ctx context.Context, store memories.Store, uri string) (string, error) {
+	contents, err := readResource(ctx, store, uri)
+	if errors.Is(err, server.ErrResourceNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	for _, content := range contents {
+		if text, ok := content.(mcp.TextResourceContents); ok {
+			fmt.Fprintf(hash, "%s\x00%s\x00", text.MIMEType, text.Text)
+		}
+	}
+
+	if path, _ := resourcePath(uri); isEntryPath(path) {
+		entry, err := lookupEntry(ctx, store, path)
+		if err != nil {
+			return "", err
+		}
+		tags, err := store.ListTagsForEntry(ctx, entry.ID)
+		if err != nil {
+			return "", err
+		}
+		names := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			names = append(names, tag.Tag)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(hash, "%s\x00", name)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,312 @@
+package mcp
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	pkgdb "github.com/unowned-ai/recall/pkg/db"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+// testSession is a client session that collects the notifications sent to it.
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return s.id }
+
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// updated returns the URIs of the notifications/resources/updated received so far,
+// skipping other notifications.
+func (s *testSession) updated() []string {
+	var uris []string
+	for {
+		select {
+		case n := <-s.notifications:
+			if n.Method == mcp.MethodNotificationResourceUpdated {
+				uris = append(uris, n.Params.AdditionalFields["uri"].(string))
+			}
+		default:
+			return uris
+		}
+	}
+}
+
+// waitUpdated waits for a notifications/resources/updated and returns its URI.
+func (s *testSession) waitUpdated(t *testing.T) string {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case n := <-s.notifications:
+			if n.Method == mcp.MethodNotificationResourceUpdated {
+				return n.Params.AdditionalFields["uri"].(string)
+			}
+		case <-timeout:
+			t.Fatal("Timed out waiting for notifications/resources/updated")
+			return ""
+		}
+	}
+}
+
+// setupTestCatalog registers the resources of store on a new in-process MCP server with
+// one connected session.
+func setupTestCatalog(t *testing.T, store memories.Store) (*ResourceCatalog, *testSession) {
+	t.Helper()
+	ctx := context.Background()
+	s := server.NewMCPServer("recall-test", "test", server.WithResourceCapabilities(true, true))
+	catalog, err := RegisterResources(ctx, s, store)
+	if err != nil {
+		t.Fatalf("RegisterResources failed: %v", err)
+	}
+	session := &testSession{id: "session", notifications: make(chan mcp.JSONRPCNotification, 100)}
+	if err := s.RegisterSession(ctx, session); err != nil {
+		t.Fatalf("RegisterSession failed: %v", err)
+	}
+	return catalog, session
+}
+
+func TestSubscribe(t *testing.T) {
+	ctx := context.Background()
+	store := memories.NewMemoryStore()
+	journal, err := store.CreateJournal(ctx, "memory", "")
+	if err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+	entry, err := store.CreateEntry(ctx, journal.ID, "Plan", "Ship it", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	catalog, session := setupTestCatalog(t, store)
+
+	if err := catalog.Subscribe(ctx, session.id, EntryResourceURI(entry.ID)); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if err := catalog.Subscribe(ctx, session.id, JournalEntryResourceURI("memory", "Missing")); err == nil {
+		t.Error("Expected subscribing to a missing entry to fail")
+	}
+
+	message := []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"recall://journals/memory"}}`)
+	response, ok := catalog.HandleSubscriptionRequest(ctx, session.id, message)
+	if !ok {
+		t.Fatal("Expected resources/subscribe to be handled")
+	}
+	if _, isError := response.(mcp.JSONRPCError); isError {
+		t.Errorf("Expected resources/subscribe to succeed, got %+v", response)
+	}
+	if _, ok := catalog.HandleSubscriptionRequest(ctx, session.id, []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)); ok {
+		t.Error("Expected other requests to be passed on")
+	}
+	if got := len(catalog.subscriptions[session.id]); got != 2 {
+		t.Errorf("Expected 2 subscriptions, got %d", got)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	store := memories.NewMemoryStore()
+	journal, err := store.CreateJournal(ctx, "memory", "")
+	if err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+	plan, err := store.CreateEntry(ctx, journal.ID, "Plan", "Ship it", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	notes, err := store.CreateEntry(ctx, journal.ID, "Notes", "Nothing yet", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	catalog, session := setupTestCatalog(t, store)
+	for _, uri := range []string{EntryResourceURI(plan.ID), EntryResourceURI(notes.ID)} {
+		if err := catalog.Subscribe(ctx, session.id, uri); err != nil {
+			t.Fatalf("Subscribe failed: %v", err)
+		}
+	}
+
+	if err := catalog.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if got := session.updated(); len(got) != 0 {
+		t.Errorf("Expected no notifications when nothing changed, got %v", got)
+	}
+
+	if _, err := store.UpdateEntry(ctx, plan.ID, "Plan", "Ship it on Friday", ""); err != nil {
+		t.Fatalf("UpdateEntry failed: %v", err)
+	}
+	if err := catalog.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if got := session.updated(); len(got) != 1 || got[0] != EntryResourceURI(plan.ID) {
+		t.Errorf("Expected one notification for the updated entry, got %v", got)
+	}
+
+	if err := store.TagEntry(ctx, notes.ID, "draft"); err != nil {
+		t.Fatalf("TagEntry failed: %v", err)
+	}
+	if err := catalog.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if got := session.updated(); len(got) != 1 || got[0] != EntryResourceURI(notes.ID) {
+		t.Errorf("Expected a notification for the retagged entry, got %v", got)
+	}
+
+	catalog.Unsubscribe(session.id, EntryResourceURI(plan.ID))
+	if err := store.DeleteEntry(ctx, plan.ID); err != nil {
+		t.Fatalf("DeleteEntry failed: %v", err)
+	}
+	if err := catalog.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if got := session.updated(); len(got) != 0 {
+		t.Errorf("Expected no notifications after unsubscribing, got %v", got)
+	}
+}
+
+func TestWatchChanges_NotifiesOfWritesByOtherConnections(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	path := filepath.Join(t.TempDir(), "recall.db")
+	serverDB, err := pkgdb.OpenDBConnection(path, true, "NORMAL")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer serverDB.Close()
+	if err := pkgdb.InitializeSchema(serverDB, pkgdb.TargetSchemaVersion); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+	// The CLI or the TUI writes through a connection of its own.
+	otherDB, err := pkgdb.OpenDBConnection(path, true, "NORMAL")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer otherDB.Close()
+
+	journal, err := memories.CreateJournal(ctx, otherDB, "memory", "")
+	if err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+	entry, err := memories.CreateEntry(ctx, otherDB, journal.ID, "Plan", "Ship it", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	catalog, session := setupTestCatalog(t, memories.NewSQLiteStore(serverDB))
+	if err := catalog.Subscribe(ctx, session.id, EntryResourceURI(entry.ID)); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- catalog.WatchChanges(ctx, 10*time.Millisecond) }()
+	// Give WatchChanges time to read the data_version it starts from.
+	time.Sleep(50 * time.Millisecond)
+	if _, err := memories.UpdateEntry(ctx, otherDB, entry.ID, "Plan", "Ship it on Friday", ""); err != nil {
+		t.Fatalf("UpdateEntry failed: %v", err)
+	}
+	if got := session.waitUpdated(t); got != EntryResourceURI(entry.ID) {
+		t.Errorf("Expected a notification for %s, got %s", EntryResourceURI(entry.ID), got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("WatchChanges failed: %v", err)
+	}
+}
+
+func TestSubscriptions_DoNotCountReads(t *testing.T) {
+	ctx := context.Background()
+	base := memories.NewMemoryStore()
+	recorder := memories.NewAccessRecorder(base, time.Hour)
+	defer recorder.Close()
+	store := memories.NewAccessTrackingStore(base, recorder)
+	journal, err := store.CreateJournal(ctx, "memory", "")
+	if err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+	entry, err := store.CreateEntry(ctx, journal.ID, "Plan", "Ship it", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	accesses := func() int64 {
+		t.Helper()
+		if err := recorder.Flush(ctx); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		got, err := store.GetEntry(ctx, entry.ID)
+		if err != nil {
+			t.Fatalf("GetEntry failed: %v", err)
+		}
+		return got.AccessCount
+	}
+	catalog, session := setupTestCatalog(t, store)
+
+	uri := EntryResourceURI(entry.ID)
+	if err := catalog.Subscribe(ctx, session.id, uri); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := catalog.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+	}
+	if got := accesses(); got != 0 {
+		t.Errorf("Expected subscribing and refreshing to leave access_count at 0, got %d", got)
+	}
+
+	var request mcp.ReadResourceRequest
+	request.Params.URI = uri
+	if _, err := catalog.readRegistered(ctx, request); err != nil {
+		t.Fatalf("readRegistered failed: %v", err)
+	}
+	if got := accesses(); got != 1 {
+		t.Errorf("Expected resources/read to count one read, got %d", got)
+	}
+}
+
+func TestToolCalled_SignalsOnlyWrites(t *testing.T) {
+	catalog, _ := setupTestCatalog(t, memories.NewMemoryStore())
+	signalled := func() bool {
+		select {
+		case <-catalog.changed:
+			return true
+		default:
+			return false
+		}
+	}
+	call := func(name string) *mcp.CallToolRequest {
+		var request mcp.CallToolRequest
+		request.Params.Name = name
+		return &request
+	}
+
+	tests := []struct {
+		name   string
+		tool   string
+		result *mcp.CallToolResult
+		want   bool
+	}{
+		{"write", "create_entry", mcp.NewToolResultText("{}"), true},
+		{"tags", "manage_entry_tags", mcp.NewToolResultText("{}"), true},
+		{"failed write", "update_entry", mcp.NewToolResultError("entry not found"), false},
+		{"read", "list_entries", mcp.NewToolResultText("[]"), false},
+		{"search", "search_entries", mcp.NewToolResultText("[]"), false},
+		{"context", "recall_context", mcp.NewToolResultText(""), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog.toolCalled(call(tt.tool), tt.result)
+			if got := signalled(); got != tt.want {
+				t.Errorf("toolCalled(%s) signalled a change: %v, want %v", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,15 +2,15 @@ package mcp
 
 import (
 	"context"
-	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/unowned-ai/recall/pkg/memories"
 )
 
 // getJournalByName searches for a journal by its name. If not found, it returns nil, nil.
-func getJournalByName(ctx context.Context, db *sql.DB, name string) (*memories.Journal, error) {
-	journals, err := memories.ListJournals(ctx, db, false)
+func getJournalByName(ctx context.Context, store memories.Store, name string) (*memories.Journal, error) {
+	journals, err := store.ListJournals(ctx, false)
 	if err != nil {
 		return nil, err
 	}
@@ -24,8 +24,8 @@ func getJournalByName(ctx context.Context, db *sql.DB, name string) (*memories.J
 
 // getEntryByTitleAndJournalID fetches an entry by its title within the specified journal.
 // If no entry is found it returns nil, nil.
-func getEntryByTitleAndJournalID(ctx context.Context, db *sql.DB, title string, journalID uuid.UUID) (*memories.Entry, error) {
-	entries, err := memories.ListEntries(ctx, db, journalID, false)
+func getEntryByTitleAndJournalID(ctx context.Context, store memories.Store, title string, journalID uuid.UUID) (*memories.Entry, error) {
+	entries, err := store.ListEntries(ctx, journalID, false)
 	if err != nil {
 		return nil, err
 	}
@@ -36,3 +36,51 @@ func getEntryByTitleAndJournalID(ctx context.Context, db *sql.DB, title string,
 	}
 	return nil, nil
 }
+
+// getTrashedEntryByTitleAndJournalID fetches the most recently deleted or expired entry
+// with the given title within the specified journal. If there is none it returns nil, nil.
+func getTrashedEntryByTitleAndJournalID(ctx context.Context, store memories.Store, title string, journalID uuid.UUID) (*memories.Entry, error) {
+	entries, err := store.ListEntries(ctx, journalID, true)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var found *memories.Entry
+	for _, e := range entries {
+		if e.Title != title || (!e.Deleted && !e.Expired(now)) {
+			continue
+		}
+		if found == nil || e.UpdatedAt > found.UpdatedAt {
+			found = &e
+		}
+	}
+	return found, nil
+}
+
+// unwrapStore returns the store that store decorates, through any number of decorators
+// such as memories.EmbeddingStore.
+func unwrapStore(store memories.Store) memories.Store {
+	for {
+		wrapped, ok := store.(interface{ Unwrap() memories.Store })
+		if !ok {
+			return store
+		}
+		store = wrapped.Unwrap()
+	}
+}
+
+// recordAccess counts a read by an agent of each of ids, when store or a store it
+// decorates tracks reads.
+func recordAccess(store memories.Store, ids ...uuid.UUID) {
+	for {
+		if tracker, ok := store.(interface{ RecordAccess(...uuid.UUID) }); ok {
+			tracker.RecordAccess(ids...)
+			return
+		}
+		wrapped, ok := store.(interface{ Unwrap() memories.Store })
+		if !ok {
+			return
+		}
+		store = wrapped.Unwrap()
+	}
+}
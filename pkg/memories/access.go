@@ -0,0 +1,164 @@
+package memories
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const recordEntryAccessStatement = `
+	UPDATE entries
+	SET access_count = access_count + ?, last_accessed_at = max(last_accessed_at, ?)
+	WHERE id = ?
+	`
+
+// RecordEntryAccesses adds counts to the access counts of entries, and moves their last
+// access time forward to at, in one transaction. Entries that no longer exist are skipped.
+func RecordEntryAccesses(ctx context.Context, db *sql.DB, counts map[uuid.UUID]int64, at float64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, recordEntryAccessStatement)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for id, count := range counts {
+		if _, err := stmt.ExecContext(ctx, count, at, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DefaultAccessFlushInterval is how often an AccessRecorder made with an interval of 0
+// writes the reads it has counted.
+const DefaultAccessFlushInterval = 5 * time.Second
+
+// AccessRecorder counts reads of entries in memory and adds them to a store in batches
+// from a background goroutine, so reads never wait for, or fail because of, a write.
+// Counts that fail to be written are kept for the next batch.
+type AccessRecorder struct {
+	store Store
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]int64
+	last    time.Time
+
+	flushMu sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewAccessRecorder returns a recorder that writes the reads it counts to store every
+// interval, or every DefaultAccessFlushInterval for 0, until it is closed.
+func NewAccessRecorder(store Store, interval time.Duration) *AccessRecorder {
+	if interval <= 0 {
+		interval = DefaultAccessFlushInterval
+	}
+	r := &AccessRecorder{
+		store:   store,
+		pending: make(map[uuid.UUID]int64),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go r.run(interval)
+	return r
+}
+
+func (r *AccessRecorder) run(interval time.Duration) {
+	defer close(r.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = r.Flush(context.Background())
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Record counts one read of each of ids.
+func (r *AccessRecorder) Record(ids ...uuid.UUID) {
+	if len(ids) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		r.pending[id]++
+	}
+	r.last = time.Now()
+}
+
+// Flush writes the reads counted so far.
+func (r *AccessRecorder) Flush(ctx context.Context) error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	r.mu.Lock()
+	counts, last := r.pending, r.last
+	r.pending = make(map[uuid.UUID]int64)
+	r.last = time.Time{}
+	r.mu.Unlock()
+	if len(counts) == 0 {
+		return nil
+	}
+
+	err := r.store.RecordEntryAccesses(ctx, counts, float64(last.Unix()))
+	if err != nil {
+		// Put the batch back, keeping the later of its last read and any read counted
+		// while it was being written.
+		r.mu.Lock()
+		for id, count := range counts {
+			r.pending[id] += count
+		}
+		if last.After(r.last) {
+			r.last = last
+		}
+		r.mu.Unlock()
+	}
+	return err
+}
+
+// Close stops the background writes and writes the reads counted since the last one. It
+// does not close the store.
+func (r *AccessRecorder) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+	return r.Flush(context.Background())
+}
+
+// AccessTrackingStore is a Store whose callers report the entries they hand out to
+// agents with RecordAccess.
+type AccessTrackingStore struct {
+	Store
+	recorder *AccessRecorder
+}
+
+// NewAccessTrackingStore returns store with reads counted by recorder.
+func NewAccessTrackingStore(store Store, recorder *AccessRecorder) *AccessTrackingStore {
+	return &AccessTrackingStore{Store: store, recorder: recorder}
+}
+
+// Unwrap returns the underlying store.
+func (s *AccessTrackingStore) Unwrap() Store {
+	return s.Store
+}
+
+// RecordAccess counts one read of each of ids.
+func (s *AccessTrackingStore) RecordAccess(ids ...uuid.UUID) {
+	s.recorder.Record(ids...)
+}
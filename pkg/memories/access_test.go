@@ -0,0 +1,122 @@
+package memories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// failingAccessStore fails to record accesses until it is told to succeed.
+type failingAccessStore struct {
+	Store
+	fail bool
+}
+
+func (s *failingAccessStore) RecordEntryAccesses(ctx context.Context, counts map[uuid.UUID]int64, at float64) error {
+	if s.fail {
+		return errors.New("database is locked")
+	}
+	return s.Store.RecordEntryAccesses(ctx, counts, at)
+}
+
+func TestAccessRecorder(t *testing.T) {
+	ctx := context.Background()
+	store := &failingAccessStore{Store: NewMemoryStore(), fail: true}
+	journal, err := store.CreateJournal(ctx, "work", "")
+	if err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+	entry, err := store.CreateEntry(ctx, journal.ID, "Entry", "Content", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	accesses := func() int64 {
+		t.Helper()
+		got, err := store.GetEntry(ctx, entry.ID)
+		if err != nil {
+			t.Fatalf("GetEntry failed: %v", err)
+		}
+		return got.AccessCount
+	}
+
+	// An hour-long interval leaves every write to Flush and Close.
+	recorder := NewAccessRecorder(store, time.Hour)
+	recorder.Record(entry.ID, entry.ID)
+	if got := accesses(); got != 0 {
+		t.Errorf("Expected reads to be batched, got %d", got)
+	}
+	if err := recorder.Flush(ctx); err == nil {
+		t.Errorf("Expected the store's error")
+	}
+
+	store.fail = false
+	recorder.Record(entry.ID)
+	if err := recorder.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got := accesses(); got != 3 {
+		t.Errorf("Expected the failed batch to be retried, got %d reads", got)
+	}
+
+	recorder.Record(entry.ID)
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := accesses(); got != 4 {
+		t.Errorf("Expected Close to write pending reads, got %d", got)
+	}
+	if got, _ := store.GetEntry(ctx, entry.ID); got.LastAccessedAt == 0 {
+		t.Errorf("Expected a last access time")
+	}
+}
+
+func TestAccessRecorderKeepsLastReadOfFailedBatch(t *testing.T) {
+	ctx := context.Background()
+	store := &failingAccessStore{Store: NewMemoryStore(), fail: true}
+	journal, _ := store.CreateJournal(ctx, "work", "")
+	entry, _ := store.CreateEntry(ctx, journal.ID, "Entry", "Content", "")
+
+	recorder := NewAccessRecorder(store, time.Hour)
+	defer recorder.Close()
+	before := time.Now().Unix()
+	recorder.Record(entry.ID)
+	if err := recorder.Flush(ctx); err == nil {
+		t.Errorf("Expected the store's error")
+	}
+
+	// The retry has no new reads, so its last read time is the failed batch's.
+	store.fail = false
+	if err := recorder.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	got, err := store.GetEntry(ctx, entry.ID)
+	if err != nil {
+		t.Fatalf("GetEntry failed: %v", err)
+	}
+	if got.AccessCount != 1 || got.LastAccessedAt < float64(before) {
+		t.Errorf("Expected 1 read at or after %d, got %d at %v", before, got.AccessCount, got.LastAccessedAt)
+	}
+}
+
+func TestAccessRecorderInterval(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	journal, _ := store.CreateJournal(ctx, "work", "")
+	entry, _ := store.CreateEntry(ctx, journal.ID, "Entry", "Content", "")
+
+	recorder := NewAccessRecorder(store, 10*time.Millisecond)
+	defer recorder.Close()
+	NewAccessTrackingStore(store, recorder).RecordAccess(entry.ID)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if got, _ := store.GetEntry(ctx, entry.ID); got.AccessCount == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("Expected the recorder to write reads in the background")
+}
@@ -0,0 +1,350 @@
+package memories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidTag       = errors.New("invalid tag")
+	ErrInvalidTagAlias  = errors.New("invalid tag alias")
+	ErrTagAliasNotFound = errors.New("tag alias not found")
+)
+
+// NormalizeTag returns tag as it is stored and searched for: without surrounding spaces and
+// in lower case, so "Kubernetes " and "kubernetes" are one tag. Tags are normalized and
+// then resolved through their aliases when they are created, attached, detached or
+// searched for.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// canonicalTag returns the tag that tag stands for: its normalized form, or the tag it is
+// an alias of in aliases, which maps normalized aliases to their tags.
+func canonicalTag(tag string, aliases map[string]string) string {
+	tag = NormalizeTag(tag)
+	if canonical, ok := aliases[tag]; ok {
+		return canonical
+	}
+	return tag
+}
+
+// canonicalTags returns canonicalTag of each of tags, keeping their order.
+func canonicalTags(tags []string, aliases map[string]string) []string {
+	resolved := make([]string, len(tags))
+	for i, tag := range tags {
+		resolved[i] = canonicalTag(tag, aliases)
+	}
+	return resolved
+}
+
+// checkTag returns the normalized tag, or ErrInvalidTag when nothing is left of it.
+func checkTag(tag string) (string, error) {
+	normalized := NormalizeTag(tag)
+	if normalized == "" {
+		return "", fmt.Errorf("%w %q: must not be empty", ErrInvalidTag, tag)
+	}
+	return normalized, nil
+}
+
+// checkAlias rejects making the normalized alias stand for tag, resolved, when they are
+// the same tag.
+func checkAlias(alias, tag string) error {
+	if alias == tag {
+		return fmt.Errorf("%w: %q cannot be an alias of itself", ErrInvalidTagAlias, alias)
+	}
+	return nil
+}
+
+// checkMerge rejects merging the normalized tag from into to, resolved, when they are the
+// same tag.
+func checkMerge(from, to string) error {
+	if from == to {
+		return fmt.Errorf("%w: cannot merge %q into itself", ErrInvalidTag, from)
+	}
+	return nil
+}
+
+const (
+	getTagAliasStatement = `
+	SELECT alias, tag, created_at
+	FROM tag_aliases
+	WHERE alias = ?
+	`
+
+	listTagAliasesStatement = `
+	SELECT alias, tag, created_at
+	FROM tag_aliases
+	ORDER BY tag, alias
+	`
+
+	setTagAliasStatement = `
+	INSERT INTO tag_aliases (alias, tag)
+	VALUES (?, ?)
+	ON CONFLICT (alias) DO UPDATE SET tag = excluded.tag, created_at = unixepoch()
+	`
+
+	deleteTagAliasStatement = `
+	DELETE FROM tag_aliases
+	WHERE alias = ?
+	`
+
+	retagEntriesStatement = `
+	INSERT INTO entry_tags (entry_id, tag, created_at)
+	SELECT entry_id, ?, created_at FROM entry_tags WHERE tag = ?
+	ON CONFLICT (entry_id, tag) DO NOTHING
+	`
+
+	detachTagFromAllEntriesStatement = `
+	DELETE FROM entry_tags
+	WHERE tag = ?
+	`
+
+	tagExistsStatement = `
+	SELECT COUNT(*)
+	FROM tags
+	WHERE tag = ?
+	`
+
+	moveTagAliasesStatement = `
+	UPDATE tag_aliases
+	SET tag = ?
+	WHERE tag = ?
+	`
+)
+
+// tagMergeStatements are the statements of a backend that MergeTags and AliasTag run.
+type tagMergeStatements struct {
+	createTag, retagEntries, detachFromAllEntries, deleteTag, moveAliases, setAlias string
+}
+
+var sqliteTagMergeStatements = tagMergeStatements{
+	createTag:            createTagStatement,
+	retagEntries:         retagEntriesStatement,
+	detachFromAllEntries: detachTagFromAllEntriesStatement,
+	deleteTag:            deleteTagStatement,
+	moveAliases:          moveTagAliasesStatement,
+	setAlias:             setTagAliasStatement,
+}
+
+// mergeTags moves the entries and aliases of the tag from to the tag to in tx, creating
+// to if needed, and deletes from. It returns the number of entries that carried from.
+func mergeTags(ctx context.Context, tx *sql.Tx, statements tagMergeStatements, from, to string) (int64, error) {
+	if _, err := tx.ExecContext(ctx, statements.createTag, to); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, statements.retagEntries, to, from); err != nil {
+		return 0, err
+	}
+	// Aliases of from would go with it, so they are moved first.
+	if _, err := tx.ExecContext(ctx, statements.moveAliases, to, from); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, statements.detachFromAllEntries, from)
+	if err != nil {
+		return 0, err
+	}
+	retagged, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, statements.deleteTag, from); err != nil {
+		return 0, err
+	}
+	return retagged, nil
+}
+
+// aliasTag makes alias stand for tag in tx, after merging a tag named alias into tag.
+func aliasTag(ctx context.Context, tx *sql.Tx, statements tagMergeStatements, alias, tag string) error {
+	if _, err := mergeTags(ctx, tx, statements, alias, tag); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, statements.setAlias, alias, tag)
+	return err
+}
+
+// tagAliasesQuery builds the statement and arguments looking up the aliases among tags,
+// normalized, with ? placeholders.
+func tagAliasesQuery(tags []string) (string, []any) {
+	args := make([]any, len(tags))
+	for i, tag := range tags {
+		args[i] = NormalizeTag(tag)
+	}
+	return `
+	SELECT alias, tag
+	FROM tag_aliases
+	WHERE alias IN (` + placeholderList(len(tags)) + `)
+	`, args
+}
+
+// scanAliasMap collects the rows of a tagAliasesQuery statement into a map from alias to tag.
+func scanAliasMap(rows *sql.Rows) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for rows.Next() {
+		var alias, tag string
+		if err := rows.Scan(&alias, &tag); err != nil {
+			return nil, err
+		}
+		aliases[alias] = tag
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+// scanTagAliases collects the rows of a statement returning tag aliases.
+func scanTagAliases(rows *sql.Rows) ([]TagAlias, error) {
+	aliases := []TagAlias{}
+	for rows.Next() {
+		var alias TagAlias
+		if err := rows.Scan(&alias.Alias, &alias.Tag, &alias.CreatedAt); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+// tagAliases returns the aliases among tags, mapped to the tags they stand for.
+func tagAliases(ctx context.Context, db *sql.DB, tags []string) (map[string]string, error) {
+	if len(tags) == 0 {
+		return map[string]string{}, nil
+	}
+
+	query, args := tagAliasesQuery(tags)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAliasMap(rows)
+}
+
+// ResolveTags returns each of tags normalized and, when it is an alias, replaced by the
+// tag it stands for.
+func ResolveTags(ctx context.Context, db *sql.DB, tags []string) ([]string, error) {
+	aliases, err := tagAliases(ctx, db, tags)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalTags(tags, aliases), nil
+}
+
+// resolveTag returns the tag a tag about to be stored stands for, or ErrInvalidTag for
+// an empty one.
+func resolveTag(ctx context.Context, db *sql.DB, tag string) (string, error) {
+	if _, err := checkTag(tag); err != nil {
+		return "", err
+	}
+	resolved, err := ResolveTags(ctx, db, []string{tag})
+	if err != nil {
+		return "", err
+	}
+	return resolved[0], nil
+}
+
+// AliasTag makes alias stand for tag, so creating, attaching or searching for alias uses
+// tag instead. Entries already tagged with alias are retagged with tag, and aliases of
+// alias become aliases of tag, all in one transaction. When tag is itself an alias, alias
+// stands for the tag it stands for.
+func AliasTag(ctx context.Context, db *sql.DB, alias, tag string) (TagAlias, error) {
+	alias, err := checkTag(alias)
+	if err != nil {
+		return TagAlias{}, err
+	}
+	tag, err = resolveTag(ctx, db, tag)
+	if err != nil {
+		return TagAlias{}, err
+	}
+	if err := checkAlias(alias, tag); err != nil {
+		return TagAlias{}, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return TagAlias{}, err
+	}
+	defer tx.Rollback()
+
+	if err := aliasTag(ctx, tx, sqliteTagMergeStatements, alias, tag); err != nil {
+		return TagAlias{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return TagAlias{}, err
+	}
+
+	var tagAlias TagAlias
+	err = db.QueryRowContext(ctx, getTagAliasStatement, alias).Scan(&tagAlias.Alias, &tagAlias.Tag, &tagAlias.CreatedAt)
+	return tagAlias, err
+}
+
+// DeleteTagAlias removes an alias. Entries retagged when it was made keep their tag.
+func DeleteTagAlias(ctx context.Context, db *sql.DB, alias string) error {
+	res, err := db.ExecContext(ctx, deleteTagAliasStatement, NormalizeTag(alias))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrTagAliasNotFound
+	}
+	return nil
+}
+
+// ListTagAliases returns every alias, ordered by the tag it stands for and then by alias.
+func ListTagAliases(ctx context.Context, db *sql.DB) ([]TagAlias, error) {
+	rows, err := db.QueryContext(ctx, listTagAliasesStatement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTagAliases(rows)
+}
+
+// MergeTags retags the entries tagged with from with to, in one transaction, and deletes
+// from. Aliases of from become aliases of to. It returns the number of entries retagged.
+// from is only normalized, so an alias cannot be merged; to is resolved through aliases.
+func MergeTags(ctx context.Context, db *sql.DB, from, to string) (int64, error) {
+	from, err := checkTag(from)
+	if err != nil {
+		return 0, err
+	}
+	to, err = resolveTag(ctx, db, to)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkMerge(from, to); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, tagExistsStatement, from).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, ErrTagNotFound
+	}
+	retagged, err := mergeTags(ctx, tx, sqliteTagMergeStatements, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return retagged, tx.Commit()
+}
@@ -0,0 +1,212 @@
+package memories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// DefaultContextTokens is the token budget of PackContext when a caller does not choose one.
+const DefaultContextTokens = 2000
+
+const (
+	// minContextEntryTokens is the smallest body worth including for a truncated entry.
+	minContextEntryTokens = 24
+	// truncationMarker ends the body of an entry that was cut short.
+	truncationMarker = " …"
+)
+
+// ContextCitation describes an entry included in a ContextPack.
+type ContextCitation struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+	Score float64   `json:"score"`
+	// Tokens is the estimated size of the entry's section of the pack.
+	Tokens int `json:"tokens"`
+	// Truncated is set when only the leading part of the entry's content is included.
+	Truncated bool `json:"truncated"`
+}
+
+// ContextPack is a set of entries rendered as one Markdown block for a model's prompt.
+type ContextPack struct {
+	Markdown string `json:"markdown"`
+	// Tokens is the estimated size of Markdown.
+	Tokens  int               `json:"tokens"`
+	Entries []ContextCitation `json:"entries"`
+	// Omitted is the number of ranked entries that did not fit in the budget.
+	Omitted int `json:"omitted"`
+}
+
+// EstimateTokens approximates the number of tokens a BPE tokenizer of a large language
+// model splits text into, without its vocabulary: short words are one token, longer ones
+// one more per six letters, numbers one per three digits, and every punctuation mark and
+// CJK character one. It tends to overestimate, which keeps packs within their budget.
+func EstimateTokens(text string) int {
+	tokens := 0
+	letters, digits := 0, 0
+	flush := func() {
+		if letters > 0 {
+			tokens += 1 + (letters-1)/6
+		}
+		if digits > 0 {
+			tokens += 1 + (digits-1)/3
+		}
+		letters, digits = 0, 0
+	}
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens++
+		case unicode.IsLetter(r):
+			if digits > 0 {
+				flush()
+			}
+			letters++
+		case unicode.IsNumber(r):
+			if letters > 0 {
+				flush()
+			}
+			digits++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// PackContext renders ranked entries, best first, as a Markdown block of at most
+// maxTokens estimated tokens, or DefaultContextTokens for 0. Every entry gets a numbered
+// section, cited with its ID and title in a list of sources at the end. Entries longer
+// than a quarter of the budget are cut down to their leading section, and the last entry
+// that fits is cut to the remaining budget; entries that do not fit at all are left out.
+func PackContext(ranked []RankedEntry, maxTokens int) ContextPack {
+	if maxTokens <= 0 {
+		maxTokens = DefaultContextTokens
+	}
+	const heading = "## Recalled memories\n\n"
+	pack := ContextPack{Entries: []ContextCitation{}}
+	if len(ranked) == 0 {
+		pack.Markdown = heading + "No relevant memories found.\n"
+		pack.Tokens = EstimateTokens(pack.Markdown)
+		return pack
+	}
+
+	longEntryTokens := max(maxTokens/4, 2*minContextEntryTokens)
+	used := EstimateTokens(heading) + EstimateTokens("Sources:\n")
+	var sections, sources []string
+	for _, entry := range ranked {
+		n := len(sections) + 1
+		source := fmt.Sprintf("[%d] %s (entry %s)\n", n, entry.Title, entry.ID)
+		body := strings.TrimSpace(entry.Content)
+		truncated := false
+		if EstimateTokens(body) > longEntryTokens {
+			body, truncated = truncateToTokens(leadingSection(body), longEntryTokens), true
+			if !strings.HasSuffix(body, truncationMarker) {
+				body += truncationMarker
+			}
+		}
+
+		// Only an entry that has to be cut to the remaining budget needs minContextEntryTokens
+		// of it; one that fits as it is goes in however little budget is left.
+		whole := EstimateTokens(source) + EstimateTokens(contextSectionHeader(n, entry, truncated)+body+"\n\n")
+		if used+whole > maxTokens {
+			overhead := EstimateTokens(source) + EstimateTokens(contextSectionHeader(n, entry, true))
+			remaining := maxTokens - used - overhead
+			if remaining < minContextEntryTokens {
+				pack.Omitted++
+				continue
+			}
+			body, truncated = truncateToTokens(strings.TrimSuffix(body, truncationMarker), remaining), true
+		}
+
+		section := contextSectionHeader(n, entry, truncated) + body + "\n\n"
+		tokens := EstimateTokens(section) + EstimateTokens(source)
+		if used+tokens > maxTokens {
+			pack.Omitted++
+			continue
+		}
+		used += tokens
+		sections = append(sections, section)
+		sources = append(sources, source)
+		pack.Entries = append(pack.Entries, ContextCitation{ID: entry.ID, Title: entry.Title, Score: entry.Score, Tokens: tokens, Truncated: truncated})
+	}
+
+	var b strings.Builder
+	b.WriteString(heading)
+	for _, section := range sections {
+		b.WriteString(section)
+	}
+	b.WriteString("Sources:\n")
+	for _, source := range sources {
+		b.WriteString(source)
+	}
+	pack.Markdown = b.String()
+	pack.Tokens = EstimateTokens(pack.Markdown)
+	return pack
+}
+
+// contextSectionHeader is the heading and details line of the nth section of a pack.
+func contextSectionHeader(n int, entry RankedEntry, truncated bool) string {
+	details := "Updated " + time.Unix(int64(entry.UpdatedAt), 0).UTC().Format(time.DateOnly)
+	if len(entry.Tags) > 0 {
+		details += " · tags: " + strings.Join(entry.Tags, ", ")
+	}
+	if truncated {
+		details += " · truncated"
+	}
+	return fmt.Sprintf("### [%d] %s\n%s\n\n", n, entry.Title, details)
+}
+
+// leadingSection returns content up to its second Markdown heading, or all of it when it
+// has fewer than two headings.
+func leadingSection(content string) string {
+	lines := strings.SplitAfter(content, "\n")
+	headings, offset := 0, 0
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimLeft(line, " "), "#") {
+			headings++
+			if headings == 2 && offset > 0 {
+				return strings.TrimSpace(content[:offset])
+			}
+		}
+		offset += len(line)
+	}
+	return content
+}
+
+// truncateToTokens returns the longest start of text of at most maxTokens estimated
+// tokens, including the truncation marker, ending at a paragraph, sentence or word
+// boundary when one is close enough. Text that already fits is returned unchanged.
+func truncateToTokens(text string, maxTokens int) string {
+	if EstimateTokens(text) <= maxTokens {
+		return text
+	}
+	budget := maxTokens - EstimateTokens(truncationMarker)
+	runes := []rune(text)
+	low, high := 0, len(runes)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if EstimateTokens(string(runes[:mid])) <= budget {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	cut := string(runes[:low])
+	// Prefer the latest boundary that keeps at least half of what fits.
+	for _, boundary := range []string{"\n\n", ". ", "\n", " "} {
+		if i := strings.LastIndex(cut, boundary); i >= len(cut)/2 {
+			cut = cut[:i+len(strings.TrimRight(boundary, " \n"))]
+			break
+		}
+	}
+	return strings.TrimSpace(cut) + truncationMarker
+}
@@ -0,0 +1,107 @@
+package memories
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	for _, tc := range []struct {
+		text   string
+		tokens int
+	}{
+		{"", 0},
+		{"   \n\t", 0},
+		{"hello world", 2},
+		{"internationalization", 4},
+		{"Hello, world!", 4},
+		{"2026", 2},
+		{"v1.2", 4},
+		{"记忆", 2},
+	} {
+		if got := EstimateTokens(tc.text); got != tc.tokens {
+			t.Errorf("EstimateTokens(%q): expected %d, got %d", tc.text, tc.tokens, got)
+		}
+	}
+}
+
+func TestTruncateToTokens(t *testing.T) {
+	text := "First sentence here. Second sentence follows. Third one ends it."
+	if got := truncateToTokens(text, 100); got != text {
+		t.Errorf("Expected text that fits to be unchanged, got %q", got)
+	}
+	got := truncateToTokens(text, 8)
+	if got != "First sentence here. Second sentence follows. …" && got != "First sentence here. …" {
+		t.Errorf("Expected a cut at a sentence boundary, got %q", got)
+	}
+	if EstimateTokens(got) > 8 {
+		t.Errorf("Expected at most 8 tokens, got %d for %q", EstimateTokens(got), got)
+	}
+
+	section := "# Setup\nInstall the tools.\n\n## Details\nLots more."
+	if got := leadingSection(section); got != "# Setup\nInstall the tools." {
+		t.Errorf("Unexpected leading section %q", got)
+	}
+	if got := leadingSection("No headings at all."); got != "No headings at all." {
+		t.Errorf("Unexpected leading section %q", got)
+	}
+}
+
+func TestPackContext(t *testing.T) {
+	entry := func(title, content string, tags ...string) RankedEntry {
+		var r RankedEntry
+		r.ID, r.Title, r.Content, r.Tags, r.UpdatedAt = uuid.New(), title, content, tags, 1767225600
+		return r
+	}
+	long := "# Runbook\n" + strings.Repeat("Restart the service and check the logs. ", 40) + "\n\n## Appendix\n" + strings.Repeat("Old notes. ", 200)
+	ranked := []RankedEntry{
+		entry("Deploys", "Deploy with make release.", "ops"),
+		entry("Runbook", long, "ops", "oncall"),
+		entry("Lunch", strings.Repeat("Pizza on Fridays. ", 300)),
+	}
+
+	pack := PackContext(ranked, 400)
+	if pack.Tokens > 400 {
+		t.Errorf("Expected at most 400 tokens, got %d", pack.Tokens)
+	}
+	if len(pack.Entries) < 2 || pack.Entries[0].ID != ranked[0].ID || pack.Entries[0].Truncated || !pack.Entries[1].Truncated {
+		t.Fatalf("Expected the short entry in full and the runbook truncated, got %+v", pack.Entries)
+	}
+	if len(pack.Entries)+pack.Omitted != len(ranked) {
+		t.Errorf("Expected every entry to be included or omitted, got %+v", pack)
+	}
+	for _, want := range []string{
+		"## Recalled memories\n\n### [1] Deploys\nUpdated 2026-01-01 · tags: ops\n\nDeploy with make release.\n\n",
+		"### [2] Runbook\nUpdated 2026-01-01 · tags: ops, oncall · truncated\n\n# Runbook\nRestart the service",
+		"Sources:\n[1] Deploys (entry " + ranked[0].ID.String() + ")\n[2] Runbook (entry " + ranked[1].ID.String() + ")\n",
+	} {
+		if !strings.Contains(pack.Markdown, want) {
+			t.Errorf("Expected the pack to contain %q, got:\n%s", want, pack.Markdown)
+		}
+	}
+	if strings.Contains(pack.Markdown, "Appendix") {
+		t.Errorf("Expected only the leading section of the runbook, got:\n%s", pack.Markdown)
+	}
+
+	tiny := PackContext(ranked, 30)
+	if len(tiny.Entries) != 0 || tiny.Omitted != 3 {
+		t.Errorf("Expected nothing to fit in 30 tokens, got %+v", tiny)
+	}
+	// A short entry that exactly fills the budget is included whole.
+	short := []RankedEntry{entry("Deploys", "Deploy with make release.", "ops")}
+	budget := PackContext(short, 1000).Tokens
+	exact := PackContext(short, budget)
+	if len(exact.Entries) != 1 || exact.Entries[0].Truncated || exact.Omitted != 0 || exact.Tokens != budget {
+		t.Errorf("Expected the entry to fill a %d-token budget exactly, got %+v", budget, exact)
+	}
+	if over := PackContext(short, budget-1); len(over.Entries) != 0 || over.Omitted != 1 {
+		t.Errorf("Expected the entry not to fit in %d tokens, got %+v", budget-1, over)
+	}
+
+	empty := PackContext(nil, 0)
+	if !strings.Contains(empty.Markdown, "No relevant memories found.") || len(empty.Entries) != 0 {
+		t.Errorf("Unexpected empty pack %+v", empty)
+	}
+}
@@ -11,6 +11,8 @@ type Journal struct {
 	Active      bool      `json:"active"`
 	CreatedAt   float64   `json:"created_at"`
 	UpdatedAt   float64   `json:"updated_at"`
+	// ExpiryPolicy is what the reaper does with the journal's expired entries.
+	ExpiryPolicy ExpiryPolicy `json:"expiry_policy"`
 }
 
 type Entry struct {
@@ -22,6 +24,13 @@ type Entry struct {
 	Deleted     bool      `json:"deleted"`
 	CreatedAt   float64   `json:"created_at"`
 	UpdatedAt   float64   `json:"updated_at"`
+	// AccessCount is how often agents have read the entry.
+	AccessCount int64 `json:"access_count"`
+	// LastAccessedAt is when an agent last read the entry, or 0 if none has.
+	LastAccessedAt float64 `json:"last_accessed_at"`
+	// ExpiresAt is when the entry expires, or 0 if it never does. Expired entries are
+	// left out of listings and searches like deleted ones until the reaper removes them.
+	ExpiresAt float64 `json:"expires_at,omitempty"`
 }
 
 type Tag struct {
@@ -35,3 +44,31 @@ type EntryTag struct {
 	Tag       string    `json:"tag"`
 	CreatedAt float64   `json:"created_at"`
 }
+
+// TagAlias maps an alternative spelling of a tag to the tag it stands for.
+type TagAlias struct {
+	Alias     string  `json:"alias"`
+	Tag       string  `json:"tag"`
+	CreatedAt float64 `json:"created_at"`
+}
+
+// EntryLink is a directed link from the source entry to the target entry, read as
+// "source relation target", e.g. "release notes supersedes draft".
+type EntryLink struct {
+	SourceID  uuid.UUID    `json:"source_id"`
+	TargetID  uuid.UUID    `json:"target_id"`
+	Relation  LinkRelation `json:"relation"`
+	CreatedAt float64      `json:"created_at"`
+}
+
+// EntryRevision is a prior version of an entry, recorded when the entry was updated.
+// Actor identifies who made the update that replaced this version (e.g. "cli", "mcp", "tui").
+type EntryRevision struct {
+	EntryID     uuid.UUID `json:"entry_id"`
+	Revision    int64     `json:"revision"`
+	Title       string    `json:"title"`
+	Content     string    `json:"content"`
+	ContentType string    `json:"content_type"`
+	Actor       string    `json:"actor"`
+	CreatedAt   float64   `json:"created_at"`
+}
@@ -0,0 +1,206 @@
+package memories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultHashingDimensions is the vector length of a HashingEmbedder made with
+// NewHashingEmbedder(0).
+const DefaultHashingDimensions = 1024
+
+// HashingEmbedder is an offline Embedder that needs no model files or network. It hashes
+// the words of a text and their character trigrams into a fixed number of buckets,
+// weighted by term frequency; semantic search weights the buckets by their inverse
+// document frequency over the searched entries, which makes the vectors TF-IDF vectors.
+// Trigrams let related word forms such as "deploy" and "deployment" match. It finds
+// rephrasings that share words or word stems, not synonyms; use an OllamaEmbedder for that.
+type HashingEmbedder struct {
+	dimensions int
+}
+
+// corpusWeighter is implemented by embedders whose vectors are weighted against the
+// vectors they are compared with.
+type corpusWeighter interface {
+	// weightByCorpus returns weighted copies of query and corpus.
+	weightByCorpus(query []float32, corpus [][]float32) ([]float32, [][]float32)
+}
+
+var _ corpusWeighter = (*HashingEmbedder)(nil)
+
+// NewHashingEmbedder returns a HashingEmbedder with vectors of the given length, or of
+// DefaultHashingDimensions for 0.
+func NewHashingEmbedder(dimensions int) *HashingEmbedder {
+	if dimensions <= 0 {
+		dimensions = DefaultHashingDimensions
+	}
+	return &HashingEmbedder{dimensions: dimensions}
+}
+
+// Model includes the vector length and a version of the hashing scheme, so that changing
+// either re-embeds entries instead of comparing incompatible vectors.
+func (e *HashingEmbedder) Model() string {
+	return fmt.Sprintf("hashed-ngrams-v1/%d", e.dimensions)
+}
+
+func (e *HashingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+// embed returns the L2-normalised vector of log-scaled feature counts of text.
+func (e *HashingEmbedder) embed(text string) []float32 {
+	counts := make([]float64, e.dimensions)
+	for _, word := range lowerWords(text) {
+		counts[e.bucket("w:"+word)]++
+		runes := []rune("<" + word + ">")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[e.bucket("t:"+string(runes[i:i+3]))] += 0.5
+		}
+	}
+
+	vector := make([]float32, e.dimensions)
+	var norm float64
+	for i, count := range counts {
+		if count > 0 {
+			// Damp repeated features; a lone trigram keeps its weight of one half.
+			weight := count
+			if count > 1 {
+				weight = 1 + math.Log(count)
+			}
+			vector[i] = float32(weight)
+			norm += weight * weight
+		}
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i] = float32(float64(vector[i]) / norm)
+		}
+	}
+	return vector
+}
+
+func (e *HashingEmbedder) bucket(feature string) int {
+	h := fnv.New32a()
+	h.Write([]byte(feature))
+	return int(h.Sum32() % uint32(e.dimensions))
+}
+
+// weightByCorpus multiplies every bucket by its smoothed inverse document frequency
+// among corpus, so that features most entries share count for little.
+func (e *HashingEmbedder) weightByCorpus(query []float32, corpus [][]float32) ([]float32, [][]float32) {
+	df := make([]float64, len(query))
+	for _, vector := range corpus {
+		for i := range df {
+			if i < len(vector) && vector[i] != 0 {
+				df[i]++
+			}
+		}
+	}
+	idf := make([]float64, len(df))
+	for i := range idf {
+		idf[i] = math.Log((1+float64(len(corpus)))/(1+df[i])) + 1
+	}
+	weight := func(vector []float32) []float32 {
+		weighted := make([]float32, len(vector))
+		for i, v := range vector {
+			if i < len(idf) {
+				weighted[i] = float32(float64(v) * idf[i])
+			}
+		}
+		return weighted
+	}
+
+	weightedCorpus := make([][]float32, len(corpus))
+	for i, vector := range corpus {
+		weightedCorpus[i] = weight(vector)
+	}
+	return weight(query), weightedCorpus
+}
+
+// DefaultOllamaURL is where Ollama listens by default.
+const DefaultOllamaURL = "http://localhost:11434"
+
+// OllamaEmbedder is an Embedder backed by the /api/embed endpoint of an Ollama server,
+// or of another local server that speaks its API, such as one running nomic-embed-text.
+type OllamaEmbedder struct {
+	url    string
+	model  string
+	client *http.Client
+}
+
+// NewOllamaEmbedder returns an embedder using model on the Ollama server at url, or at
+// DefaultOllamaURL when url is empty.
+func NewOllamaEmbedder(url, model string) *OllamaEmbedder {
+	if url == "" {
+		url = DefaultOllamaURL
+	}
+	return &OllamaEmbedder{
+		url:    strings.TrimSuffix(url, "/"),
+		model:  model,
+		client: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+func (e *OllamaEmbedder) Model() string {
+	return "ollama/" + e.model
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error"`
+}
+
+func (e *OllamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(ollamaEmbedRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama at %s: %w", e.url, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ollama at %s: %w", e.url, err)
+	}
+	var result ollamaEmbedResponse
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("ollama at %s: unexpected response (%s): %w", e.url, resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error == "" {
+			result.Error = resp.Status
+		}
+		return nil, fmt.Errorf("ollama at %s: %s", e.url, result.Error)
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama at %s: got %d embeddings for %d texts", e.url, len(result.Embeddings), len(texts))
+	}
+	return result.Embeddings, nil
+}
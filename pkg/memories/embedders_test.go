@@ -0,0 +1,100 @@
+package memories
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHashingEmbedder(t *testing.T) {
+	embedder := NewHashingEmbedder(256)
+	if embedder.Model() != "hashed-ngrams-v1/256" {
+		t.Errorf("Unexpected model %q", embedder.Model())
+	}
+
+	vectors, err := embedder.Embed(context.Background(), []string{
+		"Deployment checklist for the API",
+		"How to deploy the API",
+		"Tomatoes need water twice a week",
+		"",
+	})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(vectors) != 4 || len(vectors[0]) != 256 {
+		t.Fatalf("Expected 4 vectors of 256 values, got %d", len(vectors))
+	}
+	if similarity := cosineSimilarity(vectors[0], vectors[0]); similarity < 0.9999 || similarity > 1.0001 {
+		t.Errorf("Expected a vector to be similar to itself, got %f", similarity)
+	}
+	related, unrelated := cosineSimilarity(vectors[0], vectors[1]), cosineSimilarity(vectors[0], vectors[2])
+	if related <= unrelated {
+		t.Errorf("Expected related texts to be closer (%f) than unrelated ones (%f)", related, unrelated)
+	}
+	if similarity := cosineSimilarity(vectors[0], vectors[3]); similarity != 0 {
+		t.Errorf("Expected an empty text to have no similarity, got %f", similarity)
+	}
+
+	again, _ := embedder.Embed(context.Background(), []string{"How to deploy the API"})
+	if cosineSimilarity(again[0], vectors[1]) < 0.9999 {
+		t.Errorf("Expected embeddings to be deterministic")
+	}
+}
+
+func TestVectorEncoding(t *testing.T) {
+	vector := []float32{0, -1.5, 3.25, 1e-8}
+	decoded := decodeVector(encodeVector(vector))
+	if len(decoded) != len(vector) {
+		t.Fatalf("Expected %v, got %v", vector, decoded)
+	}
+	for i := range vector {
+		if decoded[i] != vector[i] {
+			t.Errorf("Expected %v, got %v", vector, decoded)
+		}
+	}
+	if decodeVector(nil) != nil || decodeVector([]byte{1, 2, 3}) != nil {
+		t.Errorf("Expected nil for missing or malformed vectors")
+	}
+}
+
+func TestOllamaEmbedder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaEmbedRequest
+		if r.URL.Path != "/api/embed" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if req.Model != "nomic-embed-text" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": `model "` + req.Model + `" not found`})
+			return
+		}
+		var resp ollamaEmbedResponse
+		for _, text := range req.Input {
+			resp.Embeddings = append(resp.Embeddings, []float32{float32(len(text)), 1})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	embedder := NewOllamaEmbedder(server.URL+"/", "nomic-embed-text")
+	if embedder.Model() != "ollama/nomic-embed-text" {
+		t.Errorf("Unexpected model %q", embedder.Model())
+	}
+	vectors, err := embedder.Embed(ctx, []string{"a", "abc"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][0] != 3 {
+		t.Errorf("Unexpected vectors %v", vectors)
+	}
+
+	_, err = NewOllamaEmbedder(server.URL, "missing").Embed(ctx, []string{"a"})
+	if err == nil || !strings.Contains(err.Error(), `model "missing" not found`) {
+		t.Errorf("Expected the server's error, got %v", err)
+	}
+}
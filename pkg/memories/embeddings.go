@@ -0,0 +1,411 @@
+package memories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Embedder turns texts into vectors whose cosine similarity reflects how close the texts
+// are in meaning, for semantic search.
+type Embedder interface {
+	// Model names the vector space of the embedder. Stores keep one embedding per entry
+	// and model, and vectors of different models are never compared.
+	Model() string
+	// Embed returns one vector per text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EntryEmbedding is the embedding of an entry made with an embedding model.
+type EntryEmbedding struct {
+	EntryID uuid.UUID
+	Model   string
+	// Vector is nil when the entry has not been embedded with the model yet, or was
+	// changed since.
+	Vector []float32
+}
+
+// SemanticMatch is an entry found by SearchEntriesSemantic.
+type SemanticMatch struct {
+	TaggedEntry
+	// Similarity is the cosine similarity of the entry and the query, at most 1.
+	Similarity float64 `json:"similarity"`
+}
+
+const (
+	setEntryEmbeddingStatement = `
+	INSERT INTO entry_embeddings (entry_id, model, vector, created_at)
+	VALUES (?, ?, ?, unixepoch())
+	ON CONFLICT (entry_id, model) DO UPDATE SET vector = excluded.vector, created_at = excluded.created_at
+	`
+
+	listEntryEmbeddingsStatement = `
+	SELECT e.id, ee.vector
+	FROM entries e
+	LEFT JOIN entry_embeddings ee ON ee.entry_id = e.id AND ee.model = ?
+	WHERE e.deleted = FALSE AND (e.expires_at = 0 OR e.expires_at > unixepoch()) AND (? OR e.journal_id = ?)
+	`
+
+	deleteEntryEmbeddingsStatement = `
+	DELETE FROM entry_embeddings
+	WHERE entry_id = ?
+	`
+)
+
+// embeddingText is the text of an entry that is embedded.
+func embeddingText(entry Entry) string {
+	return entry.Title + "\n\n" + entry.Content
+}
+
+// encodeVector stores a vector as little-endian float32 values.
+func encodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+// decodeVector reverses encodeVector. It returns nil for nil or malformed data.
+func decodeVector(buf []byte) []float32 {
+	if buf == nil || len(buf)%4 != 0 {
+		return nil
+	}
+	vector := make([]float32, len(buf)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vector
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0 if either is a
+// zero vector or their lengths differ.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// SetEntryEmbedding stores the embedding of an entry made with model, replacing any
+// previous one.
+func SetEntryEmbedding(ctx context.Context, db *sql.DB, entryID uuid.UUID, model string, vector []float32) error {
+	if _, err := GetEntry(ctx, db, entryID); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, setEntryEmbeddingStatement, entryID, model, encodeVector(vector))
+	return err
+}
+
+// ListEntryEmbeddings returns the embeddings made with model of the entries of a journal
+// that are not deleted, or of every journal for uuid.Nil. Entries without an embedding
+// are included with a nil Vector.
+func ListEntryEmbeddings(ctx context.Context, db *sql.DB, journalID uuid.UUID, model string) ([]EntryEmbedding, error) {
+	rows, err := db.QueryContext(ctx, listEntryEmbeddingsStatement, model, journalID == uuid.Nil, journalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanEntryEmbeddings(rows, model)
+}
+
+// scanEntryEmbeddings collects the rows of a ListEntryEmbeddings statement.
+func scanEntryEmbeddings(rows *sql.Rows, model string) ([]EntryEmbedding, error) {
+	var embeddings []EntryEmbedding
+	for rows.Next() {
+		embedding := EntryEmbedding{Model: model}
+		var vector []byte
+		if err := rows.Scan(&embedding.EntryID, &vector); err != nil {
+			return nil, err
+		}
+		embedding.Vector = decodeVector(vector)
+		embeddings = append(embeddings, embedding)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return embeddings, nil
+}
+
+// embedBatchSize is the number of entries EmbedEntries sends to the embedder at once.
+const embedBatchSize = 32
+
+// EmbedEntries embeds entries with embedder and stores their embeddings.
+func EmbedEntries(ctx context.Context, store Store, embedder Embedder, entries []Entry) error {
+	_, err := embedEntries(ctx, store, embedder, entries)
+	return err
+}
+
+// embedEntries is EmbedEntries returning the vectors of entries, in order.
+func embedEntries(ctx context.Context, store Store, embedder Embedder, entries []Entry) ([][]float32, error) {
+	embedded := make([][]float32, 0, len(entries))
+	for start := 0; start < len(entries); start += embedBatchSize {
+		batch := entries[start:min(start+embedBatchSize, len(entries))]
+		texts := make([]string, len(batch))
+		for i, entry := range batch {
+			texts[i] = embeddingText(entry)
+		}
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed entries: %w", err)
+		}
+		if len(vectors) != len(batch) {
+			return nil, fmt.Errorf("failed to embed entries: embedder returned %d vectors for %d texts", len(vectors), len(batch))
+		}
+		for i, entry := range batch {
+			if err := store.SetEntryEmbedding(ctx, entry.ID, embedder.Model(), vectors[i]); err != nil {
+				return nil, fmt.Errorf("failed to store embedding of entry %s: %w", entry.ID, err)
+			}
+		}
+		embedded = append(embedded, vectors...)
+	}
+	return embedded, nil
+}
+
+// SearchEntriesSemantic ranks the entries of a journal, or of every journal for uuid.Nil,
+// by the cosine similarity of their embeddings to that of query, most similar first.
+// Entries that have no embedding from embedder yet are embedded first. Entries with no
+// similarity at all are left out, and a limit of 0 returns every other entry.
+func SearchEntriesSemantic(ctx context.Context, store Store, embedder Embedder, journalID uuid.UUID, query string, limit int) ([]SemanticMatch, error) {
+	embeddings, err := loadEmbeddings(ctx, store, embedder, journalID)
+	if err != nil {
+		return nil, err
+	}
+	return rankEmbeddings(ctx, store, embedder, embeddings, query, limit)
+}
+
+// loadEmbeddings returns the embeddings from embedder of the entries of a journal, or of
+// every journal for uuid.Nil, that are neither deleted nor expired, embedding the entries
+// that have none yet.
+func loadEmbeddings(ctx context.Context, store Store, embedder Embedder, journalID uuid.UUID) ([]EntryEmbedding, error) {
+	embeddings, err := store.ListEntryEmbeddings(ctx, journalID, embedder.Model())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list embeddings: %w", err)
+	}
+
+	var missing []uuid.UUID
+	for _, embedding := range embeddings {
+		if embedding.Vector == nil {
+			missing = append(missing, embedding.EntryID)
+		}
+	}
+	if len(missing) > 0 {
+		found, _, err := store.FindEntries(ctx, EntryFilter{EntryIDs: missing}, Page{})
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]Entry, len(found))
+		for i, entry := range found {
+			entries[i] = entry.Entry
+		}
+		if err := EmbedEntries(ctx, store, embedder, entries); err != nil {
+			return nil, err
+		}
+		if embeddings, err = store.ListEntryEmbeddings(ctx, journalID, embedder.Model()); err != nil {
+			return nil, fmt.Errorf("failed to list embeddings: %w", err)
+		}
+	}
+	return embeddings, nil
+}
+
+// rankEmbeddings ranks the entries of embeddings for SearchEntriesSemantic. Entries that
+// were deleted or expired since their embeddings were listed are left out.
+func rankEmbeddings(ctx context.Context, store Store, embedder Embedder, embeddings []EntryEmbedding, query string, limit int) ([]SemanticMatch, error) {
+	queryVectors, err := embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(queryVectors) != 1 {
+		return nil, fmt.Errorf("failed to embed query: embedder returned %d vectors", len(queryVectors))
+	}
+	queryVector := queryVectors[0]
+	if weighter, ok := embedder.(corpusWeighter); ok {
+		corpus := make([][]float32, 0, len(embeddings))
+		for _, embedding := range embeddings {
+			if embedding.Vector != nil {
+				corpus = append(corpus, embedding.Vector)
+			}
+		}
+		queryVector, corpus = weighter.weightByCorpus(queryVector, corpus)
+		for i, j := 0, 0; i < len(embeddings); i++ {
+			if embeddings[i].Vector != nil {
+				embeddings[i].Vector = corpus[j]
+				j++
+			}
+		}
+	}
+
+	similarities := make(map[uuid.UUID]float64)
+	var ids []uuid.UUID
+	for _, embedding := range embeddings {
+		if similarity := cosineSimilarity(queryVector, embedding.Vector); similarity > 0 {
+			similarities[embedding.EntryID] = similarity
+			ids = append(ids, embedding.EntryID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if similarities[ids[i]] != similarities[ids[j]] {
+			return similarities[ids[i]] > similarities[ids[j]]
+		}
+		return ids[i].String() < ids[j].String()
+	})
+
+	// Fetch the best matches a page at a time, until limit of them are still live.
+	matches := []SemanticMatch{}
+	for start := 0; start < len(ids) && (limit <= 0 || len(matches) < limit); {
+		end := len(ids)
+		if limit > 0 {
+			end = min(start+limit-len(matches), len(ids))
+		}
+		found, _, err := store.FindEntries(ctx, EntryFilter{EntryIDs: ids[start:end]}, Page{})
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[uuid.UUID]TaggedEntry, len(found))
+		for _, entry := range found {
+			byID[entry.ID] = entry
+		}
+		for _, id := range ids[start:end] {
+			if entry, ok := byID[id]; ok {
+				matches = append(matches, SemanticMatch{TaggedEntry: entry, Similarity: similarities[id]})
+			}
+		}
+		start = end
+	}
+	return matches, nil
+}
+
+// EmbeddingStore is a Store that embeds entries with an Embedder whenever they are
+// created or their content changes, and can search them by meaning. A failure to embed
+// does not fail the write: the entry is embedded by the next semantic search instead.
+//
+// Searches are served from an in-memory index of the embeddings, which writes through
+// the store keep up to date and which is reloaded after DefaultVectorIndexMaxAge to pick
+// up writes by other processes.
+type EmbeddingStore struct {
+	Store
+	embedder Embedder
+	index    *vectorIndex
+}
+
+// NewEmbeddingStore returns store with entries embedded by embedder.
+func NewEmbeddingStore(store Store, embedder Embedder) *EmbeddingStore {
+	return &EmbeddingStore{Store: store, embedder: embedder, index: newVectorIndex(DefaultVectorIndexMaxAge)}
+}
+
+// Unwrap returns the underlying store.
+func (s *EmbeddingStore) Unwrap() Store {
+	return s.Store
+}
+
+// Embedder returns the embedder of the store.
+func (s *EmbeddingStore) Embedder() Embedder {
+	return s.embedder
+}
+
+// embed embeds an entry that was created or changed, and updates the index with it.
+func (s *EmbeddingStore) embed(ctx context.Context, entry Entry) {
+	vectors, err := embedEntries(ctx, s.Store, s.embedder, []Entry{entry})
+	if err != nil {
+		s.index.remove(entry.ID)
+		return
+	}
+	s.index.set(entry, vectors[0])
+}
+
+func (s *EmbeddingStore) CreateEntry(ctx context.Context, journalID uuid.UUID, title, content, contentType string) (Entry, error) {
+	entry, err := s.Store.CreateEntry(ctx, journalID, title, content, contentType)
+	if err == nil {
+		s.embed(ctx, entry)
+	}
+	return entry, err
+}
+
+func (s *EmbeddingStore) UpdateEntry(ctx context.Context, id uuid.UUID, title, content, contentType string) (Entry, error) {
+	entry, err := s.Store.UpdateEntry(ctx, id, title, content, contentType)
+	if err == nil {
+		s.embed(ctx, entry)
+	}
+	return entry, err
+}
+
+func (s *EmbeddingStore) RestoreEntryRevision(ctx context.Context, entryID uuid.UUID, revision int64) (Entry, error) {
+	entry, err := s.Store.RestoreEntryRevision(ctx, entryID, revision)
+	if err == nil {
+		s.embed(ctx, entry)
+	}
+	return entry, err
+}
+
+func (s *EmbeddingStore) DeleteEntry(ctx context.Context, id uuid.UUID) error {
+	err := s.Store.DeleteEntry(ctx, id)
+	s.index.remove(id)
+	return err
+}
+
+// The writes below can change which entries are live in bulk, so they drop the index.
+
+func (s *EmbeddingStore) DeleteJournal(ctx context.Context, id uuid.UUID) error {
+	defer s.index.reset()
+	return s.Store.DeleteJournal(ctx, id)
+}
+
+func (s *EmbeddingStore) DeleteInactiveJournals(ctx context.Context) (int64, error) {
+	defer s.index.reset()
+	return s.Store.DeleteInactiveJournals(ctx)
+}
+
+func (s *EmbeddingStore) DeleteEntriesByJournal(ctx context.Context, journalID uuid.UUID) (int64, error) {
+	defer s.index.reset()
+	return s.Store.DeleteEntriesByJournal(ctx, journalID)
+}
+
+func (s *EmbeddingStore) RestoreEntry(ctx context.Context, id uuid.UUID) (Entry, error) {
+	defer s.index.reset()
+	return s.Store.RestoreEntry(ctx, id)
+}
+
+func (s *EmbeddingStore) SetEntryExpiry(ctx context.Context, id uuid.UUID, expiresAt float64) (Entry, error) {
+	defer s.index.reset()
+	return s.Store.SetEntryExpiry(ctx, id, expiresAt)
+}
+
+func (s *EmbeddingStore) ReapExpiredEntries(ctx context.Context, now float64) (ReapResult, error) {
+	defer s.index.reset()
+	return s.Store.ReapExpiredEntries(ctx, now)
+}
+
+func (s *EmbeddingStore) SetEntryEmbedding(ctx context.Context, entryID uuid.UUID, model string, vector []float32) error {
+	defer s.index.reset()
+	return s.Store.SetEntryEmbedding(ctx, entryID, model, vector)
+}
+
+// SearchEntriesSemantic is SearchEntriesSemantic with the store's embedder, comparing the
+// query with the vectors of the index.
+func (s *EmbeddingStore) SearchEntriesSemantic(ctx context.Context, journalID uuid.UUID, query string, limit int) ([]SemanticMatch, error) {
+	embeddings, ok := s.index.embeddings(journalID, s.embedder.Model())
+	if !ok {
+		var err error
+		if embeddings, err = loadEmbeddings(ctx, s.Store, s.embedder, journalID); err != nil {
+			return nil, err
+		}
+		s.index.load(journalID, embeddings)
+	}
+	return rankEmbeddings(ctx, s.Store, s.embedder, embeddings, query, limit)
+}
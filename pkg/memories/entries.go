@@ -19,18 +19,27 @@ const (
 	`
 
 	getEntryStatement = `
-	SELECT id, journal_id, title, content, content_type, deleted, created_at, updated_at 
+	SELECT id, journal_id, title, content, content_type, deleted, created_at, updated_at, access_count, last_accessed_at, expires_at 
 	FROM entries 
 	WHERE id = ?
 	`
 
 	listEntriesStatement = `
-	SELECT id, journal_id, title, content, content_type, deleted, created_at, updated_at 
+	SELECT id, journal_id, title, content, content_type, deleted, created_at, updated_at, access_count, last_accessed_at, expires_at 
 	FROM entries
-	WHERE journal_id = ? AND deleted = ?
+	WHERE journal_id = ? AND ((deleted = FALSE AND (expires_at = 0 OR expires_at > unixepoch())) OR ? = TRUE)
 	ORDER BY updated_at DESC
 	`
 
+	listEntriesPageStatement = `
+	SELECT id, journal_id, title, content, content_type, deleted, created_at, updated_at, access_count, last_accessed_at, expires_at
+	FROM entries
+	WHERE journal_id = ? AND ((deleted = FALSE AND (expires_at = 0 OR expires_at > unixepoch())) OR ? = TRUE)
+		AND (? OR updated_at < ? OR (updated_at = ? AND id < ?))
+	ORDER BY updated_at DESC, id DESC
+	LIMIT ?
+	`
+
 	updateEntryStatement = `
 	UPDATE entries 
 	SET title = ?, content = ?, content_type = ?, updated_at = unixepoch()
@@ -101,6 +110,9 @@ func GetEntry(ctx context.Context, db *sql.DB, id uuid.UUID) (Entry, error) {
 		&entry.Deleted,
 		&entry.CreatedAt,
 		&entry.UpdatedAt,
+		&entry.AccessCount,
+		&entry.LastAccessedAt,
+		&entry.ExpiresAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -112,7 +124,8 @@ func GetEntry(ctx context.Context, db *sql.DB, id uuid.UUID) (Entry, error) {
 	return entry, nil
 }
 
-// TODO: Add pagination support
+// ListEntries returns the entries of a journal, most recently updated first.
+// ListEntriesPage reads them a page at a time.
 func ListEntries(ctx context.Context, db *sql.DB, journalID uuid.UUID, includeDeleted bool) ([]Entry, error) {
 	_, err := GetJournal(ctx, db, journalID)
 	if err != nil {
@@ -140,6 +153,9 @@ func ListEntries(ctx context.Context, db *sql.DB, journalID uuid.UUID, includeDe
 			&entry.Deleted,
 			&entry.CreatedAt,
 			&entry.UpdatedAt,
+			&entry.AccessCount,
+			&entry.LastAccessedAt,
+			&entry.ExpiresAt,
 		)
 		if err != nil {
 			return nil, err
@@ -155,6 +171,46 @@ func ListEntries(ctx context.Context, db *sql.DB, journalID uuid.UUID, includeDe
 	return entries, nil
 }
 
+// ListEntriesPage returns a page of the entries of a journal, most recently updated first,
+// and the cursor of the next page, or an empty string on the last page.
+func ListEntriesPage(ctx context.Context, db *sql.DB, journalID uuid.UUID, includeDeleted bool, page Page) ([]Entry, string, error) {
+	if _, err := GetJournal(ctx, db, journalID); err != nil {
+		return nil, "", err
+	}
+	keyset, err := page.keysetArgs()
+	if err != nil {
+		return nil, "", err
+	}
+	limit := page.fetchLimit()
+	if limit == 0 {
+		limit = -1 // No limit.
+	}
+	args := append([]any{journalID, includeDeleted}, keyset...)
+	rows, err := db.QueryContext(ctx, listEntriesPageStatement, append(args, limit)...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	entries, next := trimPage(entries, page, entryKey)
+	return entries, next, nil
+}
+
+// UpdateEntry overwrites an entry's title, content and content type; empty values keep the current ones.
+// The replaced version is kept in the entry's revision history, attributed to the actor set with WithActor.
 func UpdateEntry(ctx context.Context, db *sql.DB, id uuid.UUID, title, content, contentType string) (Entry, error) {
 	existingEntry, err := GetEntry(ctx, db, id)
 	if err != nil {
@@ -171,28 +227,7 @@ func UpdateEntry(ctx context.Context, db *sql.DB, id uuid.UUID, title, content,
 		contentType = existingEntry.ContentType
 	}
 
-	res, err := db.ExecContext(
-		ctx,
-		updateEntryStatement,
-		title,
-		content,
-		contentType,
-		id,
-	)
-	if err != nil {
-		return Entry{}, err
-	}
-
-	rowsAffected, err := res.RowsAffected()
-	if err != nil {
-		return Entry{}, err
-	}
-
-	if rowsAffected == 0 {
-		return Entry{}, ErrEntryNotFound
-	}
-
-	return GetEntry(ctx, db, id)
+	return replaceEntryContent(ctx, db, existingEntry, title, content, contentType)
 }
 
 func DeleteEntry(ctx context.Context, db *sql.DB, id uuid.UUID) error {
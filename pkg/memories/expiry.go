@@ -0,0 +1,259 @@
+package memories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExpiryPolicy is what the reaper does with the expired entries of a journal.
+type ExpiryPolicy string
+
+const (
+	// ExpirySoftDelete marks expired entries as deleted, so they can still be restored or
+	// cleaned later. It is the policy of new journals.
+	ExpirySoftDelete ExpiryPolicy = "soft_delete"
+	// ExpiryPurge removes expired entries with their tags and history.
+	ExpiryPurge ExpiryPolicy = "purge"
+)
+
+var (
+	ErrInvalidExpiryPolicy = errors.New("invalid expiry policy")
+	ErrInvalidDuration     = errors.New("invalid duration")
+)
+
+// ParseExpiryPolicy returns the policy named s.
+func ParseExpiryPolicy(s string) (ExpiryPolicy, error) {
+	switch policy := ExpiryPolicy(s); policy {
+	case ExpirySoftDelete, ExpiryPurge:
+		return policy, nil
+	}
+	return "", fmt.Errorf("%w %q: must be %s or %s", ErrInvalidExpiryPolicy, s, ExpirySoftDelete, ExpiryPurge)
+}
+
+// durationPattern splits a duration into a leading number of days or weeks and the rest.
+var durationPattern = regexp.MustCompile(`^(\d+)([dw])(.*)$`)
+
+// ParseDuration parses a positive duration like time.ParseDuration does, and also accepts
+// days ("d") and weeks ("w") ahead of the other units, as in "14d" or "1d12h".
+func ParseDuration(s string) (time.Duration, error) {
+	var d time.Duration
+	rest := s
+	if m := durationPattern.FindStringSubmatch(s); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, fmt.Errorf("%w %q", ErrInvalidDuration, s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+		if m[2] == "w" {
+			d *= 7
+		}
+		rest = m[3]
+	}
+	if rest != "" {
+		r, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("%w %q", ErrInvalidDuration, s)
+		}
+		d += r
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%w %q: must be positive", ErrInvalidDuration, s)
+	}
+	return d, nil
+}
+
+// NoExpiry is the TTL that removes an entry's expiry.
+const NoExpiry = "never"
+
+// ParseTTL parses the time to live of an entry with ParseDuration and returns when an
+// entry given it now expires, or 0 for NoExpiry.
+func ParseTTL(ttl string, now time.Time) (float64, error) {
+	if ttl == NoExpiry {
+		return 0, nil
+	}
+	d, err := ParseDuration(ttl)
+	if err != nil {
+		return 0, err
+	}
+	return float64(now.Add(d).Unix()), nil
+}
+
+// Expired reports whether the entry had expired at t.
+func (e Entry) Expired(t time.Time) bool {
+	return e.ExpiresAt > 0 && e.ExpiresAt <= float64(t.Unix())
+}
+
+// live reports whether the entry is neither deleted nor expired at the Unix time at.
+func (e Entry) live(at float64) bool {
+	return !e.Deleted && (e.ExpiresAt == 0 || e.ExpiresAt > at)
+}
+
+// ReapResult counts the expired entries a reaper removed.
+type ReapResult struct {
+	// Deleted is the number of entries soft-deleted in journals with ExpirySoftDelete.
+	Deleted int64 `json:"deleted"`
+	// Purged is the number of entries removed from journals with ExpiryPurge.
+	Purged int64 `json:"purged"`
+}
+
+const (
+	setEntryExpiryStatement = `
+	UPDATE entries
+	SET expires_at = ?
+	WHERE id = ?
+	`
+
+	setJournalExpiryPolicyStatement = `
+	UPDATE journals
+	SET expiry_policy = ?, updated_at = unixepoch()
+	WHERE id = ?
+	`
+
+	softDeleteExpiredEntriesStatement = `
+	UPDATE entries
+	SET deleted = TRUE, updated_at = unixepoch()
+	WHERE deleted = FALSE AND expires_at > 0 AND expires_at <= ?
+		AND journal_id IN (SELECT id FROM journals WHERE expiry_policy = 'soft_delete')
+	`
+
+	purgeExpiredEntriesStatement = `
+	DELETE FROM entries
+	WHERE expires_at > 0 AND expires_at <= ?
+		AND journal_id IN (SELECT id FROM journals WHERE expiry_policy = 'purge')
+	`
+)
+
+// SetEntryExpiry sets when an entry expires, or removes its expiry for 0. It is not an
+// update of the entry: no revision is recorded and its update time is kept.
+func SetEntryExpiry(ctx context.Context, db *sql.DB, id uuid.UUID, expiresAt float64) (Entry, error) {
+	res, err := db.ExecContext(ctx, setEntryExpiryStatement, expiresAt, id)
+	if err != nil {
+		return Entry{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return Entry{}, err
+	} else if n == 0 {
+		return Entry{}, ErrEntryNotFound
+	}
+	return GetEntry(ctx, db, id)
+}
+
+// SetJournalExpiryPolicy sets what the reaper does with the expired entries of a journal.
+func SetJournalExpiryPolicy(ctx context.Context, db *sql.DB, id uuid.UUID, policy ExpiryPolicy) (Journal, error) {
+	if _, err := ParseExpiryPolicy(string(policy)); err != nil {
+		return Journal{}, err
+	}
+	res, err := db.ExecContext(ctx, setJournalExpiryPolicyStatement, policy, id)
+	if err != nil {
+		return Journal{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return Journal{}, err
+	} else if n == 0 {
+		return Journal{}, ErrJournalNotFound
+	}
+	return GetJournal(ctx, db, id)
+}
+
+// ReapExpiredEntries soft-deletes or purges the entries that had expired at the Unix time
+// now, by the expiry policy of their journal, in one transaction.
+func ReapExpiredEntries(ctx context.Context, db *sql.DB, now float64) (ReapResult, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return ReapResult{}, err
+	}
+	defer tx.Rollback()
+	result, err := reapExpiredEntries(ctx, tx, softDeleteExpiredEntriesStatement, purgeExpiredEntriesStatement, now)
+	if err != nil {
+		return ReapResult{}, err
+	}
+	return result, tx.Commit()
+}
+
+// reapExpiredEntries runs the soft-delete and purge statements of a backend in tx.
+func reapExpiredEntries(ctx context.Context, tx *sql.Tx, softDeleteStatement, purgeStatement string, now float64) (ReapResult, error) {
+	var result ReapResult
+	res, err := tx.ExecContext(ctx, softDeleteStatement, now)
+	if err != nil {
+		return ReapResult{}, err
+	}
+	if result.Deleted, err = res.RowsAffected(); err != nil {
+		return ReapResult{}, err
+	}
+	res, err = tx.ExecContext(ctx, purgeStatement, now)
+	if err != nil {
+		return ReapResult{}, err
+	}
+	if result.Purged, err = res.RowsAffected(); err != nil {
+		return ReapResult{}, err
+	}
+	return result, nil
+}
+
+// DefaultReapInterval is how often a Reaper made with an interval of 0 reaps.
+const DefaultReapInterval = time.Minute
+
+// Reaper reaps the expired entries of a store from a background goroutine: once when it
+// starts and then every interval.
+type Reaper struct {
+	store  Store
+	report func(ReapResult, error)
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewReaper starts reaping the expired entries of store every interval, or every
+// DefaultReapInterval for 0, until it is closed. report, when not nil, is called with the
+// outcome of every pass.
+func NewReaper(store Store, interval time.Duration, report func(ReapResult, error)) *Reaper {
+	if interval <= 0 {
+		interval = DefaultReapInterval
+	}
+	r := &Reaper{
+		store:  store,
+		report: report,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go r.run(interval)
+	return r
+}
+
+func (r *Reaper) run(interval time.Duration) {
+	defer close(r.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.reap()
+		select {
+		case <-ticker.C:
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+func (r *Reaper) reap() {
+	result, err := r.store.ReapExpiredEntries(context.Background(), float64(time.Now().Unix()))
+	if r.report != nil {
+		r.report(result, err)
+	}
+}
+
+// Close stops the reaper and waits for a pass in progress to finish. It does not close
+// the store.
+func (r *Reaper) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+	return nil
+}
@@ -0,0 +1,92 @@
+package memories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	day := 24 * time.Hour
+	for _, tc := range []struct {
+		in   string
+		want time.Duration
+	}{
+		{"14d", 14 * day},
+		{"2w", 14 * day},
+		{"1d12h", day + 12*time.Hour},
+		{"90m", 90 * time.Minute},
+	} {
+		if got, err := ParseDuration(tc.in); err != nil || got != tc.want {
+			t.Errorf("ParseDuration(%q): expected %v, got %v (%v)", tc.in, tc.want, got, err)
+		}
+	}
+	for _, in := range []string{"", "0d", "-1h", "d", "14 days", "1d-48h"} {
+		if _, err := ParseDuration(in); !errors.Is(err, ErrInvalidDuration) {
+			t.Errorf("ParseDuration(%q): expected ErrInvalidDuration, got %v", in, err)
+		}
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if got, err := ParseTTL("1d", now); err != nil || got != float64(now.Add(24*time.Hour).Unix()) {
+		t.Errorf("Expected a day from now, got %v (%v)", got, err)
+	}
+	if got, err := ParseTTL(NoExpiry, now); err != nil || got != 0 {
+		t.Errorf("Expected no expiry, got %v (%v)", got, err)
+	}
+	if _, err := ParseTTL("soon", now); !errors.Is(err, ErrInvalidDuration) {
+		t.Errorf("Expected ErrInvalidDuration, got %v", err)
+	}
+}
+
+func TestParseExpiryPolicy(t *testing.T) {
+	for _, policy := range []ExpiryPolicy{ExpirySoftDelete, ExpiryPurge} {
+		if got, err := ParseExpiryPolicy(string(policy)); err != nil || got != policy {
+			t.Errorf("ParseExpiryPolicy(%q): got %q (%v)", policy, got, err)
+		}
+	}
+	if _, err := ParseExpiryPolicy("delete"); !errors.Is(err, ErrInvalidExpiryPolicy) {
+		t.Errorf("Expected ErrInvalidExpiryPolicy, got %v", err)
+	}
+}
+
+func TestReaper(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	journal, err := store.CreateJournal(ctx, "work", "")
+	if err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+	entry, err := store.CreateEntry(ctx, journal.ID, "Sprint goals", "Ship it", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	if _, err := store.SetEntryExpiry(ctx, entry.ID, float64(time.Now().Add(-time.Minute).Unix())); err != nil {
+		t.Fatalf("SetEntryExpiry failed: %v", err)
+	}
+
+	reaped := make(chan ReapResult, 1)
+	reaper := NewReaper(store, time.Hour, func(result ReapResult, err error) {
+		if err != nil {
+			t.Errorf("ReapExpiredEntries failed: %v", err)
+		}
+		reaped <- result
+	})
+	select {
+	case result := <-reaped:
+		if result.Deleted != 1 {
+			t.Errorf("Expected the first pass to delete the expired entry, got %+v", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the reaper to run when it starts")
+	}
+	if err := reaper.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got, _ := store.GetEntry(ctx, entry.ID); !got.Deleted {
+		t.Errorf("Expected the entry to be deleted, got %+v", got)
+	}
+}
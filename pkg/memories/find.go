@@ -0,0 +1,211 @@
+package memories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// TaggedEntry is an Entry together with the names of its tags, in alphabetical order.
+type TaggedEntry struct {
+	Entry
+	Tags []string `json:"tags"`
+}
+
+// EntryFilter selects the entries returned by FindEntries. The zero value selects every
+// entry that is not deleted.
+type EntryFilter struct {
+	// JournalIDs limits the result to entries of these journals; empty means all journals.
+	JournalIDs []uuid.UUID
+	// EntryIDs limits the result to these entries; empty means all entries.
+	EntryIDs []uuid.UUID
+	// Tags limits the result to entries carrying all of these tags, or any of them when
+	// MatchAnyTag is set. An entry carrying a tag below one of them, as "go/sql" is below
+	// "go", carries it too. Tags are normalized and resolved through their aliases.
+	Tags        []string
+	MatchAnyTag bool
+	// Query further limits the result to entries selected by a parsed tag query.
+	Query *TagQuery
+	// IncludeDeleted also returns soft-deleted and expired entries.
+	IncludeDeleted bool
+}
+
+// tagNames returns the tags the filter searches for, in Tags and in the tag terms of Query.
+func (f EntryFilter) tagNames() []string {
+	names := append([]string(nil), f.Tags...)
+	if f.Query != nil {
+		names = append(names, f.Query.tagNames()...)
+	}
+	return names
+}
+
+// withAliases returns the filter with its tags normalized and resolved through aliases,
+// which maps normalized aliases to the tags they stand for.
+func (f EntryFilter) withAliases(aliases map[string]string) EntryFilter {
+	f.Tags = canonicalTags(f.Tags, aliases)
+	if f.Query != nil {
+		f.Query = f.Query.withAliases(aliases)
+	}
+	return f
+}
+
+// tagSeparator joins tag names in the aggregated tag column. Tags are free text, but the
+// ASCII unit separator does not occur in names typed at a terminal or sent over MCP.
+const tagSeparator = "\x1f"
+
+// findEntriesQuery builds the statement and arguments for FindEntries with ? placeholders.
+// tagAggregate is the backend's expression joining et.tag with tagSeparator.
+func findEntriesQuery(filter EntryFilter, page Page, tagAggregate string) (string, []any, error) {
+	keyset, err := page.keysetArgs()
+	if err != nil {
+		return "", nil, err
+	}
+	conditions := []string{"(? OR e.updated_at < ? OR (e.updated_at = ? AND e.id < ?))"}
+	args := keyset
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "e.deleted = FALSE", "(e.expires_at = 0 OR e.expires_at > unixepoch())")
+	}
+	if len(filter.JournalIDs) > 0 {
+		conditions = append(conditions, "e.journal_id IN ("+placeholderList(len(filter.JournalIDs))+")")
+		for _, id := range filter.JournalIDs {
+			args = append(args, id)
+		}
+	}
+	if len(filter.EntryIDs) > 0 {
+		conditions = append(conditions, "e.id IN ("+placeholderList(len(filter.EntryIDs))+")")
+		for _, id := range filter.EntryIDs {
+			args = append(args, id)
+		}
+	}
+	if tags := distinctTags(filter.Tags); len(tags) > 0 {
+		// Each tag matches itself and the tags below it, so an entry may carry several
+		// tags matching one of them; every tag is checked on its own.
+		matches := make([]string, len(tags))
+		for i, tag := range tags {
+			condition, tagArgs := tagMatchSQL("tag", tag)
+			matches[i] = "e.id IN (SELECT entry_id FROM entry_tags WHERE " + condition + ")"
+			args = append(args, tagArgs...)
+		}
+		if filter.MatchAnyTag {
+			conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+		} else {
+			conditions = append(conditions, matches...)
+		}
+	}
+	if filter.Query != nil {
+		condition, queryArgs := filter.Query.SQL()
+		conditions = append(conditions, condition)
+		args = append(args, queryArgs...)
+	}
+
+	limit := ""
+	if n := page.fetchLimit(); n > 0 {
+		limit = "LIMIT ?"
+		args = append(args, n)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			e.id, e.journal_id, e.title, e.content, e.content_type, e.deleted, e.created_at, e.updated_at, e.access_count, e.last_accessed_at, e.expires_at,
+			COALESCE(%s, '')
+		FROM
+			entries e
+		LEFT JOIN
+			entry_tags et ON et.entry_id = e.id
+		WHERE
+			%s
+		GROUP BY
+			e.id, e.journal_id, e.title, e.content, e.content_type, e.deleted, e.created_at, e.updated_at, e.access_count, e.last_accessed_at, e.expires_at
+		ORDER BY
+			e.updated_at DESC,
+			e.id DESC
+		%s;
+	`, tagAggregate, strings.Join(conditions, " AND "), limit)
+	return query, args, nil
+}
+
+// placeholderList returns n comma-separated ? placeholders.
+func placeholderList(n int) string {
+	return strings.Repeat("?, ", n-1) + "?"
+}
+
+// distinctTags returns tags without duplicates, keeping their order.
+func distinctTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var out []string
+	for _, tag := range tags {
+		if !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// scanTaggedEntries collects the rows of a FindEntries statement.
+func scanTaggedEntries(rows *sql.Rows) ([]TaggedEntry, error) {
+	var entries []TaggedEntry
+	for rows.Next() {
+		var entry TaggedEntry
+		var tags string
+		err := rows.Scan(
+			&entry.ID,
+			&entry.JournalID,
+			&entry.Title,
+			&entry.Content,
+			&entry.ContentType,
+			&entry.Deleted,
+			&entry.CreatedAt,
+			&entry.UpdatedAt,
+			&entry.AccessCount,
+			&entry.LastAccessedAt,
+			&entry.ExpiresAt,
+			&tags,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if tags != "" {
+			entry.Tags = strings.Split(tags, tagSeparator)
+			sort.Strings(entry.Tags)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// FindEntries returns a page of the entries selected by filter with their tags, most
+// recently updated first, in a single query regardless of how many journals and entries
+// match. It also returns the cursor of the next page, or an empty string on the last page.
+func FindEntries(ctx context.Context, db *sql.DB, filter EntryFilter, page Page) ([]TaggedEntry, string, error) {
+	aliases, err := tagAliases(ctx, db, filter.tagNames())
+	if err != nil {
+		return nil, "", err
+	}
+	query, args, err := findEntriesQuery(filter.withAliases(aliases), page, "group_concat(et.tag, char(31))")
+	if err != nil {
+		return nil, "", err
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to execute find query: %w", err)
+	}
+	defer rows.Close()
+
+	entries, err := scanTaggedEntries(rows)
+	if err != nil {
+		return nil, "", err
+	}
+	entries, next := trimPage(entries, page, taggedEntryKey)
+	return entries, next, nil
+}
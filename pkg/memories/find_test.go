@@ -0,0 +1,153 @@
+package memories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/unowned-ai/recall/pkg/db"
+)
+
+// seedBenchmarkDB fills a test database with entries spread over journals, each tagged
+// with two of tagCount tags, and returns the journal IDs.
+func seedBenchmarkDB(tb testing.TB, testDB *sql.DB, journalCount, entriesPerJournal, tagCount int) []uuid.UUID {
+	tb.Helper()
+	ctx := context.Background()
+
+	var journalIDs []uuid.UUID
+	for j := 0; j < journalCount; j++ {
+		journal, err := CreateJournal(ctx, testDB, fmt.Sprintf("journal-%d", j), "")
+		if err != nil {
+			tb.Fatalf("CreateJournal failed: %v", err)
+		}
+		journalIDs = append(journalIDs, journal.ID)
+		for i := 0; i < entriesPerJournal; i++ {
+			entry, err := CreateEntry(ctx, testDB, journal.ID, fmt.Sprintf("Entry %d", i), "Some content", "")
+			if err != nil {
+				tb.Fatalf("CreateEntry failed: %v", err)
+			}
+			for _, tag := range []int{i % tagCount, (i + j + 1) % tagCount} {
+				if err := TagEntry(ctx, testDB, entry.ID, fmt.Sprintf("tag-%d", tag)); err != nil {
+					tb.Fatalf("TagEntry failed: %v", err)
+				}
+			}
+		}
+	}
+	return journalIDs
+}
+
+// findEntriesPerEntry is how the MCP tools used to find entries with all of tags: list
+// every journal's entries, then query each entry's tags.
+func findEntriesPerEntry(ctx context.Context, testDB *sql.DB, journalIDs []uuid.UUID, tags []string) ([]TaggedEntry, error) {
+	var results []TaggedEntry
+	for _, journalID := range journalIDs {
+		entries, err := ListEntries(ctx, testDB, journalID, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			entryTags, err := ListTagsForEntry(ctx, testDB, entry.ID)
+			if err != nil {
+				return nil, err
+			}
+			names := make(map[string]bool, len(entryTags))
+			result := TaggedEntry{Entry: entry}
+			for _, tag := range entryTags {
+				names[tag.Tag] = true
+				result.Tags = append(result.Tags, tag.Tag)
+			}
+			matched := true
+			for _, tag := range tags {
+				matched = matched && names[tag]
+			}
+			if matched {
+				results = append(results, result)
+			}
+		}
+	}
+	return results, nil
+}
+
+func TestFindEntriesMatchesPerEntryLookup(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer testDB.Close()
+	ctx := context.Background()
+
+	journalIDs := seedBenchmarkDB(t, testDB, 3, 20, 5)
+	tags := []string{"tag-1", "tag-2"}
+
+	want, err := findEntriesPerEntry(ctx, testDB, journalIDs, tags)
+	if err != nil {
+		t.Fatalf("findEntriesPerEntry failed: %v", err)
+	}
+	got, _, err := FindEntries(ctx, testDB, EntryFilter{JournalIDs: journalIDs, Tags: tags}, Page{})
+	if err != nil {
+		t.Fatalf("FindEntries failed: %v", err)
+	}
+	if len(got) == 0 || len(got) != len(want) {
+		t.Fatalf("Expected %d entries, got %d", len(want), len(got))
+	}
+	wantIDs := make(map[uuid.UUID]bool, len(want))
+	for _, entry := range want {
+		wantIDs[entry.ID] = true
+	}
+	for _, entry := range got {
+		if !wantIDs[entry.ID] || len(entry.Tags) != 2 {
+			t.Errorf("Unexpected entry: %+v", entry)
+		}
+	}
+}
+
+// BenchmarkFindEntries compares the single FindEntries query with the per-entry lookups
+// it replaces, on 10,000 entries in 20 journals. Run it with:
+//
+//	go test -run '^$' -bench FindEntries ./pkg/memories
+func BenchmarkFindEntries(b *testing.B) {
+	ctx := context.Background()
+	testDB, err := db.OpenDBConnection(":memory:", true, "NORMAL")
+	if err != nil {
+		b.Fatalf("Failed to open in-memory database: %v", err)
+	}
+	defer testDB.Close()
+	if err := db.InitializeSchema(testDB, db.TargetSchemaVersion); err != nil {
+		b.Fatalf("Failed to initialize schema: %v", err)
+	}
+	journalIDs := seedBenchmarkDB(b, testDB, 20, 500, 50)
+	tags := []string{"tag-3", "tag-4"}
+
+	b.Run("PerEntry", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := findEntriesPerEntry(ctx, testDB, journalIDs, tags); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("SingleQuery", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, _, err := FindEntries(ctx, testDB, EntryFilter{JournalIDs: journalIDs, Tags: tags}, Page{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("SingleQueryAnyTag", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, _, err := FindEntries(ctx, testDB, EntryFilter{JournalIDs: journalIDs, Tags: tags, MatchAnyTag: true}, Page{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("SingleQueryWithoutIndexes", func(b *testing.B) {
+		if _, err := testDB.Exec(db.DropSchemaV5); err != nil {
+			b.Fatalf("Failed to drop indexes: %v", err)
+		}
+		defer testDB.Exec(db.SchemaV5)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, _, err := FindEntries(ctx, testDB, EntryFilter{JournalIDs: journalIDs, Tags: tags}, Page{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package memories
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// DefaultLinkDepth is how many links RelatedEntries follows from an entry when asked
+// for a depth of 0.
+const DefaultLinkDepth = 1
+
+// RelatedEntry is an entry of an EntryGraph with the number of links followed to reach it.
+type RelatedEntry struct {
+	TaggedEntry
+	Depth int `json:"depth"`
+}
+
+// EntryGraph is a set of entries and the links between them.
+type EntryGraph struct {
+	Entries []RelatedEntry `json:"entries"`
+	Links   []EntryLink    `json:"links"`
+}
+
+// GraphQuery selects the links RelatedEntries follows.
+type GraphQuery struct {
+	// Depth is how many links to follow from the entry, or DefaultLinkDepth for 0.
+	Depth int
+	// Relations limits the links followed to these relations; empty means all of them.
+	Relations []LinkRelation
+	// JournalIDs limits the entries reached to those of these journals; empty means all
+	// journals.
+	JournalIDs []uuid.UUID
+}
+
+// follows reports whether the query follows links with relation.
+func (q GraphQuery) follows(relation LinkRelation) bool {
+	if len(q.Relations) == 0 {
+		return true
+	}
+	for _, r := range q.Relations {
+		if r == relation {
+			return true
+		}
+	}
+	return false
+}
+
+// RelatedEntries follows the links of an entry in both directions, breadth first, and
+// returns the entries it reaches, starting with the entry itself at depth 0, together
+// with the links between them. Deleted and expired entries are not followed through.
+func RelatedEntries(ctx context.Context, store Store, entryID uuid.UUID, query GraphQuery) (EntryGraph, error) {
+	depth := query.Depth
+	if depth <= 0 {
+		depth = DefaultLinkDepth
+	}
+	start, _, err := store.FindEntries(ctx, EntryFilter{EntryIDs: []uuid.UUID{entryID}, IncludeDeleted: true}, Page{})
+	if err != nil {
+		return EntryGraph{}, err
+	}
+	if len(start) == 0 {
+		return EntryGraph{}, ErrEntryNotFound
+	}
+
+	graph := EntryGraph{Entries: []RelatedEntry{{TaggedEntry: start[0]}}}
+	reached := map[uuid.UUID]bool{entryID: true}
+	frontier := []uuid.UUID{entryID}
+	for hop := 1; hop <= depth && len(frontier) > 0; hop++ {
+		links, err := store.ListEntryLinks(ctx, frontier)
+		if err != nil {
+			return EntryGraph{}, err
+		}
+		var candidates []uuid.UUID
+		for _, link := range links {
+			if !query.follows(link.Relation) {
+				continue
+			}
+			for _, id := range []uuid.UUID{link.SourceID, link.TargetID} {
+				if !reached[id] {
+					reached[id] = true
+					candidates = append(candidates, id)
+				}
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+
+		found, _, err := store.FindEntries(ctx, EntryFilter{JournalIDs: query.JournalIDs, EntryIDs: candidates}, Page{})
+		if err != nil {
+			return EntryGraph{}, err
+		}
+		frontier = frontier[:0]
+		for _, entry := range found {
+			graph.Entries = append(graph.Entries, RelatedEntry{TaggedEntry: entry, Depth: hop})
+			frontier = append(frontier, entry.ID)
+		}
+	}
+
+	graph.Links, err = linksBetween(ctx, store, graph.Entries, query)
+	return graph, err
+}
+
+// JournalGraph returns the entries of a journal that are neither deleted nor expired and
+// the links between them with one of relations, or any relation for none.
+func JournalGraph(ctx context.Context, store Store, journalID uuid.UUID, relations ...LinkRelation) (EntryGraph, error) {
+	if _, err := store.GetJournal(ctx, journalID); err != nil {
+		return EntryGraph{}, err
+	}
+	found, _, err := store.FindEntries(ctx, EntryFilter{JournalIDs: []uuid.UUID{journalID}}, Page{})
+	if err != nil {
+		return EntryGraph{}, err
+	}
+
+	var graph EntryGraph
+	graph.Entries = make([]RelatedEntry, len(found))
+	for i, entry := range found {
+		graph.Entries[i] = RelatedEntry{TaggedEntry: entry}
+	}
+	graph.Links, err = linksBetween(ctx, store, graph.Entries, GraphQuery{Relations: relations})
+	return graph, err
+}
+
+// linksBetween returns the links followed by query that join two of entries.
+func linksBetween(ctx context.Context, store Store, entries []RelatedEntry, query GraphQuery) ([]EntryLink, error) {
+	ids := make([]uuid.UUID, len(entries))
+	included := make(map[uuid.UUID]bool, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+		included[entry.ID] = true
+	}
+	links, err := store.ListEntryLinks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	between := []EntryLink{}
+	for _, link := range links {
+		if included[link.SourceID] && included[link.TargetID] && query.follows(link.Relation) {
+			between = append(between, link)
+		}
+	}
+	return between, nil
+}
+
+// WriteDOT writes graph to w in the Graphviz DOT language, with entries as nodes labeled
+// by title and links as edges labeled by relation.
+func WriteDOT(w io.Writer, graph EntryGraph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph recall {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [shape=box];")
+	for _, entry := range graph.Entries {
+		fmt.Fprintf(bw, "  %s [label=%s];\n", dotQuote(entry.ID.String()), dotQuote(entry.Title))
+	}
+	for _, link := range graph.Links {
+		fmt.Fprintf(bw, "  %s -> %s [label=%s];\n",
+			dotQuote(link.SourceID.String()), dotQuote(link.TargetID.String()), dotQuote(string(link.Relation)))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotQuoter escapes the characters that end or break a DOT quoted string.
+var dotQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", `\n`)
+
+// dotQuote returns s as a DOT quoted string.
+func dotQuote(s string) string {
+	return `"` + dotQuoter.Replace(s) + `"`
+}
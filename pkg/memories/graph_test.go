@@ -0,0 +1,33 @@
+package memories
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestWriteDOT(t *testing.T) {
+	source := RelatedEntry{TaggedEntry: TaggedEntry{Entry: Entry{ID: uuid.MustParse("11111111-1111-1111-1111-111111111111"), Title: `Say "hi"`}}}
+	target := RelatedEntry{TaggedEntry: TaggedEntry{Entry: Entry{ID: uuid.MustParse("22222222-2222-2222-2222-222222222222"), Title: "C:\\notes\nline two"}}}
+	graph := EntryGraph{
+		Entries: []RelatedEntry{source, target},
+		Links:   []EntryLink{{SourceID: source.ID, TargetID: target.ID, Relation: LinkSupersedes}},
+	}
+
+	var b strings.Builder
+	if err := WriteDOT(&b, graph); err != nil {
+		t.Fatalf("WriteDOT failed: %v", err)
+	}
+	want := `digraph recall {
+  rankdir=LR;
+  node [shape=box];
+  "11111111-1111-1111-1111-111111111111" [label="Say \"hi\""];
+  "22222222-2222-2222-2222-222222222222" [label="C:\\notes\nline two"];
+  "11111111-1111-1111-1111-111111111111" -> "22222222-2222-2222-2222-222222222222" [label="supersedes"];
+}
+`
+	if got := b.String(); got != want {
+		t.Errorf("Unexpected DOT output:\n%s\nwant:\n%s", got, want)
+	}
+}
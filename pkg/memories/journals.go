@@ -19,18 +19,27 @@ const (
 	`
 
 	getJournalStatement = `
-	SELECT id, name, description, active, created_at, updated_at 
+	SELECT id, name, description, active, created_at, updated_at, expiry_policy 
 	FROM journals 
 	WHERE id = ?
 	`
 
 	listJournalsStatement = `
-	SELECT id, name, description, active, created_at, updated_at 
+	SELECT id, name, description, active, created_at, updated_at, expiry_policy 
 	FROM journals
 	WHERE active = ? OR ? = false
 	ORDER BY updated_at DESC
 	`
 
+	listJournalsPageStatement = `
+	SELECT id, name, description, active, created_at, updated_at, expiry_policy
+	FROM journals
+	WHERE (active = ? OR ? = false)
+		AND (? OR updated_at < ? OR (updated_at = ? AND id < ?))
+	ORDER BY updated_at DESC, id DESC
+	LIMIT ?
+	`
+
 	updateJournalStatement = `
 	UPDATE journals 
 	SET name = ?, description = ?, active = ?, updated_at = unixepoch()
@@ -76,6 +85,7 @@ func GetJournal(ctx context.Context, db *sql.DB, id uuid.UUID) (Journal, error)
 		&journal.Active,
 		&journal.CreatedAt,
 		&journal.UpdatedAt,
+		&journal.ExpiryPolicy,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -87,7 +97,8 @@ func GetJournal(ctx context.Context, db *sql.DB, id uuid.UUID) (Journal, error)
 	return journal, nil
 }
 
-// TODO: Add pagination support
+// ListJournals returns every journal, most recently updated first. ListJournalsPage reads
+// them a page at a time.
 func ListJournals(ctx context.Context, db *sql.DB, activeOnly bool) ([]Journal, error) {
 	rows, err := db.QueryContext(ctx, listJournalsStatement, activeOnly, activeOnly)
 	if err != nil {
@@ -106,6 +117,7 @@ func ListJournals(ctx context.Context, db *sql.DB, activeOnly bool) ([]Journal,
 			&journal.Active,
 			&journal.CreatedAt,
 			&journal.UpdatedAt,
+			&journal.ExpiryPolicy,
 		)
 		if err != nil {
 			return nil, err
@@ -121,6 +133,41 @@ func ListJournals(ctx context.Context, db *sql.DB, activeOnly bool) ([]Journal,
 	return journals, nil
 }
 
+// ListJournalsPage returns a page of journals, most recently updated first, and the
+// cursor of the next page, or an empty string on the last page.
+func ListJournalsPage(ctx context.Context, db *sql.DB, activeOnly bool, page Page) ([]Journal, string, error) {
+	keyset, err := page.keysetArgs()
+	if err != nil {
+		return nil, "", err
+	}
+	limit := page.fetchLimit()
+	if limit == 0 {
+		limit = -1 // No limit.
+	}
+	args := append([]any{activeOnly, activeOnly}, keyset...)
+	rows, err := db.QueryContext(ctx, listJournalsPageStatement, append(args, limit)...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var journals []Journal
+	for rows.Next() {
+		journal, err := scanJournal(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		journals = append(journals, journal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	journals, next := trimPage(journals, page, journalKey)
+	return journals, next, nil
+}
+
 func UpdateJournal(ctx context.Context, db *sql.DB, id uuid.UUID, name, description string, active bool) (Journal, error) {
 	res, err := db.ExecContext(
 		ctx,
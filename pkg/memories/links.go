@@ -0,0 +1,168 @@
+package memories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// LinkRelation is the type of a link between two entries.
+type LinkRelation string
+
+const (
+	// LinkRelatesTo links an entry to another one worth reading with it, as "see also".
+	LinkRelatesTo LinkRelation = "relates_to"
+	// LinkSupersedes links an entry to an older one it replaces.
+	LinkSupersedes LinkRelation = "supersedes"
+	// LinkDependsOn links an entry to one it builds on.
+	LinkDependsOn LinkRelation = "depends_on"
+)
+
+// LinkRelations are the relations links can have.
+var LinkRelations = []LinkRelation{LinkRelatesTo, LinkSupersedes, LinkDependsOn}
+
+var (
+	ErrInvalidLinkRelation = errors.New("invalid link relation")
+	ErrLinkNotFound        = errors.New("link not found")
+	ErrSelfLink            = errors.New("an entry cannot link to itself")
+)
+
+// ParseLinkRelation returns the relation named s.
+func ParseLinkRelation(s string) (LinkRelation, error) {
+	names := make([]string, len(LinkRelations))
+	for i, relation := range LinkRelations {
+		if string(relation) == s {
+			return relation, nil
+		}
+		names[i] = string(relation)
+	}
+	return "", fmt.Errorf("%w %q: must be one of %s", ErrInvalidLinkRelation, s, strings.Join(names, ", "))
+}
+
+const (
+	linkEntriesStatement = `
+	INSERT INTO entry_links (source_id, target_id, relation)
+	VALUES (?, ?, ?)
+	ON CONFLICT (source_id, target_id, relation) DO NOTHING
+	`
+
+	getEntryLinkStatement = `
+	SELECT source_id, target_id, relation, created_at
+	FROM entry_links
+	WHERE source_id = ? AND target_id = ? AND relation = ?
+	`
+
+	unlinkEntriesStatement = `
+	DELETE FROM entry_links
+	WHERE source_id = ? AND target_id = ? AND (relation = ? OR ? = '')
+	`
+)
+
+// entryLinksQuery builds the statement and arguments for ListEntryLinks with ?
+// placeholders.
+func entryLinksQuery(entryIDs []uuid.UUID) (string, []any) {
+	ids := placeholderList(len(entryIDs))
+	query := `
+	SELECT source_id, target_id, relation, created_at
+	FROM entry_links
+	WHERE source_id IN (` + ids + `) OR target_id IN (` + ids + `)
+	ORDER BY created_at, source_id, target_id, relation
+	`
+	args := make([]any, 2*len(entryIDs))
+	for i, id := range entryIDs {
+		args[i] = id
+		args[len(entryIDs)+i] = id
+	}
+	return query, args
+}
+
+// scanEntryLinks collects the rows of a statement returning links.
+func scanEntryLinks(rows *sql.Rows) ([]EntryLink, error) {
+	links := []EntryLink{}
+	for rows.Next() {
+		var link EntryLink
+		if err := rows.Scan(&link.SourceID, &link.TargetID, &link.Relation, &link.CreatedAt); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// checkLink validates the relation and ends of a link about to be made.
+func checkLink(sourceID, targetID uuid.UUID, relation LinkRelation) error {
+	if _, err := ParseLinkRelation(string(relation)); err != nil {
+		return err
+	}
+	if sourceID == targetID {
+		return ErrSelfLink
+	}
+	return nil
+}
+
+// LinkEntries links the source entry to the target entry with relation. Linking entries
+// that are already linked with relation returns the existing link.
+func LinkEntries(ctx context.Context, db *sql.DB, sourceID, targetID uuid.UUID, relation LinkRelation) (EntryLink, error) {
+	if err := checkLink(sourceID, targetID, relation); err != nil {
+		return EntryLink{}, err
+	}
+	for _, id := range []uuid.UUID{sourceID, targetID} {
+		if _, err := GetEntry(ctx, db, id); err != nil {
+			return EntryLink{}, err
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, linkEntriesStatement, sourceID, targetID, relation); err != nil {
+		return EntryLink{}, err
+	}
+
+	var link EntryLink
+	err := db.QueryRowContext(ctx, getEntryLinkStatement, sourceID, targetID, relation).
+		Scan(&link.SourceID, &link.TargetID, &link.Relation, &link.CreatedAt)
+	return link, err
+}
+
+// UnlinkEntries removes the link with relation from the source entry to the target entry,
+// or the links of every relation between them for an empty relation.
+func UnlinkEntries(ctx context.Context, db *sql.DB, sourceID, targetID uuid.UUID, relation LinkRelation) error {
+	if relation != "" {
+		if _, err := ParseLinkRelation(string(relation)); err != nil {
+			return err
+		}
+	}
+
+	res, err := db.ExecContext(ctx, unlinkEntriesStatement, sourceID, targetID, relation, relation)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// ListEntryLinks returns the links from or to any of entryIDs, oldest first.
+func ListEntryLinks(ctx context.Context, db *sql.DB, entryIDs []uuid.UUID) ([]EntryLink, error) {
+	if len(entryIDs) == 0 {
+		return []EntryLink{}, nil
+	}
+
+	query, args := entryLinksQuery(entryIDs)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanEntryLinks(rows)
+}
@@ -0,0 +1,1240 @@
+package memories
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore is a Store that keeps everything in memory, for tests and short-lived
+// programs that embed recall without cgo or a database file. It is safe for concurrent
+// use and behaves like the SQLite store, including soft deletes, revisions and errors.
+// Its content can be copied out with Snapshot and back in with NewMemoryStoreFromSnapshot;
+// package portability converts snapshots to and from the JSONL export format.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	journals  map[uuid.UUID]Journal
+	entries   map[uuid.UUID]Entry
+	tags      map[string]Tag
+	entryTags map[uuid.UUID]map[string]EntryTag
+	revisions map[uuid.UUID][]EntryRevision
+	// embeddings holds the vectors of each entry by embedding model.
+	embeddings map[uuid.UUID]map[string][]float32
+	links      map[linkKey]EntryLink
+	aliases    map[string]TagAlias
+}
+
+// linkKey identifies a link like the primary key of entry_links.
+type linkKey struct {
+	sourceID, targetID uuid.UUID
+	relation           LinkRelation
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemorySnapshot is the content of a MemoryStore. Revision history and embeddings are not
+// included, matching what an export contains.
+type MemorySnapshot struct {
+	Journals  []Journal
+	Tags      []Tag
+	Aliases   []TagAlias
+	Entries   []Entry
+	EntryTags []EntryTag
+	Links     []EntryLink
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		journals:   make(map[uuid.UUID]Journal),
+		entries:    make(map[uuid.UUID]Entry),
+		tags:       make(map[string]Tag),
+		entryTags:  make(map[uuid.UUID]map[string]EntryTag),
+		revisions:  make(map[uuid.UUID][]EntryRevision),
+		embeddings: make(map[uuid.UUID]map[string][]float32),
+		links:      make(map[linkKey]EntryLink),
+		aliases:    make(map[string]TagAlias),
+	}
+}
+
+// NewMemoryStoreFromSnapshot returns a MemoryStore holding the records of snapshot, with
+// IDs and timestamps as given. Tags are normalized and resolved through the snapshot's
+// aliases, as they are when stored. Aliases and entry tags must refer to tags of the
+// snapshot, entries must belong to one of its journals and entry tags and links to its
+// entries.
+func NewMemoryStoreFromSnapshot(snapshot MemorySnapshot) (*MemoryStore, error) {
+	s := NewMemoryStore()
+	for _, journal := range snapshot.Journals {
+		if journal.ExpiryPolicy == "" {
+			journal.ExpiryPolicy = ExpirySoftDelete
+		}
+		s.journals[journal.ID] = journal
+	}
+	aliases := make(map[string]string, len(snapshot.Aliases))
+	for _, tagAlias := range snapshot.Aliases {
+		aliases[NormalizeTag(tagAlias.Alias)] = NormalizeTag(tagAlias.Tag)
+	}
+	for _, tag := range snapshot.Tags {
+		if _, err := checkTag(tag.Tag); err != nil {
+			return nil, err
+		}
+		tag.Tag = canonicalTag(tag.Tag, aliases)
+		s.tags[tag.Tag] = tag
+	}
+	for _, tagAlias := range snapshot.Aliases {
+		alias, err := checkTag(tagAlias.Alias)
+		if err != nil {
+			return nil, err
+		}
+		tagAlias.Alias = alias
+		tagAlias.Tag = canonicalTag(tagAlias.Tag, aliases)
+		if err := checkAlias(alias, tagAlias.Tag); err != nil {
+			return nil, err
+		}
+		if _, ok := s.tags[tagAlias.Tag]; !ok {
+			return nil, fmt.Errorf("alias '%s' of tag '%s': %w", alias, tagAlias.Tag, ErrTagNotFound)
+		}
+		s.aliases[alias] = tagAlias
+	}
+	for _, entry := range snapshot.Entries {
+		if _, ok := s.journals[entry.JournalID]; !ok {
+			return nil, fmt.Errorf("entry %s: %w", entry.ID, ErrJournalNotFound)
+		}
+		s.entries[entry.ID] = entry
+	}
+	for _, entryTag := range snapshot.EntryTags {
+		if _, err := checkTag(entryTag.Tag); err != nil {
+			return nil, err
+		}
+		entryTag.Tag = canonicalTag(entryTag.Tag, aliases)
+		if _, ok := s.entries[entryTag.EntryID]; !ok {
+			return nil, fmt.Errorf("tag '%s' of entry %s: %w", entryTag.Tag, entryTag.EntryID, ErrEntryNotFound)
+		}
+		if _, ok := s.tags[entryTag.Tag]; !ok {
+			return nil, fmt.Errorf("tag '%s' of entry %s: %w", entryTag.Tag, entryTag.EntryID, ErrTagNotFound)
+		}
+		s.attachTag(entryTag)
+	}
+	for _, link := range snapshot.Links {
+		if err := checkLink(link.SourceID, link.TargetID, link.Relation); err != nil {
+			return nil, fmt.Errorf("link from entry %s to %s: %w", link.SourceID, link.TargetID, err)
+		}
+		for _, id := range []uuid.UUID{link.SourceID, link.TargetID} {
+			if _, ok := s.entries[id]; !ok {
+				return nil, fmt.Errorf("link from entry %s to %s: %w", link.SourceID, link.TargetID, ErrEntryNotFound)
+			}
+		}
+		s.links[linkKey{sourceID: link.SourceID, targetID: link.TargetID, relation: link.Relation}] = link
+	}
+	return s, nil
+}
+
+// Snapshot returns a copy of the store's journals, tags, tag aliases, entries (including
+// soft-deleted ones), entry tags and links, in the order an export lists them.
+func (s *MemoryStore) Snapshot() MemorySnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var snapshot MemorySnapshot
+	for _, journal := range s.journals {
+		snapshot.Journals = append(snapshot.Journals, journal)
+	}
+	sort.Slice(snapshot.Journals, func(i, j int) bool {
+		a, b := snapshot.Journals[i], snapshot.Journals[j]
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt < b.CreatedAt
+		}
+		return a.ID.String() < b.ID.String()
+	})
+
+	snapshot.Tags = s.sortedTags(func(string) bool { return true })
+
+	for _, tagAlias := range s.aliases {
+		snapshot.Aliases = append(snapshot.Aliases, tagAlias)
+	}
+	sort.Slice(snapshot.Aliases, func(i, j int) bool {
+		a, b := snapshot.Aliases[i], snapshot.Aliases[j]
+		if a.Tag != b.Tag {
+			return a.Tag < b.Tag
+		}
+		return a.Alias < b.Alias
+	})
+
+	for _, entry := range s.entries {
+		snapshot.Entries = append(snapshot.Entries, entry)
+	}
+	sort.Slice(snapshot.Entries, func(i, j int) bool {
+		a, b := snapshot.Entries[i], snapshot.Entries[j]
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt < b.CreatedAt
+		}
+		return a.ID.String() < b.ID.String()
+	})
+
+	for _, entry := range snapshot.Entries {
+		tags := s.entryTags[entry.ID]
+		names := make([]string, 0, len(tags))
+		for name := range tags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			snapshot.EntryTags = append(snapshot.EntryTags, tags[name])
+		}
+	}
+
+	for _, link := range s.links {
+		snapshot.Links = append(snapshot.Links, link)
+	}
+	sortEntryLinks(snapshot.Links)
+	return snapshot
+}
+
+// Close does nothing; the store stays usable and keeps its content.
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// now returns the current time in Unix seconds, like unixepoch() in the SQL stores
+// but with sub-second precision so that ordering by time is stable within a second.
+func now() float64 {
+	return float64(time.Now().UnixNano()) / 1e9
+}
+
+func (s *MemoryStore) CreateJournal(ctx context.Context, name, description string) (Journal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timestamp := now()
+	journal := Journal{
+		ID:           uuid.New(),
+		Name:         name,
+		Description:  description,
+		Active:       true,
+		CreatedAt:    timestamp,
+		UpdatedAt:    timestamp,
+		ExpiryPolicy: ExpirySoftDelete,
+	}
+	s.journals[journal.ID] = journal
+	return journal, nil
+}
+
+func (s *MemoryStore) GetJournal(ctx context.Context, id uuid.UUID) (Journal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	journal, ok := s.journals[id]
+	if !ok {
+		return Journal{}, ErrJournalNotFound
+	}
+	return journal, nil
+}
+
+func (s *MemoryStore) ListJournals(ctx context.Context, activeOnly bool) ([]Journal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var journals []Journal
+	for _, journal := range s.journals {
+		if activeOnly && !journal.Active {
+			continue
+		}
+		journals = append(journals, journal)
+	}
+	sort.Slice(journals, func(i, j int) bool {
+		return journals[i].UpdatedAt > journals[j].UpdatedAt
+	})
+	return journals, nil
+}
+
+func (s *MemoryStore) ListJournalsPage(ctx context.Context, activeOnly bool, page Page) ([]Journal, string, error) {
+	journals, err := s.ListJournals(ctx, activeOnly)
+	if err != nil {
+		return nil, "", err
+	}
+	return paginate(journals, page, journalKey)
+}
+
+func (s *MemoryStore) UpdateJournal(ctx context.Context, id uuid.UUID, name, description string, active bool) (Journal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	journal, ok := s.journals[id]
+	if !ok {
+		return Journal{}, ErrJournalNotFound
+	}
+	journal.Name = name
+	journal.Description = description
+	journal.Active = active
+	journal.UpdatedAt = now()
+	s.journals[id] = journal
+	return journal, nil
+}
+
+func (s *MemoryStore) DeleteJournal(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.journals[id]; !ok {
+		return ErrJournalNotFound
+	}
+	s.deleteJournal(id)
+	return nil
+}
+
+func (s *MemoryStore) DeleteInactiveJournals(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for id, journal := range s.journals {
+		if !journal.Active {
+			s.deleteJournal(id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// deleteJournal removes a journal and, like ON DELETE CASCADE, its entries. s.mu must be held.
+func (s *MemoryStore) deleteJournal(id uuid.UUID) {
+	delete(s.journals, id)
+	s.deleteEntries(func(entry Entry) bool { return entry.JournalID == id })
+}
+
+// deleteEntries removes the entries for which match returns true, with their tags and
+// revisions, and returns how many were removed. s.mu must be held.
+func (s *MemoryStore) deleteEntries(match func(Entry) bool) int64 {
+	var count int64
+	for id, entry := range s.entries {
+		if match(entry) {
+			delete(s.entries, id)
+			delete(s.entryTags, id)
+			delete(s.revisions, id)
+			delete(s.embeddings, id)
+			count++
+		}
+	}
+	for key := range s.links {
+		if _, ok := s.entries[key.sourceID]; !ok {
+			delete(s.links, key)
+		} else if _, ok := s.entries[key.targetID]; !ok {
+			delete(s.links, key)
+		}
+	}
+	return count
+}
+
+func (s *MemoryStore) CreateEntry(ctx context.Context, journalID uuid.UUID, title, content, contentType string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.journals[journalID]; !ok {
+		return Entry{}, ErrJournalNotFound
+	}
+
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+
+	timestamp := now()
+	entry := Entry{
+		ID:          uuid.New(),
+		JournalID:   journalID,
+		Title:       title,
+		Content:     content,
+		ContentType: contentType,
+		CreatedAt:   timestamp,
+		UpdatedAt:   timestamp,
+	}
+	s.entries[entry.ID] = entry
+	return entry, nil
+}
+
+func (s *MemoryStore) GetEntry(ctx context.Context, id uuid.UUID) (Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return Entry{}, ErrEntryNotFound
+	}
+	return entry, nil
+}
+
+func (s *MemoryStore) ListEntries(ctx context.Context, journalID uuid.UUID, includeDeleted bool) ([]Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.journals[journalID]; !ok {
+		return nil, ErrJournalNotFound
+	}
+
+	at := now()
+	var entries []Entry
+	for _, entry := range s.entries {
+		if entry.JournalID != journalID || (!includeDeleted && !entry.live(at)) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].UpdatedAt > entries[j].UpdatedAt
+	})
+	return entries, nil
+}
+
+// ListEntriesPage pages through the entries ListEntries returns, most recently updated first.
+func (s *MemoryStore) ListEntriesPage(ctx context.Context, journalID uuid.UUID, includeDeleted bool, page Page) ([]Entry, string, error) {
+	entries, err := s.ListEntries(ctx, journalID, includeDeleted)
+	if err != nil {
+		return nil, "", err
+	}
+	return paginate(entries, page, entryKey)
+}
+
+// UpdateEntry overwrites an entry's title, content and content type; empty values keep the current ones.
+func (s *MemoryStore) UpdateEntry(ctx context.Context, id uuid.UUID, title, content, contentType string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return Entry{}, ErrEntryNotFound
+	}
+
+	if title == "" {
+		title = entry.Title
+	}
+	if content == "" {
+		content = entry.Content
+	}
+	if contentType == "" {
+		contentType = entry.ContentType
+	}
+
+	return s.replaceEntryContent(ctx, entry, title, content, contentType), nil
+}
+
+// replaceEntryContent stores existing as a new revision, when its content changes, and
+// overwrites the entry with the given values. s.mu must be held.
+func (s *MemoryStore) replaceEntryContent(ctx context.Context, existing Entry, title, content, contentType string) Entry {
+	timestamp := now()
+	if title != existing.Title || content != existing.Content || contentType != existing.ContentType {
+		revisions := s.revisions[existing.ID]
+		s.revisions[existing.ID] = append(revisions, EntryRevision{
+			EntryID:     existing.ID,
+			Revision:    int64(len(revisions)) + 1,
+			Title:       existing.Title,
+			Content:     existing.Content,
+			ContentType: existing.ContentType,
+			Actor:       ActorFromContext(ctx),
+			CreatedAt:   timestamp,
+		})
+		delete(s.embeddings, existing.ID)
+	}
+
+	existing.Title = title
+	existing.Content = content
+	existing.ContentType = contentType
+	existing.UpdatedAt = timestamp
+	s.entries[existing.ID] = existing
+	return existing
+}
+
+func (s *MemoryStore) DeleteEntry(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return ErrEntryNotFound
+	}
+	entry.Deleted = true
+	entry.UpdatedAt = now()
+	s.entries[id] = entry
+	return nil
+}
+
+func (s *MemoryStore) DeleteEntriesByJournal(ctx context.Context, journalID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.journals[journalID]; !ok {
+		return 0, ErrJournalNotFound
+	}
+	return s.deleteEntries(func(entry Entry) bool { return entry.JournalID == journalID }), nil
+}
+
+func (s *MemoryStore) CleanDeletedEntries(ctx context.Context, journalID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.journals[journalID]; !ok {
+		return 0, ErrJournalNotFound
+	}
+	return s.deleteEntries(func(entry Entry) bool { return entry.JournalID == journalID && entry.Deleted }), nil
+}
+
+func (s *MemoryStore) CleanDeletedEntriesBefore(ctx context.Context, journalID uuid.UUID, before float64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.journals[journalID]; !ok {
+		return 0, ErrJournalNotFound
+	}
+	return s.deleteEntries(func(entry Entry) bool {
+		return entry.JournalID == journalID && entry.Deleted && entry.UpdatedAt < before
+	}), nil
+}
+
+func (s *MemoryStore) RestoreEntry(ctx context.Context, id uuid.UUID) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return Entry{}, ErrEntryNotFound
+	}
+	timestamp := now()
+	if entry.live(timestamp) {
+		return Entry{}, ErrEntryNotDeleted
+	}
+	entry.Deleted = false
+	if entry.ExpiresAt > 0 && entry.ExpiresAt <= timestamp {
+		entry.ExpiresAt = 0
+	}
+	entry.UpdatedAt = timestamp
+	s.entries[id] = entry
+	return entry, nil
+}
+
+// ListEntryRevisions returns the prior versions of an entry, newest first.
+func (s *MemoryStore) ListEntryRevisions(ctx context.Context, entryID uuid.UUID) ([]EntryRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.entries[entryID]; !ok {
+		return nil, ErrEntryNotFound
+	}
+
+	var revisions []EntryRevision
+	stored := s.revisions[entryID]
+	for i := len(stored) - 1; i >= 0; i-- {
+		revisions = append(revisions, stored[i])
+	}
+	return revisions, nil
+}
+
+func (s *MemoryStore) GetEntryRevision(ctx context.Context, entryID uuid.UUID, revision int64) (EntryRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.entryRevision(entryID, revision)
+}
+
+// entryRevision looks up a revision. s.mu must be held.
+func (s *MemoryStore) entryRevision(entryID uuid.UUID, revision int64) (EntryRevision, error) {
+	if _, ok := s.entries[entryID]; !ok {
+		return EntryRevision{}, ErrEntryNotFound
+	}
+	revisions := s.revisions[entryID]
+	if revision < 1 || revision > int64(len(revisions)) {
+		return EntryRevision{}, ErrRevisionNotFound
+	}
+	return revisions[revision-1], nil
+}
+
+// RestoreEntryRevision brings an entry back to a prior revision, recording the replaced version as a new one.
+func (s *MemoryStore) RestoreEntryRevision(ctx context.Context, entryID uuid.UUID, revision int64) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entryRevision, err := s.entryRevision(entryID, revision)
+	if err != nil {
+		return Entry{}, err
+	}
+	return s.replaceEntryContent(ctx, s.entries[entryID], entryRevision.Title, entryRevision.Content, entryRevision.ContentType), nil
+}
+
+func (s *MemoryStore) CreateTag(ctx context.Context, tagName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tagName, err := s.resolveTag(tagName)
+	if err != nil {
+		return err
+	}
+	s.createTag(tagName)
+	return nil
+}
+
+// createTag adds a tag unless it already exists. s.mu must be held.
+func (s *MemoryStore) createTag(tagName string) {
+	if _, ok := s.tags[tagName]; ok {
+		return
+	}
+	timestamp := now()
+	s.tags[tagName] = Tag{Tag: tagName, CreatedAt: timestamp, UpdatedAt: timestamp}
+}
+
+// attachTag records entryTag unless the entry already has the tag. s.mu must be held.
+func (s *MemoryStore) attachTag(entryTag EntryTag) {
+	tags, ok := s.entryTags[entryTag.EntryID]
+	if !ok {
+		tags = make(map[string]EntryTag)
+		s.entryTags[entryTag.EntryID] = tags
+	}
+	if _, ok := tags[entryTag.Tag]; !ok {
+		tags[entryTag.Tag] = entryTag
+	}
+}
+
+func (s *MemoryStore) TagEntry(ctx context.Context, entryID uuid.UUID, tagName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[entryID]; !ok {
+		return ErrEntryNotFound
+	}
+	tagName, err := s.resolveTag(tagName)
+	if err != nil {
+		return err
+	}
+	s.createTag(tagName)
+	s.attachTag(EntryTag{EntryID: entryID, Tag: tagName, CreatedAt: now()})
+	return nil
+}
+
+func (s *MemoryStore) DetachTag(ctx context.Context, entryID uuid.UUID, tagName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[entryID]; !ok {
+		return ErrEntryNotFound
+	}
+	tagName, err := s.resolveTag(tagName)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.entryTags[entryID][tagName]; !ok {
+		return ErrTagNotFound
+	}
+	delete(s.entryTags[entryID], tagName)
+	return nil
+}
+
+// sortedTags returns the tags for which include returns true, ordered by name. s.mu must be held.
+func (s *MemoryStore) sortedTags(include func(string) bool) []Tag {
+	var tags []Tag
+	for name, tag := range s.tags {
+		if include(name) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags
+}
+
+func (s *MemoryStore) ListTags(ctx context.Context, journalID uuid.UUID) ([]Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.journals[journalID]; !ok {
+		return nil, ErrJournalNotFound
+	}
+
+	used := make(map[string]bool)
+	for entryID, tags := range s.entryTags {
+		if s.entries[entryID].JournalID != journalID {
+			continue
+		}
+		for name := range tags {
+			used[name] = true
+		}
+	}
+	return s.sortedTags(func(name string) bool { return used[name] }), nil
+}
+
+func (s *MemoryStore) ListAllTags(ctx context.Context) ([]Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sortedTags(func(string) bool { return true }), nil
+}
+
+func (s *MemoryStore) ListTagsForEntry(ctx context.Context, entryID uuid.UUID) ([]Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.entries[entryID]; !ok {
+		return nil, ErrEntryNotFound
+	}
+
+	tags := s.entryTags[entryID]
+	return s.sortedTags(func(name string) bool {
+		_, ok := tags[name]
+		return ok
+	}), nil
+}
+
+func (s *MemoryStore) DeleteTag(ctx context.Context, tagName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tagName = NormalizeTag(tagName)
+	if _, ok := s.tags[tagName]; !ok {
+		return ErrTagNotFound
+	}
+	delete(s.tags, tagName)
+	for _, tags := range s.entryTags {
+		delete(tags, tagName)
+	}
+	for alias, tagAlias := range s.aliases {
+		if tagAlias.Tag == tagName {
+			delete(s.aliases, alias)
+		}
+	}
+	return nil
+}
+
+// aliasTags maps every alias to the tag it stands for. s.mu must be held.
+func (s *MemoryStore) aliasTags() map[string]string {
+	aliases := make(map[string]string, len(s.aliases))
+	for alias, tagAlias := range s.aliases {
+		aliases[alias] = tagAlias.Tag
+	}
+	return aliases
+}
+
+// resolveTag returns the tag a tag about to be stored stands for, or ErrInvalidTag for
+// an empty one. s.mu must be held.
+func (s *MemoryStore) resolveTag(tag string) (string, error) {
+	if _, err := checkTag(tag); err != nil {
+		return "", err
+	}
+	return canonicalTag(tag, s.aliasTags()), nil
+}
+
+func (s *MemoryStore) ResolveTags(ctx context.Context, tags []string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return canonicalTags(tags, s.aliasTags()), nil
+}
+
+// mergeTags moves the entries and aliases of the tag from to the tag to, creating to if
+// needed, and deletes from. It returns the number of entries that carried from. s.mu must
+// be held.
+func (s *MemoryStore) mergeTags(from, to string) int64 {
+	s.createTag(to)
+	var retagged int64
+	for entryID, tags := range s.entryTags {
+		entryTag, ok := tags[from]
+		if !ok {
+			continue
+		}
+		delete(tags, from)
+		s.attachTag(EntryTag{EntryID: entryID, Tag: to, CreatedAt: entryTag.CreatedAt})
+		retagged++
+	}
+	for alias, tagAlias := range s.aliases {
+		if tagAlias.Tag == from {
+			tagAlias.Tag = to
+			s.aliases[alias] = tagAlias
+		}
+	}
+	delete(s.tags, from)
+	return retagged
+}
+
+func (s *MemoryStore) AliasTag(ctx context.Context, alias, tag string) (TagAlias, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alias, err := checkTag(alias)
+	if err != nil {
+		return TagAlias{}, err
+	}
+	tag, err = s.resolveTag(tag)
+	if err != nil {
+		return TagAlias{}, err
+	}
+	if err := checkAlias(alias, tag); err != nil {
+		return TagAlias{}, err
+	}
+
+	s.mergeTags(alias, tag)
+	tagAlias := TagAlias{Alias: alias, Tag: tag, CreatedAt: now()}
+	s.aliases[alias] = tagAlias
+	return tagAlias, nil
+}
+
+func (s *MemoryStore) DeleteTagAlias(ctx context.Context, alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alias = NormalizeTag(alias)
+	if _, ok := s.aliases[alias]; !ok {
+		return ErrTagAliasNotFound
+	}
+	delete(s.aliases, alias)
+	return nil
+}
+
+func (s *MemoryStore) ListTagAliases(ctx context.Context) ([]TagAlias, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	aliases := make([]TagAlias, 0, len(s.aliases))
+	for _, tagAlias := range s.aliases {
+		aliases = append(aliases, tagAlias)
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		if aliases[i].Tag != aliases[j].Tag {
+			return aliases[i].Tag < aliases[j].Tag
+		}
+		return aliases[i].Alias < aliases[j].Alias
+	})
+	return aliases, nil
+}
+
+func (s *MemoryStore) MergeTags(ctx context.Context, from, to string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from, err := checkTag(from)
+	if err != nil {
+		return 0, err
+	}
+	to, err = s.resolveTag(to)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkMerge(from, to); err != nil {
+		return 0, err
+	}
+	if _, ok := s.tags[from]; !ok {
+		return 0, ErrTagNotFound
+	}
+	return s.mergeTags(from, to), nil
+}
+
+// countTagMatches returns how many of queryTags an entry with tags carries, itself or
+// through a tag below it.
+func countTagMatches(queryTags []string, tags map[string]EntryTag) int {
+	count := 0
+	for _, queryTag := range queryTags {
+		for tag := range tags {
+			if tagMatches(queryTag, tag) {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+func (s *MemoryStore) SearchEntriesByTagMatch(ctx context.Context, journalID uuid.UUID, queryTags []string) ([]MatchedEntry, error) {
+	if len(queryTags) == 0 {
+		return []MatchedEntry{}, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := distinctTags(canonicalTags(queryTags, s.aliasTags()))
+
+	at := now()
+	var results []MatchedEntry
+	for entryID, tags := range s.entryTags {
+		entry := s.entries[entryID]
+		if entry.JournalID != journalID || !entry.live(at) {
+			continue
+		}
+		matchCount := countTagMatches(wanted, tags)
+		if matchCount > 0 {
+			results = append(results, MatchedEntry{Entry: entry, MatchCount: matchCount})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].MatchCount != results[j].MatchCount {
+			return results[i].MatchCount > results[j].MatchCount
+		}
+		return results[i].UpdatedAt > results[j].UpdatedAt
+	})
+	return results, nil
+}
+
+func (s *MemoryStore) FindEntries(ctx context.Context, filter EntryFilter, page Page) ([]TaggedEntry, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	filter = filter.withAliases(s.aliasTags())
+	journals := make(map[uuid.UUID]bool, len(filter.JournalIDs))
+	for _, id := range filter.JournalIDs {
+		journals[id] = true
+	}
+	ids := make(map[uuid.UUID]bool, len(filter.EntryIDs))
+	for _, id := range filter.EntryIDs {
+		ids[id] = true
+	}
+	wanted := distinctTags(filter.Tags)
+
+	at := now()
+	var results []TaggedEntry
+	for _, entry := range s.entries {
+		if !filter.IncludeDeleted && !entry.live(at) {
+			continue
+		}
+		if (len(journals) > 0 && !journals[entry.JournalID]) || (len(ids) > 0 && !ids[entry.ID]) {
+			continue
+		}
+		tags := s.entryTags[entry.ID]
+		matchCount := countTagMatches(wanted, tags)
+		if len(wanted) > 0 && (matchCount == 0 || (!filter.MatchAnyTag && matchCount < len(wanted))) {
+			continue
+		}
+		result := TaggedEntry{Entry: entry}
+		for name := range tags {
+			result.Tags = append(result.Tags, name)
+		}
+		sort.Strings(result.Tags)
+		if filter.Query != nil && !filter.Query.Match(entry, s.journals[entry.JournalID].Name, result.Tags) {
+			continue
+		}
+		results = append(results, result)
+	}
+	return paginate(results, page, taggedEntryKey)
+}
+
+func (s *MemoryStore) SetEntryEmbedding(ctx context.Context, entryID uuid.UUID, model string, vector []float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[entryID]; !ok {
+		return ErrEntryNotFound
+	}
+	if s.embeddings[entryID] == nil {
+		s.embeddings[entryID] = make(map[string][]float32)
+	}
+	s.embeddings[entryID][model] = append([]float32(nil), vector...)
+	return nil
+}
+
+func (s *MemoryStore) ListEntryEmbeddings(ctx context.Context, journalID uuid.UUID, model string) ([]EntryEmbedding, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	at := now()
+	var embeddings []EntryEmbedding
+	for _, entry := range s.entries {
+		if !entry.live(at) || (journalID != uuid.Nil && entry.JournalID != journalID) {
+			continue
+		}
+		embedding := EntryEmbedding{EntryID: entry.ID, Model: model}
+		if vector, ok := s.embeddings[entry.ID][model]; ok {
+			embedding.Vector = append([]float32(nil), vector...)
+		}
+		embeddings = append(embeddings, embedding)
+	}
+	return embeddings, nil
+}
+
+func (s *MemoryStore) RecordEntryAccesses(ctx context.Context, counts map[uuid.UUID]int64, at float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, count := range counts {
+		entry, ok := s.entries[id]
+		if !ok {
+			continue
+		}
+		entry.AccessCount += count
+		entry.LastAccessedAt = max(entry.LastAccessedAt, at)
+		s.entries[id] = entry
+	}
+	return nil
+}
+
+func (s *MemoryStore) SetEntryExpiry(ctx context.Context, id uuid.UUID, expiresAt float64) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return Entry{}, ErrEntryNotFound
+	}
+	entry.ExpiresAt = expiresAt
+	s.entries[id] = entry
+	return entry, nil
+}
+
+func (s *MemoryStore) SetJournalExpiryPolicy(ctx context.Context, id uuid.UUID, policy ExpiryPolicy) (Journal, error) {
+	if _, err := ParseExpiryPolicy(string(policy)); err != nil {
+		return Journal{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	journal, ok := s.journals[id]
+	if !ok {
+		return Journal{}, ErrJournalNotFound
+	}
+	journal.ExpiryPolicy = policy
+	journal.UpdatedAt = now()
+	s.journals[id] = journal
+	return journal, nil
+}
+
+func (s *MemoryStore) ReapExpiredEntries(ctx context.Context, at float64) (ReapResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := func(entry Entry, policy ExpiryPolicy) bool {
+		return entry.ExpiresAt > 0 && entry.ExpiresAt <= at && s.journals[entry.JournalID].ExpiryPolicy == policy
+	}
+	var result ReapResult
+	result.Purged = s.deleteEntries(func(entry Entry) bool { return expired(entry, ExpiryPurge) })
+	timestamp := now()
+	for id, entry := range s.entries {
+		if !entry.Deleted && expired(entry, ExpirySoftDelete) {
+			entry.Deleted = true
+			entry.UpdatedAt = timestamp
+			s.entries[id] = entry
+			result.Deleted++
+		}
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) LinkEntries(ctx context.Context, sourceID, targetID uuid.UUID, relation LinkRelation) (EntryLink, error) {
+	if err := checkLink(sourceID, targetID, relation); err != nil {
+		return EntryLink{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range []uuid.UUID{sourceID, targetID} {
+		if _, ok := s.entries[id]; !ok {
+			return EntryLink{}, ErrEntryNotFound
+		}
+	}
+	key := linkKey{sourceID: sourceID, targetID: targetID, relation: relation}
+	if link, ok := s.links[key]; ok {
+		return link, nil
+	}
+	link := EntryLink{SourceID: sourceID, TargetID: targetID, Relation: relation, CreatedAt: now()}
+	s.links[key] = link
+	return link, nil
+}
+
+func (s *MemoryStore) UnlinkEntries(ctx context.Context, sourceID, targetID uuid.UUID, relation LinkRelation) error {
+	if relation != "" {
+		if _, err := ParseLinkRelation(string(relation)); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := false
+	for key := range s.links {
+		if key.sourceID == sourceID && key.targetID == targetID && (relation == "" || key.relation == relation) {
+			delete(s.links, key)
+			removed = true
+		}
+	}
+	if !removed {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// ListEntryLinks returns the links from or to any of entryIDs in the order of the SQL
+// stores: by creation time, then source, target and relation.
+func (s *MemoryStore) ListEntryLinks(ctx context.Context, entryIDs []uuid.UUID) ([]EntryLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[uuid.UUID]bool, len(entryIDs))
+	for _, id := range entryIDs {
+		wanted[id] = true
+	}
+	links := []EntryLink{}
+	for key, link := range s.links {
+		if wanted[key.sourceID] || wanted[key.targetID] {
+			links = append(links, link)
+		}
+	}
+	sortEntryLinks(links)
+	return links, nil
+}
+
+// sortEntryLinks sorts links like the SQL stores list them: by creation time, then
+// source, target and relation.
+func sortEntryLinks(links []EntryLink) {
+	sort.Slice(links, func(i, j int) bool {
+		a, b := links[i], links[j]
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt < b.CreatedAt
+		}
+		if a.SourceID != b.SourceID {
+			return a.SourceID.String() < b.SourceID.String()
+		}
+		if a.TargetID != b.TargetID {
+			return a.TargetID.String() < b.TargetID.String()
+		}
+		return a.Relation < b.Relation
+	})
+}
+
+// SearchEntriesFullText ranks entries with BM25 computed over the words of every stored
+// entry, weighting titles like the SQLite store does.
+func (s *MemoryStore) SearchEntriesFullText(ctx context.Context, journalID uuid.UUID, query string, limit int) ([]FullTextMatch, error) {
+	terms := make(map[string]bool)
+	for _, word := range fullTextWords(query) {
+		terms[strings.ToLower(word)] = true
+	}
+	if len(terms) == 0 {
+		return []FullTextMatch{}, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Like an FTS index, document frequencies and average lengths include deleted entries.
+	type document struct {
+		entry   Entry
+		columns [2][]string
+	}
+	documents := make([]document, 0, len(s.entries))
+	docsWithTerm := make(map[string]float64)
+	var totalLengths [2]float64
+	for _, entry := range s.entries {
+		doc := document{entry: entry, columns: [2][]string{lowerWords(entry.Title), lowerWords(entry.Content)}}
+		seen := make(map[string]bool)
+		for c, words := range doc.columns {
+			totalLengths[c] += float64(len(words))
+			for _, word := range words {
+				if terms[word] && !seen[word] {
+					seen[word] = true
+					docsWithTerm[word]++
+				}
+			}
+		}
+		documents = append(documents, doc)
+	}
+
+	rowCount := float64(len(documents))
+	weights := [2]float64{bm25TitleWeight, bm25ContentWeight}
+	results := []FullTextMatch{}
+	at := now()
+	for _, doc := range documents {
+		if !doc.entry.live(at) || (journalID != uuid.Nil && doc.entry.JournalID != journalID) {
+			continue
+		}
+
+		var score float64
+		matched := false
+		for term := range terms {
+			idf := math.Log((rowCount - docsWithTerm[term] + 0.5) / (docsWithTerm[term] + 0.5))
+			if idf <= 0 {
+				idf = 1e-6
+			}
+			for c, words := range doc.columns {
+				termFreq := 0.0
+				for _, word := range words {
+					if word == term {
+						termFreq++
+					}
+				}
+				if termFreq == 0 {
+					continue
+				}
+				matched = true
+				avgLength := math.Max(totalLengths[c]/rowCount, 1)
+				norm := 1 - bm25B + bm25B*float64(len(words))/avgLength
+				score += weights[c] * idf * termFreq * (bm25K1 + 1) / (termFreq + bm25K1*norm)
+			}
+		}
+		if matched {
+			results = append(results, FullTextMatch{Entry: doc.entry, Score: score, Snippet: memorySnippet(doc.entry, terms)})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].UpdatedAt > results[j].UpdatedAt
+	})
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// lowerWords splits text into lower-cased full-text words.
+func lowerWords(text string) []string {
+	words := fullTextWords(text)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return words
+}
+
+// memorySnippet returns up to 16 words around the first matched term of the entry's
+// content, or its title when the content does not match, with matches wrapped in "**".
+func memorySnippet(entry Entry, terms map[string]bool) string {
+	const snippetWords = 16
+
+	text := entry.Content
+	if !containsTerm(text, terms) {
+		text = entry.Title
+	}
+	words := strings.Fields(text)
+
+	first := 0
+	for i, word := range words {
+		if containsTerm(word, terms) {
+			first = i
+			break
+		}
+	}
+	start := max(0, first-snippetWords/4)
+	end := min(len(words), start+snippetWords)
+
+	parts := make([]string, 0, end-start+2)
+	if start > 0 {
+		parts = append(parts, "...")
+	}
+	for _, word := range words[start:end] {
+		if containsTerm(word, terms) {
+			word = "**" + word + "**"
+		}
+		parts = append(parts, word)
+	}
+	if end < len(words) {
+		parts = append(parts, "...")
+	}
+	return strings.Join(parts, " ")
+}
+
+// containsTerm reports whether any full-text word of text is one of terms.
+func containsTerm(text string, terms map[string]bool) bool {
+	for _, word := range lowerWords(text) {
+		if terms[word] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package memories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMemoryStoreConcurrentUse(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	journal, err := store.CreateJournal(ctx, "work", "")
+	if err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				entry, err := store.CreateEntry(ctx, journal.ID, fmt.Sprintf("Entry %d-%d", i, j), "content", "")
+				if err != nil {
+					t.Errorf("CreateEntry failed: %v", err)
+					return
+				}
+				if err := store.TagEntry(ctx, entry.ID, fmt.Sprintf("worker-%d", i)); err != nil {
+					t.Errorf("TagEntry failed: %v", err)
+				}
+				if _, err := store.UpdateEntry(ctx, entry.ID, "", "updated", ""); err != nil {
+					t.Errorf("UpdateEntry failed: %v", err)
+				}
+				if _, err := store.SearchEntriesFullText(ctx, journal.ID, "updated", 5); err != nil {
+					t.Errorf("SearchEntriesFullText failed: %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	entries, err := store.ListEntries(ctx, journal.ID, false)
+	if err != nil || len(entries) != 200 {
+		t.Errorf("Expected 200 entries, got %d (%v)", len(entries), err)
+	}
+	tags, err := store.ListTags(ctx, journal.ID)
+	if err != nil || len(tags) != 8 {
+		t.Errorf("Expected 8 tags, got %d (%v)", len(tags), err)
+	}
+}
+
+func TestMemoryStoreSnapshot(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	journal, err := store.CreateJournal(ctx, "work", "Work notes")
+	if err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+	kept, err := store.CreateEntry(ctx, journal.ID, "Plan", "Ship it", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	deleted, err := store.CreateEntry(ctx, journal.ID, "Old plan", "", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	if err := store.TagEntry(ctx, kept.ID, "go"); err != nil {
+		t.Fatalf("TagEntry failed: %v", err)
+	}
+	if err := store.CreateTag(ctx, "unused"); err != nil {
+		t.Fatalf("CreateTag failed: %v", err)
+	}
+	if err := store.DeleteEntry(ctx, deleted.ID); err != nil {
+		t.Fatalf("DeleteEntry failed: %v", err)
+	}
+
+	snapshot := store.Snapshot()
+	if len(snapshot.Journals) != 1 || len(snapshot.Entries) != 2 || len(snapshot.Tags) != 2 || len(snapshot.EntryTags) != 1 {
+		t.Fatalf("Unexpected snapshot: %+v", snapshot)
+	}
+
+	restored, err := NewMemoryStoreFromSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("NewMemoryStoreFromSnapshot failed: %v", err)
+	}
+	entry, err := restored.GetEntry(ctx, kept.ID)
+	if err != nil || entry != kept {
+		t.Errorf("Expected the entry to keep its ID and timestamps, got %+v (%v)", entry, err)
+	}
+	gone, err := restored.GetEntry(ctx, deleted.ID)
+	if err != nil || !gone.Deleted {
+		t.Errorf("Expected the soft-deleted entry to stay deleted, got %+v (%v)", gone, err)
+	}
+	entryTags, err := restored.ListTagsForEntry(ctx, kept.ID)
+	if err != nil || tagNames(entryTags) != "go" {
+		t.Errorf("Expected tag go, got %s (%v)", tagNames(entryTags), err)
+	}
+
+	snapshot.Entries[0].JournalID = uuid.New()
+	if _, err := NewMemoryStoreFromSnapshot(snapshot); !errors.Is(err, ErrJournalNotFound) {
+		t.Errorf("Expected ErrJournalNotFound for an entry without its journal, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package memories
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Page asks for one page of a listing ordered by update time and ID, newest first.
+// Cursors are keyed on the last item of the previous page rather than an offset, so
+// paging stays stable while entries are added, and a page costs the same however deep
+// it is.
+type Page struct {
+	// Limit is the maximum number of items on the page; 0 means no limit.
+	Limit int
+	// Cursor is the next cursor returned with the previous page, or empty for the first page.
+	Cursor string
+}
+
+// pageKey is the position of an item in a listing: its update time and ID.
+type pageKey struct {
+	updatedAt float64
+	id        uuid.UUID
+}
+
+// cursor encodes key as an opaque cursor.
+func (k pageKey) cursor() string {
+	raw := strconv.FormatFloat(k.updatedAt, 'g', -1, 64) + "," + k.id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// before reports whether k sorts before other, that is, was updated later.
+func (k pageKey) before(other pageKey) bool {
+	if k.updatedAt != other.updatedAt {
+		return k.updatedAt > other.updatedAt
+	}
+	return k.id.String() > other.id.String()
+}
+
+// after decodes the cursor of p. ok is false for the first page.
+func (p Page) after() (key pageKey, ok bool, err error) {
+	if p.Cursor == "" {
+		return pageKey{}, false, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return pageKey{}, false, ErrInvalidCursor
+	}
+	updatedAt, id, found := strings.Cut(string(raw), ",")
+	if !found {
+		return pageKey{}, false, ErrInvalidCursor
+	}
+	if key.updatedAt, err = strconv.ParseFloat(updatedAt, 64); err != nil {
+		return pageKey{}, false, ErrInvalidCursor
+	}
+	if key.id, err = uuid.Parse(id); err != nil {
+		return pageKey{}, false, ErrInvalidCursor
+	}
+	return key, true, nil
+}
+
+// keysetArgs returns the arguments for the condition
+// (? OR updated_at < ? OR (updated_at = ? AND id < ?)), which selects the rows after the
+// cursor of p.
+func (p Page) keysetArgs() ([]any, error) {
+	key, ok, err := p.after()
+	if err != nil {
+		return nil, err
+	}
+	return []any{!ok, key.updatedAt, key.updatedAt, key.id}, nil
+}
+
+// fetchLimit is the number of rows to read for p: one more than the limit, which tells
+// whether there is a next page, or 0 for no limit.
+func (p Page) fetchLimit() int {
+	if p.Limit <= 0 {
+		return 0
+	}
+	return p.Limit + 1
+}
+
+// trimPage cuts items read with fetchLimit down to the page and returns the cursor of
+// the next page, or an empty string if this is the last one.
+func trimPage[T any](items []T, page Page, key func(T) pageKey) ([]T, string) {
+	if page.Limit <= 0 || len(items) <= page.Limit {
+		return items, ""
+	}
+	items = items[:page.Limit]
+	return items, key(items[len(items)-1]).cursor()
+}
+
+// paginate sorts items newest first and returns the page of them selected by page.
+func paginate[T any](items []T, page Page, key func(T) pageKey) ([]T, string, error) {
+	after, ok, err := page.after()
+	if err != nil {
+		return nil, "", err
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return key(items[i]).before(key(items[j]))
+	})
+	if ok {
+		start := sort.Search(len(items), func(i int) bool {
+			return after.before(key(items[i]))
+		})
+		items = items[start:]
+	}
+	items, next := trimPage(items, page, key)
+	return items, next, nil
+}
+
+func journalKey(journal Journal) pageKey {
+	return pageKey{journal.UpdatedAt, journal.ID}
+}
+
+func entryKey(entry Entry) pageKey {
+	return pageKey{entry.UpdatedAt, entry.ID}
+}
+
+func taggedEntryKey(entry TaggedEntry) pageKey {
+	return entryKey(entry.Entry)
+}
+
+// PaginateJournals returns the page of journals selected by page, for callers that
+// filter a listing themselves before paging it. It sorts journals in place.
+func PaginateJournals(journals []Journal, page Page) ([]Journal, string, error) {
+	return paginate(journals, page, journalKey)
+}
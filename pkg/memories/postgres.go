@@ -0,0 +1,1240 @@
+package memories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	pgCreateJournalStatement = `
+	INSERT INTO journals (id, name, description, active)
+	VALUES ($1, $2, $3, $4)
+	`
+
+	pgGetJournalStatement = `
+	SELECT id, name, description, active, created_at, updated_at, expiry_policy
+	FROM journals
+	WHERE id = $1
+	`
+
+	pgListJournalsStatement = `
+	SELECT id, name, description, active, created_at, updated_at, expiry_policy
+	FROM journals
+	WHERE active = $1 OR $1 = false
+	ORDER BY updated_at DESC
+	`
+
+	pgListJournalsPageStatement = `
+	SELECT id, name, description, active, created_at, updated_at, expiry_policy
+	FROM journals
+	WHERE (active = $1 OR $1 = false)
+		AND ($2 OR updated_at < $3 OR (updated_at = $4 AND id < $5))
+	ORDER BY updated_at DESC, id DESC
+	LIMIT $6
+	`
+
+	pgUpdateJournalStatement = `
+	UPDATE journals
+	SET name = $1, description = $2, active = $3, updated_at = unixepoch()
+	WHERE id = $4
+	`
+
+	pgDeleteJournalStatement = `
+	DELETE FROM journals
+	WHERE id = $1
+	`
+
+	pgDeleteInactiveJournalsStatement = `
+	DELETE FROM journals
+	WHERE active = false
+	`
+
+	pgCreateEntryStatement = `
+	INSERT INTO entries (id, journal_id, title, content, content_type, deleted)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	pgGetEntryStatement = `
+	SELECT id, journal_id, title, content, content_type, deleted, created_at, updated_at, access_count, last_accessed_at, expires_at
+	FROM entries
+	WHERE id = $1
+	`
+
+	pgListEntriesStatement = `
+	SELECT id, journal_id, title, content, content_type, deleted, created_at, updated_at, access_count, last_accessed_at, expires_at
+	FROM entries
+	WHERE journal_id = $1 AND ((deleted = FALSE AND (expires_at = 0 OR expires_at > unixepoch())) OR $2 = TRUE)
+	ORDER BY updated_at DESC
+	`
+
+	pgListEntriesPageStatement = `
+	SELECT id, journal_id, title, content, content_type, deleted, created_at, updated_at, access_count, last_accessed_at, expires_at
+	FROM entries
+	WHERE journal_id = $1 AND ((deleted = FALSE AND (expires_at = 0 OR expires_at > unixepoch())) OR $2 = TRUE)
+		AND ($3 OR updated_at < $4 OR (updated_at = $5 AND id < $6))
+	ORDER BY updated_at DESC, id DESC
+	LIMIT $7
+	`
+
+	pgUpdateEntryStatement = `
+	UPDATE entries
+	SET title = $1, content = $2, content_type = $3, updated_at = unixepoch()
+	WHERE id = $4
+	`
+
+	pgSoftDeleteEntryStatement = `
+	UPDATE entries
+	SET deleted = TRUE, updated_at = unixepoch()
+	WHERE id = $1
+	`
+
+	pgCleanDeletedEntriesStatement = `
+	DELETE FROM entries
+	WHERE journal_id = $1 AND deleted = TRUE
+	`
+
+	pgCleanDeletedEntriesBeforeStatement = `
+	DELETE FROM entries
+	WHERE journal_id = $1 AND deleted = TRUE AND updated_at < $2
+	`
+
+	pgRestoreEntryStatement = `
+	UPDATE entries
+	SET deleted = FALSE,
+		expires_at = CASE WHEN expires_at > 0 AND expires_at <= unixepoch() THEN 0 ELSE expires_at END,
+		updated_at = unixepoch()
+	WHERE id = $1 AND (deleted = TRUE OR (expires_at > 0 AND expires_at <= unixepoch()))
+	`
+
+	pgDeleteEntriesByJournalStatement = `
+	DELETE FROM entries
+	WHERE journal_id = $1
+	`
+
+	// pgCreateEntryRevisionStatement numbers revisions per entry starting at 1.
+	pgCreateEntryRevisionStatement = `
+	INSERT INTO entry_revisions (entry_id, revision, title, content, content_type, actor)
+	SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5
+	FROM entry_revisions
+	WHERE entry_id = $1
+	`
+
+	pgListEntryRevisionsStatement = `
+	SELECT entry_id, revision, title, content, content_type, actor, created_at
+	FROM entry_revisions
+	WHERE entry_id = $1
+	ORDER BY revision DESC
+	`
+
+	pgGetEntryRevisionStatement = `
+	SELECT entry_id, revision, title, content, content_type, actor, created_at
+	FROM entry_revisions
+	WHERE entry_id = $1 AND revision = $2
+	`
+
+	pgCreateTagStatement = `
+	INSERT INTO tags (tag, created_at, updated_at)
+	VALUES ($1, unixepoch(), unixepoch())
+	ON CONFLICT DO NOTHING
+	`
+
+	pgAttachTagToEntryStatement = `
+	INSERT INTO entry_tags (entry_id, tag, created_at)
+	VALUES ($1, $2, unixepoch())
+	ON CONFLICT DO NOTHING
+	`
+
+	pgDetachTagFromEntryStatement = `
+	DELETE FROM entry_tags
+	WHERE entry_id = $1 AND tag = $2
+	`
+
+	pgListTagsStatement = `
+	SELECT t.tag, t.created_at, t.updated_at
+	FROM tags t
+	JOIN entry_tags et ON t.tag = et.tag
+	JOIN entries e ON et.entry_id = e.id
+	WHERE e.journal_id = $1
+	GROUP BY t.tag
+	ORDER BY t.tag
+	`
+
+	pgListAllTagsStatement = `
+	SELECT tag, created_at, updated_at
+	FROM tags
+	ORDER BY tag
+	`
+
+	pgListTagsForEntryStatement = `
+	SELECT t.tag, t.created_at, t.updated_at
+	FROM tags t
+	JOIN entry_tags et ON t.tag = et.tag
+	WHERE et.entry_id = $1
+	ORDER BY t.tag
+	`
+
+	pgDeleteTagStatement = `
+	DELETE FROM tags
+	WHERE tag = $1
+	`
+
+	pgGetTagAliasStatement = `
+	SELECT alias, tag, created_at
+	FROM tag_aliases
+	WHERE alias = $1
+	`
+
+	pgListTagAliasesStatement = `
+	SELECT alias, tag, created_at
+	FROM tag_aliases
+	ORDER BY tag, alias
+	`
+
+	pgSetTagAliasStatement = `
+	INSERT INTO tag_aliases (alias, tag)
+	VALUES ($1, $2)
+	ON CONFLICT (alias) DO UPDATE SET tag = excluded.tag, created_at = unixepoch()
+	`
+
+	pgDeleteTagAliasStatement = `
+	DELETE FROM tag_aliases
+	WHERE alias = $1
+	`
+
+	pgTagExistsStatement = `
+	SELECT COUNT(*)
+	FROM tags
+	WHERE tag = $1
+	`
+
+	pgRetagEntriesStatement = `
+	INSERT INTO entry_tags (entry_id, tag, created_at)
+	SELECT entry_id, $1, created_at FROM entry_tags WHERE tag = $2
+	ON CONFLICT (entry_id, tag) DO NOTHING
+	`
+
+	pgDetachTagFromAllEntriesStatement = `
+	DELETE FROM entry_tags
+	WHERE tag = $1
+	`
+
+	pgMoveTagAliasesStatement = `
+	UPDATE tag_aliases
+	SET tag = $1
+	WHERE tag = $2
+	`
+
+	// pgSearchFullTextStatement ranks with ts_rank, weighting title (A) matches ten times
+	// above content (B) matches as the SQLite BM25 ranking does. A NULL limit returns all rows.
+	pgSearchFullTextStatement = `
+	SELECT
+		e.id, e.journal_id, e.title, e.content, e.content_type, e.deleted, e.created_at, e.updated_at, e.access_count, e.last_accessed_at, e.expires_at,
+		ts_rank('{0, 0, 0.1, 1.0}'::float4[], e.search_vector, q.query) AS score,
+		ts_headline('simple', e.title || ' ' || e.content, q.query, 'StartSel=**, StopSel=**, MaxWords=16, MinWords=5')
+	FROM entries e, to_tsquery('simple', $1) AS q(query)
+	WHERE e.search_vector @@ q.query
+		AND e.deleted = FALSE
+		AND (e.expires_at = 0 OR e.expires_at > unixepoch())
+		AND ($2 OR e.journal_id = $3)
+	ORDER BY score DESC, e.updated_at DESC
+	LIMIT $4
+	`
+
+	pgSetEntryEmbeddingStatement = `
+	INSERT INTO entry_embeddings (entry_id, model, vector, created_at)
+	VALUES ($1, $2, $3, unixepoch())
+	ON CONFLICT (entry_id, model) DO UPDATE SET vector = excluded.vector, created_at = excluded.created_at
+	`
+
+	pgListEntryEmbeddingsStatement = `
+	SELECT e.id, ee.vector
+	FROM entries e
+	LEFT JOIN entry_embeddings ee ON ee.entry_id = e.id AND ee.model = $1
+	WHERE e.deleted = false AND (e.expires_at = 0 OR e.expires_at > unixepoch()) AND ($2 OR e.journal_id = $3)
+	`
+
+	pgDeleteEntryEmbeddingsStatement = `
+	DELETE FROM entry_embeddings
+	WHERE entry_id = $1
+	`
+
+	pgRecordEntryAccessStatement = `
+	UPDATE entries
+	SET access_count = access_count + $1, last_accessed_at = GREATEST(last_accessed_at, $2)
+	WHERE id = $3
+	`
+
+	pgSetEntryExpiryStatement = `
+	UPDATE entries
+	SET expires_at = $1
+	WHERE id = $2
+	`
+
+	pgSetJournalExpiryPolicyStatement = `
+	UPDATE journals
+	SET expiry_policy = $1, updated_at = unixepoch()
+	WHERE id = $2
+	`
+
+	pgSoftDeleteExpiredEntriesStatement = `
+	UPDATE entries
+	SET deleted = TRUE, updated_at = unixepoch()
+	WHERE deleted = FALSE AND expires_at > 0 AND expires_at <= $1
+		AND journal_id IN (SELECT id FROM journals WHERE expiry_policy = 'soft_delete')
+	`
+
+	pgPurgeExpiredEntriesStatement = `
+	DELETE FROM entries
+	WHERE expires_at > 0 AND expires_at <= $1
+		AND journal_id IN (SELECT id FROM journals WHERE expiry_policy = 'purge')
+	`
+
+	pgLinkEntriesStatement = `
+	INSERT INTO entry_links (source_id, target_id, relation)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (source_id, target_id, relation) DO NOTHING
+	`
+
+	pgGetEntryLinkStatement = `
+	SELECT source_id, target_id, relation, created_at
+	FROM entry_links
+	WHERE source_id = $1 AND target_id = $2 AND relation = $3
+	`
+
+	pgUnlinkEntriesStatement = `
+	DELETE FROM entry_links
+	WHERE source_id = $1 AND target_id = $2 AND (relation = $3 OR $3 = '')
+	`
+)
+
+// PostgresStore is the Store backed by a PostgreSQL database, as opened by
+// db.OpenPostgresConnection and migrated by db.UpgradePostgresDB. Full-text search uses
+// the entries' search_vector column instead of an FTS table, so scores are ts_rank values
+// rather than BM25 and are not comparable with the SQLite store's.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+var _ Store = (*PostgresStore)(nil)
+
+var pgTagMergeStatements = tagMergeStatements{
+	createTag:            pgCreateTagStatement,
+	retagEntries:         pgRetagEntriesStatement,
+	detachFromAllEntries: pgDetachTagFromAllEntriesStatement,
+	deleteTag:            pgDeleteTagStatement,
+	moveAliases:          pgMoveTagAliasesStatement,
+	setAlias:             pgSetTagAliasStatement,
+}
+
+// NewPostgresStore returns a Store using db. Closing the store closes db.
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// DB returns the underlying database, for operations outside the Store interface.
+func (s *PostgresStore) DB() *sql.DB {
+	return s.db
+}
+
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJournal(row rowScanner) (Journal, error) {
+	var journal Journal
+	err := row.Scan(
+		&journal.ID,
+		&journal.Name,
+		&journal.Description,
+		&journal.Active,
+		&journal.CreatedAt,
+		&journal.UpdatedAt,
+		&journal.ExpiryPolicy,
+	)
+	return journal, err
+}
+
+func scanEntry(row rowScanner, extra ...any) (Entry, error) {
+	var entry Entry
+	dest := append([]any{
+		&entry.ID,
+		&entry.JournalID,
+		&entry.Title,
+		&entry.Content,
+		&entry.ContentType,
+		&entry.Deleted,
+		&entry.CreatedAt,
+		&entry.UpdatedAt,
+		&entry.AccessCount,
+		&entry.LastAccessedAt,
+		&entry.ExpiresAt,
+	}, extra...)
+	err := row.Scan(dest...)
+	return entry, err
+}
+
+func scanEntryRevision(row rowScanner) (EntryRevision, error) {
+	var revision EntryRevision
+	err := row.Scan(
+		&revision.EntryID,
+		&revision.Revision,
+		&revision.Title,
+		&revision.Content,
+		&revision.ContentType,
+		&revision.Actor,
+		&revision.CreatedAt,
+	)
+	return revision, err
+}
+
+// queryTags runs a statement returning tag rows and collects them.
+func (s *PostgresStore) queryTags(ctx context.Context, statement string, args ...any) ([]Tag, error) {
+	rows, err := s.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []Tag
+	for rows.Next() {
+		var tag Tag
+		if err := rows.Scan(&tag.Tag, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// execExpectingRows runs statement and returns notFound if it changed no rows.
+func (s *PostgresStore) execExpectingRows(ctx context.Context, notFound error, statement string, args ...any) error {
+	res, err := s.db.ExecContext(ctx, statement, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) CreateJournal(ctx context.Context, name, description string) (Journal, error) {
+	journalID := uuid.New()
+
+	_, err := s.db.ExecContext(ctx, pgCreateJournalStatement, journalID, name, description, true)
+	if err != nil {
+		return Journal{}, err
+	}
+
+	return s.GetJournal(ctx, journalID)
+}
+
+func (s *PostgresStore) GetJournal(ctx context.Context, id uuid.UUID) (Journal, error) {
+	journal, err := scanJournal(s.db.QueryRowContext(ctx, pgGetJournalStatement, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Journal{}, ErrJournalNotFound
+		}
+		return Journal{}, err
+	}
+
+	return journal, nil
+}
+
+func (s *PostgresStore) ListJournals(ctx context.Context, activeOnly bool) ([]Journal, error) {
+	rows, err := s.db.QueryContext(ctx, pgListJournalsStatement, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var journals []Journal
+	for rows.Next() {
+		journal, err := scanJournal(rows)
+		if err != nil {
+			return nil, err
+		}
+		journals = append(journals, journal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return journals, nil
+}
+
+func (s *PostgresStore) ListJournalsPage(ctx context.Context, activeOnly bool, page Page) ([]Journal, string, error) {
+	keyset, err := page.keysetArgs()
+	if err != nil {
+		return nil, "", err
+	}
+	args := append([]any{activeOnly}, keyset...)
+	rows, err := s.db.QueryContext(ctx, pgListJournalsPageStatement, append(args, pgLimit(page))...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var journals []Journal
+	for rows.Next() {
+		journal, err := scanJournal(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		journals = append(journals, journal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	journals, next := trimPage(journals, page, journalKey)
+	return journals, next, nil
+}
+
+func (s *PostgresStore) UpdateJournal(ctx context.Context, id uuid.UUID, name, description string, active bool) (Journal, error) {
+	if err := s.execExpectingRows(ctx, ErrJournalNotFound, pgUpdateJournalStatement, name, description, active, id); err != nil {
+		return Journal{}, err
+	}
+
+	return s.GetJournal(ctx, id)
+}
+
+func (s *PostgresStore) DeleteJournal(ctx context.Context, id uuid.UUID) error {
+	return s.execExpectingRows(ctx, ErrJournalNotFound, pgDeleteJournalStatement, id)
+}
+
+func (s *PostgresStore) DeleteInactiveJournals(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, pgDeleteInactiveJournalsStatement)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *PostgresStore) CreateEntry(ctx context.Context, journalID uuid.UUID, title, content, contentType string) (Entry, error) {
+	if _, err := s.GetJournal(ctx, journalID); err != nil {
+		return Entry{}, err
+	}
+
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+
+	entryID := uuid.New()
+	_, err := s.db.ExecContext(ctx, pgCreateEntryStatement, entryID, journalID, title, content, contentType, false)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return s.GetEntry(ctx, entryID)
+}
+
+func (s *PostgresStore) GetEntry(ctx context.Context, id uuid.UUID) (Entry, error) {
+	entry, err := scanEntry(s.db.QueryRowContext(ctx, pgGetEntryStatement, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Entry{}, ErrEntryNotFound
+		}
+		return Entry{}, err
+	}
+
+	return entry, nil
+}
+
+func (s *PostgresStore) ListEntries(ctx context.Context, journalID uuid.UUID, includeDeleted bool) ([]Entry, error) {
+	if _, err := s.GetJournal(ctx, journalID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, pgListEntriesStatement, journalID, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (s *PostgresStore) ListEntriesPage(ctx context.Context, journalID uuid.UUID, includeDeleted bool, page Page) ([]Entry, string, error) {
+	if _, err := s.GetJournal(ctx, journalID); err != nil {
+		return nil, "", err
+	}
+	keyset, err := page.keysetArgs()
+	if err != nil {
+		return nil, "", err
+	}
+	args := append([]any{journalID, includeDeleted}, keyset...)
+	rows, err := s.db.QueryContext(ctx, pgListEntriesPageStatement, append(args, pgLimit(page))...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	entries, next := trimPage(entries, page, entryKey)
+	return entries, next, nil
+}
+
+// pgLimit is the LIMIT argument for reading page; NULL means no limit.
+func pgLimit(page Page) any {
+	if limit := page.fetchLimit(); limit > 0 {
+		return limit
+	}
+	return nil
+}
+
+// UpdateEntry overwrites an entry's title, content and content type; empty values keep the current ones.
+func (s *PostgresStore) UpdateEntry(ctx context.Context, id uuid.UUID, title, content, contentType string) (Entry, error) {
+	existingEntry, err := s.GetEntry(ctx, id)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if title == "" {
+		title = existingEntry.Title
+	}
+	if content == "" {
+		content = existingEntry.Content
+	}
+	if contentType == "" {
+		contentType = existingEntry.ContentType
+	}
+
+	return s.replaceEntryContent(ctx, existingEntry, title, content, contentType)
+}
+
+// replaceEntryContent stores existing as a new revision, when its content changes, and
+// overwrites the entry with the given values in the same transaction.
+func (s *PostgresStore) replaceEntryContent(ctx context.Context, existing Entry, title, content, contentType string) (Entry, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer tx.Rollback()
+
+	if title != existing.Title || content != existing.Content || contentType != existing.ContentType {
+		_, err = tx.ExecContext(
+			ctx,
+			pgCreateEntryRevisionStatement,
+			existing.ID,
+			existing.Title,
+			existing.Content,
+			existing.ContentType,
+			ActorFromContext(ctx),
+		)
+		if err != nil {
+			return Entry{}, err
+		}
+		if _, err = tx.ExecContext(ctx, pgDeleteEntryEmbeddingsStatement, existing.ID); err != nil {
+			return Entry{}, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, pgUpdateEntryStatement, title, content, contentType, existing.ID)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if rowsAffected == 0 {
+		return Entry{}, ErrEntryNotFound
+	}
+
+	if err = tx.Commit(); err != nil {
+		return Entry{}, err
+	}
+
+	return s.GetEntry(ctx, existing.ID)
+}
+
+func (s *PostgresStore) DeleteEntry(ctx context.Context, id uuid.UUID) error {
+	return s.execExpectingRows(ctx, ErrEntryNotFound, pgSoftDeleteEntryStatement, id)
+}
+
+func (s *PostgresStore) DeleteEntriesByJournal(ctx context.Context, journalID uuid.UUID) (int64, error) {
+	if _, err := s.GetJournal(ctx, journalID); err != nil {
+		return 0, err
+	}
+
+	res, err := s.db.ExecContext(ctx, pgDeleteEntriesByJournalStatement, journalID)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *PostgresStore) CleanDeletedEntries(ctx context.Context, journalID uuid.UUID) (int64, error) {
+	if _, err := s.GetJournal(ctx, journalID); err != nil {
+		return 0, err
+	}
+
+	res, err := s.db.ExecContext(ctx, pgCleanDeletedEntriesStatement, journalID)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *PostgresStore) CleanDeletedEntriesBefore(ctx context.Context, journalID uuid.UUID, before float64) (int64, error) {
+	if _, err := s.GetJournal(ctx, journalID); err != nil {
+		return 0, err
+	}
+
+	res, err := s.db.ExecContext(ctx, pgCleanDeletedEntriesBeforeStatement, journalID, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *PostgresStore) RestoreEntry(ctx context.Context, id uuid.UUID) (Entry, error) {
+	res, err := s.db.ExecContext(ctx, pgRestoreEntryStatement, id)
+	if err != nil {
+		return Entry{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return Entry{}, err
+	} else if n == 0 {
+		if _, err := s.GetEntry(ctx, id); err != nil {
+			return Entry{}, err
+		}
+		return Entry{}, ErrEntryNotDeleted
+	}
+	return s.GetEntry(ctx, id)
+}
+
+func (s *PostgresStore) ListEntryRevisions(ctx context.Context, entryID uuid.UUID) ([]EntryRevision, error) {
+	if _, err := s.GetEntry(ctx, entryID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, pgListEntryRevisionsStatement, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []EntryRevision
+	for rows.Next() {
+		revision, err := scanEntryRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (s *PostgresStore) GetEntryRevision(ctx context.Context, entryID uuid.UUID, revision int64) (EntryRevision, error) {
+	if _, err := s.GetEntry(ctx, entryID); err != nil {
+		return EntryRevision{}, err
+	}
+
+	entryRevision, err := scanEntryRevision(s.db.QueryRowContext(ctx, pgGetEntryRevisionStatement, entryID, revision))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return EntryRevision{}, ErrRevisionNotFound
+		}
+		return EntryRevision{}, err
+	}
+
+	return entryRevision, nil
+}
+
+// RestoreEntryRevision brings an entry back to a prior revision, recording the replaced version as a new one.
+func (s *PostgresStore) RestoreEntryRevision(ctx context.Context, entryID uuid.UUID, revision int64) (Entry, error) {
+	entryRevision, err := s.GetEntryRevision(ctx, entryID, revision)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	existingEntry, err := s.GetEntry(ctx, entryID)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return s.replaceEntryContent(ctx, existingEntry, entryRevision.Title, entryRevision.Content, entryRevision.ContentType)
+}
+
+func (s *PostgresStore) CreateTag(ctx context.Context, tagName string) error {
+	tagName, err := s.resolveTag(ctx, tagName)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, pgCreateTagStatement, tagName)
+	return err
+}
+
+func (s *PostgresStore) TagEntry(ctx context.Context, entryID uuid.UUID, tagName string) error {
+	if _, err := s.GetEntry(ctx, entryID); err != nil {
+		return err
+	}
+
+	tagName, err := s.resolveTag(ctx, tagName)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, pgCreateTagStatement, tagName); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, pgAttachTagToEntryStatement, entryID, tagName); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) DetachTag(ctx context.Context, entryID uuid.UUID, tagName string) error {
+	if _, err := s.GetEntry(ctx, entryID); err != nil {
+		return err
+	}
+
+	tagName, err := s.resolveTag(ctx, tagName)
+	if err != nil {
+		return err
+	}
+
+	return s.execExpectingRows(ctx, ErrTagNotFound, pgDetachTagFromEntryStatement, entryID, tagName)
+}
+
+func (s *PostgresStore) ListTags(ctx context.Context, journalID uuid.UUID) ([]Tag, error) {
+	if _, err := s.GetJournal(ctx, journalID); err != nil {
+		return nil, err
+	}
+
+	return s.queryTags(ctx, pgListTagsStatement, journalID)
+}
+
+func (s *PostgresStore) ListAllTags(ctx context.Context) ([]Tag, error) {
+	return s.queryTags(ctx, pgListAllTagsStatement)
+}
+
+func (s *PostgresStore) ListTagsForEntry(ctx context.Context, entryID uuid.UUID) ([]Tag, error) {
+	if _, err := s.GetEntry(ctx, entryID); err != nil {
+		return nil, err
+	}
+
+	return s.queryTags(ctx, pgListTagsForEntryStatement, entryID)
+}
+
+func (s *PostgresStore) DeleteTag(ctx context.Context, tagName string) error {
+	return s.execExpectingRows(ctx, ErrTagNotFound, pgDeleteTagStatement, NormalizeTag(tagName))
+}
+
+// tagAliases returns the aliases among tags, mapped to the tags they stand for.
+func (s *PostgresStore) tagAliases(ctx context.Context, tags []string) (map[string]string, error) {
+	if len(tags) == 0 {
+		return map[string]string{}, nil
+	}
+
+	query, args := tagAliasesQuery(tags)
+	rows, err := s.db.QueryContext(ctx, numberPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAliasMap(rows)
+}
+
+func (s *PostgresStore) ResolveTags(ctx context.Context, tags []string) ([]string, error) {
+	aliases, err := s.tagAliases(ctx, tags)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalTags(tags, aliases), nil
+}
+
+// resolveTag returns the tag a tag about to be stored stands for, or ErrInvalidTag for
+// an empty one.
+func (s *PostgresStore) resolveTag(ctx context.Context, tag string) (string, error) {
+	if _, err := checkTag(tag); err != nil {
+		return "", err
+	}
+	resolved, err := s.ResolveTags(ctx, []string{tag})
+	if err != nil {
+		return "", err
+	}
+	return resolved[0], nil
+}
+
+func (s *PostgresStore) AliasTag(ctx context.Context, alias, tag string) (TagAlias, error) {
+	alias, err := checkTag(alias)
+	if err != nil {
+		return TagAlias{}, err
+	}
+	tag, err = s.resolveTag(ctx, tag)
+	if err != nil {
+		return TagAlias{}, err
+	}
+	if err := checkAlias(alias, tag); err != nil {
+		return TagAlias{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return TagAlias{}, err
+	}
+	defer tx.Rollback()
+
+	if err := aliasTag(ctx, tx, pgTagMergeStatements, alias, tag); err != nil {
+		return TagAlias{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return TagAlias{}, err
+	}
+
+	var tagAlias TagAlias
+	err = s.db.QueryRowContext(ctx, pgGetTagAliasStatement, alias).Scan(&tagAlias.Alias, &tagAlias.Tag, &tagAlias.CreatedAt)
+	return tagAlias, err
+}
+
+func (s *PostgresStore) DeleteTagAlias(ctx context.Context, alias string) error {
+	return s.execExpectingRows(ctx, ErrTagAliasNotFound, pgDeleteTagAliasStatement, NormalizeTag(alias))
+}
+
+func (s *PostgresStore) ListTagAliases(ctx context.Context) ([]TagAlias, error) {
+	rows, err := s.db.QueryContext(ctx, pgListTagAliasesStatement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTagAliases(rows)
+}
+
+func (s *PostgresStore) MergeTags(ctx context.Context, from, to string) (int64, error) {
+	from, err := checkTag(from)
+	if err != nil {
+		return 0, err
+	}
+	to, err = s.resolveTag(ctx, to)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkMerge(from, to); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, pgTagExistsStatement, from).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, ErrTagNotFound
+	}
+	retagged, err := mergeTags(ctx, tx, pgTagMergeStatements, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return retagged, tx.Commit()
+}
+
+func (s *PostgresStore) SearchEntriesByTagMatch(ctx context.Context, journalID uuid.UUID, queryTags []string) ([]MatchedEntry, error) {
+	if len(queryTags) == 0 {
+		return []MatchedEntry{}, nil
+	}
+
+	queryTags, err := s.ResolveTags(ctx, queryTags)
+	if err != nil {
+		return nil, err
+	}
+	query, args := tagMatchQuery(journalID, queryTags)
+	statement := numberPlaceholders(query)
+	rows, err := s.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute search query: %w", err)
+	}
+	defer rows.Close()
+
+	var results []MatchedEntry
+	for rows.Next() {
+		var me MatchedEntry
+		me.Entry, err = scanEntry(rows, &me.MatchCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result row: %w", err)
+		}
+		results = append(results, me)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over search results: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *PostgresStore) SearchEntriesFullText(ctx context.Context, journalID uuid.UUID, query string, limit int) ([]FullTextMatch, error) {
+	words := fullTextWords(query)
+	if len(words) == 0 {
+		return []FullTextMatch{}, nil
+	}
+
+	// Words hold only letters and digits, so they cannot carry tsquery operators.
+	tsQuery := strings.Join(words, " | ")
+	var sqlLimit any
+	if limit > 0 {
+		sqlLimit = limit
+	}
+
+	rows, err := s.db.QueryContext(ctx, pgSearchFullTextStatement, tsQuery, journalID == uuid.Nil, journalID, sqlLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute full-text search query: %w", err)
+	}
+	defer rows.Close()
+
+	results := []FullTextMatch{}
+	for rows.Next() {
+		var match FullTextMatch
+		match.Entry, err = scanEntry(rows, &match.Score, &match.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan full-text search result row: %w", err)
+		}
+		results = append(results, match)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over full-text search results: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *PostgresStore) FindEntries(ctx context.Context, filter EntryFilter, page Page) ([]TaggedEntry, string, error) {
+	aliases, err := s.tagAliases(ctx, filter.tagNames())
+	if err != nil {
+		return nil, "", err
+	}
+	query, args, err := findEntriesQuery(filter.withAliases(aliases), page, "string_agg(et.tag, chr(31))")
+	if err != nil {
+		return nil, "", err
+	}
+	rows, err := s.db.QueryContext(ctx, numberPlaceholders(query), args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to execute find query: %w", err)
+	}
+	defer rows.Close()
+
+	entries, err := scanTaggedEntries(rows)
+	if err != nil {
+		return nil, "", err
+	}
+	entries, next := trimPage(entries, page, taggedEntryKey)
+	return entries, next, nil
+}
+
+func (s *PostgresStore) SetEntryEmbedding(ctx context.Context, entryID uuid.UUID, model string, vector []float32) error {
+	if _, err := s.GetEntry(ctx, entryID); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, pgSetEntryEmbeddingStatement, entryID, model, encodeVector(vector))
+	return err
+}
+
+func (s *PostgresStore) ListEntryEmbeddings(ctx context.Context, journalID uuid.UUID, model string) ([]EntryEmbedding, error) {
+	rows, err := s.db.QueryContext(ctx, pgListEntryEmbeddingsStatement, model, journalID == uuid.Nil, journalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanEntryEmbeddings(rows, model)
+}
+
+func (s *PostgresStore) RecordEntryAccesses(ctx context.Context, counts map[uuid.UUID]int64, at float64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pgRecordEntryAccessStatement)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for id, count := range counts {
+		if _, err := stmt.ExecContext(ctx, count, at, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) SetEntryExpiry(ctx context.Context, id uuid.UUID, expiresAt float64) (Entry, error) {
+	res, err := s.db.ExecContext(ctx, pgSetEntryExpiryStatement, expiresAt, id)
+	if err != nil {
+		return Entry{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return Entry{}, err
+	} else if n == 0 {
+		return Entry{}, ErrEntryNotFound
+	}
+	return s.GetEntry(ctx, id)
+}
+
+func (s *PostgresStore) SetJournalExpiryPolicy(ctx context.Context, id uuid.UUID, policy ExpiryPolicy) (Journal, error) {
+	if _, err := ParseExpiryPolicy(string(policy)); err != nil {
+		return Journal{}, err
+	}
+	res, err := s.db.ExecContext(ctx, pgSetJournalExpiryPolicyStatement, policy, id)
+	if err != nil {
+		return Journal{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return Journal{}, err
+	} else if n == 0 {
+		return Journal{}, ErrJournalNotFound
+	}
+	return s.GetJournal(ctx, id)
+}
+
+func (s *PostgresStore) ReapExpiredEntries(ctx context.Context, now float64) (ReapResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ReapResult{}, err
+	}
+	defer tx.Rollback()
+	result, err := reapExpiredEntries(ctx, tx, pgSoftDeleteExpiredEntriesStatement, pgPurgeExpiredEntriesStatement, now)
+	if err != nil {
+		return ReapResult{}, err
+	}
+	return result, tx.Commit()
+}
+
+func (s *PostgresStore) LinkEntries(ctx context.Context, sourceID, targetID uuid.UUID, relation LinkRelation) (EntryLink, error) {
+	if err := checkLink(sourceID, targetID, relation); err != nil {
+		return EntryLink{}, err
+	}
+	for _, id := range []uuid.UUID{sourceID, targetID} {
+		if _, err := s.GetEntry(ctx, id); err != nil {
+			return EntryLink{}, err
+		}
+	}
+
+	if _, err := s.db.ExecContext(ctx, pgLinkEntriesStatement, sourceID, targetID, relation); err != nil {
+		return EntryLink{}, err
+	}
+
+	var link EntryLink
+	err := s.db.QueryRowContext(ctx, pgGetEntryLinkStatement, sourceID, targetID, relation).
+		Scan(&link.SourceID, &link.TargetID, &link.Relation, &link.CreatedAt)
+	return link, err
+}
+
+func (s *PostgresStore) UnlinkEntries(ctx context.Context, sourceID, targetID uuid.UUID, relation LinkRelation) error {
+	if relation != "" {
+		if _, err := ParseLinkRelation(string(relation)); err != nil {
+			return err
+		}
+	}
+	return s.execExpectingRows(ctx, ErrLinkNotFound, pgUnlinkEntriesStatement, sourceID, targetID, relation)
+}
+
+func (s *PostgresStore) ListEntryLinks(ctx context.Context, entryIDs []uuid.UUID) ([]EntryLink, error) {
+	if len(entryIDs) == 0 {
+		return []EntryLink{}, nil
+	}
+
+	query, args := entryLinksQuery(entryIDs)
+	rows, err := s.db.QueryContext(ctx, numberPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanEntryLinks(rows)
+}
+
+// numberPlaceholders rewrites the ? placeholders of a generated statement as $1, $2, ...
+// The statements it is used on contain no ? inside literals.
+func numberPlaceholders(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
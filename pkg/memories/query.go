@@ -0,0 +1,556 @@
+package memories
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidQuery = errors.New("invalid tag query")
+)
+
+// TagQuery is a parsed boolean query over entry tags and fields. Queries are written as
+//
+//	go AND (proj-* OR "release notes") NOT archived journal:work updated:>2026-01-01
+//
+// Terms are tag names, which also match the tags below them in a path ("go" matches
+// "go/sql"); a trailing * matches every tag with that prefix, and double quotes keep
+// spaces, parentheses and operators literal. Terms next to each other must all match, as
+// with AND. OR binds looser than AND, NOT and a leading - negate the next term, and
+// parentheses group. Operators are only recognised in upper case.
+//
+// A term of the form field:value matches a field of the entry instead of a tag:
+//
+//   - journal:name matches the name of the entry's journal.
+//   - type:text/markdown matches the content type; type:text/* matches a prefix.
+//   - updated:>2026-01-01 compares the update time with >, >=, <, <= or = (the default).
+//     A date covers the whole UTC day; RFC 3339 times are compared exactly.
+//   - tag:name matches a tag, for tags that contain a colon or look like a field.
+//
+// Other words containing a colon are tag names.
+//
+// FindEntries compares tag terms like the tags they are stored as: normalized, and with
+// aliases replaced by the tags they stand for.
+type TagQuery struct {
+	text string
+	root queryNode
+}
+
+// queryTarget is an entry as seen by TagQuery.Match.
+type queryTarget struct {
+	entry   Entry
+	journal string
+	tags    []string
+}
+
+// queryNode is a node of a parsed TagQuery.
+type queryNode interface {
+	// sql returns a condition on the entries table aliased e, with ? placeholders.
+	sql() (string, []any)
+	match(target queryTarget) bool
+}
+
+type andNode struct{ left, right queryNode }
+type orNode struct{ left, right queryNode }
+type notNode struct{ operand queryNode }
+
+func (n andNode) sql() (string, []any) {
+	left, leftArgs := n.left.sql()
+	right, rightArgs := n.right.sql()
+	return "(" + left + " AND " + right + ")", append(leftArgs, rightArgs...)
+}
+
+func (n andNode) match(target queryTarget) bool {
+	return n.left.match(target) && n.right.match(target)
+}
+
+func (n orNode) sql() (string, []any) {
+	left, leftArgs := n.left.sql()
+	right, rightArgs := n.right.sql()
+	return "(" + left + " OR " + right + ")", append(leftArgs, rightArgs...)
+}
+
+func (n orNode) match(target queryTarget) bool {
+	return n.left.match(target) || n.right.match(target)
+}
+
+func (n notNode) sql() (string, []any) {
+	operand, args := n.operand.sql()
+	return "NOT " + operand, args
+}
+
+func (n notNode) match(target queryTarget) bool {
+	return !n.operand.match(target)
+}
+
+// textMatch is an exact or prefix match on a text value.
+type textMatch struct {
+	value  string
+	prefix bool
+}
+
+// sql returns a condition comparing column with the value. A prefix is matched as the
+// range of values from the prefix up to the first string after all those starting with
+// it, which unlike LIKE is case-sensitive on both SQLite and PostgreSQL and can use an
+// index on column.
+func (m textMatch) sql(column string) (string, []any) {
+	if !m.prefix {
+		return column + " = ?", []any{m.value}
+	}
+	upper, ok := prefixUpperBound(m.value)
+	if !ok {
+		return column + " >= ?", []any{m.value}
+	}
+	return "(" + column + " >= ? AND " + column + " < ?)", []any{m.value, upper}
+}
+
+// prefixUpperBound returns the smallest string greater than every string starting with
+// prefix, or false if there is none, as for an empty prefix.
+func prefixUpperBound(prefix string) (string, bool) {
+	runes := []rune(prefix)
+	for i := len(runes) - 1; i >= 0; i-- {
+		next := runes[i] + 1
+		if next == 0xD800 {
+			// Surrogates can't be encoded in UTF-8.
+			next = 0xE000
+		}
+		if next <= utf8.MaxRune {
+			runes[i] = next
+			return string(runes[:i+1]), true
+		}
+	}
+	return "", false
+}
+
+func (m textMatch) match(value string) bool {
+	if m.prefix {
+		return strings.HasPrefix(value, m.value)
+	}
+	return value == m.value
+}
+
+type journalNode struct{ textMatch }
+type typeNode struct{ textMatch }
+
+// tagNode matches a tag, or with an exact match a tag and the tags below it.
+type tagNode struct{ textMatch }
+
+func (n tagNode) sql() (string, []any) {
+	condition, args := n.textMatch.sql("tag")
+	if !n.prefix {
+		condition, args = tagMatchSQL("tag", n.value)
+	}
+	return "e.id IN (SELECT entry_id FROM entry_tags WHERE " + condition + ")", args
+}
+
+func (n tagNode) match(target queryTarget) bool {
+	for _, tag := range target.tags {
+		if (n.prefix && n.textMatch.match(tag)) || (!n.prefix && tagMatches(n.value, tag)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n journalNode) sql() (string, []any) {
+	condition, args := n.textMatch.sql("name")
+	return "e.journal_id IN (SELECT id FROM journals WHERE " + condition + ")", args
+}
+
+func (n journalNode) match(target queryTarget) bool {
+	return n.textMatch.match(target.journal)
+}
+
+func (n typeNode) sql() (string, []any) {
+	return n.textMatch.sql("e.content_type")
+}
+
+func (n typeNode) match(target queryTarget) bool {
+	return n.textMatch.match(target.entry.ContentType)
+}
+
+// updatedNode compares the update time of an entry with a Unix time in seconds.
+type updatedNode struct {
+	op   string
+	time float64
+}
+
+func (n updatedNode) sql() (string, []any) {
+	return "e.updated_at " + n.op + " ?", []any{n.time}
+}
+
+func (n updatedNode) match(target queryTarget) bool {
+	updatedAt := target.entry.UpdatedAt
+	switch n.op {
+	case ">":
+		return updatedAt > n.time
+	case ">=":
+		return updatedAt >= n.time
+	case "<":
+		return updatedAt < n.time
+	case "<=":
+		return updatedAt <= n.time
+	default:
+		return updatedAt == n.time
+	}
+}
+
+// ParseTagQuery parses a query in the syntax described on TagQuery. Errors wrap
+// ErrInvalidQuery.
+func ParseTagQuery(query string) (*TagQuery, error) {
+	tokens, err := lexTagQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: empty query", ErrInvalidQuery)
+	}
+	p := &queryParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %s", p.tokens[p.pos])
+	}
+	return &TagQuery{text: query, root: root}, nil
+}
+
+// String returns the query as it was written.
+func (q *TagQuery) String() string {
+	return q.text
+}
+
+// tagNames returns the tags the exact tag terms of the query name.
+func (q *TagQuery) tagNames() []string {
+	var names []string
+	mapTagNodes(q.root, func(n tagNode) tagNode {
+		if !n.prefix {
+			names = append(names, n.value)
+		}
+		return n
+	})
+	return names
+}
+
+// withAliases returns the query with its tag terms normalized and its exact tag terms
+// resolved through aliases, which maps normalized aliases to the tags they stand for.
+func (q *TagQuery) withAliases(aliases map[string]string) *TagQuery {
+	root := mapTagNodes(q.root, func(n tagNode) tagNode {
+		if n.prefix {
+			n.value = NormalizeTag(n.value)
+		} else {
+			n.value = canonicalTag(n.value, aliases)
+		}
+		return n
+	})
+	return &TagQuery{text: q.text, root: root}
+}
+
+// mapTagNodes returns a copy of node with f applied to its tag terms.
+func mapTagNodes(node queryNode, f func(tagNode) tagNode) queryNode {
+	switch n := node.(type) {
+	case andNode:
+		return andNode{mapTagNodes(n.left, f), mapTagNodes(n.right, f)}
+	case orNode:
+		return orNode{mapTagNodes(n.left, f), mapTagNodes(n.right, f)}
+	case notNode:
+		return notNode{mapTagNodes(n.operand, f)}
+	case tagNode:
+		return f(n)
+	}
+	return node
+}
+
+// SQL returns the query as a condition on the entries table aliased e, with ? placeholders
+// and their arguments.
+func (q *TagQuery) SQL() (string, []any) {
+	return q.root.sql()
+}
+
+// Match reports whether entry, which belongs to the journal named journalName and carries
+// tags, is selected by the query.
+func (q *TagQuery) Match(entry Entry, journalName string, tags []string) bool {
+	return q.root.match(queryTarget{entry: entry, journal: journalName, tags: tags})
+}
+
+type queryTokenKind int
+
+const (
+	tokenWord queryTokenKind = iota
+	tokenOpen
+	tokenClose
+	tokenAnd
+	tokenOr
+	tokenNot
+)
+
+type queryToken struct {
+	kind   queryTokenKind
+	offset int
+	// text is the word with quotes removed and, for wildcards, without the trailing *.
+	text string
+	// wildcard is set for words ending in an unquoted *.
+	wildcard bool
+	// field is the text before the first unquoted colon, or empty.
+	field string
+	value string
+}
+
+func (t queryToken) String() string {
+	switch t.kind {
+	case tokenOpen:
+		return fmt.Sprintf("'(' at offset %d", t.offset)
+	case tokenClose:
+		return fmt.Sprintf("')' at offset %d", t.offset)
+	case tokenAnd:
+		return fmt.Sprintf("AND at offset %d", t.offset)
+	case tokenOr:
+		return fmt.Sprintf("OR at offset %d", t.offset)
+	case tokenNot:
+		return fmt.Sprintf("NOT at offset %d", t.offset)
+	}
+	return fmt.Sprintf("%q at offset %d", t.text, t.offset)
+}
+
+// lexTagQuery splits a query into parentheses, operators and words.
+func lexTagQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	i := 0
+	for i < len(query) {
+		r, size := utf8.DecodeRuneInString(query[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: tokenOpen, offset: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: tokenClose, offset: i})
+			i++
+		case r == '-':
+			tokens = append(tokens, queryToken{kind: tokenNot, offset: i})
+			i++
+		default:
+			token, end, err := lexWord(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token)
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// lexWord reads the word starting at offset start and returns it with the offset after it.
+func lexWord(query string, start int) (queryToken, int, error) {
+	token := queryToken{kind: tokenWord, offset: start}
+	var text strings.Builder
+	quoted := false
+	colon := -1
+	i := start
+	for i < len(query) {
+		r, size := utf8.DecodeRuneInString(query[i:])
+		if unicode.IsSpace(r) || r == '(' || r == ')' {
+			break
+		}
+		i += size
+		token.wildcard = false
+		switch r {
+		case '"':
+			end := strings.IndexByte(query[i:], '"')
+			if end < 0 {
+				return queryToken{}, 0, fmt.Errorf("%w: unterminated quote at offset %d", ErrInvalidQuery, i-size)
+			}
+			text.WriteString(query[i : i+end])
+			i += end + 1
+			quoted = true
+		case '*':
+			text.WriteRune(r)
+			token.wildcard = true
+		case ':':
+			if colon < 0 {
+				colon = text.Len()
+			}
+			text.WriteRune(r)
+		default:
+			text.WriteRune(r)
+		}
+	}
+
+	token.text = text.String()
+	if token.wildcard {
+		token.text = strings.TrimSuffix(token.text, "*")
+	}
+	if colon > 0 {
+		token.field, token.value = token.text[:colon], token.text[colon+1:]
+	}
+	if !quoted {
+		switch token.text {
+		case "AND":
+			token.kind = tokenAnd
+		case "OR":
+			token.kind = tokenOr
+		case "NOT":
+			token.kind = tokenNot
+		}
+	}
+	return token, i, nil
+}
+
+// queryParser is a recursive descent parser over the grammar
+//
+//	or   = and { OR and }
+//	and  = not { [AND] not }
+//	not  = ( NOT | - ) not | term | "(" or ")"
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidQuery, fmt.Sprintf(format, args...))
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token, ok := p.peek()
+		if !ok || token.kind != tokenOr {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token, ok := p.peek()
+		if !ok || token.kind == tokenOr || token.kind == tokenClose {
+			return left, nil
+		}
+		if token.kind == tokenAnd {
+			p.pos++
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	token, ok := p.peek()
+	if !ok {
+		return nil, p.errorf("unexpected end of query")
+	}
+	p.pos++
+	switch token.kind {
+	case tokenNot:
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	case tokenOpen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.peek(); !ok || closing.kind != tokenClose {
+			return nil, p.errorf("missing ')' for %s", token)
+		}
+		p.pos++
+		return inner, nil
+	case tokenWord:
+		return parseTerm(token)
+	}
+	return nil, p.errorf("unexpected %s", token)
+}
+
+// parseTerm turns a word into a tag or field match.
+func parseTerm(token queryToken) (queryNode, error) {
+	value := textMatch{value: token.value, prefix: token.wildcard}
+	switch token.field {
+	case "tag", "journal", "type", "updated":
+		if token.value == "" && !token.wildcard {
+			return nil, fmt.Errorf("%w: missing value for %s: at offset %d", ErrInvalidQuery, token.field, token.offset)
+		}
+	}
+	switch token.field {
+	case "tag":
+		return tagNode{value}, nil
+	case "journal":
+		return journalNode{value}, nil
+	case "type":
+		return typeNode{value}, nil
+	case "updated":
+		return parseUpdated(token)
+	}
+	if token.text == "" && !token.wildcard {
+		return nil, fmt.Errorf("%w: empty term at offset %d", ErrInvalidQuery, token.offset)
+	}
+	return tagNode{textMatch{value: token.text, prefix: token.wildcard}}, nil
+}
+
+// parseUpdated turns the value of an updated: term into a comparison, or for a date with
+// = into a pair of them covering the day.
+func parseUpdated(token queryToken) (queryNode, error) {
+	value := token.value
+	op := "="
+	for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, candidate) {
+			op, value = candidate, value[len(candidate):]
+			break
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return updatedNode{op, unixSeconds(t)}, nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q at offset %d is not a date (YYYY-MM-DD) or RFC 3339 time", ErrInvalidQuery, value, token.offset)
+	}
+	start, end := unixSeconds(day), unixSeconds(day.AddDate(0, 0, 1))
+	switch op {
+	case ">":
+		return updatedNode{">=", end}, nil
+	case ">=":
+		return updatedNode{">=", start}, nil
+	case "<":
+		return updatedNode{"<", start}, nil
+	case "<=":
+		return updatedNode{"<", end}, nil
+	}
+	return andNode{updatedNode{">=", start}, updatedNode{"<", end}}, nil
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
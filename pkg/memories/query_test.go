@@ -0,0 +1,98 @@
+package memories
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTagQuerySQL(t *testing.T) {
+	day := func(s string) float64 {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return float64(d.Unix())
+	}
+	tag := "e.id IN (SELECT entry_id FROM entry_tags WHERE (tag = ? OR (tag >= ? AND tag < ?)))"
+	tags := func(names ...string) []any {
+		var args []any
+		for _, name := range names {
+			args = append(args, name, name+"/", name+"0")
+		}
+		return args
+	}
+	prefix := "e.id IN (SELECT entry_id FROM entry_tags WHERE (tag >= ? AND tag < ?))"
+
+	for _, tc := range []struct {
+		query string
+		sql   string
+		args  []any
+	}{
+		{"go", tag, tags("go")},
+		{"go rust", "(" + tag + " AND " + tag + ")", tags("go", "rust")},
+		{"a OR b c", "(" + tag + " OR (" + tag + " AND " + tag + "))", tags("a", "b", "c")},
+		{"(a OR b) AND c", "((" + tag + " OR " + tag + ") AND " + tag + ")", tags("a", "b", "c")},
+		{"NOT a -b", "(NOT " + tag + " AND NOT " + tag + ")", tags("a", "b")},
+		{"proj-*", prefix, []any{"proj-", "proj."}},
+		{`"release notes" "OR" "x*"`, "((" + tag + " AND " + tag + ") AND " + tag + ")", tags("release notes", "OR", "x*")},
+		{"lang:go tag:journal:x", "(" + tag + " AND " + tag + ")", tags("lang:go", "journal:x")},
+		{`journal:"my work"`, "e.journal_id IN (SELECT id FROM journals WHERE name = ?)", []any{"my work"}},
+		{"type:text/*", "(e.content_type >= ? AND e.content_type < ?)", []any{"text/", "text0"}},
+		{"updated:>2026-01-01", "e.updated_at >= ?", []any{day("2026-01-02")}},
+		{"updated:<=2026-01-01", "e.updated_at < ?", []any{day("2026-01-02")}},
+		{"updated:2026-01-01", "(e.updated_at >= ? AND e.updated_at < ?)", []any{day("2026-01-01"), day("2026-01-02")}},
+		{"updated:<2026-01-01T12:00:00Z", "e.updated_at < ?", []any{day("2026-01-01") + 12*3600}},
+	} {
+		query, err := ParseTagQuery(tc.query)
+		if err != nil {
+			t.Errorf("ParseTagQuery(%q) failed: %v", tc.query, err)
+			continue
+		}
+		sql, args := query.SQL()
+		if sql != tc.sql || !reflect.DeepEqual(args, tc.args) {
+			t.Errorf("ParseTagQuery(%q):\nexpected %s %v\ngot      %s %v", tc.query, tc.sql, tc.args, sql, args)
+		}
+	}
+}
+
+func TestPrefixUpperBound(t *testing.T) {
+	for _, tc := range []struct {
+		prefix string
+		upper  string
+		ok     bool
+	}{
+		{"", "", false},
+		{"proj-", "proj.", true},
+		{"日本", "日札", true},
+		{"a\uD7FF", "a\uE000", true},
+		{"a\U0010FFFF", "b", true},
+		{"\U0010FFFF", "", false},
+	} {
+		upper, ok := prefixUpperBound(tc.prefix)
+		if upper != tc.upper || ok != tc.ok {
+			t.Errorf("prefixUpperBound(%q) = %q, %v; expected %q, %v", tc.prefix, upper, ok, tc.upper, tc.ok)
+		}
+	}
+}
+
+func TestParseTagQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"   ",
+		"a AND",
+		"OR a",
+		"(a OR b",
+		"a)",
+		"NOT",
+		`"unterminated`,
+		"journal:",
+		"updated:>yesterday",
+		"a AND OR b",
+	} {
+		if _, err := ParseTagQuery(query); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("ParseTagQuery(%q): expected ErrInvalidQuery, got %v", query, err)
+		}
+	}
+}
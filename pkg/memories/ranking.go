@@ -0,0 +1,275 @@
+package memories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidRankWeights is returned for ranking weights that cannot be used.
+var ErrInvalidRankWeights = errors.New("invalid rank weights")
+
+// RankFusion is how RankEntries combines the signals of an entry into its score.
+type RankFusion string
+
+const (
+	// FusionWeighted scales every signal to [0, 1] and sums them times their weights.
+	FusionWeighted RankFusion = "weighted"
+	// FusionRRF ranks the entries by every signal on its own and sums weight / (60 + rank)
+	// over those rankings (reciprocal rank fusion). Only the order of the signal values
+	// matters, so one outlier cannot dominate the results.
+	FusionRRF RankFusion = "rrf"
+)
+
+// rrfK damps the lead of the first ranks in reciprocal rank fusion.
+const rrfK = 60
+
+// RankWeights configures how RankEntries weighs the ranking signals of entries. A weight
+// of 0 ignores its signal.
+type RankWeights struct {
+	// TagMatch weighs the number of query tags an entry carries.
+	TagMatch float64 `json:"tag_match"`
+	// Text weighs the full-text relevance of an entry to the query text.
+	Text float64 `json:"text"`
+	// Recency weighs how recently an entry was updated, decaying exponentially.
+	Recency float64 `json:"recency"`
+	// Usage weighs how often an entry has been read, its AccessCount.
+	Usage float64 `json:"usage"`
+	// RecencyHalfLife is the age at which the recency of an entry has halved.
+	RecencyHalfLife time.Duration `json:"-"`
+	Fusion          RankFusion    `json:"fusion"`
+}
+
+// DefaultRankWeights returns the weights used when a caller does not choose its own:
+// tags and text count the most, and a month-old entry keeps half its recency.
+func DefaultRankWeights() RankWeights {
+	return RankWeights{
+		TagMatch:        1,
+		Text:            1,
+		Recency:         0.25,
+		Usage:           0.25,
+		RecencyHalfLife: 30 * 24 * time.Hour,
+		Fusion:          FusionWeighted,
+	}
+}
+
+// Validate reports weights that are negative, a half-life that is not positive and
+// unknown fusion methods.
+func (w RankWeights) Validate() error {
+	names := []string{"tag match", "text", "recency", "usage"}
+	for i, weight := range []float64{w.TagMatch, w.Text, w.Recency, w.Usage} {
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("%w: %s weight must be a non-negative number, got %v", ErrInvalidRankWeights, names[i], weight)
+		}
+	}
+	if w.RecencyHalfLife <= 0 {
+		return fmt.Errorf("%w: recency half-life must be positive, got %s", ErrInvalidRankWeights, w.RecencyHalfLife)
+	}
+	if w.Fusion != FusionWeighted && w.Fusion != FusionRRF {
+		return fmt.Errorf("%w: fusion must be %q or %q, got %q", ErrInvalidRankWeights, FusionWeighted, FusionRRF, w.Fusion)
+	}
+	return nil
+}
+
+// RankCandidate is an entry to rank with the raw values of its ranking signals.
+type RankCandidate struct {
+	TaggedEntry
+	// MatchCount is the number of query tags the entry carries, itself or below them.
+	MatchCount int `json:"match_count"`
+	// TextScore is the full-text relevance of the entry, 0 when it does not match.
+	TextScore float64 `json:"text_score"`
+}
+
+// ScoreBreakdown is what every signal contributed to the score of a RankedEntry.
+type ScoreBreakdown struct {
+	TagMatch float64 `json:"tag_match"`
+	Text     float64 `json:"text"`
+	Recency  float64 `json:"recency"`
+	Usage    float64 `json:"usage"`
+}
+
+// RankedEntry is a RankCandidate with its score, the sum of its breakdown.
+type RankedEntry struct {
+	RankCandidate
+	Score     float64        `json:"score"`
+	Breakdown ScoreBreakdown `json:"breakdown"`
+}
+
+// RankEntries scores candidates with weights, as of now, and returns them best first.
+// Ties go to the most recently updated entry. weights must be valid.
+func RankEntries(candidates []RankCandidate, weights RankWeights, now time.Time) []RankedEntry {
+	recency := func(c RankCandidate) float64 {
+		age := math.Max(float64(now.Unix())-c.UpdatedAt, 0)
+		return math.Exp2(-age / weights.RecencyHalfLife.Seconds())
+	}
+	signals := []struct {
+		weight float64
+		value  func(RankCandidate) float64
+		set    func(*ScoreBreakdown, float64)
+	}{
+		{weights.TagMatch, func(c RankCandidate) float64 { return float64(c.MatchCount) }, func(b *ScoreBreakdown, v float64) { b.TagMatch = v }},
+		{weights.Text, func(c RankCandidate) float64 { return c.TextScore }, func(b *ScoreBreakdown, v float64) { b.Text = v }},
+		{weights.Recency, recency, func(b *ScoreBreakdown, v float64) { b.Recency = v }},
+		{weights.Usage, func(c RankCandidate) float64 { return math.Log1p(float64(c.AccessCount)) }, func(b *ScoreBreakdown, v float64) { b.Usage = v }},
+	}
+
+	ranked := make([]RankedEntry, len(candidates))
+	for i, c := range candidates {
+		ranked[i].RankCandidate = c
+	}
+	for _, signal := range signals {
+		if signal.weight == 0 {
+			continue
+		}
+		values := make([]float64, len(candidates))
+		for i, c := range candidates {
+			values[i] = math.Max(signal.value(c), 0)
+		}
+		var contributions []float64
+		if weights.Fusion == FusionRRF {
+			contributions = reciprocalRanks(values)
+		} else {
+			contributions = scaleToMax(values)
+		}
+		for i := range ranked {
+			contribution := signal.weight * contributions[i]
+			signal.set(&ranked[i].Breakdown, contribution)
+			ranked[i].Score += contribution
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		if ranked[i].UpdatedAt != ranked[j].UpdatedAt {
+			return ranked[i].UpdatedAt > ranked[j].UpdatedAt
+		}
+		return ranked[i].ID.String() < ranked[j].ID.String()
+	})
+	return ranked
+}
+
+// scaleToMax divides values by the largest of them.
+func scaleToMax(values []float64) []float64 {
+	var largest float64
+	for _, v := range values {
+		largest = math.Max(largest, v)
+	}
+	scaled := make([]float64, len(values))
+	if largest > 0 {
+		for i, v := range values {
+			scaled[i] = v / largest
+		}
+	}
+	return scaled
+}
+
+// reciprocalRanks returns 1 / (rrfK + rank) for every value, ranking larger values first.
+// Equal values share a rank, and values of 0 get nothing.
+func reciprocalRanks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return values[order[i]] > values[order[j]] })
+
+	reciprocals := make([]float64, len(values))
+	rank := 0
+	for position, i := range order {
+		if values[i] == 0 {
+			break
+		}
+		if position == 0 || values[i] != values[order[position-1]] {
+			rank = position + 1
+		}
+		reciprocals[i] = 1 / float64(rrfK+rank)
+	}
+	return reciprocals
+}
+
+// SearchEntriesHybrid finds the entries of a journal, or of every journal for uuid.Nil,
+// that carry any of tags or whose title or content match text, and ranks them with
+// RankEntries. A limit of 0 returns every match.
+func SearchEntriesHybrid(ctx context.Context, store Store, journalID uuid.UUID, tags []string, text string, weights RankWeights, limit int) ([]RankedEntry, error) {
+	if err := weights.Validate(); err != nil {
+		return nil, err
+	}
+
+	tags, err := store.ResolveTags(ctx, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[uuid.UUID]*RankCandidate)
+	var order []uuid.UUID
+	if len(tags) > 0 {
+		filter := EntryFilter{Tags: tags, MatchAnyTag: true}
+		if journalID != uuid.Nil {
+			filter.JournalIDs = []uuid.UUID{journalID}
+		}
+		tagged, _, err := store.FindEntries(ctx, filter, Page{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find tagged entries: %w", err)
+		}
+		for _, entry := range tagged {
+			candidates[entry.ID] = &RankCandidate{TaggedEntry: entry}
+			order = append(order, entry.ID)
+		}
+	}
+	if text != "" {
+		matches, err := store.SearchEntriesFullText(ctx, journalID, text, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search entries: %w", err)
+		}
+		var untagged []uuid.UUID
+		scores := make(map[uuid.UUID]float64, len(matches))
+		for _, match := range matches {
+			scores[match.ID] = match.Score
+			if candidates[match.ID] == nil {
+				untagged = append(untagged, match.ID)
+			}
+		}
+		if len(untagged) > 0 {
+			found, _, err := store.FindEntries(ctx, EntryFilter{EntryIDs: untagged}, Page{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch entries: %w", err)
+			}
+			for _, entry := range found {
+				candidates[entry.ID] = &RankCandidate{TaggedEntry: entry}
+				order = append(order, entry.ID)
+			}
+		}
+		for id, score := range scores {
+			if candidate := candidates[id]; candidate != nil {
+				candidate.TextScore = score
+			}
+		}
+	}
+
+	queryTags := distinctTags(tags)
+	list := make([]RankCandidate, 0, len(order))
+	for _, id := range order {
+		candidate := candidates[id]
+		for _, queryTag := range queryTags {
+			for _, tag := range candidate.Tags {
+				if tagMatches(queryTag, tag) {
+					candidate.MatchCount++
+					break
+				}
+			}
+		}
+		list = append(list, *candidate)
+	}
+
+	ranked := RankEntries(list, weights, time.Now())
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked, nil
+}
@@ -0,0 +1,99 @@
+package memories
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRankEntries(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	candidate := func(title string, matches int, textScore float64, age time.Duration, accesses int64) RankCandidate {
+		entry := Entry{ID: uuid.New(), Title: title, UpdatedAt: float64(now.Add(-age).Unix()), AccessCount: accesses}
+		return RankCandidate{TaggedEntry: TaggedEntry{Entry: entry}, MatchCount: matches, TextScore: textScore}
+	}
+	titles := func(ranked []RankedEntry) []string {
+		out := make([]string, len(ranked))
+		for i, r := range ranked {
+			out[i] = r.Title
+		}
+		return out
+	}
+	candidates := []RankCandidate{
+		candidate("old, both tags", 2, 0, 60*day, 0),
+		candidate("fresh, one tag", 1, 0, 0, 0),
+		candidate("text only", 0, 8, 10*day, 0),
+		candidate("popular", 1, 6, 30*day, 100),
+	}
+
+	weights := DefaultRankWeights()
+	ranked := RankEntries(candidates, weights, now)
+	for _, r := range ranked {
+		b := r.Breakdown
+		if sum := b.TagMatch + b.Text + b.Recency + b.Usage; math.Abs(sum-r.Score) > 1e-9 {
+			t.Errorf("%q: breakdown %+v does not add up to score %f", r.Title, b, r.Score)
+		}
+	}
+	if got := titles(ranked); got[0] != "popular" {
+		t.Errorf("Expected the entry with tags, text and usage first, got %v", got)
+	}
+	if got := byTitle(ranked, "old, both tags").Breakdown; got.TagMatch != 1 || got.Text != 0 || math.Abs(got.Recency-0.25/4) > 1e-9 {
+		t.Errorf("Unexpected breakdown %+v", got)
+	}
+	if got := byTitle(ranked, "popular").Breakdown; math.Abs(got.TagMatch-0.5) > 1e-9 || math.Abs(got.Text-0.75) > 1e-9 || math.Abs(got.Recency-0.125) > 1e-9 || got.Usage != 0.25 {
+		t.Errorf("Unexpected breakdown %+v", got)
+	}
+
+	weights = RankWeights{Recency: 1, RecencyHalfLife: day, Fusion: FusionWeighted}
+	if got := titles(RankEntries(candidates, weights, now)); got[0] != "fresh, one tag" || got[3] != "old, both tags" {
+		t.Errorf("Expected recency order, got %v", got)
+	}
+
+	weights = RankWeights{TagMatch: 1, Text: 1, RecencyHalfLife: day, Fusion: FusionRRF}
+	ranked = RankEntries(candidates, weights, now)
+	if got := titles(ranked); got[0] != "popular" {
+		t.Errorf("Expected the entry ranked by both signals first, got %v", got)
+	}
+	if got := byTitle(ranked, "text only").Breakdown; got.TagMatch != 0 || got.Text != 1.0/61 {
+		t.Errorf("Unexpected RRF breakdown %+v", got)
+	}
+	if got := byTitle(ranked, "fresh, one tag").Breakdown; got.TagMatch != 1.0/62 {
+		t.Errorf("Expected tied entries to share a rank, got %+v", got)
+	}
+
+	if got := RankEntries(nil, DefaultRankWeights(), now); len(got) != 0 {
+		t.Errorf("Expected no results, got %v", got)
+	}
+}
+
+// byTitle returns the ranked entry with the given title.
+func byTitle(ranked []RankedEntry, title string) RankedEntry {
+	for _, r := range ranked {
+		if r.Title == title {
+			return r
+		}
+	}
+	return RankedEntry{}
+}
+
+func TestRankWeightsValidate(t *testing.T) {
+	if err := DefaultRankWeights().Validate(); err != nil {
+		t.Errorf("Expected the default weights to be valid, got %v", err)
+	}
+	for _, modify := range []func(*RankWeights){
+		func(w *RankWeights) { w.Text = -1 },
+		func(w *RankWeights) { w.Usage = math.NaN() },
+		func(w *RankWeights) { w.RecencyHalfLife = 0 },
+		func(w *RankWeights) { w.Fusion = "max" },
+	} {
+		weights := DefaultRankWeights()
+		modify(&weights)
+		if err := weights.Validate(); !errors.Is(err, ErrInvalidRankWeights) {
+			t.Errorf("Expected ErrInvalidRankWeights for %+v, got %v", weights, err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package memories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+const (
+	// createEntryRevisionStatement numbers revisions per entry starting at 1.
+	createEntryRevisionStatement = `
+	INSERT INTO entry_revisions (entry_id, revision, title, content, content_type, actor)
+	SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ?, ?, ?
+	FROM entry_revisions
+	WHERE entry_id = ?
+	`
+
+	listEntryRevisionsStatement = `
+	SELECT entry_id, revision, title, content, content_type, actor, created_at
+	FROM entry_revisions
+	WHERE entry_id = ?
+	ORDER BY revision DESC
+	`
+
+	getEntryRevisionStatement = `
+	SELECT entry_id, revision, title, content, content_type, actor, created_at
+	FROM entry_revisions
+	WHERE entry_id = ? AND revision = ?
+	`
+)
+
+type actorContextKey struct{}
+
+// WithActor returns a context that attributes entry updates made with it to actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor set with WithActor, or an empty string.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorContextKey{}).(string)
+	return actor
+}
+
+// replaceEntryContent stores existing as a new revision, when its content changes, and
+// overwrites the entry with the given values in the same transaction.
+func replaceEntryContent(ctx context.Context, db *sql.DB, existing Entry, title, content, contentType string) (Entry, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer tx.Rollback()
+
+	if title != existing.Title || content != existing.Content || contentType != existing.ContentType {
+		_, err = tx.ExecContext(
+			ctx,
+			createEntryRevisionStatement,
+			existing.ID,
+			existing.Title,
+			existing.Content,
+			existing.ContentType,
+			ActorFromContext(ctx),
+			existing.ID,
+		)
+		if err != nil {
+			return Entry{}, err
+		}
+		if _, err = tx.ExecContext(ctx, deleteEntryEmbeddingsStatement, existing.ID); err != nil {
+			return Entry{}, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, updateEntryStatement, title, content, contentType, existing.ID)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if rowsAffected == 0 {
+		return Entry{}, ErrEntryNotFound
+	}
+
+	if err = tx.Commit(); err != nil {
+		return Entry{}, err
+	}
+
+	return GetEntry(ctx, db, existing.ID)
+}
+
+// ListEntryRevisions returns the prior versions of an entry, newest first.
+func ListEntryRevisions(ctx context.Context, db *sql.DB, entryID uuid.UUID) ([]EntryRevision, error) {
+	_, err := GetEntry(ctx, db, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, listEntryRevisionsStatement, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []EntryRevision
+	for rows.Next() {
+		var revision EntryRevision
+
+		err := rows.Scan(
+			&revision.EntryID,
+			&revision.Revision,
+			&revision.Title,
+			&revision.Content,
+			&revision.ContentType,
+			&revision.Actor,
+			&revision.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// GetEntryRevision retrieves a single prior version of an entry.
+func GetEntryRevision(ctx context.Context, db *sql.DB, entryID uuid.UUID, revision int64) (EntryRevision, error) {
+	_, err := GetEntry(ctx, db, entryID)
+	if err != nil {
+		return EntryRevision{}, err
+	}
+
+	var entryRevision EntryRevision
+
+	err = db.QueryRowContext(ctx, getEntryRevisionStatement, entryID, revision).Scan(
+		&entryRevision.EntryID,
+		&entryRevision.Revision,
+		&entryRevision.Title,
+		&entryRevision.Content,
+		&entryRevision.ContentType,
+		&entryRevision.Actor,
+		&entryRevision.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return EntryRevision{}, ErrRevisionNotFound
+		}
+		return EntryRevision{}, err
+	}
+
+	return entryRevision, nil
+}
+
+// RestoreEntryRevision brings an entry back to the title, content and content type of a prior revision.
+// The version being replaced is itself recorded as a new revision, so a restore can be undone.
+func RestoreEntryRevision(ctx context.Context, db *sql.DB, entryID uuid.UUID, revision int64) (Entry, error) {
+	entryRevision, err := GetEntryRevision(ctx, db, entryID, revision)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	existingEntry, err := GetEntry(ctx, db, entryID)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return replaceEntryContent(ctx, db, existingEntry, entryRevision.Title, entryRevision.Content, entryRevision.ContentType)
+}
@@ -0,0 +1,83 @@
+package memories
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEntryRevisions(t *testing.T) {
+	testDB, journalID := setupTestDBWithJournal(t)
+	defer testDB.Close()
+
+	ctx := context.Background()
+	entry := createTestEntry(t, ctx, testDB, journalID, "Draft", "first version", "text/plain")
+
+	revisions, err := ListEntryRevisions(ctx, testDB, entry.ID)
+	if err != nil {
+		t.Fatalf("ListEntryRevisions failed: %v", err)
+	}
+	if len(revisions) != 0 {
+		t.Fatalf("Expected no revisions for a new entry, got %d", len(revisions))
+	}
+
+	if _, err := UpdateEntry(WithActor(ctx, "cli"), testDB, entry.ID, "", "second version", ""); err != nil {
+		t.Fatalf("UpdateEntry failed: %v", err)
+	}
+	if _, err := UpdateEntry(WithActor(ctx, "mcp"), testDB, entry.ID, "Final", "third version", "text/markdown"); err != nil {
+		t.Fatalf("UpdateEntry failed: %v", err)
+	}
+	// An update that changes nothing does not add a revision.
+	if _, err := UpdateEntry(ctx, testDB, entry.ID, "", "", ""); err != nil {
+		t.Fatalf("UpdateEntry failed: %v", err)
+	}
+
+	revisions, err = ListEntryRevisions(ctx, testDB, entry.ID)
+	if err != nil {
+		t.Fatalf("ListEntryRevisions failed: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("Expected 2 revisions, got %d", len(revisions))
+	}
+	if revisions[0].Revision != 2 || revisions[0].Content != "second version" || revisions[0].Actor != "mcp" {
+		t.Errorf("Unexpected newest revision: %+v", revisions[0])
+	}
+	if revisions[1].Revision != 1 || revisions[1].Title != "Draft" || revisions[1].Content != "first version" || revisions[1].Actor != "cli" {
+		t.Errorf("Unexpected oldest revision: %+v", revisions[1])
+	}
+
+	restored, err := RestoreEntryRevision(WithActor(ctx, "tui"), testDB, entry.ID, 1)
+	if err != nil {
+		t.Fatalf("RestoreEntryRevision failed: %v", err)
+	}
+	if restored.Title != "Draft" || restored.Content != "first version" || restored.ContentType != "text/plain" {
+		t.Errorf("Expected entry restored to revision 1, got %+v", restored)
+	}
+
+	latest, err := GetEntryRevision(ctx, testDB, entry.ID, 3)
+	if err != nil {
+		t.Fatalf("GetEntryRevision failed: %v", err)
+	}
+	if latest.Title != "Final" || latest.ContentType != "text/markdown" || latest.Actor != "tui" {
+		t.Errorf("Expected restore to record the replaced version as revision 3, got %+v", latest)
+	}
+
+	if _, err := GetEntryRevision(ctx, testDB, entry.ID, 42); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("Expected ErrRevisionNotFound, got %v", err)
+	}
+
+	// Revisions are removed together with their entry.
+	if err := DeleteEntry(ctx, testDB, entry.ID); err != nil {
+		t.Fatalf("DeleteEntry failed: %v", err)
+	}
+	if _, err := CleanDeletedEntries(ctx, testDB, journalID); err != nil {
+		t.Fatalf("CleanDeletedEntries failed: %v", err)
+	}
+	var remaining int
+	if err := testDB.QueryRowContext(ctx, `SELECT COUNT(*) FROM entry_revisions`).Scan(&remaining); err != nil {
+		t.Fatalf("Failed to count revisions: %v", err)
+	}
+	if remaining != 0 {
+		t.Errorf("Expected revisions to be removed with their entry, got %d", remaining)
+	}
+}
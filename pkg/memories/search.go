@@ -3,8 +3,12 @@ package memories
 import (
 	"context"
 	"database/sql"
+	"encoding/binary"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
+	"unicode"
 
 	"github.com/google/uuid"
 )
@@ -15,24 +19,27 @@ type MatchedEntry struct {
 	MatchCount int
 }
 
-// SearchEntriesByTagMatchSQL searches for entries in a specific journal that match the given query tags.
-// Entries are ranked by the number of matching tags in descending order.
-// Only non-deleted entries with at least one matching tag are returned.
-func SearchEntriesByTagMatchSQL(ctx context.Context, db *sql.DB, journalID uuid.UUID, queryTags []string) ([]MatchedEntry, error) {
-	if len(queryTags) == 0 {
-		return []MatchedEntry{}, nil // No tags to search for, return empty result.
+// tagMatchQuery builds the statement and arguments for SearchEntriesByTagMatch with ?
+// placeholders. An entry scores one match for every query tag it carries, either itself or
+// through a tag below it.
+func tagMatchQuery(journalID uuid.UUID, queryTags []string) (string, []any) {
+	var counts, matches []string
+	var countArgs, matchArgs []any
+	for _, tag := range distinctTags(queryTags) {
+		condition, args := tagMatchSQL("et.tag", tag)
+		counts = append(counts, "MAX(CASE WHEN "+condition+" THEN 1 ELSE 0 END)")
+		countArgs = append(countArgs, args...)
+		matches = append(matches, condition)
+		matchArgs = append(matchArgs, args...)
 	}
 
-	// Construct the IN clause placeholders for the SQL query
-	placeholders := strings.Repeat("?,", len(queryTags)-1) + "?"
-
 	// SQL query to find entries, count matching tags, and order by match count
 	// We also include a secondary sort by updated_at to have stable ordering for ties.
 	// Note: All columns from the entries table must be listed in GROUP BY if they are in SELECT.
-	sqlQuery := fmt.Sprintf(`
+	query := fmt.Sprintf(`
 		SELECT
-			e.id, e.journal_id, e.title, e.content, e.content_type, e.deleted, e.created_at, e.updated_at,
-			COUNT(et.tag) as match_count
+			e.id, e.journal_id, e.title, e.content, e.content_type, e.deleted, e.created_at, e.updated_at, e.access_count, e.last_accessed_at, e.expires_at,
+			%s AS match_count
 		FROM
 			entries e
 		JOIN
@@ -40,23 +47,34 @@ func SearchEntriesByTagMatchSQL(ctx context.Context, db *sql.DB, journalID uuid.
 		WHERE
 			e.journal_id = ?
 			AND e.deleted = FALSE
-			AND et.tag IN (%s)
+			AND (e.expires_at = 0 OR e.expires_at > unixepoch())
+			AND (%s)
 		GROUP BY
-			e.id, e.journal_id, e.title, e.content, e.content_type, e.deleted, e.created_at, e.updated_at
-		HAVING
-			COUNT(et.tag) > 0
+			e.id, e.journal_id, e.title, e.content, e.content_type, e.deleted, e.created_at, e.updated_at, e.access_count, e.last_accessed_at, e.expires_at
 		ORDER BY
 			match_count DESC,
 			e.updated_at DESC;
-	`, placeholders)
+	`, strings.Join(counts, " + "), strings.Join(matches, " OR "))
+
+	args := append(countArgs, journalID)
+	return query, append(args, matchArgs...)
+}
 
-	// Prepare arguments for the SQL query
-	args := make([]interface{}, 0, 1+len(queryTags))
-	args = append(args, journalID) // First argument is the journalID
-	for _, tag := range queryTags {
-		args = append(args, tag) // Subsequent arguments are the query tags
+// SearchEntriesByTagMatchSQL searches for entries in a specific journal that match the given query tags.
+// Query tags are normalized and resolved through their aliases, and a query tag matches itself and the
+// tags below it, so "project" matches "project/recall".
+// Entries are ranked by the number of matching query tags in descending order.
+// Only non-deleted entries with at least one matching tag are returned.
+func SearchEntriesByTagMatchSQL(ctx context.Context, db *sql.DB, journalID uuid.UUID, queryTags []string) ([]MatchedEntry, error) {
+	if len(queryTags) == 0 {
+		return []MatchedEntry{}, nil // No tags to search for, return empty result.
 	}
 
+	queryTags, err := ResolveTags(ctx, db, queryTags)
+	if err != nil {
+		return nil, err
+	}
+	sqlQuery, args := tagMatchQuery(journalID, queryTags)
 	rows, err := db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute search query: %w", err)
@@ -75,6 +93,9 @@ func SearchEntriesByTagMatchSQL(ctx context.Context, db *sql.DB, journalID uuid.
 			&me.Entry.Deleted,
 			&me.Entry.CreatedAt,
 			&me.Entry.UpdatedAt,
+			&me.Entry.AccessCount,
+			&me.Entry.LastAccessedAt,
+			&me.Entry.ExpiresAt,
 			&me.MatchCount,
 		)
 		if err != nil {
@@ -89,3 +110,203 @@ func SearchEntriesByTagMatchSQL(ctx context.Context, db *sql.DB, journalID uuid.
 
 	return results, nil
 }
+
+// FullTextMatch holds an Entry found by full-text search, its relevance score and a highlighted snippet.
+type FullTextMatch struct {
+	Entry
+	// Score is the BM25 relevance of the entry; higher is more relevant.
+	Score float64 `json:"score"`
+	// Snippet is a short excerpt around the matched terms, which are wrapped in "**".
+	Snippet string `json:"snippet"`
+}
+
+const (
+	// BM25 parameters and per-column weights (entry_id, title, content) for entries_fts,
+	// bound into the FTS5 statement and applied to matchinfo for FTS4.
+	bm25K1            = 1.2
+	bm25B             = 0.75
+	bm25TitleWeight   = 10.0
+	bm25ContentWeight = 1.0
+
+	searchFullTextFTS5Statement = `
+	SELECT
+		e.id, e.journal_id, e.title, e.content, e.content_type, e.deleted, e.created_at, e.updated_at, e.access_count, e.last_accessed_at, e.expires_at,
+		-bm25(entries_fts, 0.0, ?, ?) AS score,
+		snippet(entries_fts, -1, '**', '**', '...', 16)
+	FROM entries_fts
+	JOIN entries e ON e.id = entries_fts.entry_id
+	WHERE entries_fts MATCH ?
+		AND e.deleted = FALSE
+		AND (e.expires_at = 0 OR e.expires_at > unixepoch())
+		AND (? OR e.journal_id = ?)
+	ORDER BY score DESC, e.updated_at DESC
+	LIMIT ?
+	`
+
+	searchFullTextFTS4Statement = `
+	SELECT
+		e.id, e.journal_id, e.title, e.content, e.content_type, e.deleted, e.created_at, e.updated_at, e.access_count, e.last_accessed_at, e.expires_at,
+		matchinfo(entries_fts, 'pcnalx'),
+		snippet(entries_fts, '**', '**', '...', -1, 16)
+	FROM entries_fts
+	JOIN entries e ON e.id = entries_fts.entry_id
+	WHERE entries_fts MATCH ?
+		AND e.deleted = FALSE
+		AND (e.expires_at = 0 OR e.expires_at > unixepoch())
+		AND (? OR e.journal_id = ?)
+	`
+
+	fullTextTableSQLStatement = `
+	SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'entries_fts'
+	`
+)
+
+// SearchEntriesFullText searches non-deleted entry titles and content for the words in query.
+// Any word may match; entries are ranked by BM25 with title matches weighted above content matches.
+// A journalID of uuid.Nil searches across all journals. A limit of 0 or less returns all matches.
+func SearchEntriesFullText(ctx context.Context, db *sql.DB, journalID uuid.UUID, query string, limit int) ([]FullTextMatch, error) {
+	matchQuery := fullTextQuery(query)
+	if matchQuery == "" {
+		return []FullTextMatch{}, nil
+	}
+
+	var tableSQL string
+	if err := db.QueryRowContext(ctx, fullTextTableSQLStatement).Scan(&tableSQL); err != nil {
+		return nil, fmt.Errorf("failed to inspect full-text index: %w", err)
+	}
+
+	allJournals := journalID == uuid.Nil
+	if strings.Contains(strings.ToLower(tableSQL), "fts5") {
+		sqlLimit := limit
+		if sqlLimit <= 0 {
+			sqlLimit = -1 // SQLite treats a negative LIMIT as no limit.
+		}
+		return queryFullTextMatches(ctx, db, searchFullTextFTS5Statement, nil, bm25TitleWeight, bm25ContentWeight, matchQuery, allJournals, journalID, sqlLimit)
+	}
+
+	results, err := queryFullTextMatches(ctx, db, searchFullTextFTS4Statement, scoreMatchInfo, matchQuery, allJournals, journalID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].UpdatedAt > results[j].UpdatedAt
+	})
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// queryFullTextMatches runs a full-text statement and scans its rows. When scoreFn is nil the
+// score column is read directly, otherwise it is read as a matchinfo blob and passed to scoreFn.
+func queryFullTextMatches(ctx context.Context, db *sql.DB, statement string, scoreFn func([]byte) float64, args ...interface{}) ([]FullTextMatch, error) {
+	rows, err := db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute full-text search query: %w", err)
+	}
+	defer rows.Close()
+
+	results := []FullTextMatch{}
+	for rows.Next() {
+		var match FullTextMatch
+		var matchInfo []byte
+		dest := []interface{}{
+			&match.Entry.ID,
+			&match.Entry.JournalID,
+			&match.Entry.Title,
+			&match.Entry.Content,
+			&match.Entry.ContentType,
+			&match.Entry.Deleted,
+			&match.Entry.CreatedAt,
+			&match.Entry.UpdatedAt,
+			&match.Entry.AccessCount,
+			&match.Entry.LastAccessedAt,
+			&match.Entry.ExpiresAt,
+			&match.Score,
+			&match.Snippet,
+		}
+		if scoreFn != nil {
+			dest[11] = &matchInfo
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan full-text search result row: %w", err)
+		}
+		if scoreFn != nil {
+			match.Score = scoreFn(matchInfo)
+		}
+		results = append(results, match)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over full-text search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// fullTextQuery turns free text into an FTS query that ORs every quoted word,
+// so punctuation in the input is never interpreted as FTS query syntax.
+func fullTextQuery(text string) string {
+	words := fullTextWords(text)
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		quoted = append(quoted, `"`+word+`"`)
+	}
+	return strings.Join(quoted, " OR ")
+}
+
+// fullTextWords splits text into the runs of letters and digits that full-text search matches.
+func fullTextWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// scoreMatchInfo computes a BM25 score from an FTS4 matchinfo(..., 'pcnalx') blob,
+// mirroring the column weights used with FTS5's bm25().
+func scoreMatchInfo(info []byte) float64 {
+	values := make([]uint32, len(info)/4)
+	for i := range values {
+		values[i] = binary.NativeEndian.Uint32(info[i*4:])
+	}
+	if len(values) < 3 {
+		return 0
+	}
+
+	phrases, columns, rowCount := int(values[0]), int(values[1]), float64(values[2])
+	if len(values) < 3+2*columns+3*columns*phrases {
+		return 0
+	}
+	avgLengths := values[3 : 3+columns]
+	lengths := values[3+columns : 3+2*columns]
+	hits := values[3+2*columns:]
+	weights := []float64{0, bm25TitleWeight, bm25ContentWeight}
+
+	var score float64
+	for p := 0; p < phrases; p++ {
+		// FTS5 counts documents containing the phrase in any column; matchinfo only reports
+		// per-column counts, so the largest one is used as the closest lower bound.
+		var docsWithHits float64
+		for c := 0; c < columns; c++ {
+			docsWithHits = math.Max(docsWithHits, float64(hits[3*(c+p*columns)+2]))
+		}
+		idf := math.Log((rowCount - docsWithHits + 0.5) / (docsWithHits + 0.5))
+		if idf <= 0 {
+			idf = 1e-6
+		}
+
+		for c := 0; c < columns && c < len(weights); c++ {
+			termFreq := float64(hits[3*(c+p*columns)])
+			if termFreq == 0 || weights[c] == 0 {
+				continue
+			}
+			avgLength := math.Max(float64(avgLengths[c]), 1)
+			norm := 1 - bm25B + bm25B*float64(lengths[c])/avgLength
+			score += weights[c] * idf * termFreq * (bm25K1 + 1) / (termFreq + bm25K1*norm)
+		}
+	}
+	return score
+}
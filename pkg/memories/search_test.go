@@ -3,6 +3,7 @@ package memories
 import (
 	"context"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -159,3 +160,99 @@ func TestSearchEntriesByTagMatchSQL(t *testing.T) {
 		}
 	})
 }
+
+func TestSearchEntriesFullText(t *testing.T) {
+	testDB, journalID := setupTestDBWithJournal(t)
+	defer testDB.Close()
+	ctx := context.Background()
+
+	deployEntry := createTestEntry(t, ctx, testDB, journalID, "Deployment checklist", "Run migrations before restarting the service.", "text/plain")
+	mentionEntry := createTestEntry(t, ctx, testDB, journalID, "Weekly notes", "Talked about the deployment schedule for next week.", "text/plain")
+	_ = createTestEntry(t, ctx, testDB, journalID, "Grocery list", "Milk, eggs, bread.", "text/plain")
+
+	otherJournal, err := CreateJournal(ctx, testDB, "Other Journal", "")
+	if err != nil {
+		t.Fatalf("Failed to create second journal: %v", err)
+	}
+	otherEntry := createTestEntry(t, ctx, testDB, otherJournal.ID, "Deployment retro", "What went wrong.", "text/plain")
+
+	t.Run("RanksTitleMatchesFirst", func(t *testing.T) {
+		results, err := SearchEntriesFullText(ctx, testDB, journalID, "deployment", 0)
+		if err != nil {
+			t.Fatalf("SearchEntriesFullText failed: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %d: %+v", len(results), results)
+		}
+		if results[0].ID != deployEntry.ID || results[1].ID != mentionEntry.ID {
+			t.Errorf("Expected title match to rank above content match, got %s then %s", results[0].Title, results[1].Title)
+		}
+		if results[0].Score <= results[1].Score {
+			t.Errorf("Expected descending scores, got %f then %f", results[0].Score, results[1].Score)
+		}
+		if !strings.Contains(results[1].Snippet, "**deployment**") {
+			t.Errorf("Expected highlighted snippet, got %q", results[1].Snippet)
+		}
+	})
+
+	t.Run("AllJournalsAndLimit", func(t *testing.T) {
+		results, err := SearchEntriesFullText(ctx, testDB, uuid.Nil, "deployment", 0)
+		if err != nil {
+			t.Fatalf("SearchEntriesFullText failed: %v", err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("Expected 3 results across journals, got %d", len(results))
+		}
+		found := false
+		for _, r := range results {
+			if r.ID == otherEntry.ID {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected entry from the other journal in results")
+		}
+
+		limited, err := SearchEntriesFullText(ctx, testDB, uuid.Nil, "deployment", 1)
+		if err != nil {
+			t.Fatalf("SearchEntriesFullText with limit failed: %v", err)
+		}
+		if len(limited) != 1 {
+			t.Errorf("Expected 1 result with limit, got %d", len(limited))
+		}
+	})
+
+	t.Run("TracksUpdatesAndDeletes", func(t *testing.T) {
+		if _, err := UpdateEntry(ctx, testDB, mentionEntry.ID, "", "Nothing relevant anymore.", ""); err != nil {
+			t.Fatalf("UpdateEntry failed: %v", err)
+		}
+		if err := DeleteEntry(ctx, testDB, deployEntry.ID); err != nil {
+			t.Fatalf("DeleteEntry failed: %v", err)
+		}
+		results, err := SearchEntriesFullText(ctx, testDB, journalID, "deployment", 0)
+		if err != nil {
+			t.Fatalf("SearchEntriesFullText failed: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected no results after update and delete, got %d", len(results))
+		}
+	})
+
+	t.Run("PunctuationAndEmptyQuery", func(t *testing.T) {
+		results, err := SearchEntriesFullText(ctx, testDB, journalID, `milk" AND (eggs`, 0)
+		if err != nil {
+			t.Fatalf("SearchEntriesFullText with punctuation failed: %v", err)
+		}
+		if len(results) != 1 {
+			t.Errorf("Expected 1 result, got %d", len(results))
+		}
+
+		results, err = SearchEntriesFullText(ctx, testDB, journalID, "  ?! ", 0)
+		if err != nil {
+			t.Fatalf("SearchEntriesFullText with empty query failed: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected no results for empty query, got %d", len(results))
+		}
+	})
+}
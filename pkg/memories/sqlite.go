@@ -0,0 +1,207 @@
+package memories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteStore is the Store backed by a recall SQLite database, as opened by
+// db.OpenDBConnection. Its methods are the package-level functions of this package.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+var _ Store = (*SQLiteStore)(nil)
+
+// NewSQLiteStore returns a Store using db. Closing the store closes db.
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
+
+// DB returns the underlying database, for operations outside the Store interface such
+// as migrations, backups and exports.
+func (s *SQLiteStore) DB() *sql.DB {
+	return s.db
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) CreateJournal(ctx context.Context, name, description string) (Journal, error) {
+	return CreateJournal(ctx, s.db, name, description)
+}
+
+func (s *SQLiteStore) GetJournal(ctx context.Context, id uuid.UUID) (Journal, error) {
+	return GetJournal(ctx, s.db, id)
+}
+
+func (s *SQLiteStore) ListJournals(ctx context.Context, activeOnly bool) ([]Journal, error) {
+	return ListJournals(ctx, s.db, activeOnly)
+}
+
+func (s *SQLiteStore) ListJournalsPage(ctx context.Context, activeOnly bool, page Page) ([]Journal, string, error) {
+	return ListJournalsPage(ctx, s.db, activeOnly, page)
+}
+
+func (s *SQLiteStore) UpdateJournal(ctx context.Context, id uuid.UUID, name, description string, active bool) (Journal, error) {
+	return UpdateJournal(ctx, s.db, id, name, description, active)
+}
+
+func (s *SQLiteStore) DeleteJournal(ctx context.Context, id uuid.UUID) error {
+	return DeleteJournal(ctx, s.db, id)
+}
+
+func (s *SQLiteStore) DeleteInactiveJournals(ctx context.Context) (int64, error) {
+	return DeleteInactiveJournals(ctx, s.db)
+}
+
+func (s *SQLiteStore) CreateEntry(ctx context.Context, journalID uuid.UUID, title, content, contentType string) (Entry, error) {
+	return CreateEntry(ctx, s.db, journalID, title, content, contentType)
+}
+
+func (s *SQLiteStore) GetEntry(ctx context.Context, id uuid.UUID) (Entry, error) {
+	return GetEntry(ctx, s.db, id)
+}
+
+func (s *SQLiteStore) ListEntries(ctx context.Context, journalID uuid.UUID, includeDeleted bool) ([]Entry, error) {
+	return ListEntries(ctx, s.db, journalID, includeDeleted)
+}
+
+func (s *SQLiteStore) ListEntriesPage(ctx context.Context, journalID uuid.UUID, includeDeleted bool, page Page) ([]Entry, string, error) {
+	return ListEntriesPage(ctx, s.db, journalID, includeDeleted, page)
+}
+
+func (s *SQLiteStore) UpdateEntry(ctx context.Context, id uuid.UUID, title, content, contentType string) (Entry, error) {
+	return UpdateEntry(ctx, s.db, id, title, content, contentType)
+}
+
+func (s *SQLiteStore) DeleteEntry(ctx context.Context, id uuid.UUID) error {
+	return DeleteEntry(ctx, s.db, id)
+}
+
+func (s *SQLiteStore) DeleteEntriesByJournal(ctx context.Context, journalID uuid.UUID) (int64, error) {
+	return DeleteEntriesByJournal(ctx, s.db, journalID)
+}
+
+func (s *SQLiteStore) CleanDeletedEntries(ctx context.Context, journalID uuid.UUID) (int64, error) {
+	return CleanDeletedEntries(ctx, s.db, journalID)
+}
+
+func (s *SQLiteStore) CleanDeletedEntriesBefore(ctx context.Context, journalID uuid.UUID, before float64) (int64, error) {
+	return CleanDeletedEntriesBefore(ctx, s.db, journalID, before)
+}
+
+func (s *SQLiteStore) RestoreEntry(ctx context.Context, id uuid.UUID) (Entry, error) {
+	return RestoreEntry(ctx, s.db, id)
+}
+
+func (s *SQLiteStore) ListEntryRevisions(ctx context.Context, entryID uuid.UUID) ([]EntryRevision, error) {
+	return ListEntryRevisions(ctx, s.db, entryID)
+}
+
+func (s *SQLiteStore) GetEntryRevision(ctx context.Context, entryID uuid.UUID, revision int64) (EntryRevision, error) {
+	return GetEntryRevision(ctx, s.db, entryID, revision)
+}
+
+func (s *SQLiteStore) RestoreEntryRevision(ctx context.Context, entryID uuid.UUID, revision int64) (Entry, error) {
+	return RestoreEntryRevision(ctx, s.db, entryID, revision)
+}
+
+func (s *SQLiteStore) CreateTag(ctx context.Context, tagName string) error {
+	return CreateTag(ctx, s.db, tagName)
+}
+
+func (s *SQLiteStore) TagEntry(ctx context.Context, entryID uuid.UUID, tagName string) error {
+	return TagEntry(ctx, s.db, entryID, tagName)
+}
+
+func (s *SQLiteStore) DetachTag(ctx context.Context, entryID uuid.UUID, tagName string) error {
+	return DetachTag(ctx, s.db, entryID, tagName)
+}
+
+func (s *SQLiteStore) ListTags(ctx context.Context, journalID uuid.UUID) ([]Tag, error) {
+	return ListTags(ctx, s.db, journalID)
+}
+
+func (s *SQLiteStore) ListAllTags(ctx context.Context) ([]Tag, error) {
+	return ListAllTags(ctx, s.db)
+}
+
+func (s *SQLiteStore) ListTagsForEntry(ctx context.Context, entryID uuid.UUID) ([]Tag, error) {
+	return ListTagsForEntry(ctx, s.db, entryID)
+}
+
+func (s *SQLiteStore) DeleteTag(ctx context.Context, tagName string) error {
+	return DeleteTag(ctx, s.db, tagName)
+}
+
+func (s *SQLiteStore) ResolveTags(ctx context.Context, tags []string) ([]string, error) {
+	return ResolveTags(ctx, s.db, tags)
+}
+
+func (s *SQLiteStore) AliasTag(ctx context.Context, alias, tag string) (TagAlias, error) {
+	return AliasTag(ctx, s.db, alias, tag)
+}
+
+func (s *SQLiteStore) DeleteTagAlias(ctx context.Context, alias string) error {
+	return DeleteTagAlias(ctx, s.db, alias)
+}
+
+func (s *SQLiteStore) ListTagAliases(ctx context.Context) ([]TagAlias, error) {
+	return ListTagAliases(ctx, s.db)
+}
+
+func (s *SQLiteStore) MergeTags(ctx context.Context, from, to string) (int64, error) {
+	return MergeTags(ctx, s.db, from, to)
+}
+
+func (s *SQLiteStore) SearchEntriesByTagMatch(ctx context.Context, journalID uuid.UUID, queryTags []string) ([]MatchedEntry, error) {
+	return SearchEntriesByTagMatchSQL(ctx, s.db, journalID, queryTags)
+}
+
+func (s *SQLiteStore) SearchEntriesFullText(ctx context.Context, journalID uuid.UUID, query string, limit int) ([]FullTextMatch, error) {
+	return SearchEntriesFullText(ctx, s.db, journalID, query, limit)
+}
+
+func (s *SQLiteStore) FindEntries(ctx context.Context, filter EntryFilter, page Page) ([]TaggedEntry, string, error) {
+	return FindEntries(ctx, s.db, filter, page)
+}
+
+func (s *SQLiteStore) SetEntryEmbedding(ctx context.Context, entryID uuid.UUID, model string, vector []float32) error {
+	return SetEntryEmbedding(ctx, s.db, entryID, model, vector)
+}
+
+func (s *SQLiteStore) ListEntryEmbeddings(ctx context.Context, journalID uuid.UUID, model string) ([]EntryEmbedding, error) {
+	return ListEntryEmbeddings(ctx, s.db, journalID, model)
+}
+
+func (s *SQLiteStore) RecordEntryAccesses(ctx context.Context, counts map[uuid.UUID]int64, at float64) error {
+	return RecordEntryAccesses(ctx, s.db, counts, at)
+}
+
+func (s *SQLiteStore) SetEntryExpiry(ctx context.Context, id uuid.UUID, expiresAt float64) (Entry, error) {
+	return SetEntryExpiry(ctx, s.db, id, expiresAt)
+}
+
+func (s *SQLiteStore) SetJournalExpiryPolicy(ctx context.Context, id uuid.UUID, policy ExpiryPolicy) (Journal, error) {
+	return SetJournalExpiryPolicy(ctx, s.db, id, policy)
+}
+
+func (s *SQLiteStore) ReapExpiredEntries(ctx context.Context, now float64) (ReapResult, error) {
+	return ReapExpiredEntries(ctx, s.db, now)
+}
+
+func (s *SQLiteStore) LinkEntries(ctx context.Context, sourceID, targetID uuid.UUID, relation LinkRelation) (EntryLink, error) {
+	return LinkEntries(ctx, s.db, sourceID, targetID, relation)
+}
+
+func (s *SQLiteStore) UnlinkEntries(ctx context.Context, sourceID, targetID uuid.UUID, relation LinkRelation) error {
+	return UnlinkEntries(ctx, s.db, sourceID, targetID, relation)
+}
+
+func (s *SQLiteStore) ListEntryLinks(ctx context.Context, entryIDs []uuid.UUID) ([]EntryLink, error) {
+	return ListEntryLinks(ctx, s.db, entryIDs)
+}
@@ -0,0 +1,113 @@
+package memories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Store is a storage backend for journals, entries, tags and their search. The CLI, the
+// MCP server and the TUI work against a Store, so that backends can be swapped and tests
+// can use fakes. Implementations return ErrJournalNotFound, ErrEntryNotFound,
+// ErrTagNotFound and ErrRevisionNotFound like the SQLite implementation does.
+type Store interface {
+	CreateJournal(ctx context.Context, name, description string) (Journal, error)
+	GetJournal(ctx context.Context, id uuid.UUID) (Journal, error)
+	ListJournals(ctx context.Context, activeOnly bool) ([]Journal, error)
+	// ListJournalsPage returns a page of journals, most recently updated first, and the
+	// cursor of the next page, or an empty string on the last page. A cursor that was not
+	// returned by the store fails with ErrInvalidCursor.
+	ListJournalsPage(ctx context.Context, activeOnly bool, page Page) ([]Journal, string, error)
+	UpdateJournal(ctx context.Context, id uuid.UUID, name, description string, active bool) (Journal, error)
+	DeleteJournal(ctx context.Context, id uuid.UUID) error
+	DeleteInactiveJournals(ctx context.Context) (int64, error)
+
+	CreateEntry(ctx context.Context, journalID uuid.UUID, title, content, contentType string) (Entry, error)
+	GetEntry(ctx context.Context, id uuid.UUID) (Entry, error)
+	ListEntries(ctx context.Context, journalID uuid.UUID, includeDeleted bool) ([]Entry, error)
+	// ListEntriesPage pages through the entries of a journal like ListJournalsPage.
+	ListEntriesPage(ctx context.Context, journalID uuid.UUID, includeDeleted bool, page Page) ([]Entry, string, error)
+	// UpdateEntry records the replaced version as a revision attributed to the actor set with WithActor.
+	UpdateEntry(ctx context.Context, id uuid.UUID, title, content, contentType string) (Entry, error)
+	DeleteEntry(ctx context.Context, id uuid.UUID) error
+	DeleteEntriesByJournal(ctx context.Context, journalID uuid.UUID) (int64, error)
+	CleanDeletedEntries(ctx context.Context, journalID uuid.UUID) (int64, error)
+	// CleanDeletedEntriesBefore is CleanDeletedEntries for the entries deleted before the
+	// Unix time before.
+	CleanDeletedEntriesBefore(ctx context.Context, journalID uuid.UUID, before float64) (int64, error)
+	// RestoreEntry undeletes a deleted or expired entry and removes an expiry that has
+	// passed. An entry in neither state fails with ErrEntryNotDeleted.
+	RestoreEntry(ctx context.Context, id uuid.UUID) (Entry, error)
+
+	ListEntryRevisions(ctx context.Context, entryID uuid.UUID) ([]EntryRevision, error)
+	GetEntryRevision(ctx context.Context, entryID uuid.UUID, revision int64) (EntryRevision, error)
+	RestoreEntryRevision(ctx context.Context, entryID uuid.UUID, revision int64) (Entry, error)
+
+	CreateTag(ctx context.Context, tagName string) error
+	TagEntry(ctx context.Context, entryID uuid.UUID, tagName string) error
+	DetachTag(ctx context.Context, entryID uuid.UUID, tagName string) error
+	// ListTags returns the tags used by entries of a journal.
+	ListTags(ctx context.Context, journalID uuid.UUID) ([]Tag, error)
+	// ListAllTags returns every tag, including ones no entry uses.
+	ListAllTags(ctx context.Context) ([]Tag, error)
+	ListTagsForEntry(ctx context.Context, entryID uuid.UUID) ([]Tag, error)
+	DeleteTag(ctx context.Context, tagName string) error
+
+	// ResolveTags returns tags normalized and with aliases replaced by their tags.
+	ResolveTags(ctx context.Context, tags []string) ([]string, error)
+	// AliasTag makes alias stand for tag, retagging the entries tagged with alias.
+	AliasTag(ctx context.Context, alias, tag string) (TagAlias, error)
+	DeleteTagAlias(ctx context.Context, alias string) error
+	ListTagAliases(ctx context.Context) ([]TagAlias, error)
+	// MergeTags retags the entries tagged with from with to and deletes from, returning
+	// the number of entries retagged.
+	MergeTags(ctx context.Context, from, to string) (int64, error)
+
+	// SearchEntriesByTagMatch returns the entries of a journal with any of queryTags,
+	// those matching the most tags first.
+	SearchEntriesByTagMatch(ctx context.Context, journalID uuid.UUID, queryTags []string) ([]MatchedEntry, error)
+	// SearchEntriesFullText ranks entries by how well their title and content match query.
+	// A journalID of uuid.Nil searches across all journals; a limit of 0 returns all matches.
+	SearchEntriesFullText(ctx context.Context, journalID uuid.UUID, query string, limit int) ([]FullTextMatch, error)
+	// FindEntries returns a page of the entries selected by filter with their tags, most
+	// recently updated first, without a query per journal or entry, and the next cursor.
+	FindEntries(ctx context.Context, filter EntryFilter, page Page) ([]TaggedEntry, string, error)
+
+	// SetEntryEmbedding stores the embedding of an entry made with model. Changing the
+	// title, content or content type of an entry drops its embeddings.
+	SetEntryEmbedding(ctx context.Context, entryID uuid.UUID, model string, vector []float32) error
+	// ListEntryEmbeddings returns the embeddings made with model of the entries of a
+	// journal that are not deleted, or of all journals for uuid.Nil. Entries without one
+	// have a nil Vector.
+	ListEntryEmbeddings(ctx context.Context, journalID uuid.UUID, model string) ([]EntryEmbedding, error)
+
+	// RecordEntryAccesses adds counts to the access counts of entries and moves their last
+	// access time forward to at. Unknown entries are skipped. Use an AccessRecorder to
+	// batch reads instead of calling it for every one.
+	RecordEntryAccesses(ctx context.Context, counts map[uuid.UUID]int64, at float64) error
+
+	// SetEntryExpiry sets when an entry expires, or removes its expiry for 0, without
+	// recording a revision. Listings and searches leave expired entries out unless they
+	// include deleted ones.
+	SetEntryExpiry(ctx context.Context, id uuid.UUID, expiresAt float64) (Entry, error)
+	// SetJournalExpiryPolicy sets what ReapExpiredEntries does with a journal's expired
+	// entries; an unknown policy fails with ErrInvalidExpiryPolicy.
+	SetJournalExpiryPolicy(ctx context.Context, id uuid.UUID, policy ExpiryPolicy) (Journal, error)
+	// ReapExpiredEntries soft-deletes or purges the entries that had expired at the Unix
+	// time now, by the expiry policy of their journal. Use a Reaper to run it periodically.
+	ReapExpiredEntries(ctx context.Context, now float64) (ReapResult, error)
+
+	// LinkEntries links the source entry to the target entry with relation, or returns the
+	// link they already have. An unknown relation fails with ErrInvalidLinkRelation and a
+	// link from an entry to itself with ErrSelfLink.
+	LinkEntries(ctx context.Context, sourceID, targetID uuid.UUID, relation LinkRelation) (EntryLink, error)
+	// UnlinkEntries removes the link with relation from the source entry to the target
+	// entry, or their links of every relation for an empty relation.
+	UnlinkEntries(ctx context.Context, sourceID, targetID uuid.UUID, relation LinkRelation) error
+	// ListEntryLinks returns the links from or to any of entryIDs, oldest first. Use
+	// RelatedEntries to follow them.
+	ListEntryLinks(ctx context.Context, entryIDs []uuid.UUID) ([]EntryLink, error)
+
+	// Close releases the backend's resources.
+	Close() error
+}
@@ -0,0 +1,1301 @@
+package memories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testStore runs the behaviour every Store implementation must share against stores
+// created by newStore, each of which must start out empty.
+func testStore(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Run("Journals", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		journal, err := store.CreateJournal(ctx, "work", "Work notes")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		if journal.Name != "work" || journal.Description != "Work notes" || !journal.Active {
+			t.Errorf("Unexpected journal: %+v", journal)
+		}
+		if _, err := store.CreateJournal(ctx, "archive", ""); err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+
+		got, err := store.GetJournal(ctx, journal.ID)
+		if err != nil || got.Name != "work" {
+			t.Errorf("GetJournal returned %+v, %v", got, err)
+		}
+		if _, err := store.GetJournal(ctx, uuid.New()); !errors.Is(err, ErrJournalNotFound) {
+			t.Errorf("Expected ErrJournalNotFound, got %v", err)
+		}
+
+		updated, err := store.UpdateJournal(ctx, journal.ID, "office", "Office notes", false)
+		if err != nil {
+			t.Fatalf("UpdateJournal failed: %v", err)
+		}
+		if updated.Name != "office" || updated.Description != "Office notes" || updated.Active {
+			t.Errorf("Unexpected updated journal: %+v", updated)
+		}
+		if _, err := store.UpdateJournal(ctx, uuid.New(), "x", "", true); !errors.Is(err, ErrJournalNotFound) {
+			t.Errorf("Expected ErrJournalNotFound, got %v", err)
+		}
+
+		all, err := store.ListJournals(ctx, false)
+		if err != nil || len(all) != 2 {
+			t.Errorf("Expected 2 journals, got %d (%v)", len(all), err)
+		}
+		active, err := store.ListJournals(ctx, true)
+		if err != nil || len(active) != 1 || active[0].Name != "archive" {
+			t.Errorf("Expected only the active journal, got %+v (%v)", active, err)
+		}
+
+		count, err := store.DeleteInactiveJournals(ctx)
+		if err != nil || count != 1 {
+			t.Errorf("Expected 1 inactive journal deleted, got %d (%v)", count, err)
+		}
+		if err := store.DeleteJournal(ctx, journal.ID); !errors.Is(err, ErrJournalNotFound) {
+			t.Errorf("Expected ErrJournalNotFound for a deleted journal, got %v", err)
+		}
+		if err := store.DeleteJournal(ctx, active[0].ID); err != nil {
+			t.Errorf("DeleteJournal failed: %v", err)
+		}
+	})
+
+	t.Run("Entries", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := WithActor(context.Background(), "test")
+
+		journal, err := store.CreateJournal(ctx, "work", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		if _, err := store.CreateEntry(ctx, uuid.New(), "Orphan", "", ""); !errors.Is(err, ErrJournalNotFound) {
+			t.Errorf("Expected ErrJournalNotFound, got %v", err)
+		}
+
+		entry, err := store.CreateEntry(ctx, journal.ID, "Plan", "Ship it", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		if entry.ContentType != "text/plain" || entry.JournalID != journal.ID || entry.Deleted {
+			t.Errorf("Unexpected entry: %+v", entry)
+		}
+		if _, err := store.GetEntry(ctx, uuid.New()); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected ErrEntryNotFound, got %v", err)
+		}
+
+		updated, err := store.UpdateEntry(ctx, entry.ID, "", "Ship it today", "text/markdown")
+		if err != nil {
+			t.Fatalf("UpdateEntry failed: %v", err)
+		}
+		if updated.Title != "Plan" || updated.Content != "Ship it today" || updated.ContentType != "text/markdown" {
+			t.Errorf("Unexpected updated entry: %+v", updated)
+		}
+
+		revisions, err := store.ListEntryRevisions(ctx, entry.ID)
+		if err != nil || len(revisions) != 1 {
+			t.Fatalf("Expected 1 revision, got %d (%v)", len(revisions), err)
+		}
+		if revisions[0].Content != "Ship it" || revisions[0].Actor != "test" {
+			t.Errorf("Unexpected revision: %+v", revisions[0])
+		}
+		if _, err := store.GetEntryRevision(ctx, entry.ID, 2); !errors.Is(err, ErrRevisionNotFound) {
+			t.Errorf("Expected ErrRevisionNotFound, got %v", err)
+		}
+		restored, err := store.RestoreEntryRevision(ctx, entry.ID, revisions[0].Revision)
+		if err != nil || restored.Content != "Ship it" {
+			t.Errorf("RestoreEntryRevision returned %+v, %v", restored, err)
+		}
+
+		other, err := store.CreateEntry(ctx, journal.ID, "Other", "", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		if err := store.DeleteEntry(ctx, other.ID); err != nil {
+			t.Fatalf("DeleteEntry failed: %v", err)
+		}
+		visible, err := store.ListEntries(ctx, journal.ID, false)
+		if err != nil || len(visible) != 1 {
+			t.Errorf("Expected 1 visible entry, got %d (%v)", len(visible), err)
+		}
+		withDeleted, err := store.ListEntries(ctx, journal.ID, true)
+		if err != nil || len(withDeleted) != 2 {
+			t.Errorf("Expected 2 entries including deleted ones, got %d (%v)", len(withDeleted), err)
+		}
+		if _, err := store.ListEntries(ctx, uuid.New(), false); !errors.Is(err, ErrJournalNotFound) {
+			t.Errorf("Expected ErrJournalNotFound, got %v", err)
+		}
+
+		cleaned, err := store.CleanDeletedEntries(ctx, journal.ID)
+		if err != nil || cleaned != 1 {
+			t.Errorf("Expected 1 entry cleaned, got %d (%v)", cleaned, err)
+		}
+		if _, err := store.GetEntry(ctx, other.ID); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected ErrEntryNotFound for a cleaned entry, got %v", err)
+		}
+
+		removed, err := store.DeleteEntriesByJournal(ctx, journal.ID)
+		if err != nil || removed != 1 {
+			t.Errorf("Expected 1 entry removed, got %d (%v)", removed, err)
+		}
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		journal, err := store.CreateJournal(ctx, "work", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		entry, err := store.CreateEntry(ctx, journal.ID, "Plan", "", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+
+		for _, tag := range []string{"go", "db", "go"} {
+			if err := store.TagEntry(ctx, entry.ID, tag); err != nil {
+				t.Fatalf("TagEntry(%s) failed: %v", tag, err)
+			}
+		}
+		if err := store.TagEntry(ctx, uuid.New(), "go"); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected ErrEntryNotFound, got %v", err)
+		}
+		if err := store.CreateTag(ctx, "unused"); err != nil {
+			t.Fatalf("CreateTag failed: %v", err)
+		}
+
+		entryTags, err := store.ListTagsForEntry(ctx, entry.ID)
+		if err != nil || tagNames(entryTags) != "db,go" {
+			t.Errorf("Expected tags db,go, got %s (%v)", tagNames(entryTags), err)
+		}
+		journalTags, err := store.ListTags(ctx, journal.ID)
+		if err != nil || tagNames(journalTags) != "db,go" {
+			t.Errorf("Expected journal tags db,go, got %s (%v)", tagNames(journalTags), err)
+		}
+		allTags, err := store.ListAllTags(ctx)
+		if err != nil || tagNames(allTags) != "db,go,unused" {
+			t.Errorf("Expected all tags db,go,unused, got %s (%v)", tagNames(allTags), err)
+		}
+
+		if err := store.DetachTag(ctx, entry.ID, "db"); err != nil {
+			t.Errorf("DetachTag failed: %v", err)
+		}
+		if err := store.DetachTag(ctx, entry.ID, "db"); !errors.Is(err, ErrTagNotFound) {
+			t.Errorf("Expected ErrTagNotFound, got %v", err)
+		}
+		if err := store.DeleteTag(ctx, "go"); err != nil {
+			t.Errorf("DeleteTag failed: %v", err)
+		}
+		if err := store.DeleteTag(ctx, "go"); !errors.Is(err, ErrTagNotFound) {
+			t.Errorf("Expected ErrTagNotFound, got %v", err)
+		}
+		entryTags, err = store.ListTagsForEntry(ctx, entry.ID)
+		if err != nil || len(entryTags) != 0 {
+			t.Errorf("Expected deleting a tag to detach it, got %s (%v)", tagNames(entryTags), err)
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		journal, err := store.CreateJournal(ctx, "work", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		both, err := store.CreateEntry(ctx, journal.ID, "Database migrations", "Run them before deploying.", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		one, err := store.CreateEntry(ctx, journal.ID, "Lunch", "Pizza on Fridays, then migrations.", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		deleted, err := store.CreateEntry(ctx, journal.ID, "Old migrations", "Gone", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		for entryID, tags := range map[uuid.UUID][]string{both.ID: {"db", "ops"}, one.ID: {"ops"}, deleted.ID: {"db", "ops"}} {
+			for _, tag := range tags {
+				if err := store.TagEntry(ctx, entryID, tag); err != nil {
+					t.Fatalf("TagEntry failed: %v", err)
+				}
+			}
+		}
+		if err := store.DeleteEntry(ctx, deleted.ID); err != nil {
+			t.Fatalf("DeleteEntry failed: %v", err)
+		}
+
+		byTags, err := store.SearchEntriesByTagMatch(ctx, journal.ID, []string{"db", "ops"})
+		if err != nil {
+			t.Fatalf("SearchEntriesByTagMatch failed: %v", err)
+		}
+		if len(byTags) != 2 || byTags[0].ID != both.ID || byTags[0].MatchCount != 2 || byTags[1].MatchCount != 1 {
+			t.Errorf("Unexpected tag matches: %+v", byTags)
+		}
+
+		byText, err := store.SearchEntriesFullText(ctx, uuid.Nil, "migrations", 0)
+		if err != nil {
+			t.Fatalf("SearchEntriesFullText failed: %v", err)
+		}
+		if len(byText) != 2 || byText[0].ID != both.ID {
+			t.Errorf("Expected the title match to rank first, got %+v", byText)
+		}
+		limited, err := store.SearchEntriesFullText(ctx, journal.ID, "migrations", 1)
+		if err != nil || len(limited) != 1 {
+			t.Errorf("Expected 1 result with a limit, got %d (%v)", len(limited), err)
+		}
+	})
+
+	t.Run("HybridSearch", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		work, err := store.CreateJournal(ctx, "work", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		home, err := store.CreateJournal(ctx, "home", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		both, err := store.CreateEntry(ctx, work.ID, "Database migrations", "Run them before deploying.", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		tagged, err := store.CreateEntry(ctx, work.ID, "Lunch", "Pizza on Fridays.", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		text, err := store.CreateEntry(ctx, work.ID, "Notes", "Squash migrations once a year.", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		other, err := store.CreateEntry(ctx, home.ID, "Chores", "Take out the bins.", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		for entryID, tags := range map[uuid.UUID][]string{both.ID: {"db", "ops"}, tagged.ID: {"ops", "food"}, other.ID: {"ops"}} {
+			for _, tag := range tags {
+				if err := store.TagEntry(ctx, entryID, tag); err != nil {
+					t.Fatalf("TagEntry failed: %v", err)
+				}
+			}
+		}
+
+		ranked, err := SearchEntriesHybrid(ctx, store, work.ID, []string{"db", "ops"}, "migrations", DefaultRankWeights(), 0)
+		if err != nil {
+			t.Fatalf("SearchEntriesHybrid failed: %v", err)
+		}
+		if len(ranked) != 3 || ranked[0].ID != both.ID {
+			t.Fatalf("Expected 3 results with the entry matching tags and text first, got %+v", ranked)
+		}
+		for _, r := range ranked {
+			switch r.ID {
+			case both.ID:
+				if r.MatchCount != 2 || r.TextScore <= 0 || r.Breakdown.TagMatch != 1 || !reflect.DeepEqual(r.Tags, []string{"db", "ops"}) {
+					t.Errorf("Unexpected result %+v", r)
+				}
+			case tagged.ID:
+				if r.MatchCount != 1 || r.TextScore != 0 || r.Breakdown.Text != 0 {
+					t.Errorf("Unexpected result %+v", r)
+				}
+			case text.ID:
+				if r.MatchCount != 0 || r.TextScore <= 0 || r.Breakdown.TagMatch != 0 {
+					t.Errorf("Unexpected result %+v", r)
+				}
+			default:
+				t.Errorf("Unexpected entry %s from another journal", r.ID)
+			}
+		}
+
+		everywhere, err := SearchEntriesHybrid(ctx, store, uuid.Nil, []string{"ops"}, "", DefaultRankWeights(), 0)
+		if err != nil || len(everywhere) != 3 {
+			t.Errorf("Expected 3 results across journals, got %+v (%v)", everywhere, err)
+		}
+		limited, err := SearchEntriesHybrid(ctx, store, work.ID, []string{"db", "ops"}, "migrations", DefaultRankWeights(), 1)
+		if err != nil || len(limited) != 1 || limited[0].ID != both.ID {
+			t.Errorf("Expected only the best result with a limit, got %+v (%v)", limited, err)
+		}
+		weights := DefaultRankWeights()
+		weights.Fusion = "best"
+		if _, err := SearchEntriesHybrid(ctx, store, work.ID, []string{"db"}, "", weights, 0); !errors.Is(err, ErrInvalidRankWeights) {
+			t.Errorf("Expected ErrInvalidRankWeights, got %v", err)
+		}
+	})
+
+	t.Run("FindEntries", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		work, err := store.CreateJournal(ctx, "work", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		home, err := store.CreateJournal(ctx, "home", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		create := func(journalID uuid.UUID, title string, tags ...string) Entry {
+			t.Helper()
+			entry, err := store.CreateEntry(ctx, journalID, title, "", "")
+			if err != nil {
+				t.Fatalf("CreateEntry failed: %v", err)
+			}
+			for _, tag := range tags {
+				if err := store.TagEntry(ctx, entry.ID, tag); err != nil {
+					t.Fatalf("TagEntry failed: %v", err)
+				}
+			}
+			return entry
+		}
+		create(work.ID, "Deploy", "ops", "db")
+		standup := create(work.ID, "Standup", "ops")
+		create(home.ID, "Garden", "db")
+		untagged := create(home.ID, "Untagged")
+		deleted := create(home.ID, "Deleted", "ops", "db")
+		if err := store.DeleteEntry(ctx, deleted.ID); err != nil {
+			t.Fatalf("DeleteEntry failed: %v", err)
+		}
+
+		titles := func(entries []TaggedEntry) map[string]string {
+			out := make(map[string]string, len(entries))
+			for _, e := range entries {
+				out[e.Title] = strings.Join(e.Tags, ",")
+			}
+			return out
+		}
+		for _, tc := range []struct {
+			name   string
+			filter EntryFilter
+			want   map[string]string
+		}{
+			{"All", EntryFilter{}, map[string]string{"Deploy": "db,ops", "Standup": "ops", "Garden": "db", "Untagged": ""}},
+			{"Journal", EntryFilter{JournalIDs: []uuid.UUID{home.ID}}, map[string]string{"Garden": "db", "Untagged": ""}},
+			{"AllTags", EntryFilter{Tags: []string{"ops", "db", "ops"}}, map[string]string{"Deploy": "db,ops"}},
+			{"AnyTag", EntryFilter{Tags: []string{"ops", "db"}, MatchAnyTag: true}, map[string]string{"Deploy": "db,ops", "Standup": "ops", "Garden": "db"}},
+			{"AnyTagInJournal", EntryFilter{JournalIDs: []uuid.UUID{work.ID}, Tags: []string{"db"}, MatchAnyTag: true}, map[string]string{"Deploy": "db,ops"}},
+			{"EntryIDs", EntryFilter{EntryIDs: []uuid.UUID{standup.ID, untagged.ID, deleted.ID}}, map[string]string{"Standup": "ops", "Untagged": ""}},
+			{"IncludeDeleted", EntryFilter{JournalIDs: []uuid.UUID{home.ID}, Tags: []string{"ops"}, IncludeDeleted: true}, map[string]string{"Deleted": "db,ops"}},
+			{"UnknownTag", EntryFilter{Tags: []string{"ops", "missing"}}, map[string]string{}},
+		} {
+			found, _, err := store.FindEntries(ctx, tc.filter, Page{})
+			if err != nil {
+				t.Fatalf("%s: FindEntries failed: %v", tc.name, err)
+			}
+			if got := titles(found); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+			}
+		}
+
+		found, _, err := store.FindEntries(ctx, EntryFilter{IncludeDeleted: true}, Page{})
+		if err != nil || len(found) != 5 {
+			t.Fatalf("Expected 5 entries including deleted ones, got %d (%v)", len(found), err)
+		}
+		for i := 1; i < len(found); i++ {
+			if found[i].UpdatedAt > found[i-1].UpdatedAt {
+				t.Errorf("Expected entries most recently updated first, got %+v", found)
+			}
+		}
+	})
+
+	t.Run("TagQuery", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		work, err := store.CreateJournal(ctx, "work", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		home, err := store.CreateJournal(ctx, "home", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		create := func(journalID uuid.UUID, title, contentType string, tags ...string) {
+			t.Helper()
+			entry, err := store.CreateEntry(ctx, journalID, title, "", contentType)
+			if err != nil {
+				t.Fatalf("CreateEntry failed: %v", err)
+			}
+			for _, tag := range tags {
+				if err := store.TagEntry(ctx, entry.ID, tag); err != nil {
+					t.Fatalf("TagEntry failed: %v", err)
+				}
+			}
+		}
+		create(work.ID, "Roadmap", "text/markdown", "proj-alpha", "planning")
+		create(work.ID, "Retro", "text/plain", "proj-beta", "archived")
+		create(work.ID, "Standup", "text/plain", "meeting")
+		create(home.ID, "Garden", "text/markdown", "proj-garden")
+
+		for _, tc := range []struct {
+			query string
+			want  string
+		}{
+			{"proj-*", "Garden,Retro,Roadmap"},
+			{"proj-* NOT archived", "Garden,Roadmap"},
+			{"proj-* -archived journal:work", "Roadmap"},
+			{"meeting OR planning", "Roadmap,Standup"},
+			{"(meeting OR archived) AND type:text/plain", "Retro,Standup"},
+			{"type:text/* NOT type:text/markdown", "Retro,Standup"},
+			{"journal:home OR proj-beta", "Garden,Retro"},
+			{"NOT (proj-* OR meeting)", ""},
+			{"updated:>2000-01-01 journal:home", "Garden"},
+			{"updated:<2000-01-01", ""},
+			{"proj-", ""},
+		} {
+			query, err := ParseTagQuery(tc.query)
+			if err != nil {
+				t.Fatalf("ParseTagQuery(%q) failed: %v", tc.query, err)
+			}
+			found, _, err := store.FindEntries(ctx, EntryFilter{Query: query}, Page{})
+			if err != nil {
+				t.Fatalf("FindEntries(%q) failed: %v", tc.query, err)
+			}
+			var titles []string
+			for _, e := range found {
+				titles = append(titles, e.Title)
+			}
+			sort.Strings(titles)
+			if got := strings.Join(titles, ","); got != tc.want {
+				t.Errorf("%q: expected %q, got %q", tc.query, tc.want, got)
+			}
+		}
+	})
+
+	t.Run("TagPaths", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		journal, err := store.CreateJournal(ctx, "work", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		create := func(title string, tags ...string) Entry {
+			t.Helper()
+			entry, err := store.CreateEntry(ctx, journal.ID, title, "", "")
+			if err != nil {
+				t.Fatalf("CreateEntry failed: %v", err)
+			}
+			for _, tag := range tags {
+				if err := store.TagEntry(ctx, entry.ID, tag); err != nil {
+					t.Fatalf("TagEntry failed: %v", err)
+				}
+			}
+			return entry
+		}
+		create("Server", "project/recall/mcp", "project/recall/db")
+		create("Roadmap", "project/recall")
+		create("Docs", "project/recall-docs")
+		create("Garden", "home")
+		deleted := create("Old", "project/recall/mcp")
+		if err := store.DeleteEntry(ctx, deleted.ID); err != nil {
+			t.Fatalf("DeleteEntry failed: %v", err)
+		}
+
+		matched, err := store.SearchEntriesByTagMatch(ctx, journal.ID, []string{"project/recall", "project/recall/mcp"})
+		if err != nil {
+			t.Fatalf("SearchEntriesByTagMatch failed: %v", err)
+		}
+		counts := make(map[string]int)
+		for _, m := range matched {
+			counts[m.Title] = m.MatchCount
+		}
+		if want := map[string]int{"Server": 2, "Roadmap": 1}; !reflect.DeepEqual(counts, want) {
+			t.Errorf("Expected parent tags to match their descendants once each, %v, got %v", want, counts)
+		}
+
+		titles := func(filter EntryFilter) string {
+			t.Helper()
+			found, _, err := store.FindEntries(ctx, filter, Page{})
+			if err != nil {
+				t.Fatalf("FindEntries failed: %v", err)
+			}
+			var out []string
+			for _, e := range found {
+				out = append(out, e.Title)
+			}
+			sort.Strings(out)
+			return strings.Join(out, ",")
+		}
+		if got := titles(EntryFilter{Tags: []string{"project"}}); got != "Docs,Roadmap,Server" {
+			t.Errorf("Expected a parent tag to match every tag below it, got %s", got)
+		}
+		if got := titles(EntryFilter{Tags: []string{"project/recall"}}); got != "Roadmap,Server" {
+			t.Errorf("Expected a tag not to match siblings sharing its prefix, got %s", got)
+		}
+		if got := titles(EntryFilter{Tags: []string{"project/recall/db", "project/recall/mcp"}}); got != "Server" {
+			t.Errorf("Expected all tags to be required, got %s", got)
+		}
+		if got := titles(EntryFilter{Tags: []string{"project/recall/db", "home"}, MatchAnyTag: true}); got != "Garden,Server" {
+			t.Errorf("Expected any tag to match, got %s", got)
+		}
+		query, err := ParseTagQuery("project/recall NOT project/recall/mcp")
+		if err != nil {
+			t.Fatalf("ParseTagQuery failed: %v", err)
+		}
+		if got := titles(EntryFilter{Query: query}); got != "Roadmap" {
+			t.Errorf("Expected tag queries to match descendants, got %s", got)
+		}
+
+		tree, err := TagTree(ctx, store, []uuid.UUID{journal.ID})
+		if err != nil {
+			t.Fatalf("TagTree failed: %v", err)
+		}
+		var flatten func(nodes []TagNode, out []string) []string
+		flatten = func(nodes []TagNode, out []string) []string {
+			for _, n := range nodes {
+				out = append(out, fmt.Sprintf("%s=%d/%d", n.Path, n.Count, n.Direct))
+				out = flatten(n.Children, out)
+			}
+			return out
+		}
+		want := "home=1/1 project=3/0 project/recall=2/1 project/recall/db=1/1 project/recall/mcp=1/1 project/recall-docs=1/1"
+		if got := strings.Join(flatten(tree, nil), " "); got != want {
+			t.Errorf("Expected tag tree %s, got %s", want, got)
+		}
+	})
+
+	t.Run("TagAliases", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		journal, err := store.CreateJournal(ctx, "work", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		create := func(title string, tags ...string) Entry {
+			t.Helper()
+			entry, err := store.CreateEntry(ctx, journal.ID, title, "", "")
+			if err != nil {
+				t.Fatalf("CreateEntry failed: %v", err)
+			}
+			for _, tag := range tags {
+				if err := store.TagEntry(ctx, entry.ID, tag); err != nil {
+					t.Fatalf("TagEntry failed: %v", err)
+				}
+			}
+			return entry
+		}
+		entryTags := func(entry Entry) string {
+			t.Helper()
+			tags, err := store.ListTagsForEntry(ctx, entry.ID)
+			if err != nil {
+				t.Fatalf("ListTagsForEntry failed: %v", err)
+			}
+			var out []string
+			for _, tag := range tags {
+				out = append(out, tag.Tag)
+			}
+			sort.Strings(out)
+			return strings.Join(out, ",")
+		}
+		titles := func(filter EntryFilter) string {
+			t.Helper()
+			found, _, err := store.FindEntries(ctx, filter, Page{})
+			if err != nil {
+				t.Fatalf("FindEntries failed: %v", err)
+			}
+			var out []string
+			for _, e := range found {
+				out = append(out, e.Title)
+			}
+			sort.Strings(out)
+			return strings.Join(out, ",")
+		}
+
+		cluster := create("Cluster", " Kubernetes ", "kubernetes")
+		if got := entryTags(cluster); got != "kubernetes" {
+			t.Errorf("Expected tags to be normalized, got %s", got)
+		}
+		if err := store.TagEntry(ctx, cluster.ID, "  "); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("Expected ErrInvalidTag for a blank tag, got %v", err)
+		}
+
+		deploy := create("Deploy", "k8s", "ops")
+		alias, err := store.AliasTag(ctx, "K8s", "Kubernetes")
+		if err != nil {
+			t.Fatalf("AliasTag failed: %v", err)
+		}
+		if alias.Alias != "k8s" || alias.Tag != "kubernetes" {
+			t.Errorf("Expected alias k8s of kubernetes, got %+v", alias)
+		}
+		if got := entryTags(deploy); got != "kubernetes,ops" {
+			t.Errorf("Expected entries tagged with the alias to be retagged, got %s", got)
+		}
+		helm := create("Helm", "K8S")
+		if got := entryTags(helm); got != "kubernetes" {
+			t.Errorf("Expected the alias to resolve when tagging, got %s", got)
+		}
+		if err := store.DetachTag(ctx, helm.ID, "k8s"); err != nil {
+			t.Fatalf("DetachTag failed: %v", err)
+		}
+		if got := entryTags(helm); got != "" {
+			t.Errorf("Expected the alias to resolve when detaching, got %s", got)
+		}
+
+		matched, err := store.SearchEntriesByTagMatch(ctx, journal.ID, []string{"K8S", "ops"})
+		if err != nil {
+			t.Fatalf("SearchEntriesByTagMatch failed: %v", err)
+		}
+		counts := make(map[string]int)
+		for _, m := range matched {
+			counts[m.Title] = m.MatchCount
+		}
+		if want := map[string]int{"Deploy": 2, "Cluster": 1}; !reflect.DeepEqual(counts, want) {
+			t.Errorf("Expected searches to resolve aliases, %v, got %v", want, counts)
+		}
+		if got := titles(EntryFilter{Tags: []string{"k8s"}}); got != "Cluster,Deploy" {
+			t.Errorf("Expected filters to resolve aliases, got %s", got)
+		}
+		query, err := ParseTagQuery("K8s NOT ops")
+		if err != nil {
+			t.Fatalf("ParseTagQuery failed: %v", err)
+		}
+		if got := titles(EntryFilter{Query: query}); got != "Cluster" {
+			t.Errorf("Expected tag queries to resolve aliases, got %s", got)
+		}
+		resolved, err := store.ResolveTags(ctx, []string{"K8s", " Ops"})
+		if err != nil {
+			t.Fatalf("ResolveTags failed: %v", err)
+		}
+		if want := []string{"kubernetes", "ops"}; !reflect.DeepEqual(resolved, want) {
+			t.Errorf("Expected resolved tags %v, got %v", want, resolved)
+		}
+
+		if _, err := store.AliasTag(ctx, "kubernetes", "k8s"); !errors.Is(err, ErrInvalidTagAlias) {
+			t.Errorf("Expected ErrInvalidTagAlias for a tag aliased to itself, got %v", err)
+		}
+		// An alias of an alias stands for the tag, and aliasing the tag moves its aliases.
+		if _, err := store.AliasTag(ctx, "kube", "k8s"); err != nil {
+			t.Fatalf("AliasTag failed: %v", err)
+		}
+		if _, err := store.AliasTag(ctx, "kubernetes", "container-orchestration"); err != nil {
+			t.Fatalf("AliasTag failed: %v", err)
+		}
+		aliases, err := store.ListTagAliases(ctx)
+		if err != nil {
+			t.Fatalf("ListTagAliases failed: %v", err)
+		}
+		var pairs []string
+		for _, a := range aliases {
+			pairs = append(pairs, a.Alias+"="+a.Tag)
+		}
+		if want := "k8s=container-orchestration kube=container-orchestration kubernetes=container-orchestration"; strings.Join(pairs, " ") != want {
+			t.Errorf("Expected aliases %s, got %s", want, strings.Join(pairs, " "))
+		}
+		if got := entryTags(cluster); got != "container-orchestration" {
+			t.Errorf("Expected aliasing a tag to retag its entries, got %s", got)
+		}
+
+		if err := store.DeleteTagAlias(ctx, "KUBE"); err != nil {
+			t.Fatalf("DeleteTagAlias failed: %v", err)
+		}
+		if err := store.DeleteTagAlias(ctx, "kube"); !errors.Is(err, ErrTagAliasNotFound) {
+			t.Errorf("Expected ErrTagAliasNotFound, got %v", err)
+		}
+
+		create("Runbook", "ops", "operations")
+		retagged, err := store.MergeTags(ctx, "Ops", "operations")
+		if err != nil {
+			t.Fatalf("MergeTags failed: %v", err)
+		}
+		if retagged != 2 {
+			t.Errorf("Expected 2 entries retagged, got %d", retagged)
+		}
+		if got := entryTags(deploy); got != "container-orchestration,operations" {
+			t.Errorf("Expected merged tags on the entry, got %s", got)
+		}
+		if got := titles(EntryFilter{Tags: []string{"operations"}}); got != "Deploy,Runbook" {
+			t.Errorf("Expected merged entries to carry the tag once, got %s", got)
+		}
+		if _, err := store.MergeTags(ctx, "ops", "operations"); !errors.Is(err, ErrTagNotFound) {
+			t.Errorf("Expected ErrTagNotFound for a merged tag, got %v", err)
+		}
+		if _, err := store.MergeTags(ctx, "operations", "Operations"); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("Expected ErrInvalidTag for a merge into itself, got %v", err)
+		}
+
+		if err := store.DeleteTag(ctx, "Container-Orchestration"); err != nil {
+			t.Fatalf("DeleteTag failed: %v", err)
+		}
+		aliases, err = store.ListTagAliases(ctx)
+		if err != nil {
+			t.Fatalf("ListTagAliases failed: %v", err)
+		}
+		if len(aliases) != 0 {
+			t.Errorf("Expected deleting a tag to delete its aliases, got %+v", aliases)
+		}
+	})
+
+	t.Run("Embeddings", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		journal, err := store.CreateJournal(ctx, "work", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		deploy, err := store.CreateEntry(ctx, journal.ID, "Deploying the API", "Roll out the new release to production servers.", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		garden, err := store.CreateEntry(ctx, journal.ID, "Garden", "Water the tomatoes and basil twice a week.", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+
+		if err := store.SetEntryEmbedding(ctx, deploy.ID, "test", []float32{1, 0.5}); err != nil {
+			t.Fatalf("SetEntryEmbedding failed: %v", err)
+		}
+		if err := store.SetEntryEmbedding(ctx, uuid.New(), "test", []float32{1}); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected ErrEntryNotFound, got %v", err)
+		}
+		vectors := func(journalID uuid.UUID, model string) map[uuid.UUID][]float32 {
+			t.Helper()
+			embeddings, err := store.ListEntryEmbeddings(ctx, journalID, model)
+			if err != nil {
+				t.Fatalf("ListEntryEmbeddings failed: %v", err)
+			}
+			out := make(map[uuid.UUID][]float32, len(embeddings))
+			for _, embedding := range embeddings {
+				out[embedding.EntryID] = embedding.Vector
+			}
+			return out
+		}
+		want := map[uuid.UUID][]float32{deploy.ID: {1, 0.5}, garden.ID: nil}
+		if got := vectors(uuid.Nil, "test"); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+		if got := vectors(journal.ID, "other")[deploy.ID]; got != nil {
+			t.Errorf("Expected no embedding for another model, got %v", got)
+		}
+
+		if _, err := store.UpdateEntry(ctx, deploy.ID, "", "Roll out the new release to all production servers.", ""); err != nil {
+			t.Fatalf("UpdateEntry failed: %v", err)
+		}
+		if got := vectors(journal.ID, "test")[deploy.ID]; got != nil {
+			t.Errorf("Expected the embedding to be dropped on update, got %v", got)
+		}
+		if err := store.DeleteEntry(ctx, garden.ID); err != nil {
+			t.Fatalf("DeleteEntry failed: %v", err)
+		}
+		if got := vectors(journal.ID, "test"); len(got) != 1 {
+			t.Errorf("Expected deleted entries to be left out, got %v", got)
+		}
+		if _, err := store.CreateEntry(ctx, journal.ID, "Garden", "Water the tomatoes and basil twice a week.", ""); err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+
+		embedded := NewEmbeddingStore(store, NewHashingEmbedder(0))
+		matches, err := embedded.SearchEntriesSemantic(ctx, uuid.Nil, "when do we deploy the release", 0)
+		if err != nil {
+			t.Fatalf("SearchEntriesSemantic failed: %v", err)
+		}
+		if len(matches) == 0 || matches[0].ID != deploy.ID || matches[0].Similarity <= 0 || matches[0].Similarity > 1.0001 {
+			t.Fatalf("Expected the deployment entry first, got %+v", matches)
+		}
+		for _, embedding := range vectors(journal.ID, embedded.Embedder().Model()) {
+			if embedding == nil {
+				t.Errorf("Expected search to embed every entry")
+			}
+		}
+
+		created, err := embedded.CreateEntry(ctx, journal.ID, "Incident", "The database ran out of disk space.", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		if vectors(journal.ID, embedded.Embedder().Model())[created.ID] == nil {
+			t.Errorf("Expected EmbeddingStore to embed created entries")
+		}
+		matches, err = embedded.SearchEntriesSemantic(ctx, journal.ID, "disks filling up", 1)
+		if err != nil || len(matches) != 1 || matches[0].ID != created.ID {
+			t.Errorf("Expected the incident entry, got %+v (%v)", matches, err)
+		}
+	})
+
+	t.Run("Accesses", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		journal, err := store.CreateJournal(ctx, "work", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		read, err := store.CreateEntry(ctx, journal.ID, "Read", "Often", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		unread, err := store.CreateEntry(ctx, journal.ID, "Unread", "Never", "")
+		if err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+
+		if err := store.RecordEntryAccesses(ctx, map[uuid.UUID]int64{read.ID: 2, uuid.New(): 1}, 2000); err != nil {
+			t.Fatalf("RecordEntryAccesses failed: %v", err)
+		}
+		if err := store.RecordEntryAccesses(ctx, map[uuid.UUID]int64{read.ID: 1}, 1000); err != nil {
+			t.Fatalf("RecordEntryAccesses failed: %v", err)
+		}
+		got, err := store.GetEntry(ctx, read.ID)
+		if err != nil || got.AccessCount != 3 || got.LastAccessedAt != 2000 {
+			t.Errorf("Expected 3 reads last at 2000, got %d at %v (%v)", got.AccessCount, got.LastAccessedAt, err)
+		}
+		if got.UpdatedAt != read.UpdatedAt {
+			t.Errorf("Expected reads to leave updated_at alone, got %v instead of %v", got.UpdatedAt, read.UpdatedAt)
+		}
+		if got, _ := store.GetEntry(ctx, unread.ID); got.AccessCount != 0 || got.LastAccessedAt != 0 {
+			t.Errorf("Expected no reads, got %+v", got)
+		}
+
+		updated, err := store.UpdateEntry(ctx, read.ID, "Read", "Often, and updated", "")
+		if err != nil || updated.AccessCount != 3 {
+			t.Errorf("Expected an update to keep the read count, got %+v (%v)", updated, err)
+		}
+		found, _, err := store.FindEntries(ctx, EntryFilter{EntryIDs: []uuid.UUID{read.ID}}, Page{})
+		if err != nil || len(found) != 1 || found[0].AccessCount != 3 || found[0].LastAccessedAt != 2000 {
+			t.Errorf("Expected FindEntries to return read statistics, got %+v (%v)", found, err)
+		}
+		listed, err := store.ListEntries(ctx, journal.ID, false)
+		if err != nil || len(listed) != 2 {
+			t.Fatalf("ListEntries failed: %v", err)
+		}
+		for _, e := range listed {
+			if e.ID == read.ID && e.AccessCount != 3 {
+				t.Errorf("Expected ListEntries to return read statistics, got %+v", e)
+			}
+		}
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		kept, err := store.CreateJournal(ctx, "kept", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		if kept.ExpiryPolicy != ExpirySoftDelete {
+			t.Errorf("Expected new journals to soft-delete expired entries, got %q", kept.ExpiryPolicy)
+		}
+		purged, err := store.CreateJournal(ctx, "purged", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		if purged, err = store.SetJournalExpiryPolicy(ctx, purged.ID, ExpiryPurge); err != nil || purged.ExpiryPolicy != ExpiryPurge {
+			t.Fatalf("SetJournalExpiryPolicy failed: %+v (%v)", purged, err)
+		}
+		if _, err := store.SetJournalExpiryPolicy(ctx, kept.ID, "archive"); !errors.Is(err, ErrInvalidExpiryPolicy) {
+			t.Errorf("Expected ErrInvalidExpiryPolicy, got %v", err)
+		}
+		if _, err := store.SetJournalExpiryPolicy(ctx, uuid.New(), ExpiryPurge); !errors.Is(err, ErrJournalNotFound) {
+			t.Errorf("Expected ErrJournalNotFound, got %v", err)
+		}
+
+		create := func(journalID uuid.UUID, title string, expiresAt float64) Entry {
+			t.Helper()
+			entry, err := store.CreateEntry(ctx, journalID, title, "temporary note", "")
+			if err != nil {
+				t.Fatalf("CreateEntry failed: %v", err)
+			}
+			if err := store.TagEntry(ctx, entry.ID, "tmp"); err != nil {
+				t.Fatalf("TagEntry failed: %v", err)
+			}
+			if expiresAt == 0 {
+				return entry
+			}
+			expiring, err := store.SetEntryExpiry(ctx, entry.ID, expiresAt)
+			if err != nil || expiring.ExpiresAt != expiresAt {
+				t.Fatalf("SetEntryExpiry failed: %+v (%v)", expiring, err)
+			}
+			if expiring.UpdatedAt != entry.UpdatedAt {
+				t.Errorf("Expected setting an expiry to keep updated_at, got %v instead of %v", expiring.UpdatedAt, entry.UpdatedAt)
+			}
+			return expiring
+		}
+		future := float64(time.Now().Add(time.Hour).Unix())
+		permanent := create(kept.ID, "Permanent", 0)
+		pending := create(kept.ID, "Pending", future)
+		expired := create(kept.ID, "Expired", 1000)
+		expiredPurged := create(purged.ID, "Expired purged", 1000)
+		if _, err := store.SetEntryExpiry(ctx, uuid.New(), future); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected ErrEntryNotFound, got %v", err)
+		}
+
+		listed, err := store.ListEntries(ctx, kept.ID, false)
+		if err != nil {
+			t.Fatalf("ListEntries failed: %v", err)
+		}
+		if len(listed) != 2 || (listed[0].ID != permanent.ID && listed[1].ID != permanent.ID) {
+			t.Errorf("Expected expired entries to be hidden, got %+v", listed)
+		}
+		if all, err := store.ListEntries(ctx, kept.ID, true); err != nil || len(all) != 3 {
+			t.Errorf("Expected expired entries with deleted ones, got %d (%v)", len(all), err)
+		}
+		found, _, err := store.FindEntries(ctx, EntryFilter{Tags: []string{"tmp"}}, Page{})
+		if err != nil || len(found) != 2 {
+			t.Errorf("Expected FindEntries to hide expired entries, got %d (%v)", len(found), err)
+		}
+		matched, err := store.SearchEntriesByTagMatch(ctx, kept.ID, []string{"tmp"})
+		if err != nil || len(matched) != 2 {
+			t.Errorf("Expected tag search to hide expired entries, got %d (%v)", len(matched), err)
+		}
+		byText, err := store.SearchEntriesFullText(ctx, uuid.Nil, "temporary", 0)
+		if err != nil || len(byText) != 2 {
+			t.Errorf("Expected full-text search to find the two live entries, got %d (%v)", len(byText), err)
+		}
+		for _, match := range byText {
+			if match.ID == expired.ID || match.ID == expiredPurged.ID {
+				t.Errorf("Expected full-text search to hide expired entries, got %q", match.Title)
+			}
+		}
+		if got, err := store.GetEntry(ctx, expired.ID); err != nil || !got.Expired(time.Now()) {
+			t.Errorf("Expected GetEntry to return the expired entry, got %+v (%v)", got, err)
+		}
+
+		result, err := store.ReapExpiredEntries(ctx, float64(time.Now().Unix()))
+		if err != nil || result != (ReapResult{Deleted: 1, Purged: 1}) {
+			t.Fatalf("Expected one entry deleted and one purged, got %+v (%v)", result, err)
+		}
+		if got, err := store.GetEntry(ctx, expired.ID); err != nil || !got.Deleted {
+			t.Errorf("Expected the expired entry to be soft-deleted, got %+v (%v)", got, err)
+		}
+		if _, err := store.GetEntry(ctx, expiredPurged.ID); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected the expired entry to be purged, got %v", err)
+		}
+		if got, err := store.GetEntry(ctx, pending.ID); err != nil || got.Deleted {
+			t.Errorf("Expected the pending entry to be kept, got %+v (%v)", got, err)
+		}
+		if result, err := store.ReapExpiredEntries(ctx, float64(time.Now().Unix())); err != nil || result != (ReapResult{}) {
+			t.Errorf("Expected nothing left to reap, got %+v (%v)", result, err)
+		}
+	})
+
+	t.Run("Trash", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		journal, err := store.CreateJournal(ctx, "work", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		create := func(title string) Entry {
+			t.Helper()
+			entry, err := store.CreateEntry(ctx, journal.ID, title, "", "")
+			if err != nil {
+				t.Fatalf("CreateEntry failed: %v", err)
+			}
+			return entry
+		}
+		deleted, kept, expired, scheduled := create("Deleted"), create("Kept"), create("Expired"), create("Scheduled")
+		future := float64(time.Now().Add(time.Hour).Unix())
+		if _, err := store.SetEntryExpiry(ctx, expired.ID, 1000); err != nil {
+			t.Fatalf("SetEntryExpiry failed: %v", err)
+		}
+		if _, err := store.SetEntryExpiry(ctx, scheduled.ID, future); err != nil {
+			t.Fatalf("SetEntryExpiry failed: %v", err)
+		}
+		for _, entry := range []Entry{deleted, scheduled} {
+			if err := store.DeleteEntry(ctx, entry.ID); err != nil {
+				t.Fatalf("DeleteEntry failed: %v", err)
+			}
+		}
+
+		if _, err := store.RestoreEntry(ctx, kept.ID); !errors.Is(err, ErrEntryNotDeleted) {
+			t.Errorf("Expected ErrEntryNotDeleted, got %v", err)
+		}
+		if _, err := store.RestoreEntry(ctx, uuid.New()); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected ErrEntryNotFound, got %v", err)
+		}
+		if restored, err := store.RestoreEntry(ctx, deleted.ID); err != nil || restored.Deleted {
+			t.Errorf("Expected the deleted entry to be restored, got %+v (%v)", restored, err)
+		}
+		if restored, err := store.RestoreEntry(ctx, expired.ID); err != nil || restored.ExpiresAt != 0 {
+			t.Errorf("Expected the passed expiry to be removed, got %+v (%v)", restored, err)
+		}
+		if restored, err := store.RestoreEntry(ctx, scheduled.ID); err != nil || restored.ExpiresAt != future {
+			t.Errorf("Expected the pending expiry to be kept, got %+v (%v)", restored, err)
+		}
+		if listed, err := store.ListEntries(ctx, journal.ID, false); err != nil || len(listed) != 4 {
+			t.Errorf("Expected the restored entries to be listed, got %d (%v)", len(listed), err)
+		}
+
+		if err := store.DeleteEntry(ctx, kept.ID); err != nil {
+			t.Fatalf("DeleteEntry failed: %v", err)
+		}
+		if cleaned, err := store.CleanDeletedEntriesBefore(ctx, journal.ID, float64(time.Now().Add(-time.Hour).Unix())); err != nil || cleaned != 0 {
+			t.Errorf("Expected a recently deleted entry to stay in the trash, got %d cleaned (%v)", cleaned, err)
+		}
+		if cleaned, err := store.CleanDeletedEntriesBefore(ctx, journal.ID, future); err != nil || cleaned != 1 {
+			t.Errorf("Expected 1 entry cleaned, got %d (%v)", cleaned, err)
+		}
+		if _, err := store.CleanDeletedEntriesBefore(ctx, uuid.New(), future); !errors.Is(err, ErrJournalNotFound) {
+			t.Errorf("Expected ErrJournalNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Links", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		work, err := store.CreateJournal(ctx, "work", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		private, err := store.CreateJournal(ctx, "private", "")
+		if err != nil {
+			t.Fatalf("CreateJournal failed: %v", err)
+		}
+		create := func(journalID uuid.UUID, title string) Entry {
+			t.Helper()
+			entry, err := store.CreateEntry(ctx, journalID, title, "", "")
+			if err != nil {
+				t.Fatalf("CreateEntry failed: %v", err)
+			}
+			return entry
+		}
+		checklist, notes, runbook := create(work.ID, "Deploy checklist"), create(work.ID, "Release notes"), create(work.ID, "Old runbook")
+		rollback, secret := create(work.ID, "Rollback plan"), create(private.ID, "Credentials")
+		link := func(source, target Entry, relation LinkRelation) EntryLink {
+			t.Helper()
+			link, err := store.LinkEntries(ctx, source.ID, target.ID, relation)
+			if err != nil {
+				t.Fatalf("LinkEntries failed: %v", err)
+			}
+			return link
+		}
+		superseded := link(notes, runbook, LinkSupersedes)
+		if superseded.SourceID != notes.ID || superseded.TargetID != runbook.ID || superseded.Relation != LinkSupersedes {
+			t.Errorf("Unexpected link: %+v", superseded)
+		}
+		if again := link(notes, runbook, LinkSupersedes); again != superseded {
+			t.Errorf("Expected linking again to return the existing link, got %+v", again)
+		}
+		link(notes, checklist, LinkRelatesTo)
+		link(checklist, rollback, LinkDependsOn)
+		link(runbook, secret, LinkRelatesTo)
+		if err := store.DeleteEntry(ctx, rollback.ID); err != nil {
+			t.Fatalf("DeleteEntry failed: %v", err)
+		}
+
+		if _, err := store.LinkEntries(ctx, notes.ID, runbook.ID, "replaces"); !errors.Is(err, ErrInvalidLinkRelation) {
+			t.Errorf("Expected ErrInvalidLinkRelation, got %v", err)
+		}
+		if _, err := store.LinkEntries(ctx, notes.ID, notes.ID, LinkRelatesTo); !errors.Is(err, ErrSelfLink) {
+			t.Errorf("Expected ErrSelfLink, got %v", err)
+		}
+		if _, err := store.LinkEntries(ctx, notes.ID, uuid.New(), LinkRelatesTo); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected ErrEntryNotFound, got %v", err)
+		}
+		if links, err := store.ListEntryLinks(ctx, []uuid.UUID{notes.ID}); err != nil || len(links) != 2 {
+			t.Errorf("Expected the 2 links of the entry, got %+v (%v)", links, err)
+		}
+		if links, err := store.ListEntryLinks(ctx, nil); err != nil || len(links) != 0 {
+			t.Errorf("Expected no links, got %+v (%v)", links, err)
+		}
+
+		titles := func(graph EntryGraph) []string {
+			var out []string
+			for _, entry := range graph.Entries {
+				out = append(out, fmt.Sprintf("%s@%d", entry.Title, entry.Depth))
+			}
+			sort.Strings(out)
+			return out
+		}
+		related := func(query GraphQuery) EntryGraph {
+			t.Helper()
+			graph, err := RelatedEntries(ctx, store, checklist.ID, query)
+			if err != nil {
+				t.Fatalf("RelatedEntries failed: %v", err)
+			}
+			return graph
+		}
+		graph := related(GraphQuery{})
+		if got := titles(graph); !reflect.DeepEqual(got, []string{"Deploy checklist@0", "Release notes@1"}) {
+			t.Errorf("Expected the live neighbours of the entry, got %v", got)
+		}
+		if len(graph.Links) != 1 || graph.Links[0].SourceID != notes.ID {
+			t.Errorf("Expected the link between them, got %+v", graph.Links)
+		}
+		graph = related(GraphQuery{Depth: 3})
+		if got := titles(graph); !reflect.DeepEqual(got, []string{"Credentials@3", "Deploy checklist@0", "Old runbook@2", "Release notes@1"}) {
+			t.Errorf("Expected entries three links away, got %v", got)
+		}
+		if len(graph.Links) != 3 {
+			t.Errorf("Expected 3 links, got %+v", graph.Links)
+		}
+		if got := titles(related(GraphQuery{Depth: 3, JournalIDs: []uuid.UUID{work.ID}})); len(got) != 3 {
+			t.Errorf("Expected entries of other journals to be left out, got %v", got)
+		}
+		if got := titles(related(GraphQuery{Depth: 3, Relations: []LinkRelation{LinkRelatesTo}})); len(got) != 2 {
+			t.Errorf("Expected only relates_to links to be followed, got %v", got)
+		}
+		if _, err := RelatedEntries(ctx, store, uuid.New(), GraphQuery{}); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected ErrEntryNotFound, got %v", err)
+		}
+
+		if err := store.UnlinkEntries(ctx, notes.ID, runbook.ID, ""); err != nil {
+			t.Fatalf("UnlinkEntries failed: %v", err)
+		}
+		if err := store.UnlinkEntries(ctx, notes.ID, runbook.ID, LinkSupersedes); !errors.Is(err, ErrLinkNotFound) {
+			t.Errorf("Expected ErrLinkNotFound, got %v", err)
+		}
+		if err := store.UnlinkEntries(ctx, notes.ID, runbook.ID, "replaces"); !errors.Is(err, ErrInvalidLinkRelation) {
+			t.Errorf("Expected ErrInvalidLinkRelation, got %v", err)
+		}
+		journalGraph, err := JournalGraph(ctx, store, work.ID)
+		if err != nil || len(journalGraph.Entries) != 3 || len(journalGraph.Links) != 1 {
+			t.Errorf("Expected the 3 live entries of the journal and 1 link, got %+v (%v)", journalGraph, err)
+		}
+		if _, err := JournalGraph(ctx, store, uuid.New()); !errors.Is(err, ErrJournalNotFound) {
+			t.Errorf("Expected ErrJournalNotFound, got %v", err)
+		}
+
+		if _, err := store.CleanDeletedEntries(ctx, work.ID); err != nil {
+			t.Fatalf("CleanDeletedEntries failed: %v", err)
+		}
+		if links, err := store.ListEntryLinks(ctx, []uuid.UUID{checklist.ID}); err != nil || len(links) != 1 {
+			t.Errorf("Expected the links of a purged entry to be removed, got %+v (%v)", links, err)
+		}
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		store := newStore(t)
+		defer store.Close()
+		ctx := context.Background()
+
+		var journalIDs []uuid.UUID
+		for _, name := range []string{"a", "b", "c"} {
+			journal, err := store.CreateJournal(ctx, name, "")
+			if err != nil {
+				t.Fatalf("CreateJournal failed: %v", err)
+			}
+			journalIDs = append(journalIDs, journal.ID)
+		}
+		for i := 0; i < 7; i++ {
+			entry, err := store.CreateEntry(ctx, journalIDs[0], fmt.Sprintf("Entry %d", i), "", "")
+			if err != nil {
+				t.Fatalf("CreateEntry failed: %v", err)
+			}
+			if i%2 == 0 {
+				if err := store.TagEntry(ctx, entry.ID, "even"); err != nil {
+					t.Fatalf("TagEntry failed: %v", err)
+				}
+			}
+		}
+
+		// pageThrough calls list with a limit of 3 until it returns no cursor and checks that
+		// the pages together hold the same IDs, in the same order, as the unpaged listing.
+		pageThrough := func(name string, list func(page Page) ([]uuid.UUID, string, error)) {
+			t.Helper()
+			all, next, err := list(Page{})
+			if err != nil || next != "" {
+				t.Fatalf("%s: unpaged listing returned cursor %q (%v)", name, next, err)
+			}
+			var paged []uuid.UUID
+			page := Page{Limit: 3}
+			for pages := 1; ; pages++ {
+				ids, next, err := list(page)
+				if err != nil {
+					t.Fatalf("%s: page %d failed: %v", name, pages, err)
+				}
+				if len(ids) > 3 || pages > len(all)+1 {
+					t.Fatalf("%s: page %d holds %d items", name, pages, len(ids))
+				}
+				paged = append(paged, ids...)
+				if next == "" {
+					break
+				}
+				page.Cursor = next
+			}
+			if !reflect.DeepEqual(paged, all) {
+				t.Errorf("%s: pages hold %v, expected %v", name, paged, all)
+			}
+		}
+
+		pageThrough("ListJournalsPage", func(page Page) ([]uuid.UUID, string, error) {
+			journals, next, err := store.ListJournalsPage(ctx, false, page)
+			var ids []uuid.UUID
+			for _, j := range journals {
+				ids = append(ids, j.ID)
+			}
+			return ids, next, err
+		})
+		pageThrough("ListEntriesPage", func(page Page) ([]uuid.UUID, string, error) {
+			entries, next, err := store.ListEntriesPage(ctx, journalIDs[0], false, page)
+			var ids []uuid.UUID
+			for _, e := range entries {
+				ids = append(ids, e.ID)
+			}
+			return ids, next, err
+		})
+		pageThrough("FindEntries", func(page Page) ([]uuid.UUID, string, error) {
+			entries, next, err := store.FindEntries(ctx, EntryFilter{Tags: []string{"even"}}, page)
+			var ids []uuid.UUID
+			for _, e := range entries {
+				ids = append(ids, e.ID)
+			}
+			return ids, next, err
+		})
+
+		entries, next, err := store.ListEntriesPage(ctx, journalIDs[0], false, Page{Limit: 7})
+		if err != nil || len(entries) != 7 || next != "" {
+			t.Errorf("Expected a single full page without a cursor, got %d entries and %q (%v)", len(entries), next, err)
+		}
+		if _, _, err := store.ListEntriesPage(ctx, journalIDs[0], false, Page{Limit: 3, Cursor: "bogus"}); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor, got %v", err)
+		}
+		if _, _, err := store.ListEntriesPage(ctx, uuid.New(), false, Page{Limit: 3}); !errors.Is(err, ErrJournalNotFound) {
+			t.Errorf("Expected ErrJournalNotFound, got %v", err)
+		}
+	})
+}
+
+// tagNames joins the names of tags with commas.
+func tagNames(tags []Tag) string {
+	names := ""
+	for i, tag := range tags {
+		if i > 0 {
+			names += ","
+		}
+		names += tag.Tag
+	}
+	return names
+}
+
+func TestSQLiteStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		return NewSQLiteStore(setupTestDB(t))
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		return NewMemoryStore()
+	})
+}
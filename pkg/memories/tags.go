@@ -38,6 +38,12 @@ const (
 	ORDER BY t.tag
 	`
 
+	listAllTagsStatement = `
+	SELECT tag, created_at, updated_at
+	FROM tags
+	ORDER BY tag
+	`
+
 	listTagsForEntryStatement = `
 	SELECT t.tag, t.created_at, t.updated_at
 	FROM tags t
@@ -52,8 +58,14 @@ const (
 	`
 )
 
+// CreateTag creates a tag, normalized and resolved through its aliases, unless it exists.
 func CreateTag(ctx context.Context, db *sql.DB, tagName string) error {
-	_, err := db.ExecContext(ctx, createTagStatement, tagName)
+	tagName, err := resolveTag(ctx, db, tagName)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, createTagStatement, tagName)
 	if err != nil {
 		return err
 	}
@@ -61,12 +73,18 @@ func CreateTag(ctx context.Context, db *sql.DB, tagName string) error {
 	return nil
 }
 
+// TagEntry attaches a tag, normalized and resolved through its aliases, to an entry.
 func TagEntry(ctx context.Context, db *sql.DB, entryID uuid.UUID, tagName string) error {
 	_, err := GetEntry(ctx, db, entryID)
 	if err != nil {
 		return err
 	}
 
+	tagName, err = resolveTag(ctx, db, tagName)
+	if err != nil {
+		return err
+	}
+
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -86,12 +104,18 @@ func TagEntry(ctx context.Context, db *sql.DB, entryID uuid.UUID, tagName string
 	return tx.Commit()
 }
 
+// DetachTag removes a tag, normalized and resolved through its aliases, from an entry.
 func DetachTag(ctx context.Context, db *sql.DB, entryID uuid.UUID, tagName string) error {
 	_, err := GetEntry(ctx, db, entryID)
 	if err != nil {
 		return err
 	}
 
+	tagName, err = resolveTag(ctx, db, tagName)
+	if err != nil {
+		return err
+	}
+
 	res, err := db.ExecContext(ctx, detachTagFromEntryStatement, entryID, tagName)
 	if err != nil {
 		return err
@@ -144,6 +168,37 @@ func ListTags(ctx context.Context, db *sql.DB, journalID uuid.UUID) ([]Tag, erro
 	return tags, nil
 }
 
+// ListAllTags returns every tag in the database, including tags no entry uses.
+func ListAllTags(ctx context.Context, db *sql.DB) ([]Tag, error) {
+	rows, err := db.QueryContext(ctx, listAllTagsStatement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []Tag
+	for rows.Next() {
+		var tag Tag
+
+		err := rows.Scan(
+			&tag.Tag,
+			&tag.CreatedAt,
+			&tag.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
 func ListTagsForEntry(ctx context.Context, db *sql.DB, entryID uuid.UUID) ([]Tag, error) {
 	_, err := GetEntry(ctx, db, entryID)
 	if err != nil {
@@ -179,8 +234,10 @@ func ListTagsForEntry(ctx context.Context, db *sql.DB, entryID uuid.UUID) ([]Tag
 	return tags, nil
 }
 
+// DeleteTag deletes a tag, normalized but not resolved, so deleting an alias does not
+// delete the tag it stands for, and removes it from all entries.
 func DeleteTag(ctx context.Context, db *sql.DB, tagName string) error {
-	res, err := db.ExecContext(ctx, deleteTagStatement, tagName)
+	res, err := db.ExecContext(ctx, deleteTagStatement, NormalizeTag(tagName))
 	if err != nil {
 		return err
 	}
@@ -2,6 +2,7 @@ package memories
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -244,3 +245,32 @@ func TestDeleteTag(t *testing.T) {
 		t.Errorf("Expected ErrTagNotFound for non-existent tag, got: %v", err)
 	}
 }
+
+func TestTagMatchSQLUsesIndex(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer testDB.Close()
+
+	condition, args := tagMatchSQL("tag", "project/recall")
+	rows, err := testDB.Query("EXPLAIN QUERY PLAN SELECT entry_id FROM entry_tags WHERE "+condition, args...)
+	if err != nil {
+		t.Fatalf("EXPLAIN QUERY PLAN failed: %v", err)
+	}
+	defer rows.Close()
+	var plan []string
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			t.Fatalf("Failed to scan query plan: %v", err)
+		}
+		plan = append(plan, detail)
+	}
+	for _, detail := range plan {
+		if strings.HasPrefix(detail, "SCAN") {
+			t.Errorf("Expected the tag match to search entry_tags_tag_idx, got plan %q", plan)
+		}
+	}
+	if !strings.Contains(strings.Join(plan, "\n"), "entry_tags_tag_idx") {
+		t.Errorf("Expected the tag match to use entry_tags_tag_idx, got plan %q", plan)
+	}
+}
@@ -0,0 +1,111 @@
+package memories
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// TagPathSeparator splits a tag into the path of a hierarchy, as in "project/recall/mcp".
+// Searching for a tag also matches the tags below it: "project/recall" matches
+// "project/recall/mcp", but not "project/recall-docs".
+const TagPathSeparator = "/"
+
+// tagMatches reports whether tag is queryTag or one of its descendants.
+func tagMatches(queryTag, tag string) bool {
+	return tag == queryTag || strings.HasPrefix(tag, queryTag+TagPathSeparator)
+}
+
+// tagMatchSQL returns a condition matching column against queryTag and its descendants,
+// with ? placeholders. Descendants are matched as the range of tags from queryTag+"/"
+// up to queryTag+"0", '0' being the character after '/', so that an index on column
+// can be used.
+func tagMatchSQL(column, queryTag string) (string, []any) {
+	return "(" + column + " = ? OR (" + column + " >= ? AND " + column + " < ?))",
+		[]any{queryTag, queryTag + TagPathSeparator, queryTag + "0"}
+}
+
+// TagNode is a segment of a tag path in a TagTree.
+type TagNode struct {
+	// Name is the last segment of the path.
+	Name string `json:"name"`
+	// Path is the full tag, as in "project/recall".
+	Path string `json:"path"`
+	// Count is the number of entries carrying the tag or a tag below it.
+	Count int `json:"count"`
+	// Direct is the number of entries carrying exactly the tag; 0 for a segment that is
+	// only a parent of other tags.
+	Direct   int       `json:"direct"`
+	Children []TagNode `json:"children,omitempty"`
+}
+
+// TagTree returns the tags of the entries of journalIDs, or of all journals for none, as a
+// forest of their paths sorted by name. Only entries that are neither deleted nor expired
+// are counted, so tags no entry uses are left out.
+func TagTree(ctx context.Context, store Store, journalIDs []uuid.UUID) ([]TagNode, error) {
+	entries, _, err := store.FindEntries(ctx, EntryFilter{JournalIDs: journalIDs}, Page{})
+	if err != nil {
+		return nil, err
+	}
+	return buildTagTree(entries), nil
+}
+
+// tagTreeNode is a TagNode while the tree is built, with the entries counted below it.
+type tagTreeNode struct {
+	name, path string
+	entries    map[uuid.UUID]bool
+	direct     int
+	children   map[string]*tagTreeNode
+}
+
+// child returns the node below n for the segment name, whose full tag is path.
+func (n *tagTreeNode) child(name, path string) *tagTreeNode {
+	c := n.children[name]
+	if c == nil {
+		c = &tagTreeNode{name: name, path: path, entries: make(map[uuid.UUID]bool), children: make(map[string]*tagTreeNode)}
+		n.children[name] = c
+	}
+	return c
+}
+
+// nodes returns the children of n as TagNodes, sorted by name.
+func (n *tagTreeNode) nodes() []TagNode {
+	if len(n.children) == 0 {
+		return nil
+	}
+	nodes := make([]TagNode, 0, len(n.children))
+	for _, c := range n.children {
+		nodes = append(nodes, TagNode{
+			Name:     c.name,
+			Path:     c.path,
+			Count:    len(c.entries),
+			Direct:   c.direct,
+			Children: c.nodes(),
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes
+}
+
+// buildTagTree counts the tags of entries into a forest of tag paths.
+func buildTagTree(entries []TaggedEntry) []TagNode {
+	root := &tagTreeNode{children: make(map[string]*tagTreeNode)}
+	for _, entry := range entries {
+		for _, tag := range entry.Tags {
+			node := root
+			names := strings.Split(tag, TagPathSeparator)
+			for i, name := range names {
+				node = node.child(name, strings.Join(names[:i+1], TagPathSeparator))
+				node.entries[entry.ID] = true
+			}
+			node.direct++
+		}
+	}
+	nodes := root.nodes()
+	if nodes == nil {
+		return []TagNode{}
+	}
+	return nodes
+}
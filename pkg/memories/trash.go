@@ -0,0 +1,60 @@
+package memories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ErrEntryNotDeleted is returned when restoring an entry that is neither deleted nor expired.
+var ErrEntryNotDeleted = errors.New("entry is not deleted")
+
+const (
+	restoreEntryStatement = `
+	UPDATE entries
+	SET deleted = FALSE,
+		expires_at = CASE WHEN expires_at > 0 AND expires_at <= unixepoch() THEN 0 ELSE expires_at END,
+		updated_at = unixepoch()
+	WHERE id = ? AND (deleted = TRUE OR (expires_at > 0 AND expires_at <= unixepoch()))
+	`
+
+	cleanDeletedEntriesBeforeStatement = `
+	DELETE FROM entries
+	WHERE journal_id = ? AND deleted = TRUE AND updated_at < ?
+	`
+)
+
+// RestoreEntry brings a deleted or expired entry back. An expiry that has passed is
+// removed, so the reaper does not delete the entry again; one still ahead is kept.
+func RestoreEntry(ctx context.Context, db *sql.DB, id uuid.UUID) (Entry, error) {
+	res, err := db.ExecContext(ctx, restoreEntryStatement, id)
+	if err != nil {
+		return Entry{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return Entry{}, err
+	} else if n == 0 {
+		if _, err := GetEntry(ctx, db, id); err != nil {
+			return Entry{}, err
+		}
+		return Entry{}, ErrEntryNotDeleted
+	}
+	return GetEntry(ctx, db, id)
+}
+
+// CleanDeletedEntriesBefore permanently deletes the soft-deleted entries of a journal
+// that were deleted before the Unix time before. Entries deleted since stay in the trash.
+func CleanDeletedEntriesBefore(ctx context.Context, db *sql.DB, journalID uuid.UUID, before float64) (int64, error) {
+	if _, err := GetJournal(ctx, db, journalID); err != nil {
+		return 0, err
+	}
+
+	res, err := db.ExecContext(ctx, cleanDeletedEntriesBeforeStatement, journalID, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,93 @@
+package memories
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultVectorIndexMaxAge is how long an EmbeddingStore serves semantic searches from its
+// vector index before reloading it, which picks up entries written by other processes.
+const DefaultVectorIndexMaxAge = 30 * time.Second
+
+// vectorIndex keeps the decoded embeddings of the entries an EmbeddingStore searched, by
+// journal, so that a search compares the query with vectors held in memory instead of
+// reading and decoding every stored embedding. Writes through the store keep it up to
+// date. Searches stay exact: every vector of the journal is compared with the query.
+type vectorIndex struct {
+	mu     sync.Mutex
+	maxAge time.Duration
+	// scopes holds the embeddings of the entries of a journal, or of every journal under
+	// uuid.Nil, that are neither deleted nor expired.
+	scopes map[uuid.UUID]*vectorScope
+}
+
+// vectorScope is the part of a vectorIndex loaded for one journal, or for all of them.
+type vectorScope struct {
+	loadedAt time.Time
+	vectors  map[uuid.UUID][]float32
+}
+
+func newVectorIndex(maxAge time.Duration) *vectorIndex {
+	return &vectorIndex{maxAge: maxAge, scopes: make(map[uuid.UUID]*vectorScope)}
+}
+
+// embeddings returns the embeddings of the entries of a journal, or of every journal for
+// uuid.Nil, and false when they have not been loaded or were loaded too long ago.
+func (x *vectorIndex) embeddings(journalID uuid.UUID, model string) ([]EntryEmbedding, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	scope, ok := x.scopes[journalID]
+	if !ok || time.Since(scope.loadedAt) > x.maxAge {
+		return nil, false
+	}
+	embeddings := make([]EntryEmbedding, 0, len(scope.vectors))
+	for entryID, vector := range scope.vectors {
+		embeddings = append(embeddings, EntryEmbedding{EntryID: entryID, Model: model, Vector: vector})
+	}
+	return embeddings, true
+}
+
+// load replaces the embeddings of a journal, or of every journal for uuid.Nil, with
+// embeddings. Entries without a vector are left out.
+func (x *vectorIndex) load(journalID uuid.UUID, embeddings []EntryEmbedding) {
+	scope := &vectorScope{loadedAt: time.Now(), vectors: make(map[uuid.UUID][]float32, len(embeddings))}
+	for _, embedding := range embeddings {
+		if embedding.Vector != nil {
+			scope.vectors[embedding.EntryID] = embedding.Vector
+		}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.scopes[journalID] = scope
+}
+
+// set stores the vector of an entry of a journal in the loaded scopes that include it.
+func (x *vectorIndex) set(entry Entry, vector []float32) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, journalID := range []uuid.UUID{entry.JournalID, uuid.Nil} {
+		if scope, ok := x.scopes[journalID]; ok {
+			scope.vectors[entry.ID] = vector
+		}
+	}
+}
+
+// remove drops the vector of an entry.
+func (x *vectorIndex) remove(entryID uuid.UUID) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, scope := range x.scopes {
+		delete(scope.vectors, entryID)
+	}
+}
+
+// reset drops every loaded scope, for writes that can change which entries are live.
+func (x *vectorIndex) reset() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.scopes = make(map[uuid.UUID]*vectorScope)
+}
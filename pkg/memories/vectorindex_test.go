@@ -0,0 +1,95 @@
+package memories
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestEmbeddingStoreIndex(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	embedded := NewEmbeddingStore(store, NewHashingEmbedder(0))
+	journal, err := embedded.CreateJournal(ctx, "work", "")
+	if err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+	deploy, err := embedded.CreateEntry(ctx, journal.ID, "Deploy", "Roll out the release to production servers.", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	garden, err := embedded.CreateEntry(ctx, journal.ID, "Garden", "Water the tomatoes twice a week.", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	search := func(query string, limit int) []string {
+		t.Helper()
+		matches, err := embedded.SearchEntriesSemantic(ctx, journal.ID, query, limit)
+		if err != nil {
+			t.Fatalf("SearchEntriesSemantic failed: %v", err)
+		}
+		var titles []string
+		for _, m := range matches {
+			titles = append(titles, m.Title)
+		}
+		return titles
+	}
+
+	if got := search("releasing to production", 1); !reflect.DeepEqual(got, []string{"Deploy"}) {
+		t.Fatalf("Expected the deployment entry, got %v", got)
+	}
+	if _, ok := embedded.index.embeddings(journal.ID, embedded.Embedder().Model()); !ok {
+		t.Fatal("Expected the search to load the index")
+	}
+	indexed, err := embedded.SearchEntriesSemantic(ctx, journal.ID, "water the garden", 0)
+	if err != nil {
+		t.Fatalf("SearchEntriesSemantic failed: %v", err)
+	}
+	scanned, err := SearchEntriesSemantic(ctx, store, embedded.Embedder(), journal.ID, "water the garden", 0)
+	if err != nil {
+		t.Fatalf("SearchEntriesSemantic failed: %v", err)
+	}
+	if !reflect.DeepEqual(indexed, scanned) {
+		t.Errorf("Expected the index to rank like a scan of the store:\n got  %+v\n want %+v", indexed, scanned)
+	}
+
+	// Writes through the store update the index without reloading it.
+	if _, err := embedded.UpdateEntry(ctx, garden.ID, "", "Repot the basil and water the ferns.", ""); err != nil {
+		t.Fatalf("UpdateEntry failed: %v", err)
+	}
+	if got := search("basil ferns", 1); !reflect.DeepEqual(got, []string{"Garden"}) {
+		t.Errorf("Expected updated content to be searched, got %v", got)
+	}
+	if err := embedded.DeleteEntry(ctx, garden.ID); err != nil {
+		t.Fatalf("DeleteEntry failed: %v", err)
+	}
+	if got := search("basil ferns", 0); slices.Contains(got, "Garden") {
+		t.Errorf("Expected deleted entries to be left out, got %v", got)
+	}
+	if _, err := embedded.RestoreEntry(ctx, garden.ID); err != nil {
+		t.Fatalf("RestoreEntry failed: %v", err)
+	}
+	if got := search("basil ferns", 1); !reflect.DeepEqual(got, []string{"Garden"}) {
+		t.Errorf("Expected restored entries to be searched, got %v", got)
+	}
+
+	// Writes by another process show once the index is reloaded, except for deletions,
+	// which are left out right away.
+	if err := store.DeleteEntry(ctx, deploy.ID); err != nil {
+		t.Fatalf("DeleteEntry failed: %v", err)
+	}
+	if got := search("releasing to production", 0); slices.Contains(got, "Deploy") {
+		t.Errorf("Expected entries deleted elsewhere to be left out, got %v", got)
+	}
+	if _, err := store.CreateEntry(ctx, journal.ID, "Incident", "The database ran out of disk space.", ""); err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	if got := search("disk space", 0); slices.Contains(got, "Incident") {
+		t.Errorf("Expected the index not to see other writes before it is reloaded, got %v", got)
+	}
+	embedded.index.maxAge = 0
+	if got := search("disk space", 1); !reflect.DeepEqual(got, []string{"Incident"}) {
+		t.Errorf("Expected a reloaded index to see other writes, got %v", got)
+	}
+}
@@ -0,0 +1,222 @@
+package portability
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unowned-ai/recall/pkg/db"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+const (
+	exportJournalsStatement = `
+	SELECT id, name, COALESCE(description, ''), active, created_at, updated_at, expiry_policy
+	FROM journals
+	WHERE (? OR id = ?)
+	ORDER BY created_at, id
+	`
+
+	// exportTagsStatement selects every tag for a full export, and only the tags
+	// attached to exported entries when a single journal is exported.
+	exportTagsStatement = `
+	SELECT t.tag, t.created_at, t.updated_at
+	FROM tags t
+	WHERE ? OR t.tag IN (
+		SELECT et.tag
+		FROM entry_tags et
+		JOIN entries e ON e.id = et.entry_id
+		WHERE e.journal_id = ? AND (e.deleted = FALSE OR ? = TRUE)
+	)
+	ORDER BY t.tag
+	`
+
+	// exportTagAliasesStatement selects every alias for a full export, and only the aliases
+	// of exported tags when a single journal is exported.
+	exportTagAliasesStatement = `
+	SELECT a.alias, a.tag, a.created_at
+	FROM tag_aliases a
+	WHERE ? OR a.tag IN (
+		SELECT et.tag
+		FROM entry_tags et
+		JOIN entries e ON e.id = et.entry_id
+		WHERE e.journal_id = ? AND (e.deleted = FALSE OR ? = TRUE)
+	)
+	ORDER BY a.tag, a.alias
+	`
+
+	exportEntriesStatement = `
+	SELECT id, journal_id, title, content, content_type, deleted, created_at, updated_at, expires_at
+	FROM entries
+	WHERE (? OR journal_id = ?) AND (deleted = FALSE OR ? = TRUE)
+	ORDER BY created_at, id
+	`
+
+	exportEntryTagsStatement = `
+	SELECT et.entry_id, et.tag, et.created_at
+	FROM entry_tags et
+	JOIN entries e ON e.id = et.entry_id
+	WHERE (? OR e.journal_id = ?) AND (e.deleted = FALSE OR ? = TRUE)
+	ORDER BY e.created_at, e.id, et.tag
+	`
+
+	// exportEntryLinksStatement selects the links whose source and target are both exported.
+	exportEntryLinksStatement = `
+	SELECT l.source_id, l.target_id, l.relation, l.created_at
+	FROM entry_links l
+	JOIN entries s ON s.id = l.source_id
+	JOIN entries t ON t.id = l.target_id
+	WHERE (? OR (s.journal_id = ? AND t.journal_id = ?))
+		AND ((s.deleted = FALSE AND t.deleted = FALSE) OR ? = TRUE)
+	ORDER BY l.created_at, l.source_id, l.target_id, l.relation
+	`
+)
+
+// ExportOptions selects what Export writes.
+type ExportOptions struct {
+	// JournalID limits the export to one journal; uuid.Nil exports every journal.
+	JournalID uuid.UUID
+	// IncludeDeleted also exports soft-deleted entries.
+	IncludeDeleted bool
+}
+
+// Export writes the selected journals, tags, tag aliases, entries, entry tags and entry
+// links to w as JSONL.
+// All records are read in one transaction, so the export is a consistent snapshot.
+func Export(ctx context.Context, dbConn *sql.DB, w io.Writer, opts ExportOptions) (Stats, error) {
+	var stats Stats
+
+	schemaVersion, err := db.GetComponentSchemaVersion(dbConn, db.MemoriesDBComponent)
+	if err != nil {
+		return stats, err
+	}
+
+	tx, err := dbConn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return stats, err
+	}
+	defer tx.Rollback()
+
+	allJournals := opts.JournalID == uuid.Nil
+	if !allJournals {
+		var exists bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM journals WHERE id = ?)`, opts.JournalID).Scan(&exists)
+		if err != nil {
+			return stats, err
+		}
+		if !exists {
+			return stats, memories.ErrJournalNotFound
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	header := Header{
+		Format:        FormatName,
+		Version:       FormatVersion,
+		SchemaVersion: schemaVersion,
+		ExportedAt:    float64(time.Now().Unix()),
+	}
+	if err := enc.Encode(Record{Type: RecordHeader, Header: &header}); err != nil {
+		return stats, err
+	}
+
+	err = exportRows(ctx, tx, exportJournalsStatement, []any{allJournals, opts.JournalID}, func(rows *sql.Rows) error {
+		var journal memories.Journal
+		if err := rows.Scan(&journal.ID, &journal.Name, &journal.Description, &journal.Active, &journal.CreatedAt, &journal.UpdatedAt, &journal.ExpiryPolicy); err != nil {
+			return err
+		}
+		stats.Journals++
+		return enc.Encode(Record{Type: RecordJournal, Journal: &journal})
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to export journals: %w", err)
+	}
+
+	err = exportRows(ctx, tx, exportTagsStatement, []any{allJournals, opts.JournalID, opts.IncludeDeleted}, func(rows *sql.Rows) error {
+		var tag memories.Tag
+		if err := rows.Scan(&tag.Tag, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return err
+		}
+		stats.Tags++
+		return enc.Encode(Record{Type: RecordTag, Tag: &tag})
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to export tags: %w", err)
+	}
+
+	err = exportRows(ctx, tx, exportTagAliasesStatement, []any{allJournals, opts.JournalID, opts.IncludeDeleted}, func(rows *sql.Rows) error {
+		var tagAlias memories.TagAlias
+		if err := rows.Scan(&tagAlias.Alias, &tagAlias.Tag, &tagAlias.CreatedAt); err != nil {
+			return err
+		}
+		stats.TagAliases++
+		return enc.Encode(Record{Type: RecordTagAlias, TagAlias: &tagAlias})
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to export tag aliases: %w", err)
+	}
+
+	err = exportRows(ctx, tx, exportEntriesStatement, []any{allJournals, opts.JournalID, opts.IncludeDeleted}, func(rows *sql.Rows) error {
+		var entry memories.Entry
+		if err := rows.Scan(&entry.ID, &entry.JournalID, &entry.Title, &entry.Content, &entry.ContentType, &entry.Deleted, &entry.CreatedAt, &entry.UpdatedAt, &entry.ExpiresAt); err != nil {
+			return err
+		}
+		stats.Entries++
+		return enc.Encode(Record{Type: RecordEntry, Entry: &entry})
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to export entries: %w", err)
+	}
+
+	err = exportRows(ctx, tx, exportEntryTagsStatement, []any{allJournals, opts.JournalID, opts.IncludeDeleted}, func(rows *sql.Rows) error {
+		var entryTag memories.EntryTag
+		if err := rows.Scan(&entryTag.EntryID, &entryTag.Tag, &entryTag.CreatedAt); err != nil {
+			return err
+		}
+		stats.EntryTags++
+		return enc.Encode(Record{Type: RecordEntryTag, EntryTag: &entryTag})
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to export entry tags: %w", err)
+	}
+
+	err = exportRows(ctx, tx, exportEntryLinksStatement, []any{allJournals, opts.JournalID, opts.JournalID, opts.IncludeDeleted}, func(rows *sql.Rows) error {
+		var link memories.EntryLink
+		if err := rows.Scan(&link.SourceID, &link.TargetID, &link.Relation, &link.CreatedAt); err != nil {
+			return err
+		}
+		stats.EntryLinks++
+		return enc.Encode(Record{Type: RecordEntryLink, EntryLink: &link})
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to export entry links: %w", err)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// exportRows runs query inside tx and calls write for each row.
+func exportRows(ctx context.Context, tx *sql.Tx, query string, args []any, write func(rows *sql.Rows) error) error {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := write(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
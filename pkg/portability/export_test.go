@@ -0,0 +1,158 @@
+package portability
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/unowned-ai/recall/pkg/db"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	testDB, err := db.OpenDBConnection(":memory:", true, "NORMAL")
+	if err != nil {
+		t.Fatalf("Failed to open in-memory database: %v", err)
+	}
+
+	if err := db.InitializeSchema(testDB, db.TargetSchemaVersion); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	return testDB
+}
+
+// seedTestData creates two journals: "work", which purges expired entries, with a tagged
+// entry that expires in 2100 and a soft-deleted entry, and "home" with one entry, which the
+// tagged entry links to. "todo" is an alias of the tag "tasks".
+func seedTestData(t *testing.T, ctx context.Context, testDB *sql.DB) (work, home memories.Journal) {
+	t.Helper()
+
+	work, err := memories.CreateJournal(ctx, testDB, "work", "Work notes")
+	if err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+	work, err = memories.SetJournalExpiryPolicy(ctx, testDB, work.ID, memories.ExpiryPurge)
+	if err != nil {
+		t.Fatalf("SetJournalExpiryPolicy failed: %v", err)
+	}
+	home, err = memories.CreateJournal(ctx, testDB, "home", "")
+	if err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+
+	report, err := memories.CreateEntry(ctx, testDB, work.ID, "report", "finish the report", "text/markdown")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	for _, tag := range []string{"urgent", "tasks"} {
+		if err := memories.TagEntry(ctx, testDB, report.ID, tag); err != nil {
+			t.Fatalf("TagEntry failed: %v", err)
+		}
+	}
+	if _, err := memories.AliasTag(ctx, testDB, "todo", "tasks"); err != nil {
+		t.Fatalf("AliasTag failed: %v", err)
+	}
+	if _, err := memories.SetEntryExpiry(ctx, testDB, report.ID, 4102444800); err != nil {
+		t.Fatalf("SetEntryExpiry failed: %v", err)
+	}
+
+	stale, err := memories.CreateEntry(ctx, testDB, work.ID, "stale", "old idea", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	if err := memories.TagEntry(ctx, testDB, stale.ID, "ideas"); err != nil {
+		t.Fatalf("TagEntry failed: %v", err)
+	}
+	if err := memories.DeleteEntry(ctx, testDB, stale.ID); err != nil {
+		t.Fatalf("DeleteEntry failed: %v", err)
+	}
+
+	groceries, err := memories.CreateEntry(ctx, testDB, home.ID, "groceries", "milk, eggs", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	if err := memories.TagEntry(ctx, testDB, groceries.ID, "shopping"); err != nil {
+		t.Fatalf("TagEntry failed: %v", err)
+	}
+	if _, err := memories.LinkEntries(ctx, testDB, report.ID, groceries.ID, memories.LinkRelatesTo); err != nil {
+		t.Fatalf("LinkEntries failed: %v", err)
+	}
+
+	return work, home
+}
+
+func decodeRecords(t *testing.T, data []byte) []Record {
+	t.Helper()
+
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Export line is not a JSON record: %v\n%s", err, scanner.Text())
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestExport(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer testDB.Close()
+
+	ctx := context.Background()
+	work, _ := seedTestData(t, ctx, testDB)
+
+	var buf bytes.Buffer
+	stats, err := Export(ctx, testDB, &buf, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if stats.Journals != 2 || stats.Entries != 2 || stats.EntryTags != 3 || stats.Tags != 4 || stats.TagAliases != 1 || stats.EntryLinks != 1 {
+		t.Errorf("Unexpected export stats: %+v", stats)
+	}
+
+	records := decodeRecords(t, buf.Bytes())
+	if len(records) != 1+stats.Journals+stats.Tags+stats.TagAliases+stats.Entries+stats.EntryTags+stats.EntryLinks {
+		t.Fatalf("Expected one line per record plus a header, got %d lines", len(records))
+	}
+	header := records[0]
+	if header.Type != RecordHeader || header.Header == nil || header.Header.Format != FormatName ||
+		header.Header.Version != FormatVersion || header.Header.SchemaVersion != db.TargetSchemaVersion {
+		t.Errorf("Unexpected header record: %+v", header.Header)
+	}
+
+	// Records must only refer to records that came before them.
+	order := map[RecordType]int{
+		RecordHeader: 0, RecordJournal: 1, RecordTag: 2, RecordTagAlias: 3, RecordEntry: 4, RecordEntryTag: 5, RecordEntryLink: 6,
+	}
+	for i := 1; i < len(records); i++ {
+		if order[records[i].Type] < order[records[i-1].Type] {
+			t.Errorf("Record %d of type %s follows a %s record", i+1, records[i].Type, records[i-1].Type)
+		}
+	}
+
+	buf.Reset()
+	stats, err = Export(ctx, testDB, &buf, ExportOptions{JournalID: work.ID, IncludeDeleted: true})
+	if err != nil {
+		t.Fatalf("Export of a single journal failed: %v", err)
+	}
+	// The link to the other journal's entry is left out, as its target is not exported.
+	if stats.Journals != 1 || stats.Entries != 2 || stats.EntryTags != 3 || stats.Tags != 3 || stats.TagAliases != 1 || stats.EntryLinks != 0 {
+		t.Errorf("Unexpected single-journal export stats: %+v", stats)
+	}
+	for _, record := range decodeRecords(t, buf.Bytes()) {
+		if record.Entry != nil && record.Entry.JournalID != work.ID {
+			t.Errorf("Single-journal export contains entry %s from journal %s", record.Entry.ID, record.Entry.JournalID)
+		}
+		if record.Tag != nil && record.Tag.Tag == "shopping" {
+			t.Errorf("Single-journal export contains a tag only used by another journal")
+		}
+	}
+}
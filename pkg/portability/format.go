@@ -0,0 +1,68 @@
+// Package portability moves recall data in and out of a database as versioned JSONL.
+//
+// An export is a stream of JSON objects, one per line. The first line is a header
+// naming the format and its version; it is followed by every journal, then every tag and
+// tag alias, then every entry, every entry/tag association and finally every link between
+// entries, so that each record only refers to records that came before it. IDs and
+// timestamps are written as stored.
+package portability
+
+import (
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+const (
+	// FormatName identifies recall exports in the header record.
+	FormatName = "recall-export"
+
+	// FormatVersion is the version of the JSONL layout written by Export.
+	// Import accepts this version and any older one. Version 2 adds entry links and tag aliases.
+	FormatVersion = 2
+)
+
+// RecordType tells which field of a Record is set.
+type RecordType string
+
+const (
+	RecordHeader    RecordType = "header"
+	RecordJournal   RecordType = "journal"
+	RecordTag       RecordType = "tag"
+	RecordTagAlias  RecordType = "tag_alias"
+	RecordEntry     RecordType = "entry"
+	RecordEntryTag  RecordType = "entry_tag"
+	RecordEntryLink RecordType = "entry_link"
+)
+
+// Header is the first record of every export.
+type Header struct {
+	Format        string  `json:"format"`
+	Version       int     `json:"version"`
+	SchemaVersion int64   `json:"schema_version"`
+	ExportedAt    float64 `json:"exported_at"`
+}
+
+// Record is a single line of an export. Exactly one of the payload fields is set, matching Type.
+type Record struct {
+	Type      RecordType          `json:"type"`
+	Header    *Header             `json:"header,omitempty"`
+	Journal   *memories.Journal   `json:"journal,omitempty"`
+	Tag       *memories.Tag       `json:"tag,omitempty"`
+	TagAlias  *memories.TagAlias  `json:"tag_alias,omitempty"`
+	Entry     *memories.Entry     `json:"entry,omitempty"`
+	EntryTag  *memories.EntryTag  `json:"entry_tag,omitempty"`
+	EntryLink *memories.EntryLink `json:"entry_link,omitempty"`
+}
+
+// Stats counts the records written by Export or applied by Import.
+type Stats struct {
+	Journals   int `json:"journals"`
+	Tags       int `json:"tags"`
+	TagAliases int `json:"tag_aliases"`
+	Entries    int `json:"entries"`
+	EntryTags  int `json:"entry_tags"`
+	EntryLinks int `json:"entry_links"`
+	// Skipped counts journals and entries left untouched because their ID already existed.
+	Skipped int `json:"skipped"`
+	// Updated counts journals and entries overwritten because their ID already existed.
+	Updated int `json:"updated"`
+}
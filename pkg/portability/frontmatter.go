@@ -0,0 +1,271 @@
+package portability
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// frontMatter is the YAML block at the top of an exported Markdown entry.
+// Only this fixed set of keys is written; other keys added by editors are ignored on import.
+type frontMatter struct {
+	ID          uuid.UUID
+	JournalID   uuid.UUID
+	Title       string
+	Tags        []string
+	ContentType string
+	Deleted     bool
+	CreatedAt   float64
+	UpdatedAt   float64
+	ExpiresAt   float64
+	Links       []frontMatterLink
+}
+
+// frontMatterLink is a link from the entry to another one, written as "relation target-id".
+type frontMatterLink struct {
+	Relation string
+	TargetID uuid.UUID
+}
+
+// String renders the link as it is written in front matter.
+func (l frontMatterLink) String() string {
+	return l.Relation + " " + l.TargetID.String()
+}
+
+const frontMatterDelimiter = "---"
+
+// plainYAMLScalar matches strings that YAML reads back unchanged without quotes.
+var plainYAMLScalar = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_./-]*$`)
+
+// yamlString renders s as a YAML scalar, double-quoting it unless it is unambiguous as a plain string.
+// Go's quoted-string escapes are a subset of YAML's double-quoted escapes.
+func yamlString(s string) string {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return strconv.Quote(s)
+	}
+	if plainYAMLScalar.MatchString(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// formatFrontMatterTime renders a unix timestamp as RFC 3339 in UTC.
+func formatFrontMatterTime(timestamp float64) string {
+	return unixTime(timestamp).UTC().Format(time.RFC3339Nano)
+}
+
+// writeMarkdownEntry renders fm as YAML front matter followed by the entry content.
+func writeMarkdownEntry(fm frontMatter, content string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelimiter + "\n")
+	fmt.Fprintf(&buf, "id: %s\n", fm.ID)
+	fmt.Fprintf(&buf, "journal_id: %s\n", fm.JournalID)
+	fmt.Fprintf(&buf, "title: %s\n", yamlString(fm.Title))
+	if len(fm.Tags) == 0 {
+		buf.WriteString("tags: []\n")
+	} else {
+		buf.WriteString("tags:\n")
+		for _, tag := range fm.Tags {
+			fmt.Fprintf(&buf, "  - %s\n", yamlString(tag))
+		}
+	}
+	fmt.Fprintf(&buf, "content_type: %s\n", yamlString(fm.ContentType))
+	if fm.Deleted {
+		buf.WriteString("deleted: true\n")
+	}
+	fmt.Fprintf(&buf, "created_at: %s\n", formatFrontMatterTime(fm.CreatedAt))
+	fmt.Fprintf(&buf, "updated_at: %s\n", formatFrontMatterTime(fm.UpdatedAt))
+	if fm.ExpiresAt != 0 {
+		fmt.Fprintf(&buf, "expires_at: %s\n", formatFrontMatterTime(fm.ExpiresAt))
+	}
+	if len(fm.Links) > 0 {
+		buf.WriteString("links:\n")
+		for _, link := range fm.Links {
+			fmt.Fprintf(&buf, "  - %s\n", yamlString(link.String()))
+		}
+	}
+	buf.WriteString(frontMatterDelimiter + "\n")
+	buf.WriteString(content)
+	return buf.Bytes()
+}
+
+// parseMarkdownEntry splits a Markdown file into its front matter and content.
+// Files without a front matter block are returned whole as content with ok set to false.
+func parseMarkdownEntry(data []byte) (fm frontMatter, content string, ok bool, err error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, frontMatterDelimiter+"\n") {
+		return frontMatter{}, text, false, nil
+	}
+
+	rest := text[len(frontMatterDelimiter)+1:]
+	var block string
+	switch {
+	case strings.HasPrefix(rest, frontMatterDelimiter+"\n"):
+		content = rest[len(frontMatterDelimiter)+1:]
+	case rest == frontMatterDelimiter:
+	default:
+		end := strings.Index(rest, "\n"+frontMatterDelimiter+"\n")
+		if end >= 0 {
+			block, content = rest[:end], rest[end+len(frontMatterDelimiter)+2:]
+		} else if strings.HasSuffix(rest, "\n"+frontMatterDelimiter) {
+			block = strings.TrimSuffix(rest, "\n"+frontMatterDelimiter)
+		} else {
+			return frontMatter{}, "", false, fmt.Errorf("front matter is not closed with '%s'", frontMatterDelimiter)
+		}
+	}
+
+	lines := strings.Split(block, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || line[0] == '-' {
+			// Continuation of a key we don't read, such as a nested map or list.
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return frontMatter{}, "", false, fmt.Errorf("front matter line %d is not a 'key: value' pair", i+1)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch key {
+		case "id", "journal_id":
+			if value == "" {
+				continue
+			}
+			id, err := uuid.Parse(unquoteYAML(value))
+			if err != nil {
+				return frontMatter{}, "", false, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			if key == "id" {
+				fm.ID = id
+			} else {
+				fm.JournalID = id
+			}
+		case "title":
+			fm.Title = unquoteYAML(value)
+		case "content_type":
+			fm.ContentType = unquoteYAML(value)
+		case "deleted":
+			fm.Deleted = unquoteYAML(value) == "true"
+		case "created_at", "updated_at", "expires_at":
+			if value == "" {
+				continue
+			}
+			timestamp, err := parseFrontMatterTime(unquoteYAML(value))
+			if err != nil {
+				return frontMatter{}, "", false, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			switch key {
+			case "created_at":
+				fm.CreatedAt = timestamp
+			case "updated_at":
+				fm.UpdatedAt = timestamp
+			default:
+				fm.ExpiresAt = timestamp
+			}
+		case "tags":
+			if value != "" {
+				fm.Tags = parseInlineTags(value)
+				continue
+			}
+			for i+1 < len(lines) {
+				item := strings.TrimSpace(lines[i+1])
+				if !strings.HasPrefix(item, "-") {
+					break
+				}
+				i++
+				if tag := normalizeVaultTag(unquoteYAML(strings.TrimSpace(strings.TrimPrefix(item, "-")))); tag != "" {
+					fm.Tags = append(fm.Tags, tag)
+				}
+			}
+		case "links":
+			var items []string
+			if value != "" {
+				items = strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"), ",")
+			}
+			for value == "" && i+1 < len(lines) {
+				item := strings.TrimSpace(lines[i+1])
+				if !strings.HasPrefix(item, "-") {
+					break
+				}
+				i++
+				items = append(items, strings.TrimPrefix(item, "-"))
+			}
+			for _, item := range items {
+				item = unquoteYAML(strings.TrimSpace(item))
+				if item == "" {
+					continue
+				}
+				link, err := parseFrontMatterLink(item)
+				if err != nil {
+					return frontMatter{}, "", false, err
+				}
+				fm.Links = append(fm.Links, link)
+			}
+		}
+	}
+	return fm, content, true, nil
+}
+
+// parseFrontMatterLink reads a link written as "relation target-id".
+func parseFrontMatterLink(item string) (frontMatterLink, error) {
+	fields := strings.Fields(item)
+	if len(fields) != 2 {
+		return frontMatterLink{}, fmt.Errorf("invalid link %q: must be 'relation target-id'", item)
+	}
+	targetID, err := uuid.Parse(fields[1])
+	if err != nil {
+		return frontMatterLink{}, fmt.Errorf("invalid link %q: %w", item, err)
+	}
+	return frontMatterLink{Relation: fields[0], TargetID: targetID}, nil
+}
+
+// parseInlineTags reads a flow list ("[a, b]") or a comma-separated value ("a, b").
+func parseInlineTags(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	var tags []string
+	for _, item := range strings.Split(value, ",") {
+		if tag := normalizeVaultTag(unquoteYAML(strings.TrimSpace(item))); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// normalizeVaultTag drops the leading '#' Obsidian-style editors allow in tag lists.
+func normalizeVaultTag(tag string) string {
+	return strings.TrimSpace(strings.TrimPrefix(tag, "#"))
+}
+
+// unquoteYAML returns the string value of a single- or double-quoted YAML scalar, or value itself if it is plain.
+func unquoteYAML(value string) string {
+	if len(value) >= 2 {
+		switch {
+		case value[0] == '"' && value[len(value)-1] == '"':
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				return unquoted
+			}
+			return value[1 : len(value)-1]
+		case value[0] == '\'' && value[len(value)-1] == '\'':
+			return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+		}
+	}
+	return value
+}
+
+// parseFrontMatterTime accepts RFC 3339 timestamps as written by export, or unix seconds.
+func parseFrontMatterTime(value string) (float64, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return float64(t.Unix()) + float64(t.Nanosecond())/float64(time.Second), nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
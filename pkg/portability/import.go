@@ -0,0 +1,571 @@
+package portability
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+var (
+	// ErrIDConflict is returned by Import when a record's ID already exists and no merge mode is set.
+	ErrIDConflict = errors.New("id already exists")
+
+	// ErrInvalidExport is returned by Import for input that is not a recall export it can read.
+	ErrInvalidExport = errors.New("invalid recall export")
+)
+
+// MergeMode decides what Import does with journals and entries whose ID already exists.
+type MergeMode string
+
+const (
+	// MergeNone fails the import on the first existing ID.
+	MergeNone MergeMode = ""
+	// MergeSkip keeps existing journals and entries, including their tags and the links from them, untouched.
+	MergeSkip MergeMode = "skip"
+	// MergeUpdate overwrites existing journals and entries, and replaces entry tags and the
+	// links from those entries, with the imported ones.
+	MergeUpdate MergeMode = "update"
+)
+
+// ParseMergeMode converts a --merge flag value into a MergeMode.
+func ParseMergeMode(value string) (MergeMode, error) {
+	switch mode := MergeMode(value); mode {
+	case MergeNone, MergeSkip, MergeUpdate:
+		return mode, nil
+	default:
+		return MergeNone, fmt.Errorf("invalid merge mode '%s': must be '%s' or '%s'", value, MergeSkip, MergeUpdate)
+	}
+}
+
+// ImportOptions controls how Import resolves records that already exist.
+type ImportOptions struct {
+	Merge MergeMode
+	// RemapIDs gives every imported journal and entry a new ID, so the import never
+	// collides with existing data; references between records are rewritten to match.
+	RemapIDs bool
+}
+
+// importActor attributes revisions recorded by MergeUpdate.
+const importActor = "import"
+
+const (
+	journalExistsStatement = `SELECT EXISTS (SELECT 1 FROM journals WHERE id = ?)`
+
+	entryExistsStatement = `SELECT EXISTS (SELECT 1 FROM entries WHERE id = ?)`
+
+	importJournalStatement = `
+	INSERT INTO journals (id, name, description, active, created_at, updated_at, expiry_policy)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	overwriteJournalStatement = `
+	UPDATE journals
+	SET name = ?, description = ?, active = ?, created_at = ?, updated_at = ?, expiry_policy = ?
+	WHERE id = ?
+	`
+
+	importTagStatement = `
+	INSERT OR IGNORE INTO tags (tag, created_at, updated_at)
+	VALUES (?, ?, ?)
+	`
+
+	importEntryStatement = `
+	INSERT INTO entries (id, journal_id, title, content, content_type, deleted, created_at, updated_at, expires_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	// recordOverwrittenEntryStatement keeps the current version of an entry as a revision
+	// before MergeUpdate replaces it, unless the import leaves it unchanged.
+	recordOverwrittenEntryStatement = `
+	INSERT INTO entry_revisions (entry_id, revision, title, content, content_type, actor)
+	SELECT e.id,
+		(SELECT COALESCE(MAX(r.revision), 0) + 1 FROM entry_revisions r WHERE r.entry_id = e.id),
+		e.title, e.content, e.content_type, ?
+	FROM entries e
+	WHERE e.id = ? AND (e.title != ? OR e.content != ? OR e.content_type != ?)
+	`
+
+	// dropChangedEntryEmbeddingsStatement drops the embeddings of an entry before MergeUpdate
+	// changes its content, like an update does, so it is embedded again.
+	dropChangedEntryEmbeddingsStatement = `
+	DELETE FROM entry_embeddings
+	WHERE entry_id = ? AND EXISTS (
+		SELECT 1 FROM entries e
+		WHERE e.id = entry_embeddings.entry_id AND (e.title != ? OR e.content != ? OR e.content_type != ?)
+	)
+	`
+
+	overwriteEntryStatement = `
+	UPDATE entries
+	SET journal_id = ?, title = ?, content = ?, content_type = ?, deleted = ?, created_at = ?, updated_at = ?, expires_at = ?
+	WHERE id = ?
+	`
+
+	clearEntryTagsStatement = `DELETE FROM entry_tags WHERE entry_id = ?`
+
+	importEntryTagStatement = `
+	INSERT OR IGNORE INTO entry_tags (entry_id, tag, created_at)
+	VALUES (?, ?, ?)
+	`
+
+	clearEntryLinksStatement = `DELETE FROM entry_links WHERE source_id = ?`
+
+	importEntryLinkStatement = `
+	INSERT OR IGNORE INTO entry_links (source_id, target_id, relation, created_at)
+	VALUES (?, ?, ?, ?)
+	`
+
+	listTagAliasesStatement = `SELECT alias, tag FROM tag_aliases`
+
+	// The statements below make an imported alias stand for its tag like memories.AliasTag
+	// does: a tag named like the alias is merged into the tag first.
+
+	retagEntriesStatement = `
+	INSERT OR IGNORE INTO entry_tags (entry_id, tag, created_at)
+	SELECT entry_id, ?, created_at FROM entry_tags WHERE tag = ?
+	`
+
+	moveTagAliasesStatement = `UPDATE tag_aliases SET tag = ? WHERE tag = ?`
+
+	detachTagFromAllEntriesStatement = `DELETE FROM entry_tags WHERE tag = ?`
+
+	deleteTagStatement = `DELETE FROM tags WHERE tag = ?`
+
+	importTagAliasStatement = `
+	INSERT INTO tag_aliases (alias, tag, created_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT (alias) DO UPDATE SET tag = excluded.tag, created_at = excluded.created_at
+	`
+)
+
+// importer applies records to a transaction and remembers how IDs were resolved.
+type importer struct {
+	tx    *sql.Tx
+	opts  ImportOptions
+	stats Stats
+
+	journalIDs map[uuid.UUID]uuid.UUID
+	entryIDs   map[uuid.UUID]uuid.UUID
+	// skippedEntries holds entries left untouched by MergeSkip; their tags and the links from
+	// them are not changed either.
+	skippedEntries map[uuid.UUID]bool
+	// aliases maps the aliases in the database, including those imported so far, to the
+	// tags they stand for.
+	aliases map[string]string
+}
+
+// newImporter returns an importer writing to tx, with the tag aliases already in the
+// database loaded.
+func newImporter(ctx context.Context, tx *sql.Tx, opts ImportOptions) (*importer, error) {
+	imp := &importer{
+		tx:             tx,
+		opts:           opts,
+		journalIDs:     make(map[uuid.UUID]uuid.UUID),
+		entryIDs:       make(map[uuid.UUID]uuid.UUID),
+		skippedEntries: make(map[uuid.UUID]bool),
+		aliases:        make(map[string]string),
+	}
+
+	rows, err := tx.QueryContext(ctx, listTagAliasesStatement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tag aliases: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var alias, tag string
+		if err := rows.Scan(&alias, &tag); err != nil {
+			return nil, err
+		}
+		imp.aliases[alias] = tag
+	}
+	return imp, rows.Err()
+}
+
+// Import reads a JSONL export from r and writes its records to dbConn in a single
+// transaction: either every record is applied or, on error, none is.
+func Import(ctx context.Context, dbConn *sql.DB, r io.Reader, opts ImportOptions) (Stats, error) {
+	if opts.RemapIDs && opts.Merge != MergeNone {
+		return Stats{}, errors.New("merge modes cannot be combined with remapping IDs")
+	}
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer tx.Rollback()
+
+	imp, err := newImporter(ctx, tx, opts)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	err = readRecords(r, func(record Record) error {
+		return imp.apply(ctx, record)
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Stats{}, err
+	}
+	return imp.stats, nil
+}
+
+// readRecords reads a JSONL export from r, checks its header and passes every
+// following record to apply, stopping at the first error.
+func readRecords(r io.Reader, apply func(Record) error) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for line := 1; ; line++ {
+		var record Record
+		if err := dec.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				if line == 1 {
+					return fmt.Errorf("%w: input is empty", ErrInvalidExport)
+				}
+				return nil
+			}
+			return fmt.Errorf("%w: record %d: %v", ErrInvalidExport, line, err)
+		}
+
+		var err error
+		if line == 1 {
+			err = checkHeader(record)
+		} else {
+			err = apply(record)
+		}
+		if err != nil {
+			return fmt.Errorf("record %d: %w", line, err)
+		}
+	}
+}
+
+// checkHeader verifies that the first record announces a format version Import understands.
+func checkHeader(record Record) error {
+	if record.Type != RecordHeader || record.Header == nil {
+		return fmt.Errorf("%w: missing header", ErrInvalidExport)
+	}
+	if record.Header.Format != FormatName {
+		return fmt.Errorf("%w: unknown format '%s'", ErrInvalidExport, record.Header.Format)
+	}
+	if record.Header.Version < 1 || record.Header.Version > FormatVersion {
+		return fmt.Errorf("%w: format version %d is not supported (this build reads up to version %d)", ErrInvalidExport, record.Header.Version, FormatVersion)
+	}
+	return nil
+}
+
+func (imp *importer) apply(ctx context.Context, record Record) error {
+	switch {
+	case record.Type == RecordJournal && record.Journal != nil:
+		return imp.importJournal(ctx, record)
+	case record.Type == RecordTag && record.Tag != nil:
+		tag := record.Tag
+		name, err := imp.resolveTag(tag.Tag)
+		if err != nil {
+			return err
+		}
+		if _, err := imp.tx.ExecContext(ctx, importTagStatement, name, tag.CreatedAt, tag.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to import tag '%s': %w", name, err)
+		}
+		imp.stats.Tags++
+		return nil
+	case record.Type == RecordTagAlias && record.TagAlias != nil:
+		return imp.importTagAlias(ctx, record)
+	case record.Type == RecordEntry && record.Entry != nil:
+		return imp.importEntry(ctx, record)
+	case record.Type == RecordEntryTag && record.EntryTag != nil:
+		return imp.importEntryTag(ctx, record)
+	case record.Type == RecordEntryLink && record.EntryLink != nil:
+		return imp.importEntryLink(ctx, record)
+	case record.Type == RecordHeader:
+		return fmt.Errorf("%w: unexpected second header", ErrInvalidExport)
+	default:
+		return fmt.Errorf("%w: unknown or empty record of type '%s'", ErrInvalidExport, record.Type)
+	}
+}
+
+func (imp *importer) importJournal(ctx context.Context, record Record) error {
+	journal := *record.Journal
+	originalID := journal.ID
+	// Exports written before entry expiry existed leave the policy out.
+	if journal.ExpiryPolicy == "" {
+		journal.ExpiryPolicy = memories.ExpirySoftDelete
+	}
+
+	if imp.opts.RemapIDs {
+		journal.ID = uuid.New()
+	} else {
+		exists, err := imp.exists(ctx, journalExistsStatement, journal.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			switch imp.opts.Merge {
+			case MergeSkip:
+				imp.journalIDs[originalID] = journal.ID
+				imp.stats.Skipped++
+				return nil
+			case MergeUpdate:
+				_, err := imp.tx.ExecContext(ctx, overwriteJournalStatement,
+					journal.Name, journal.Description, journal.Active, journal.CreatedAt, journal.UpdatedAt, journal.ExpiryPolicy, journal.ID)
+				if err != nil {
+					return fmt.Errorf("failed to update journal %s: %w", journal.ID, err)
+				}
+				imp.journalIDs[originalID] = journal.ID
+				imp.stats.Journals++
+				imp.stats.Updated++
+				return nil
+			default:
+				return fmt.Errorf("journal %s: %w", journal.ID, ErrIDConflict)
+			}
+		}
+	}
+
+	_, err := imp.tx.ExecContext(ctx, importJournalStatement,
+		journal.ID, journal.Name, journal.Description, journal.Active, journal.CreatedAt, journal.UpdatedAt, journal.ExpiryPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to import journal %s: %w", originalID, err)
+	}
+	imp.journalIDs[originalID] = journal.ID
+	imp.stats.Journals++
+	return nil
+}
+
+func (imp *importer) importEntry(ctx context.Context, record Record) error {
+	entry := *record.Entry
+	originalID := entry.ID
+
+	if journalID, ok := imp.journalIDs[entry.JournalID]; ok {
+		entry.JournalID = journalID
+	} else {
+		exists, err := imp.exists(ctx, journalExistsStatement, entry.JournalID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("entry %s refers to unknown journal %s", originalID, entry.JournalID)
+		}
+	}
+	if entry.ContentType == "" {
+		entry.ContentType = "text/plain"
+	}
+
+	if imp.opts.RemapIDs {
+		entry.ID = uuid.New()
+	} else {
+		exists, err := imp.exists(ctx, entryExistsStatement, entry.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			switch imp.opts.Merge {
+			case MergeSkip:
+				imp.entryIDs[originalID] = entry.ID
+				imp.skippedEntries[entry.ID] = true
+				imp.stats.Skipped++
+				return nil
+			case MergeUpdate:
+				if err := imp.overwriteEntry(ctx, entry); err != nil {
+					return fmt.Errorf("failed to update entry %s: %w", entry.ID, err)
+				}
+				imp.entryIDs[originalID] = entry.ID
+				imp.stats.Entries++
+				imp.stats.Updated++
+				return nil
+			default:
+				return fmt.Errorf("entry %s: %w", entry.ID, ErrIDConflict)
+			}
+		}
+	}
+
+	_, err := imp.tx.ExecContext(ctx, importEntryStatement,
+		entry.ID, entry.JournalID, entry.Title, entry.Content, entry.ContentType, entry.Deleted, entry.CreatedAt, entry.UpdatedAt, entry.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to import entry %s: %w", originalID, err)
+	}
+	imp.entryIDs[originalID] = entry.ID
+	imp.stats.Entries++
+	return nil
+}
+
+// overwriteEntry replaces an existing entry with its imported version, keeping the
+// replaced content as a revision and dropping its embeddings if the content changes, and
+// drops its tags and the links from it so the imported ones take their place.
+func (imp *importer) overwriteEntry(ctx context.Context, entry memories.Entry) error {
+	_, err := imp.tx.ExecContext(ctx, recordOverwrittenEntryStatement,
+		importActor, entry.ID, entry.Title, entry.Content, entry.ContentType)
+	if err != nil {
+		return err
+	}
+	_, err = imp.tx.ExecContext(ctx, dropChangedEntryEmbeddingsStatement,
+		entry.ID, entry.Title, entry.Content, entry.ContentType)
+	if err != nil {
+		return err
+	}
+	_, err = imp.tx.ExecContext(ctx, overwriteEntryStatement,
+		entry.JournalID, entry.Title, entry.Content, entry.ContentType, entry.Deleted, entry.CreatedAt, entry.UpdatedAt, entry.ExpiresAt, entry.ID)
+	if err != nil {
+		return err
+	}
+	_, err = imp.tx.ExecContext(ctx, clearEntryTagsStatement, entry.ID)
+	if err != nil {
+		return err
+	}
+	_, err = imp.tx.ExecContext(ctx, clearEntryLinksStatement, entry.ID)
+	return err
+}
+
+func (imp *importer) importEntryTag(ctx context.Context, record Record) error {
+	entryTag := *record.EntryTag
+	tag, err := imp.resolveTag(entryTag.Tag)
+	if err != nil {
+		return err
+	}
+	entryTag.Tag = tag
+
+	entryID, ok := imp.entryIDs[entryTag.EntryID]
+	if !ok {
+		return fmt.Errorf("tag '%s' refers to entry %s, which is not part of the import", entryTag.Tag, entryTag.EntryID)
+	}
+	if imp.skippedEntries[entryID] {
+		return nil
+	}
+
+	// Exports list every tag before it is used, but tolerate hand-written files that don't.
+	if _, err := imp.tx.ExecContext(ctx, importTagStatement, entryTag.Tag, entryTag.CreatedAt, entryTag.CreatedAt); err != nil {
+		return fmt.Errorf("failed to import tag '%s': %w", entryTag.Tag, err)
+	}
+	if _, err := imp.tx.ExecContext(ctx, importEntryTagStatement, entryID, entryTag.Tag, entryTag.CreatedAt); err != nil {
+		return fmt.Errorf("failed to tag entry %s with '%s': %w", entryID, entryTag.Tag, err)
+	}
+	imp.stats.EntryTags++
+	return nil
+}
+
+// resolveTag returns the tag an imported tag stands for: normalized and, when it is an
+// alias, replaced by the tag it stands for, as the memories package stores tags.
+func (imp *importer) resolveTag(tag string) (string, error) {
+	normalized := memories.NormalizeTag(tag)
+	if normalized == "" {
+		return "", fmt.Errorf("%w %q: must not be empty", memories.ErrInvalidTag, tag)
+	}
+	if canonical, ok := imp.aliases[normalized]; ok {
+		return canonical, nil
+	}
+	return normalized, nil
+}
+
+// resolveTags returns resolveTag of each of tags, without duplicates.
+func (imp *importer) resolveTags(tags []string) ([]string, error) {
+	var resolved []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		name, err := imp.resolveTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			resolved = append(resolved, name)
+		}
+	}
+	return resolved, nil
+}
+
+// importTagAlias makes an imported alias stand for its tag. Aliases already in the database
+// are kept unless MergeUpdate is set.
+func (imp *importer) importTagAlias(ctx context.Context, record Record) error {
+	tagAlias := *record.TagAlias
+	alias := memories.NormalizeTag(tagAlias.Alias)
+	if alias == "" {
+		return fmt.Errorf("%w %q: must not be empty", memories.ErrInvalidTagAlias, tagAlias.Alias)
+	}
+	tag, err := imp.resolveTag(tagAlias.Tag)
+	if err != nil {
+		return err
+	}
+	if current, ok := imp.aliases[alias]; ok && (current == tag || imp.opts.Merge != MergeUpdate) {
+		return nil
+	}
+	// The database has it the other way round, with tag standing for alias; keep that.
+	if alias == tag {
+		return nil
+	}
+
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{importTagStatement, []any{tag, tagAlias.CreatedAt, tagAlias.CreatedAt}},
+		{retagEntriesStatement, []any{tag, alias}},
+		{moveTagAliasesStatement, []any{tag, alias}},
+		{detachTagFromAllEntriesStatement, []any{alias}},
+		{deleteTagStatement, []any{alias}},
+		{importTagAliasStatement, []any{alias, tag, tagAlias.CreatedAt}},
+	}
+	for _, statement := range statements {
+		if _, err := imp.tx.ExecContext(ctx, statement.query, statement.args...); err != nil {
+			return fmt.Errorf("failed to import tag alias '%s': %w", alias, err)
+		}
+	}
+
+	for other, otherTag := range imp.aliases {
+		if otherTag == alias {
+			imp.aliases[other] = tag
+		}
+	}
+	imp.aliases[alias] = tag
+	imp.stats.TagAliases++
+	return nil
+}
+
+func (imp *importer) importEntryLink(ctx context.Context, record Record) error {
+	link := *record.EntryLink
+	if _, err := memories.ParseLinkRelation(string(link.Relation)); err != nil {
+		return err
+	}
+
+	var ends [2]uuid.UUID
+	for i, id := range []uuid.UUID{link.SourceID, link.TargetID} {
+		if entryID, ok := imp.entryIDs[id]; ok {
+			ends[i] = entryID
+			continue
+		}
+		exists, err := imp.exists(ctx, entryExistsStatement, id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("link '%s' refers to entry %s, which is not part of the import", link.Relation, id)
+		}
+		ends[i] = id
+	}
+	sourceID, targetID := ends[0], ends[1]
+	if sourceID == targetID {
+		return fmt.Errorf("link '%s' of entry %s: %w", link.Relation, sourceID, memories.ErrSelfLink)
+	}
+	if imp.skippedEntries[sourceID] {
+		return nil
+	}
+
+	if _, err := imp.tx.ExecContext(ctx, importEntryLinkStatement, sourceID, targetID, link.Relation, link.CreatedAt); err != nil {
+		return fmt.Errorf("failed to link entry %s to %s: %w", sourceID, targetID, err)
+	}
+	imp.stats.EntryLinks++
+	return nil
+}
+
+func (imp *importer) exists(ctx context.Context, query string, id uuid.UUID) (bool, error) {
+	var exists bool
+	if err := imp.tx.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
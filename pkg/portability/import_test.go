@@ -0,0 +1,379 @@
+package portability
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+func countRows(t *testing.T, testDB *sql.DB, table string) int {
+	t.Helper()
+	var count int
+	if err := testDB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+		t.Fatalf("Failed to count rows in '%s': %v", table, err)
+	}
+	return count
+}
+
+func exportAll(t *testing.T, ctx context.Context, testDB *sql.DB) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := Export(ctx, testDB, &buf, ExportOptions{IncludeDeleted: true}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestImport_RoundTripPreservesIDsAndTimestamps(t *testing.T) {
+	ctx := context.Background()
+	source := setupTestDB(t)
+	defer source.Close()
+	work, _ := seedTestData(t, ctx, source)
+	data := exportAll(t, ctx, source)
+
+	target := setupTestDB(t)
+	defer target.Close()
+	stats, err := Import(ctx, target, bytes.NewReader(data), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if stats.Journals != 2 || stats.Entries != 3 || stats.EntryTags != 4 || stats.Tags != 4 || stats.TagAliases != 1 || stats.EntryLinks != 1 {
+		t.Errorf("Unexpected import stats: %+v", stats)
+	}
+
+	original, err := memories.ListEntries(ctx, source, work.ID, true)
+	if err != nil {
+		t.Fatalf("ListEntries failed on source: %v", err)
+	}
+	imported, err := memories.ListEntries(ctx, target, work.ID, true)
+	if err != nil {
+		t.Fatalf("ListEntries failed on target: %v", err)
+	}
+	if len(imported) != len(original) {
+		t.Fatalf("Expected %d entries in imported journal, got %d", len(original), len(imported))
+	}
+	importedByID := make(map[uuid.UUID]memories.Entry, len(imported))
+	for _, e := range imported {
+		importedByID[e.ID] = e
+	}
+	for _, want := range original {
+		if got := importedByID[want.ID]; got != want {
+			t.Errorf("Imported entry differs from original:\n got  %+v\n want %+v", got, want)
+		}
+	}
+
+	journal, err := memories.GetJournal(ctx, target, work.ID)
+	if err != nil {
+		t.Fatalf("GetJournal failed on target: %v", err)
+	}
+	if journal != work {
+		t.Errorf("Imported journal differs from original:\n got  %+v\n want %+v", journal, work)
+	}
+	if journal.ExpiryPolicy != memories.ExpiryPurge {
+		t.Errorf("Expected the journal's expiry policy to survive the round trip, got %q", journal.ExpiryPolicy)
+	}
+	var expiring int
+	for _, e := range imported {
+		if e.ExpiresAt != 0 {
+			expiring++
+		}
+	}
+	if expiring != 1 {
+		t.Errorf("Expected the expiry of one entry to survive the round trip, got %d", expiring)
+	}
+
+	ids := make([]uuid.UUID, len(original))
+	for i, e := range original {
+		ids[i] = e.ID
+	}
+	originalLinks, err := memories.ListEntryLinks(ctx, source, ids)
+	if err != nil {
+		t.Fatalf("ListEntryLinks failed on source: %v", err)
+	}
+	importedLinks, err := memories.ListEntryLinks(ctx, target, ids)
+	if err != nil {
+		t.Fatalf("ListEntryLinks failed on target: %v", err)
+	}
+	if len(importedLinks) != 1 || importedLinks[0] != originalLinks[0] {
+		t.Errorf("Imported links differ from original:\n got  %+v\n want %+v", importedLinks, originalLinks)
+	}
+
+	originalAliases, err := memories.ListTagAliases(ctx, source)
+	if err != nil {
+		t.Fatalf("ListTagAliases failed on source: %v", err)
+	}
+	importedAliases, err := memories.ListTagAliases(ctx, target)
+	if err != nil {
+		t.Fatalf("ListTagAliases failed on target: %v", err)
+	}
+	if len(importedAliases) != 1 || importedAliases[0] != originalAliases[0] {
+		t.Errorf("Imported tag aliases differ from original:\n got  %+v\n want %+v", importedAliases, originalAliases)
+	}
+
+	// The imported entries are searchable, so the full-text index was kept in sync.
+	matches, err := memories.SearchEntriesFullText(ctx, target, work.ID, "report", 10)
+	if err != nil {
+		t.Fatalf("SearchEntriesFullText failed: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("Expected 1 full-text match after import, got %d", len(matches))
+	}
+}
+
+func TestImport_ConflictRollsBack(t *testing.T) {
+	ctx := context.Background()
+	testDB := setupTestDB(t)
+	defer testDB.Close()
+	seedTestData(t, ctx, testDB)
+	data := exportAll(t, ctx, testDB)
+
+	if _, err := memories.CreateJournal(ctx, testDB, "extra", ""); err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+	journalsBefore := countRows(t, testDB, "journals")
+
+	_, err := Import(ctx, testDB, bytes.NewReader(data), ImportOptions{})
+	if !errors.Is(err, ErrIDConflict) {
+		t.Fatalf("Expected ErrIDConflict importing into the source database, got %v", err)
+	}
+	if got := countRows(t, testDB, "journals"); got != journalsBefore {
+		t.Errorf("Expected failed import to leave %d journals, got %d", journalsBefore, got)
+	}
+}
+
+func TestImport_MergeModes(t *testing.T) {
+	ctx := context.Background()
+	testDB := setupTestDB(t)
+	defer testDB.Close()
+	work, _ := seedTestData(t, ctx, testDB)
+	data := exportAll(t, ctx, testDB)
+
+	entries, err := memories.ListEntries(ctx, testDB, work.ID, false)
+	if err != nil {
+		t.Fatalf("ListEntries failed: %v", err)
+	}
+	report := entries[0]
+	if _, err := memories.UpdateEntry(ctx, testDB, report.ID, "", "report finished", ""); err != nil {
+		t.Fatalf("UpdateEntry failed: %v", err)
+	}
+	if err := memories.DetachTag(ctx, testDB, report.ID, "urgent"); err != nil {
+		t.Fatalf("DetachTag failed: %v", err)
+	}
+	links, err := memories.ListEntryLinks(ctx, testDB, []uuid.UUID{report.ID})
+	if err != nil {
+		t.Fatalf("ListEntryLinks failed: %v", err)
+	}
+	link := links[0]
+	if err := memories.UnlinkEntries(ctx, testDB, link.SourceID, link.TargetID, link.Relation); err != nil {
+		t.Fatalf("UnlinkEntries failed: %v", err)
+	}
+
+	stats, err := Import(ctx, testDB, bytes.NewReader(data), ImportOptions{Merge: MergeSkip})
+	if err != nil {
+		t.Fatalf("Import with MergeSkip failed: %v", err)
+	}
+	if stats.Skipped != 5 || stats.Journals != 0 || stats.Entries != 0 || stats.EntryTags != 0 || stats.EntryLinks != 0 {
+		t.Errorf("Unexpected MergeSkip stats: %+v", stats)
+	}
+	if got := countRows(t, testDB, "entry_links"); got != 0 {
+		t.Errorf("Expected MergeSkip to leave the links from skipped entries alone, got %d links", got)
+	}
+	current, err := memories.GetEntry(ctx, testDB, report.ID)
+	if err != nil {
+		t.Fatalf("GetEntry failed: %v", err)
+	}
+	if current.Content != "report finished" {
+		t.Errorf("Expected MergeSkip to keep the local edit, got content %q", current.Content)
+	}
+
+	for _, entry := range entries {
+		if err := memories.SetEntryEmbedding(ctx, testDB, entry.ID, "test", []float32{1, 0}); err != nil {
+			t.Fatalf("SetEntryEmbedding failed: %v", err)
+		}
+	}
+
+	stats, err = Import(ctx, testDB, bytes.NewReader(data), ImportOptions{Merge: MergeUpdate})
+	if err != nil {
+		t.Fatalf("Import with MergeUpdate failed: %v", err)
+	}
+	if stats.Updated != 5 || stats.Entries != 3 {
+		t.Errorf("Unexpected MergeUpdate stats: %+v", stats)
+	}
+	current, err = memories.GetEntry(ctx, testDB, report.ID)
+	if err != nil {
+		t.Fatalf("GetEntry failed: %v", err)
+	}
+	if current != report {
+		t.Errorf("Expected MergeUpdate to restore the exported entry:\n got  %+v\n want %+v", current, report)
+	}
+	tags, err := memories.ListTagsForEntry(ctx, testDB, report.ID)
+	if err != nil {
+		t.Fatalf("ListTagsForEntry failed: %v", err)
+	}
+	if len(tags) != 2 {
+		t.Errorf("Expected MergeUpdate to restore both exported tags, got %v", tags)
+	}
+	links, err = memories.ListEntryLinks(ctx, testDB, []uuid.UUID{report.ID})
+	if err != nil {
+		t.Fatalf("ListEntryLinks failed: %v", err)
+	}
+	if len(links) != 1 || links[0] != link {
+		t.Errorf("Expected MergeUpdate to restore the exported link %+v, got %+v", link, links)
+	}
+	revisions, err := memories.ListEntryRevisions(ctx, testDB, report.ID)
+	if err != nil {
+		t.Fatalf("ListEntryRevisions failed: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Content != "report finished" || revisions[0].Actor != importActor {
+		t.Errorf("Expected the overwritten version to be kept as an import revision, got %+v", revisions)
+	}
+	embeddings, err := memories.ListEntryEmbeddings(ctx, testDB, work.ID, "test")
+	if err != nil {
+		t.Fatalf("ListEntryEmbeddings failed: %v", err)
+	}
+	for _, embedding := range embeddings {
+		if changed := embedding.EntryID == report.ID; changed != (embedding.Vector == nil) {
+			t.Errorf("Expected only the embedding of the overwritten entry to be dropped, got %+v", embedding)
+		}
+	}
+}
+
+func TestImport_RemapIDs(t *testing.T) {
+	ctx := context.Background()
+	testDB := setupTestDB(t)
+	defer testDB.Close()
+	seedTestData(t, ctx, testDB)
+	data := exportAll(t, ctx, testDB)
+
+	stats, err := Import(ctx, testDB, bytes.NewReader(data), ImportOptions{RemapIDs: true})
+	if err != nil {
+		t.Fatalf("Import with RemapIDs failed: %v", err)
+	}
+	if stats.Journals != 2 || stats.Entries != 3 || stats.EntryTags != 4 || stats.EntryLinks != 1 {
+		t.Errorf("Unexpected RemapIDs stats: %+v", stats)
+	}
+	if got := countRows(t, testDB, "journals"); got != 4 {
+		t.Errorf("Expected 4 journals after importing a remapped copy, got %d", got)
+	}
+	if got := countRows(t, testDB, "entry_tags"); got != 8 {
+		t.Errorf("Expected 8 entry tags after importing a remapped copy, got %d", got)
+	}
+	var targets int
+	err = testDB.QueryRow(`
+		SELECT COUNT(DISTINCT l.target_id)
+		FROM entry_links l
+		JOIN entries s ON s.id = l.source_id
+		JOIN entries t ON t.id = l.target_id
+		WHERE s.title = 'report' AND t.title = 'groceries'
+	`).Scan(&targets)
+	if err != nil {
+		t.Fatalf("Failed to count links: %v", err)
+	}
+	if got := countRows(t, testDB, "entry_links"); got != 2 || targets != 2 {
+		t.Errorf("Expected the remapped copy of the link to point at the copied entry, got %d links to %d entries", got, targets)
+	}
+
+	if _, err := Import(ctx, testDB, bytes.NewReader(data), ImportOptions{RemapIDs: true, Merge: MergeSkip}); err == nil {
+		t.Errorf("Expected combining RemapIDs with a merge mode to fail")
+	}
+}
+
+func TestImport_ResolvesTags(t *testing.T) {
+	ctx := context.Background()
+	testDB := setupTestDB(t)
+	defer testDB.Close()
+	journal, err := memories.CreateJournal(ctx, testDB, "work", "")
+	if err != nil {
+		t.Fatalf("CreateJournal failed: %v", err)
+	}
+	if _, err := memories.AliasTag(ctx, testDB, "k8s", "kubernetes"); err != nil {
+		t.Fatalf("AliasTag failed: %v", err)
+	}
+	// A local entry tagged with what the import makes an alias is retagged.
+	local, err := memories.CreateEntry(ctx, testDB, journal.ID, "local", "", "")
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	if err := memories.TagEntry(ctx, testDB, local.ID, "todo"); err != nil {
+		t.Fatalf("TagEntry failed: %v", err)
+	}
+
+	entryID := uuid.New()
+	input := `{"type":"header","header":{"format":"recall-export","version":2}}
+{"type":"tag","tag":{"tag":" K8s","created_at":1,"updated_at":1}}
+{"type":"tag","tag":{"tag":"Tasks","created_at":1,"updated_at":1}}
+{"type":"tag_alias","tag_alias":{"alias":"TODO","tag":"tasks ","created_at":1}}
+{"type":"entry","entry":{"id":"` + entryID.String() + `","journal_id":"` + journal.ID.String() + `","title":"plan","content":"","created_at":1,"updated_at":1}}
+{"type":"entry_tag","entry_tag":{"entry_id":"` + entryID.String() + `","tag":"K8S","created_at":1}}
+{"type":"entry_tag","entry_tag":{"entry_id":"` + entryID.String() + `","tag":"Todo","created_at":1}}
+`
+	stats, err := Import(ctx, testDB, strings.NewReader(input), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if stats.TagAliases != 1 || stats.EntryTags != 2 {
+		t.Errorf("Unexpected import stats: %+v", stats)
+	}
+
+	for id, want := range map[uuid.UUID]string{entryID: "kubernetes,tasks", local.ID: "tasks"} {
+		tags, err := memories.ListTagsForEntry(ctx, testDB, id)
+		if err != nil {
+			t.Fatalf("ListTagsForEntry failed: %v", err)
+		}
+		var names []string
+		for _, tag := range tags {
+			names = append(names, tag.Tag)
+		}
+		if got := strings.Join(names, ","); got != want {
+			t.Errorf("Expected entry %s to be tagged %s, got %s", id, want, got)
+		}
+	}
+	var stray int
+	if err := testDB.QueryRow(`SELECT COUNT(*) FROM tags WHERE tag IN ('k8s', 'todo') OR tag != lower(trim(tag))`).Scan(&stray); err != nil {
+		t.Fatalf("Failed to count tags: %v", err)
+	}
+	if stray != 0 {
+		t.Errorf("Expected imported tags to be normalized and resolved, got %d stray tags", stray)
+	}
+	resolved, err := memories.ResolveTags(ctx, testDB, []string{"todo"})
+	if err != nil {
+		t.Fatalf("ResolveTags failed: %v", err)
+	}
+	if resolved[0] != "tasks" {
+		t.Errorf("Expected the imported alias to resolve todo to tasks, got %s", resolved[0])
+	}
+
+	empty := `{"type":"header","header":{"format":"recall-export","version":2}}
+{"type":"tag","tag":{"tag":"  ","created_at":1,"updated_at":1}}
+`
+	if _, err := Import(ctx, testDB, strings.NewReader(empty), ImportOptions{}); !errors.Is(err, memories.ErrInvalidTag) {
+		t.Errorf("Expected ErrInvalidTag for an empty tag, got %v", err)
+	}
+}
+
+func TestImport_RejectsInvalidInput(t *testing.T) {
+	ctx := context.Background()
+	testDB := setupTestDB(t)
+	defer testDB.Close()
+
+	tests := map[string]string{
+		"empty":          "",
+		"no header":      `{"type":"journal","journal":{"id":"8c3a1c8e-1f57-4d4b-9d0c-1d1b7c0b9b11","name":"x"}}`,
+		"future version": `{"type":"header","header":{"format":"recall-export","version":99}}`,
+		"not json":       "journal,entry\n",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Import(ctx, testDB, strings.NewReader(input), ImportOptions{})
+			if !errors.Is(err, ErrInvalidExport) {
+				t.Errorf("Expected ErrInvalidExport, got %v", err)
+			}
+		})
+	}
+}
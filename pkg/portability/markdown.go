@@ -0,0 +1,513 @@
+package portability
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+// Markdown vaults hold one folder per journal and one .md file per entry, with the
+// entry's ID, tags, content type and timestamps in YAML front matter. They can be
+// edited with ordinary Markdown tools (e.g. Obsidian) and imported back.
+
+const (
+	markdownExtension = ".md"
+
+	// defaultMarkdownContentType is used for files imported without a content_type.
+	defaultMarkdownContentType = "text/markdown"
+
+	// maxFileNameBytes keeps generated names well below common file system limits.
+	maxFileNameBytes = 200
+
+	findJournalByNameStatement = `
+	SELECT id FROM journals
+	WHERE name = ?
+	ORDER BY created_at
+	LIMIT 1
+	`
+
+	// findEntriesByTitleStatement lists the live entries of a journal a file without an id
+	// may stand for, most recently updated first.
+	findEntriesByTitleStatement = `
+	SELECT id, content, created_at FROM entries
+	WHERE journal_id = ? AND title = ? AND deleted = FALSE
+	ORDER BY updated_at DESC, id
+	`
+
+	entryForCompareStatement = `
+	SELECT journal_id, title, content, content_type, deleted, expires_at,
+		COALESCE((SELECT group_concat(tag, char(10)) FROM (SELECT tag FROM entry_tags WHERE entry_id = ? ORDER BY tag)), ''),
+		COALESCE((SELECT group_concat(link, char(10)) FROM (
+			SELECT relation || ' ' || target_id AS link FROM entry_links WHERE source_id = ? ORDER BY link
+		)), '')
+	FROM entries
+	WHERE id = ?
+	`
+)
+
+// ExportMarkdown writes the selected journals into dir as a Markdown vault: a folder per
+// journal named after it and a file per entry named after its title. Existing files with the
+// same names are overwritten; each file's modification time is set to the entry's updated_at.
+// Links are written to the front matter of their source entry when both ends are exported.
+func ExportMarkdown(ctx context.Context, db *sql.DB, dir string, opts ExportOptions) (Stats, error) {
+	var stats Stats
+
+	var journals []memories.Journal
+	if opts.JournalID != uuid.Nil {
+		journal, err := memories.GetJournal(ctx, db, opts.JournalID)
+		if err != nil {
+			return stats, err
+		}
+		journals = append(journals, journal)
+	} else {
+		list, err := memories.ListJournals(ctx, db, false)
+		if err != nil {
+			return stats, err
+		}
+		journals = list
+	}
+	// Journals are listed newest first; export oldest first so that name collisions resolve stably.
+	sort.SliceStable(journals, func(i, j int) bool { return journals[i].CreatedAt < journals[j].CreatedAt })
+
+	// Every entry is listed before any file is written, so that links can be limited to
+	// entries that are part of the export.
+	journalEntries := make([][]memories.Entry, len(journals))
+	exported := make(map[uuid.UUID]bool)
+	for i, journal := range journals {
+		entries, err := memories.ListEntries(ctx, db, journal.ID, opts.IncludeDeleted)
+		if err != nil {
+			return stats, fmt.Errorf("failed to list entries of journal '%s': %w", journal.Name, err)
+		}
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt < entries[j].CreatedAt })
+		for _, entry := range entries {
+			exported[entry.ID] = true
+		}
+		journalEntries[i] = entries
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return stats, fmt.Errorf("failed to create export directory '%s': %w", dir, err)
+	}
+
+	tagNames := make(map[string]struct{})
+	journalFolders := make(map[string]bool)
+	for i, journal := range journals {
+		folder := uniqueFileName(journal.Name, journal.ID, "", journalFolders)
+		journalDir := filepath.Join(dir, folder)
+		if err := os.MkdirAll(journalDir, 0755); err != nil {
+			return stats, fmt.Errorf("failed to create journal folder '%s': %w", journalDir, err)
+		}
+		stats.Journals++
+
+		entryFiles := make(map[string]bool)
+		for _, entry := range journalEntries[i] {
+			tags, err := memories.ListTagsForEntry(ctx, db, entry.ID)
+			if err != nil {
+				return stats, fmt.Errorf("failed to list tags of entry %s: %w", entry.ID, err)
+			}
+			links, err := memories.ListEntryLinks(ctx, db, []uuid.UUID{entry.ID})
+			if err != nil {
+				return stats, fmt.Errorf("failed to list links of entry %s: %w", entry.ID, err)
+			}
+			fm := frontMatter{
+				ID:          entry.ID,
+				JournalID:   entry.JournalID,
+				Title:       entry.Title,
+				ContentType: entry.ContentType,
+				Deleted:     entry.Deleted,
+				CreatedAt:   entry.CreatedAt,
+				UpdatedAt:   entry.UpdatedAt,
+				ExpiresAt:   entry.ExpiresAt,
+			}
+			for _, tag := range tags {
+				fm.Tags = append(fm.Tags, tag.Tag)
+				tagNames[tag.Tag] = struct{}{}
+			}
+			for _, link := range links {
+				if link.SourceID == entry.ID && exported[link.TargetID] {
+					fm.Links = append(fm.Links, frontMatterLink{Relation: string(link.Relation), TargetID: link.TargetID})
+				}
+			}
+
+			path := filepath.Join(journalDir, uniqueFileName(entry.Title, entry.ID, markdownExtension, entryFiles))
+			if err := os.WriteFile(path, writeMarkdownEntry(fm, entry.Content), 0644); err != nil {
+				return stats, fmt.Errorf("failed to write entry file '%s': %w", path, err)
+			}
+			modTime := unixTime(entry.UpdatedAt)
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				return stats, fmt.Errorf("failed to set modification time of '%s': %w", path, err)
+			}
+			stats.Entries++
+			stats.EntryTags += len(tags)
+			stats.EntryLinks += len(fm.Links)
+		}
+	}
+	stats.Tags = len(tagNames)
+	return stats, nil
+}
+
+// ImportMarkdown reads a Markdown vault from dir in a single transaction. Every top-level
+// folder is a journal, matched by the journal_id in its files' front matter or else by name,
+// and created if neither exists. Files whose front matter id names an existing entry update
+// that entry (keeping the replaced content as a revision) instead of creating a duplicate,
+// as do files without an id whose title names a live entry of their journal; other files
+// become new entries. Files that match their entry exactly are skipped.
+// The links in a file's front matter replace the links from its entry; they may point at
+// entries of other files or already in the database. Hidden folders such as .obsidian are
+// ignored.
+func ImportMarkdown(ctx context.Context, db *sql.DB, dir string) (Stats, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to read vault '%s': %w", dir, err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer tx.Rollback()
+
+	imp, err := newImporter(ctx, tx, ImportOptions{Merge: MergeUpdate})
+	if err != nil {
+		return Stats{}, err
+	}
+	tagNames := make(map[string]struct{})
+	var links []vaultLink
+
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if !dirEntry.IsDir() {
+			if strings.EqualFold(filepath.Ext(name), markdownExtension) {
+				return Stats{}, fmt.Errorf("'%s' is not inside a journal folder", name)
+			}
+			continue
+		}
+
+		files, err := readVaultFolder(filepath.Join(dir, name))
+		if err != nil {
+			return Stats{}, err
+		}
+		journalID, err := imp.resolveVaultJournal(ctx, name, files)
+		if err != nil {
+			return Stats{}, err
+		}
+		for _, file := range files {
+			// Tags are compared and stored as the memories package stores them.
+			file.fm.Tags, err = imp.resolveTags(file.fm.Tags)
+			if err != nil {
+				return Stats{}, fmt.Errorf("%s: %w", file.path, err)
+			}
+			fileLinks, err := imp.importVaultFile(ctx, journalID, file)
+			if err != nil {
+				return Stats{}, fmt.Errorf("%s: %w", file.path, err)
+			}
+			for _, tag := range file.fm.Tags {
+				tagNames[tag] = struct{}{}
+			}
+			for _, link := range fileLinks {
+				links = append(links, vaultLink{path: file.path, link: link})
+			}
+		}
+	}
+
+	// Links are made once every file is imported, as they may point at files read later.
+	for _, l := range links {
+		if err := imp.importEntryLink(ctx, Record{Type: RecordEntryLink, EntryLink: &l.link}); err != nil {
+			return Stats{}, fmt.Errorf("%s: %w", l.path, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Stats{}, err
+	}
+	imp.stats.Tags = len(tagNames)
+	return imp.stats, nil
+}
+
+// vaultFile is a parsed Markdown file from a journal folder.
+type vaultFile struct {
+	path    string
+	fm      frontMatter
+	content string
+	modTime time.Time
+}
+
+// vaultLink is a link read from the front matter of the file at path.
+type vaultLink struct {
+	path string
+	link memories.EntryLink
+}
+
+// readVaultFolder parses every .md file below a journal folder, including subfolders.
+func readVaultFolder(folder string) ([]vaultFile, error) {
+	var files []vaultFile
+	err := filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != folder && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), markdownExtension) || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fm, content, _, err := parseMarkdownEntry(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if fm.Title == "" {
+			fm.Title = strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
+		}
+		files = append(files, vaultFile{path: path, fm: fm, content: content, modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal folder '%s': %w", folder, err)
+	}
+	return files, nil
+}
+
+// resolveVaultJournal returns the journal a folder's files belong to, creating it if needed.
+func (imp *importer) resolveVaultJournal(ctx context.Context, folderName string, files []vaultFile) (uuid.UUID, error) {
+	var newJournalID uuid.UUID
+	for _, file := range files {
+		if file.fm.JournalID == uuid.Nil {
+			continue
+		}
+		exists, err := imp.exists(ctx, journalExistsStatement, file.fm.JournalID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if exists {
+			return file.fm.JournalID, nil
+		}
+		if newJournalID == uuid.Nil {
+			newJournalID = file.fm.JournalID
+		}
+	}
+
+	var journalID uuid.UUID
+	err := imp.tx.QueryRowContext(ctx, findJournalByNameStatement, folderName).Scan(&journalID)
+	if err == nil {
+		return journalID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, err
+	}
+
+	// Keep the ID from the front matter so that a vault moved to another database keeps its identity.
+	if newJournalID == uuid.Nil {
+		newJournalID = uuid.New()
+	}
+	now := float64(time.Now().Unix())
+	_, err = imp.tx.ExecContext(ctx, importJournalStatement, newJournalID, folderName, "", true, now, now, memories.ExpirySoftDelete)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create journal '%s': %w", folderName, err)
+	}
+	imp.stats.Journals++
+	return newJournalID, nil
+}
+
+// importVaultFile creates or updates the entry described by file in journalID, and returns
+// the links from it to make once every file is imported. Nothing is returned for files that
+// match their entry.
+func (imp *importer) importVaultFile(ctx context.Context, journalID uuid.UUID, file vaultFile) ([]memories.EntryLink, error) {
+	modTime := float64(file.modTime.Unix())
+
+	entry := memories.Entry{
+		ID:          file.fm.ID,
+		JournalID:   journalID,
+		Title:       file.fm.Title,
+		Content:     file.content,
+		ContentType: file.fm.ContentType,
+		Deleted:     file.fm.Deleted,
+		CreatedAt:   file.fm.CreatedAt,
+		UpdatedAt:   file.fm.UpdatedAt,
+		ExpiresAt:   file.fm.ExpiresAt,
+	}
+	if entry.ID == uuid.Nil {
+		id, createdAt, err := imp.findVaultEntry(ctx, journalID, entry.Title, entry.Content)
+		if err != nil {
+			return nil, err
+		}
+		entry.ID = id
+		if entry.CreatedAt == 0 {
+			entry.CreatedAt = createdAt
+		}
+	}
+	if entry.ContentType == "" {
+		entry.ContentType = defaultMarkdownContentType
+	}
+	if entry.CreatedAt == 0 {
+		entry.CreatedAt = modTime
+	}
+	// Editors don't touch the front matter timestamps, so an edit is dated by the file itself.
+	if modTime > entry.UpdatedAt {
+		entry.UpdatedAt = modTime
+	}
+
+	unchanged, err := imp.vaultEntryUnchanged(ctx, entry, file.fm.Tags, file.fm.Links)
+	if err != nil {
+		return nil, err
+	}
+	if unchanged {
+		imp.entryIDs[entry.ID] = entry.ID
+		imp.stats.Skipped++
+		return nil, nil
+	}
+
+	if err := imp.importEntry(ctx, Record{Type: RecordEntry, Entry: &entry}); err != nil {
+		return nil, err
+	}
+	for _, tag := range file.fm.Tags {
+		entryTag := memories.EntryTag{EntryID: entry.ID, Tag: tag, CreatedAt: entry.UpdatedAt}
+		if err := imp.importEntryTag(ctx, Record{Type: RecordEntryTag, EntryTag: &entryTag}); err != nil {
+			return nil, err
+		}
+	}
+	var links []memories.EntryLink
+	for _, link := range file.fm.Links {
+		links = append(links, memories.EntryLink{
+			SourceID:  entry.ID,
+			TargetID:  link.TargetID,
+			Relation:  memories.LinkRelation(link.Relation),
+			CreatedAt: entry.UpdatedAt,
+		})
+	}
+	return links, nil
+}
+
+// findVaultEntry returns the ID and creation time of the entry a file without an id stands
+// for: a live entry of journalID titled title that no other file of the import claimed,
+// preferring one with the same content and then the most recently updated. A file that
+// matches none gets a new ID.
+func (imp *importer) findVaultEntry(ctx context.Context, journalID uuid.UUID, title, content string) (uuid.UUID, float64, error) {
+	rows, err := imp.tx.QueryContext(ctx, findEntriesByTitleStatement, journalID, title)
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+	defer rows.Close()
+
+	id, createdAt := uuid.New(), 0.0
+	found := false
+	for rows.Next() {
+		var candidate memories.Entry
+		if err := rows.Scan(&candidate.ID, &candidate.Content, &candidate.CreatedAt); err != nil {
+			return uuid.Nil, 0, err
+		}
+		if _, claimed := imp.entryIDs[candidate.ID]; claimed {
+			continue
+		}
+		if candidate.Content == content {
+			return candidate.ID, candidate.CreatedAt, nil
+		}
+		if !found {
+			id, createdAt, found = candidate.ID, candidate.CreatedAt, true
+		}
+	}
+	return id, createdAt, rows.Err()
+}
+
+// vaultEntryUnchanged reports whether entry already exists with the same journal, title,
+// content, content type, deleted flag, expiry, tags and links.
+func (imp *importer) vaultEntryUnchanged(ctx context.Context, entry memories.Entry, tags []string, links []frontMatterLink) (bool, error) {
+	var current memories.Entry
+	var currentTags, currentLinks string
+	err := imp.tx.QueryRowContext(ctx, entryForCompareStatement, entry.ID, entry.ID, entry.ID).Scan(
+		&current.JournalID, &current.Title, &current.Content, &current.ContentType, &current.Deleted, &current.ExpiresAt,
+		&currentTags, &currentLinks)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	sortedTags := append([]string(nil), tags...)
+	sort.Strings(sortedTags)
+	sortedLinks := make([]string, len(links))
+	for i, link := range links {
+		sortedLinks[i] = link.String()
+	}
+	sort.Strings(sortedLinks)
+	return current.JournalID == entry.JournalID &&
+		current.Title == entry.Title &&
+		current.Content == entry.Content &&
+		current.ContentType == entry.ContentType &&
+		current.Deleted == entry.Deleted &&
+		current.ExpiresAt == entry.ExpiresAt &&
+		currentTags == strings.Join(sortedTags, "\n") &&
+		currentLinks == strings.Join(sortedLinks, "\n"), nil
+}
+
+// uniqueFileName turns name into a safe file name with the given extension, appending a
+// short form of id when the result is already taken (compared case-insensitively).
+func uniqueFileName(name string, id uuid.UUID, ext string, taken map[string]bool) string {
+	base := sanitizeFileName(name)
+	candidate := base + ext
+	if taken[strings.ToLower(candidate)] {
+		candidate = fmt.Sprintf("%s (%s)%s", base, id.String()[:8], ext)
+	}
+	if taken[strings.ToLower(candidate)] {
+		candidate = fmt.Sprintf("%s (%s)%s", base, id, ext)
+	}
+	taken[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// sanitizeFileName replaces characters that are invalid in file names on common
+// platforms, or special in Obsidian links, and trims the result to a safe length.
+func sanitizeFileName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			b.WriteRune(' ')
+		case strings.ContainsRune(`/\:*?"<>|#^[]`, r):
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	sanitized := strings.Trim(strings.TrimSpace(b.String()), ".")
+	for len(sanitized) > maxFileNameBytes {
+		_, size := utf8.DecodeLastRuneInString(sanitized)
+		sanitized = sanitized[:len(sanitized)-size]
+	}
+	if sanitized == "" {
+		return "untitled"
+	}
+	return sanitized
+}
+
+// unixTime converts a unix timestamp in seconds to a time.Time.
+func unixTime(timestamp float64) time.Time {
+	sec := int64(timestamp)
+	return time.Unix(sec, int64((timestamp-float64(sec))*float64(time.Second)))
+}
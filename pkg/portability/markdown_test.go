@@ -0,0 +1,286 @@
+package portability
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+func TestMarkdownEntryRoundTrip(t *testing.T) {
+	fm := frontMatter{
+		ID:          uuid.New(),
+		JournalID:   uuid.New(),
+		Title:       `Plan: "Q3" \ roadmap`,
+		Tags:        []string{"projects/recall", "2024", "needs review"},
+		ContentType: "text/markdown",
+		Deleted:     true,
+		CreatedAt:   1700000000,
+		UpdatedAt:   1700000123.5,
+		ExpiresAt:   1800000000,
+		Links:       []frontMatterLink{{Relation: "supersedes", TargetID: uuid.New()}},
+	}
+	content := "# Heading\n\n---\n\nbody without trailing newline"
+
+	parsed, parsedContent, ok, err := parseMarkdownEntry(writeMarkdownEntry(fm, content))
+	if err != nil || !ok {
+		t.Fatalf("parseMarkdownEntry failed: ok=%t err=%v", ok, err)
+	}
+	if parsedContent != content {
+		t.Errorf("Content changed in round trip:\n got  %q\n want %q", parsedContent, content)
+	}
+	if parsed.ID != fm.ID || parsed.JournalID != fm.JournalID || parsed.Title != fm.Title ||
+		parsed.ContentType != fm.ContentType || parsed.Deleted != fm.Deleted ||
+		parsed.CreatedAt != fm.CreatedAt || parsed.UpdatedAt != fm.UpdatedAt || parsed.ExpiresAt != fm.ExpiresAt ||
+		strings.Join(parsed.Tags, ",") != strings.Join(fm.Tags, ",") ||
+		len(parsed.Links) != 1 || parsed.Links[0] != fm.Links[0] {
+		t.Errorf("Front matter changed in round trip:\n got  %+v\n want %+v", parsed, fm)
+	}
+
+	// Front matter as an editor might write it.
+	edited := "---\r\ntitle: 'It''s done'\r\ntags: [\"#a\", b]\r\naliases:\r\n  - other\r\n---\r\nbody\r\n"
+	parsed, parsedContent, ok, err = parseMarkdownEntry([]byte(edited))
+	if err != nil || !ok {
+		t.Fatalf("parseMarkdownEntry failed on edited file: ok=%t err=%v", ok, err)
+	}
+	if parsed.Title != "It's done" || strings.Join(parsed.Tags, ",") != "a,b" || parsedContent != "body\n" {
+		t.Errorf("Unexpected parse of edited file: %+v content=%q", parsed, parsedContent)
+	}
+
+	_, parsedContent, ok, err = parseMarkdownEntry([]byte("just notes\n"))
+	if err != nil || ok || parsedContent != "just notes\n" {
+		t.Errorf("Expected a file without front matter to be returned as content, got ok=%t content=%q err=%v", ok, parsedContent, err)
+	}
+
+	if _, _, _, err := parseMarkdownEntry([]byte("---\ntitle: x\nbody\n")); err == nil {
+		t.Errorf("Expected an unclosed front matter block to fail")
+	}
+}
+
+func TestMarkdownExportImport(t *testing.T) {
+	ctx := context.Background()
+	source := setupTestDB(t)
+	defer source.Close()
+	work, home := seedTestData(t, ctx, source)
+
+	vault := t.TempDir()
+	stats, err := ExportMarkdown(ctx, source, vault, ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportMarkdown failed: %v", err)
+	}
+	if stats.Journals != 2 || stats.Entries != 2 || stats.EntryTags != 3 || stats.EntryLinks != 1 {
+		t.Errorf("Unexpected export stats: %+v", stats)
+	}
+	reportPath := filepath.Join(vault, "work", "report.md")
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("Expected entry file '%s': %v", reportPath, err)
+	}
+	if !strings.Contains(string(data), "  - tasks\n  - urgent\n") || !strings.Contains(string(data), "links:\n  - \"relates_to ") ||
+		!strings.HasSuffix(string(data), "---\nfinish the report") {
+		t.Errorf("Unexpected entry file contents:\n%s", data)
+	}
+
+	// Importing the untouched vault into the same database changes nothing.
+	stats, err = ImportMarkdown(ctx, source, vault)
+	if err != nil {
+		t.Fatalf("ImportMarkdown of an unchanged vault failed: %v", err)
+	}
+	if stats.Skipped != 2 || stats.Entries != 0 || stats.Journals != 0 {
+		t.Errorf("Expected every entry to be skipped, got %+v", stats)
+	}
+
+	// Edit one entry, add a new file to an existing journal and a new journal folder.
+	edited := strings.Replace(string(data), "finish the report", "report sent", 1)
+	edited = strings.Replace(edited, "  - urgent\n", "", 1)
+	if err := os.WriteFile(reportPath, []byte(edited), 0644); err != nil {
+		t.Fatalf("Failed to edit entry file: %v", err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(reportPath, later, later); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(vault, "home", "ideas.md"), []byte("paint the fence\n"), 0644); err != nil {
+		t.Fatalf("Failed to write new entry file: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(vault, "travel", ".obsidian"), 0755); err != nil {
+		t.Fatalf("Failed to create journal folder: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(vault, "travel", "packing.md"), []byte("---\ntags: [Trips, TODO, todo]\n---\npassport\n"), 0644); err != nil {
+		t.Fatalf("Failed to write new entry file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(vault, "travel", ".obsidian", "workspace.md"), []byte("ignored"), 0644); err != nil {
+		t.Fatalf("Failed to write hidden file: %v", err)
+	}
+
+	stats, err = ImportMarkdown(ctx, source, vault)
+	if err != nil {
+		t.Fatalf("ImportMarkdown failed: %v", err)
+	}
+	if stats.Journals != 1 || stats.Entries != 3 || stats.Updated != 1 || stats.Skipped != 1 {
+		t.Errorf("Unexpected import stats: %+v", stats)
+	}
+
+	workEntries, err := memories.ListEntries(ctx, source, work.ID, true)
+	if err != nil {
+		t.Fatalf("ListEntries failed: %v", err)
+	}
+	if len(workEntries) != 2 {
+		t.Fatalf("Expected the edited entry to be updated rather than duplicated, got %d entries", len(workEntries))
+	}
+	var report memories.Entry
+	for _, e := range workEntries {
+		if e.Title == "report" {
+			report = e
+		}
+	}
+	if report.Content != "report sent" || int64(report.UpdatedAt) != later.Unix() {
+		t.Errorf("Expected the edit to be imported and dated by the file, got %+v", report)
+	}
+	tags, err := memories.ListTagsForEntry(ctx, source, report.ID)
+	if err != nil {
+		t.Fatalf("ListTagsForEntry failed: %v", err)
+	}
+	if len(tags) != 1 || tags[0].Tag != "tasks" {
+		t.Errorf("Expected tags to follow the front matter, got %v", tags)
+	}
+	links, err := memories.ListEntryLinks(ctx, source, []uuid.UUID{report.ID})
+	if err != nil {
+		t.Fatalf("ListEntryLinks failed: %v", err)
+	}
+	if len(links) != 1 || links[0].Relation != memories.LinkRelatesTo {
+		t.Errorf("Expected the link in the front matter to be kept, got %+v", links)
+	}
+
+	homeEntries, err := memories.ListEntries(ctx, source, home.ID, false)
+	if err != nil {
+		t.Fatalf("ListEntries failed: %v", err)
+	}
+	if len(homeEntries) != 2 {
+		t.Errorf("Expected the new file to be added to the existing journal, got %d entries", len(homeEntries))
+	}
+	for _, e := range homeEntries {
+		if e.Title == "ideas" && e.ContentType != defaultMarkdownContentType {
+			t.Errorf("Expected a file without front matter to be imported as %s, got %s", defaultMarkdownContentType, e.ContentType)
+		}
+	}
+
+	journals, err := memories.ListJournals(ctx, source, false)
+	if err != nil {
+		t.Fatalf("ListJournals failed: %v", err)
+	}
+	if len(journals) != 3 {
+		t.Errorf("Expected a journal to be created for the new folder, got %d journals", len(journals))
+	}
+	for _, journal := range journals {
+		if journal.Name != "travel" {
+			continue
+		}
+		packing, err := memories.ListEntries(ctx, source, journal.ID, false)
+		if err != nil || len(packing) != 1 {
+			t.Fatalf("Expected the new folder's file to be imported, got %d entries (%v)", len(packing), err)
+		}
+		tags, err := memories.ListTagsForEntry(ctx, source, packing[0].ID)
+		if err != nil {
+			t.Fatalf("ListTagsForEntry failed: %v", err)
+		}
+		if len(tags) != 2 || tags[0].Tag != "tasks" || tags[1].Tag != "trips" {
+			t.Errorf("Expected front matter tags to be normalized and resolved through aliases, got %v", tags)
+		}
+	}
+
+	// A tag written in another spelling, or as an alias, still matches the entry's tag, and
+	// files without an id match their entry by title.
+	if err := os.WriteFile(reportPath, []byte(strings.Replace(edited, "  - tasks\n", "  - TODO\n", 1)), 0644); err != nil {
+		t.Fatalf("Failed to edit entry file: %v", err)
+	}
+	stats, err = ImportMarkdown(ctx, source, vault)
+	if err != nil {
+		t.Fatalf("ImportMarkdown failed: %v", err)
+	}
+	if stats.Entries != 0 || stats.Skipped != 4 {
+		t.Errorf("Expected re-importing the vault to skip every entry, got %+v", stats)
+	}
+}
+
+func TestImportMarkdownTwiceKeepsEntries(t *testing.T) {
+	ctx := context.Background()
+	testDB := setupTestDB(t)
+	defer testDB.Close()
+
+	vault := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(vault, "notes", "drafts"), 0755); err != nil {
+		t.Fatalf("Failed to create journal folder: %v", err)
+	}
+	files := map[string]string{
+		filepath.Join(vault, "notes", "groceries.md"):           "milk, eggs\n",
+		filepath.Join(vault, "notes", "drafts", "plan.md"):      "---\ntags: [ideas]\n---\nship it\n",
+		filepath.Join(vault, "notes", "drafts", "groceries.md"): "---\ntitle: groceries\n---\nbread\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write '%s': %v", path, err)
+		}
+	}
+
+	countEntries := func() int {
+		t.Helper()
+		return countRows(t, testDB, "entries")
+	}
+	if _, err := ImportMarkdown(ctx, testDB, vault); err != nil {
+		t.Fatalf("ImportMarkdown failed: %v", err)
+	}
+	// Both files titled groceries get an entry of their own.
+	if got := countEntries(); got != 3 {
+		t.Fatalf("Expected 3 entries after the first import, got %d", got)
+	}
+
+	stats, err := ImportMarkdown(ctx, testDB, vault)
+	if err != nil {
+		t.Fatalf("ImportMarkdown failed: %v", err)
+	}
+	if got := countEntries(); got != 3 || stats.Entries != 0 || stats.Skipped != 3 {
+		t.Errorf("Expected importing the vault again to keep 3 entries and skip every file, got %d entries and %+v", got, stats)
+	}
+
+	planPath := filepath.Join(vault, "notes", "drafts", "plan.md")
+	if err := os.WriteFile(planPath, []byte("---\ntags: [ideas]\n---\nship it on Friday\n"), 0644); err != nil {
+		t.Fatalf("Failed to edit '%s': %v", planPath, err)
+	}
+	stats, err = ImportMarkdown(ctx, testDB, vault)
+	if err != nil {
+		t.Fatalf("ImportMarkdown failed: %v", err)
+	}
+	if got := countEntries(); got != 3 || stats.Updated != 1 {
+		t.Errorf("Expected the edited file to update its entry, got %d entries and %+v", got, stats)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := map[string]string{
+		"report":           "report",
+		"a/b: c?":          "a-b- c-",
+		"  .hidden. ":      "hidden",
+		"":                 "untitled",
+		"line\nbreak":      "line break",
+		"[[wiki]] #tag ^x": "--wiki-- -tag -x",
+	}
+	for input, want := range tests {
+		if got := sanitizeFileName(input); got != want {
+			t.Errorf("sanitizeFileName(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	taken := make(map[string]bool)
+	id := uuid.MustParse("12345678-1234-1234-1234-123456789abc")
+	first := uniqueFileName("Notes", id, ".md", taken)
+	second := uniqueFileName("notes", id, ".md", taken)
+	if first != "Notes.md" || second != "notes (12345678).md" {
+		t.Errorf("Unexpected unique file names: %q, %q", first, second)
+	}
+}
@@ -0,0 +1,119 @@
+package portability
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/unowned-ai/recall/pkg/db"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+// WriteSnapshot writes everything in store, including soft-deleted entries, to w in the
+// JSONL export format. The output can be read back with ReadSnapshot or imported into a
+// database with Import. Revision history is not written.
+func WriteSnapshot(w io.Writer, store *memories.MemoryStore) (Stats, error) {
+	var stats Stats
+	snapshot := store.Snapshot()
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	header := Header{
+		Format:        FormatName,
+		Version:       FormatVersion,
+		SchemaVersion: db.TargetSchemaVersion,
+		ExportedAt:    float64(time.Now().Unix()),
+	}
+	if err := enc.Encode(Record{Type: RecordHeader, Header: &header}); err != nil {
+		return stats, err
+	}
+
+	for i := range snapshot.Journals {
+		if err := enc.Encode(Record{Type: RecordJournal, Journal: &snapshot.Journals[i]}); err != nil {
+			return stats, err
+		}
+		stats.Journals++
+	}
+	for i := range snapshot.Tags {
+		if err := enc.Encode(Record{Type: RecordTag, Tag: &snapshot.Tags[i]}); err != nil {
+			return stats, err
+		}
+		stats.Tags++
+	}
+	for i := range snapshot.Aliases {
+		if err := enc.Encode(Record{Type: RecordTagAlias, TagAlias: &snapshot.Aliases[i]}); err != nil {
+			return stats, err
+		}
+		stats.TagAliases++
+	}
+	for i := range snapshot.Entries {
+		if err := enc.Encode(Record{Type: RecordEntry, Entry: &snapshot.Entries[i]}); err != nil {
+			return stats, err
+		}
+		stats.Entries++
+	}
+	for i := range snapshot.EntryTags {
+		if err := enc.Encode(Record{Type: RecordEntryTag, EntryTag: &snapshot.EntryTags[i]}); err != nil {
+			return stats, err
+		}
+		stats.EntryTags++
+	}
+	for i := range snapshot.Links {
+		if err := enc.Encode(Record{Type: RecordEntryLink, EntryLink: &snapshot.Links[i]}); err != nil {
+			return stats, err
+		}
+		stats.EntryLinks++
+	}
+
+	if err := bw.Flush(); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// ReadSnapshot reads a JSONL export, such as one written by Export or WriteSnapshot,
+// into a new MemoryStore with IDs and timestamps intact.
+func ReadSnapshot(r io.Reader) (*memories.MemoryStore, Stats, error) {
+	var stats Stats
+	var snapshot memories.MemorySnapshot
+
+	err := readRecords(r, func(record Record) error {
+		switch {
+		case record.Type == RecordJournal && record.Journal != nil:
+			snapshot.Journals = append(snapshot.Journals, *record.Journal)
+			stats.Journals++
+		case record.Type == RecordTag && record.Tag != nil:
+			snapshot.Tags = append(snapshot.Tags, *record.Tag)
+			stats.Tags++
+		case record.Type == RecordTagAlias && record.TagAlias != nil:
+			snapshot.Aliases = append(snapshot.Aliases, *record.TagAlias)
+			stats.TagAliases++
+		case record.Type == RecordEntry && record.Entry != nil:
+			snapshot.Entries = append(snapshot.Entries, *record.Entry)
+			stats.Entries++
+		case record.Type == RecordEntryTag && record.EntryTag != nil:
+			snapshot.EntryTags = append(snapshot.EntryTags, *record.EntryTag)
+			stats.EntryTags++
+		case record.Type == RecordEntryLink && record.EntryLink != nil:
+			snapshot.Links = append(snapshot.Links, *record.EntryLink)
+			stats.EntryLinks++
+		case record.Type == RecordHeader:
+			return fmt.Errorf("%w: unexpected second header", ErrInvalidExport)
+		default:
+			return fmt.Errorf("%w: unknown or empty record of type '%s'", ErrInvalidExport, record.Type)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, Stats{}, err
+	}
+
+	store, err := memories.NewMemoryStoreFromSnapshot(snapshot)
+	if err != nil {
+		return nil, Stats{}, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+	return store, stats, nil
+}
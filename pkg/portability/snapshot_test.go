@@ -0,0 +1,110 @@
+package portability
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/unowned-ai/recall/pkg/memories"
+)
+
+func TestSnapshotRoundTripThroughDatabase(t *testing.T) {
+	sourceDB := setupTestDB(t)
+	defer sourceDB.Close()
+
+	ctx := context.Background()
+	work, _ := seedTestData(t, ctx, sourceDB)
+
+	var exported bytes.Buffer
+	if _, err := Export(ctx, sourceDB, &exported, ExportOptions{IncludeDeleted: true}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	store, stats, err := ReadSnapshot(&exported)
+	if err != nil {
+		t.Fatalf("ReadSnapshot failed: %v", err)
+	}
+	if stats.Journals != 2 || stats.Entries != 3 || stats.EntryTags != 4 || stats.TagAliases != 1 || stats.EntryLinks != 1 {
+		t.Errorf("Unexpected read stats: %+v", stats)
+	}
+	entries, err := store.ListEntries(ctx, work.ID, true)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected 2 work entries in the memory store, got %d (%v)", len(entries), err)
+	}
+	matches, err := store.SearchEntriesByTagMatch(ctx, work.ID, []string{"urgent", "tasks"})
+	if err != nil || len(matches) != 1 || matches[0].Title != "report" || matches[0].MatchCount != 2 {
+		t.Errorf("Unexpected tag search result in the memory store: %+v (%v)", matches, err)
+	}
+
+	var snapshot bytes.Buffer
+	if _, err := WriteSnapshot(&snapshot, store); err != nil {
+		t.Fatalf("WriteSnapshot failed: %v", err)
+	}
+
+	targetDB := setupTestDB(t)
+	defer targetDB.Close()
+	if _, err := Import(ctx, targetDB, &snapshot, ImportOptions{}); err != nil {
+		t.Fatalf("Import of a snapshot failed: %v", err)
+	}
+	imported, err := memories.ListEntries(ctx, targetDB, work.ID, true)
+	if err != nil {
+		t.Fatalf("ListEntries failed: %v", err)
+	}
+	if len(imported) != len(entries) {
+		t.Fatalf("Expected %d imported entries, got %d", len(entries), len(imported))
+	}
+	if got := countRows(t, targetDB, "entry_links"); got != 1 {
+		t.Errorf("Expected the link to survive a snapshot, got %d links", got)
+	}
+	if got := countRows(t, targetDB, "tag_aliases"); got != 1 {
+		t.Errorf("Expected the tag alias to survive a snapshot, got %d aliases", got)
+	}
+	byID := make(map[uuid.UUID]memories.Entry)
+	for _, entry := range entries {
+		byID[entry.ID] = entry
+	}
+	for _, entry := range imported {
+		if byID[entry.ID] != entry {
+			t.Errorf("Entry changed on the way through a snapshot:\n%+v\n%+v", byID[entry.ID], entry)
+		}
+	}
+}
+
+func TestReadSnapshotResolvesTags(t *testing.T) {
+	input := `{"type":"header","header":{"format":"recall-export","version":2}}
+{"type":"journal","journal":{"id":"0c3c3b8e-8d0a-4d5c-bb36-51b0b1a0e5d2","name":"work","active":true}}
+{"type":"tag","tag":{"tag":"Kubernetes "}}
+{"type":"tag","tag":{"tag":"K8s"}}
+{"type":"tag_alias","tag_alias":{"alias":"K8S","tag":"kubernetes"}}
+{"type":"entry","entry":{"id":"7b0e6f4c-64a5-4f0a-9f43-1d7f1c4b5a10","journal_id":"0c3c3b8e-8d0a-4d5c-bb36-51b0b1a0e5d2","title":"deploy","content":""}}
+{"type":"entry_tag","entry_tag":{"entry_id":"7b0e6f4c-64a5-4f0a-9f43-1d7f1c4b5a10","tag":"k8s"}}
+`
+	store, _, err := ReadSnapshot(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadSnapshot failed: %v", err)
+	}
+	ctx := context.Background()
+	if tags := store.Snapshot().Tags; len(tags) != 1 || tags[0].Tag != "kubernetes" {
+		t.Errorf("Expected one normalized tag, got %+v", tags)
+	}
+	entryTags, err := store.ListTagsForEntry(ctx, uuid.MustParse("7b0e6f4c-64a5-4f0a-9f43-1d7f1c4b5a10"))
+	if err != nil || len(entryTags) != 1 || entryTags[0].Tag != "kubernetes" {
+		t.Errorf("Expected the entry to be tagged kubernetes, got %+v (%v)", entryTags, err)
+	}
+	aliases, err := store.ListTagAliases(ctx)
+	if err != nil || len(aliases) != 1 || aliases[0].Alias != "k8s" || aliases[0].Tag != "kubernetes" {
+		t.Errorf("Expected the alias to be normalized, got %+v (%v)", aliases, err)
+	}
+}
+
+func TestReadSnapshotRejectsDanglingReferences(t *testing.T) {
+	input := `{"type":"header","header":{"format":"recall-export","version":1}}
+{"type":"entry","entry":{"id":"7b0e6f4c-64a5-4f0a-9f43-1d7f1c4b5a10","journal_id":"0c3c3b8e-8d0a-4d5c-bb36-51b0b1a0e5d2","title":"orphan","content":""}}
+`
+	if _, _, err := ReadSnapshot(strings.NewReader(input)); !errors.Is(err, ErrInvalidExport) {
+		t.Errorf("Expected ErrInvalidExport for an entry without its journal, got %v", err)
+	}
+}
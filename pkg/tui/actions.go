@@ -2,7 +2,7 @@ package tui
 
 import (
 	"context"
-	"database/sql"
+	"time"
 
 	"github.com/unowned-ai/recall/pkg/memories"
 
@@ -11,9 +11,9 @@ import (
 )
 
 // List journals from the database and return tea data
-func listJournals(db *sql.DB) tea.Cmd {
+func listJournals(store memories.Store) tea.Cmd {
 	return func() tea.Msg {
-		journals, err := memories.ListJournals(context.Background(), db, false)
+		journals, err := store.ListJournals(context.Background(), false)
 		if err != nil {
 			return err
 		}
@@ -22,9 +22,9 @@ func listJournals(db *sql.DB) tea.Cmd {
 }
 
 // List entries for journal from the database and return tea data
-func listEntries(db *sql.DB, journalID uuid.UUID, includeDeleted bool) tea.Cmd {
+func listEntries(store memories.Store, journalID uuid.UUID, includeDeleted bool) tea.Cmd {
 	return func() tea.Msg {
-		entries, err := memories.ListEntries(context.Background(), db, journalID, includeDeleted)
+		entries, err := store.ListEntries(context.Background(), journalID, includeDeleted)
 		if err != nil {
 			return err
 		}
@@ -32,32 +32,58 @@ func listEntries(db *sql.DB, journalID uuid.UUID, includeDeleted bool) tea.Cmd {
 	}
 }
 
+// List the deleted and expired entries of a journal and return tea data
+func listTrashedEntries(store memories.Store, journalID uuid.UUID) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := store.ListEntries(context.Background(), journalID, true)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		trashed := []memories.Entry{}
+		for _, entry := range entries {
+			if entry.Deleted || entry.Expired(now) {
+				trashed = append(trashed, entry)
+			}
+		}
+		return trashed
+	}
+}
+
+// Find entries of a journal selected by a tag query and return tea data
+func findEntries(store memories.Store, journalID uuid.UUID, query *memories.TagQuery) tea.Cmd {
+	return func() tea.Msg {
+		found, _, err := store.FindEntries(context.Background(), memories.EntryFilter{
+			JournalIDs: []uuid.UUID{journalID},
+			Query:      query,
+		}, memories.Page{})
+		if err != nil {
+			return err
+		}
+		entries := make([]memories.Entry, len(found))
+		for i, entry := range found {
+			entries[i] = entry.Entry
+		}
+		return entries
+	}
+}
+
 type entryDetailsMsg struct {
 	entry memories.Entry
 	tags  []memories.Tag
 }
 
 // Get a combined message with the entry and its tags
-func getEntryDetails(db *sql.DB, entryID uuid.UUID) tea.Cmd {
+func getEntryDetails(store memories.Store, entryID uuid.UUID) tea.Cmd {
 	return func() tea.Msg {
-		entry, err := memories.GetEntry(context.Background(), db, entryID)
+		entry, err := store.GetEntry(context.Background(), entryID)
 		if err != nil {
 			return err
 		}
-		tags, err := memories.ListTagsForEntry(context.Background(), db, entry.ID)
+		tags, err := store.ListTagsForEntry(context.Background(), entry.ID)
 		if err != nil {
 			return err
 		}
 		return entryDetailsMsg{entry: entry, tags: tags}
 	}
 }
-
-// Get database name and file path
-func getDbPragmaList(db *sql.DB) (string, string) {
-	var name, file string
-	err := db.QueryRow(`PRAGMA database_list`).Scan(new(int), &name, &file)
-	if err != nil {
-		return name, file
-	}
-	return name, file
-}
@@ -2,7 +2,6 @@ package tui
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"path/filepath"
 	"strconv"
@@ -36,7 +35,7 @@ type model struct {
 
 	mcpUsage bool
 
-	db         *sql.DB
+	store      memories.Store
 	dbFilename string
 
 	quitting bool
@@ -60,6 +59,13 @@ type model struct {
 	entryDeleting         bool
 	entryDeleteConfirmIdx int // 0 = "Yes" selected, 1 = "No"
 
+	entryQuerying      bool // true while the tag query filter is being typed
+	entryQueryInput    textinput.Model
+	entryQuery         *memories.TagQuery // Filter applied to the entries list, nil for none
+	entryQueryingError string
+
+	entryTrash bool // true while the entries column shows the deleted entries of the journal
+
 	dynamicWidth bool // Toggle for dynamic column widths
 
 	// Animation state
@@ -73,10 +79,7 @@ type model struct {
 }
 
 // Initialize TUI model
-func initModel(db *sql.DB) model {
-	// Fetch database file path with name
-	_, file := getDbPragmaList(db)
-
+func initModel(store memories.Store, location string) model {
 	// Initialize text input fields for the new journal form
 	jtname := textinput.New()
 	jtname.Placeholder = "Journal Name"
@@ -101,6 +104,11 @@ func initModel(db *sql.DB) model {
 	ettags.Placeholder = "Tags (comma or space separated)"
 	ettags.CharLimit = 1024
 
+	// Initialize text input field for the entries filter
+	etquery := textinput.New()
+	etquery.Placeholder = "Tag query, e.g. proj-* AND NOT archived"
+	etquery.CharLimit = 1024
+
 	vp := viewport.New(0, 0)
 	vp.YPosition = 0
 	vp.SetContent("")
@@ -117,8 +125,8 @@ func initModel(db *sql.DB) model {
 
 		mcpUsage: false,
 
-		db:         db,
-		dbFilename: filepath.Base(file),
+		store:      store,
+		dbFilename: filepath.Base(location),
 
 		journalCursor:    0,
 		journalNameInput: jtname,
@@ -128,6 +136,7 @@ func initModel(db *sql.DB) model {
 		entryTitleInput:   ettitle,
 		entryContentInput: etcont,
 		entryTagsInput:    ettags,
+		entryQueryInput:   etquery,
 
 		contentViewport: vp,
 
@@ -143,7 +152,7 @@ func initModel(db *sql.DB) model {
 // Execute commands concurrently with no ordering guarantees during initialization
 func (m model) Init() tea.Cmd {
 	return tea.Batch(
-		listJournals(m.db),
+		listJournals(m.store),
 		tea.Tick(marqueeTickDuration, func(t time.Time) tea.Msg {
 			return t
 		}),
@@ -185,7 +194,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.journals = msg
 		if len(m.journals) > 0 {
 			// Load entries for the first journal
-			return m, listEntries(m.db, m.journals[0].ID, false)
+			return m, m.loadEntries(m.journals[0].ID)
 		}
 		return m, nil
 
@@ -211,6 +220,43 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// Handle key presses for navigation and input
 	case tea.KeyMsg:
+		if m.entryQuerying {
+			// Typing Entries Filter Mode
+			switch msg.Type {
+			case tea.KeyEnter:
+				// An empty query removes the filter
+				var query *memories.TagQuery
+				if strings.TrimSpace(m.entryQueryInput.Value()) != "" {
+					var err error
+					query, err = memories.ParseTagQuery(m.entryQueryInput.Value())
+					if err != nil {
+						m.entryQueryingError = err.Error()
+						return m, nil
+					}
+				}
+				m.entryQuery = query
+				m.entryQuerying = false
+				m.entryQueryingError = ""
+				m.entryQueryInput.Blur()
+				if len(m.journals) == 0 {
+					return m, nil
+				}
+				m.columnFocus = 1
+				return m, m.loadEntries(m.journals[m.journalCursor].ID)
+
+			case tea.KeyEsc:
+				// Cancel typing and keep the current filter
+				m.entryQuerying = false
+				m.entryQueryingError = ""
+				m.entryQueryInput.Blur()
+				return m, nil
+			}
+
+			var cmd tea.Cmd
+			m.entryQueryInput, cmd = m.entryQueryInput.Update(msg)
+			return m, cmd
+		}
+
 		if m.journalCreating {
 			// Creating New Journal Mode
 			switch msg.Type {
@@ -229,7 +275,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.journalDescInput.Focus()
 				} else {
 					// Press Enter on description field -> submit the form (create journal)
-					journal, err := memories.CreateJournal(context.Background(), m.db,
+					journal, err := m.store.CreateJournal(context.Background(),
 						m.journalNameInput.Value(), m.journalDescInput.Value())
 					if err != nil {
 						m.err = err
@@ -284,7 +330,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.journalDeleteConfirmIdx == 0 {
 					// Confirmed deletion of selected journal
 					journalID := m.journals[m.journalCursor].ID
-					err := memories.DeleteJournal(context.Background(), m.db, journalID)
+					err := m.store.DeleteJournal(context.Background(), journalID)
 					if err != nil {
 						m.err = err
 						m.journalDeleting = false
@@ -302,7 +348,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							m.journalCursor--
 						}
 						m.currentEntry = entryDetailsMsg{}
-						return m, listEntries(m.db, m.journals[m.journalCursor].ID, false)
+						return m, m.loadEntries(m.journals[m.journalCursor].ID)
 					} else {
 						// No journals remaining; clear entries
 						m.entries = []memories.Entry{}
@@ -345,7 +391,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.entryContentInput.Focus()
 				} else {
 					// Press Enter on content field -> submit the form (create entry)
-					entry, err := memories.CreateEntry(context.Background(), m.db, m.journals[m.journalCursor].ID,
+					entry, err := m.store.CreateEntry(context.Background(), m.journals[m.journalCursor].ID,
 						m.entryTitleInput.Value(), m.entryContentInput.Value(), "text/plain")
 					if err != nil {
 						m.err = err
@@ -363,7 +409,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						for _, tag := range tags {
 							tag = strings.TrimSpace(tag)
 							if tag != "" {
-								err := memories.TagEntry(context.Background(), m.db, entry.ID, tag)
+								err := m.store.TagEntry(context.Background(), entry.ID, tag)
 								if err != nil {
 									m.err = fmt.Errorf("error creating tag '%s': %v", tag, err)
 									return m, nil
@@ -386,7 +432,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 					// Empty old current entry and fetch details of newly created
 					m.currentEntry = entryDetailsMsg{}
-					return m, getEntryDetails(m.db, m.entries[m.entryCursor].ID)
+					return m, getEntryDetails(m.store, m.entries[m.entryCursor].ID)
 				}
 
 			case tea.KeyEsc:
@@ -423,7 +469,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.entryDeleteConfirmIdx == 0 {
 					// Confirm deletion of selected entry
 					entryID := m.entries[m.entryCursor].ID
-					err := memories.DeleteEntry(context.Background(), m.db, entryID)
+					err := m.store.DeleteEntry(context.Background(), entryID)
 					if err != nil {
 						m.err = err
 						m.entryDeleting = false
@@ -441,7 +487,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							m.entryCursor--
 						}
 						m.currentEntry = entryDetailsMsg{}
-						return m, getEntryDetails(m.db, m.entries[m.entryCursor].ID)
+						return m, getEntryDetails(m.store, m.entries[m.entryCursor].ID)
 					} else {
 						// No entry remaining; clear current entry, entry list, move focus to journals
 						m.currentEntry = entryDetailsMsg{}
@@ -603,7 +649,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					updateContentWithCursor(&m)
 				case tea.KeyEsc:
 					// Exit edit mode and update entry in database
-					updatedEntry, err := memories.UpdateEntry(context.Background(), m.db,
+					updatedEntry, err := m.store.UpdateEntry(memories.WithActor(context.Background(), "tui"),
 						m.currentEntry.entry.ID,
 						m.currentEntry.entry.Title,
 						m.currentEntry.entry.Content,
@@ -637,7 +683,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Handle mode switching and other commands
 			switch msg.String() {
 			case "enter", "i":
-				if !m.contentEditing {
+				// Entries in the trash are read-only until restored
+				if !m.contentEditing && !m.entryTrash {
 					m.contentEditing = true
 					m.editCursorPos = 0
 					m.editCursorVisible = true
@@ -668,12 +715,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.columnFocus == 0 && m.journalCursor > 0 {
 				// Iterating over journals column
 				m.journalCursor--
-				return m, listEntries(m.db, m.journals[m.journalCursor].ID, false)
+				return m, m.loadEntries(m.journals[m.journalCursor].ID)
 			}
 			if m.columnFocus == 1 && m.entryCursor > 0 {
 				// Iterating over entries column
 				m.entryCursor--
-				return m, getEntryDetails(m.db, m.entries[m.entryCursor].ID)
+				return m, getEntryDetails(m.store, m.entries[m.entryCursor].ID)
 			}
 
 		case "down", "j":
@@ -681,12 +728,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.columnFocus == 0 && m.journalCursor < len(m.journals)-1 {
 				// Iterating over journals column
 				m.journalCursor++
-				return m, listEntries(m.db, m.journals[m.journalCursor].ID, false)
+				return m, m.loadEntries(m.journals[m.journalCursor].ID)
 			}
 			if m.columnFocus == 1 && m.entryCursor < len(m.entries)-1 {
 				// Iterating over entries column
 				m.entryCursor++
-				return m, getEntryDetails(m.db, m.entries[m.entryCursor].ID)
+				return m, getEntryDetails(m.store, m.entries[m.entryCursor].ID)
 			}
 
 		case "right", "l":
@@ -705,7 +752,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.columnFocus++
 				// If there are entries, load the first entry's details
 				if len(m.entries) > 0 {
-					return m, getEntryDetails(m.db, m.entries[0].ID)
+					return m, getEntryDetails(m.store, m.entries[0].ID)
 				}
 				return m, nil
 			}
@@ -737,7 +784,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.journalDescInput.Blur()  // Ensure description input is not focused
 				m.journalNameInput.Focus() // Make sure to focus the name input
 				m.journalCreating = true
-			} else if m.columnFocus == 1 {
+			} else if m.columnFocus == 1 && !m.entryTrash {
 				m.entryCreatingStep = 0
 				m.entryTitleInput.Reset()
 				m.entryContentInput.Reset()
@@ -750,12 +797,79 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.columnFocus == 0 && len(m.journals) > 0 {
 				m.journalDeleteConfirmIdx = 1
 				m.journalDeleting = true
-			} else if m.columnFocus == 1 && len(m.entries) > 0 {
+			} else if m.columnFocus == 1 && len(m.entries) > 0 && !m.entryTrash {
 				m.entryDeleteConfirmIdx = 1
 				m.entryDeleting = true
 			}
 			return m, nil
 
+		case "/":
+			// The trash is not filtered
+			if m.entryTrash {
+				return m, nil
+			}
+			// Start typing a filter for the entries list, starting from the current one
+			if m.entryQuery != nil {
+				m.entryQueryInput.SetValue(m.entryQuery.String())
+			} else {
+				m.entryQueryInput.Reset()
+			}
+			m.entryQueryInput.CursorEnd()
+			m.entryQueryInput.Focus()
+			m.entryQuerying = true
+			return m, nil
+
+		case "t":
+			// Toggle between the entries of the journal and its trash
+			if len(m.journals) == 0 {
+				return m, nil
+			}
+			m.entryTrash = !m.entryTrash
+			m.entryQuery = nil
+			m.currentEntry = entryDetailsMsg{}
+			return m, m.loadEntries(m.journals[m.journalCursor].ID)
+
+		case "r":
+			// Restore the selected entry from the trash
+			if !m.entryTrash || m.columnFocus != 1 || len(m.entries) == 0 {
+				return m, nil
+			}
+			if _, err := m.store.RestoreEntry(context.Background(), m.entries[m.entryCursor].ID); err != nil {
+				m.err = err
+				return m, nil
+			}
+			// Remove entry from the trash and adjust selection
+			oldIndex := m.entryCursor
+			m.entries = append(m.entries[:oldIndex], m.entries[oldIndex+1:]...)
+			m.currentEntry = entryDetailsMsg{}
+			if len(m.entries) == 0 {
+				m.columnFocus = 0
+				return m, nil
+			}
+			if oldIndex > 0 {
+				m.entryCursor--
+			}
+			return m, getEntryDetails(m.store, m.entries[m.entryCursor].ID)
+
+		case "esc":
+			// Leave the trash
+			if m.entryTrash {
+				m.entryTrash = false
+				m.currentEntry = entryDetailsMsg{}
+				if len(m.journals) > 0 {
+					return m, m.loadEntries(m.journals[m.journalCursor].ID)
+				}
+				return m, nil
+			}
+			// Remove the entries filter
+			if m.entryQuery != nil {
+				m.entryQuery = nil
+				if len(m.journals) > 0 {
+					return m, m.loadEntries(m.journals[m.journalCursor].ID)
+				}
+			}
+			return m, nil
+
 		case "z":
 			if m.journalCreating || m.entryCreating {
 				return m, nil
@@ -807,6 +921,7 @@ func (m model) View() string {
 	m.entryTitleInput.Width = rightWidth - m.bordersAndPaddingWidth
 	m.entryContentInput.Width = rightWidth - m.bordersAndPaddingWidth
 	m.entryTagsInput.Width = rightWidth - m.bordersAndPaddingWidth
+	m.entryQueryInput.Width = middleWidth - m.bordersAndPaddingWidth - 2
 
 	// Left Column: Journals list and Info panel
 	var journalsBuilder, infoBuilder strings.Builder
@@ -869,10 +984,28 @@ func (m model) View() string {
 
 	// Middle Column - Entries list
 	var middleBuilder strings.Builder
-	middleBuilder.WriteString(subtitleStyle.Width(middleWidth - m.bordersAndPaddingWidth).Render("  Entries"))
+	middleSubtitleText := "  Entries"
+	if m.entryTrash {
+		middleSubtitleText = "  Trash"
+	}
+	middleBuilder.WriteString(subtitleStyle.Width(middleWidth - m.bordersAndPaddingWidth).Render(middleSubtitleText))
 	middleBuilder.WriteString("\n\n")
 
-	if len(m.entries) == 0 {
+	if m.entryQuerying {
+		middleBuilder.WriteString(elemTitleHeaderStyle.Render("/ ") + m.entryQueryInput.View() + "\n")
+		if m.entryQueryingError != "" {
+			middleBuilder.WriteString(textRedStyle.Render(m.entryQueryingError) + "\n")
+		}
+		middleBuilder.WriteString("\n")
+	} else if m.entryQuery != nil {
+		middleBuilder.WriteString(elemTitleHeaderStyle.Render("Filter: ") + multiElemsTitleStyle.Render(m.entryQuery.String()) + "\n\n")
+	}
+
+	if len(m.entries) == 0 && m.entryTrash {
+		middleBuilder.WriteString("  Trash is empty.\n")
+	} else if len(m.entries) == 0 && m.entryQuery != nil {
+		middleBuilder.WriteString("  No entries match the filter.\n")
+	} else if len(m.entries) == 0 {
 		middleBuilder.WriteString("  No entries yet.\n")
 	} else {
 		for i, entry := range m.entries {
@@ -898,6 +1031,9 @@ func (m model) View() string {
 		if m.contentEditing {
 			rightBuilderSubtitleText = "Entry (edit mode)"
 		}
+		if m.entryTrash {
+			rightBuilderSubtitleText = "Entry (in trash)"
+		}
 	}
 	if m.journalCreating {
 		rightBuilderSubtitleText = "Create New Journal"
@@ -986,6 +1122,16 @@ func (m model) View() string {
 			// Combine all sections into rightBuilder
 			rightBuilder.WriteString(entryTitleBuilder.String())
 			rightBuilder.WriteString(entryTagsBuilder.String())
+			if m.entryTrash {
+				// Entries expire before the reaper deletes them
+				trashedLabel, trashedAt := "Deleted: ", m.currentEntry.entry.UpdatedAt
+				if !m.currentEntry.entry.Deleted {
+					trashedLabel, trashedAt = "Expired: ", m.currentEntry.entry.ExpiresAt
+				}
+				rightBuilder.WriteString(elemTitleHeaderStyle.Render(trashedLabel) +
+					textStyle.Render(time.Unix(int64(trashedAt), 0).Format("2006-01-02 15:04")) +
+					" (r to restore)\n\n")
+			}
 			rightBuilder.WriteString(m.contentViewport.View())
 		} else {
 			rightBuilder.WriteString("Select an entry to view details.")
@@ -1020,7 +1166,7 @@ func (m model) View() string {
 	columns := lipgloss.JoinHorizontal(lipgloss.Top, leftPanel, middlePanel, rightPanel)
 
 	// Footer with usage instructions
-	footerText := "\n↑/↓ to navigate • n to create • d to delete • i to edit • z to toggle layout • esc to apply and exit edit mode • q to quit"
+	footerText := "\n↑/↓ to navigate • n to create • d to delete • i to edit • / to filter entries • t to toggle trash • r to restore • z to toggle layout • esc to apply and exit edit mode • q to quit"
 	// Render the footer bar (full width)
 	footerBar := footerStyle.Width(m.width).Render(footerText)
 
@@ -1028,6 +1174,17 @@ func (m model) View() string {
 	return titleBar + "\n\n" + columns + footerBar
 }
 
+// Load the entries of a journal, filtered by the entries query if one is set, or its trash
+func (m model) loadEntries(journalID uuid.UUID) tea.Cmd {
+	if m.entryTrash {
+		return listTrashedEntries(m.store, journalID)
+	}
+	if m.entryQuery != nil {
+		return findEntries(m.store, journalID, m.entryQuery)
+	}
+	return listEntries(m.store, journalID, false)
+}
+
 // View of normal truncation for non-selected list element
 func (m model) ViewListElemNormal(elemName string, builder *strings.Builder, availableWidth int) {
 	if len(elemName) > availableWidth {
@@ -1052,9 +1209,9 @@ func (m model) ViewListElemMarquee(elemName string, builder *strings.Builder, av
 	builder.WriteString(generateLinePointer(true, m.pointerLen) + selectedStyle.Render(elemName) + "\n")
 }
 
-// Create and start the Bubble Tea TUI
-func ShowTUI(db *sql.DB) error {
-	p := tea.NewProgram(initModel(db), tea.WithAltScreen())
+// Create and start the Bubble Tea TUI; location is the database shown in the status bar
+func ShowTUI(store memories.Store, location string) error {
+	p := tea.NewProgram(initModel(store, location), tea.WithAltScreen())
 	_, err := p.Run()
 	return err
 }